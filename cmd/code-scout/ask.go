@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/llm"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// maxContextChars caps each chunk's code in LLM prompts, so one huge chunk
+// can't crowd out the others
+const maxContextChars = 4000
+
+var (
+	askLimit int
+	askJSON  bool
+)
+
+var askCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Answer a question about the codebase using retrieved code",
+	Long: `Retrieve the chunks most relevant to a question, send them to the configured
+chat model (chat_model in config), and print its answer with file:line citations.
+
+Requires an OpenAI-compatible chat completions API; chat_endpoint and
+chat_api_key default to the embedding endpoint and api_key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if askLimit < 1 {
+			return fmt.Errorf("--limit must be at least 1, got: %d", askLimit)
+		}
+
+		client, err := newChatClient()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		if err := store.OpenTable(); err != nil {
+			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
+		}
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
+			return err
+		}
+
+		answer, err := runAsk(cmd.Context(), store, metadata, client, args[0], askLimit, cwd)
+		if err != nil {
+			return err
+		}
+
+		if askJSON {
+			jsonBytes, err := json.MarshalIndent(answer, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+		printAnswer(os.Stdout, answer)
+		return nil
+	},
+}
+
+// askAnswer is the output of the ask command
+type askAnswer struct {
+	Question string      `json:"question"`
+	Answer   string      `json:"answer"`
+	Sources  []askSource `json:"sources"`
+}
+
+// askSource is a chunk given to the model as context, numbered as cited in the answer
+type askSource struct {
+	Ref       int    `json:"ref"`
+	FilePath  string `json:"file_path"` // Relative to the current directory when possible
+	LineStart int    `json:"line_start"`
+	LineEnd   int    `json:"line_end"`
+	Name      string `json:"name,omitempty"`
+}
+
+// runAsk retrieves the top chunks for question and asks the chat model to answer from them
+func runAsk(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, client llm.Client, question string, limit int, cwd string) (*askAnswer, error) {
+	page, err := executeSearch(ctx, store, metadata, searchOptions{
+		Query:  question,
+		Mode:   modeHybrid,
+		Limit:  limit,
+		Filter: storage.SearchFilter{Project: currentProject(cwd)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Results) == 0 {
+		return nil, fmt.Errorf("no indexed code matches the question")
+	}
+
+	sources := make([]askSource, len(page.Results))
+	for i, result := range page.Results {
+		sources[i] = askSource{Ref: i + 1, FilePath: displayPath(result.FilePath, cwd), LineStart: result.LineStart, LineEnd: result.LineEnd, Name: result.Name}
+	}
+
+	reply, err := client.CompleteContext(ctx, buildAskPrompt(question, page.Results, sources))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer: %w", err)
+	}
+	return &askAnswer{Question: question, Answer: strings.TrimSpace(reply), Sources: sources}, nil
+}
+
+// buildAskPrompt builds the chat messages for a question, with each retrieved
+// chunk labeled by its source reference and location
+func buildAskPrompt(question string, results []SearchResult, sources []askSource) []llm.Message {
+	var context strings.Builder
+	for i, result := range results {
+		source := sources[i]
+		fmt.Fprintf(&context, "[%d] %s:%d-%d", source.Ref, source.FilePath, source.LineStart, source.LineEnd)
+		if result.Name != "" {
+			fmt.Fprintf(&context, " (%s)", result.Name)
+		}
+		code := result.Code
+		if len(code) > maxContextChars {
+			code = code[:maxContextChars] + "\n..."
+		}
+		fmt.Fprintf(&context, "\n```%s\n%s\n```\n\n", result.Language, code)
+	}
+
+	return []llm.Message{
+		{Role: "system", Content: "You answer questions about a codebase using only the excerpts provided. " +
+			"Cite the excerpts you rely on as file:line (e.g. internal/foo.go:42). " +
+			"If the excerpts don't contain the answer, say so instead of guessing."},
+		{Role: "user", Content: fmt.Sprintf("Code excerpts:\n\n%sQuestion: %s", context.String(), question)},
+	}
+}
+
+// printAnswer writes an answer and its sources as human-readable text
+func printAnswer(w io.Writer, answer *askAnswer) {
+	fmt.Fprintf(w, "%s\n\nSources:\n", answer.Answer)
+	for _, source := range answer.Sources {
+		fmt.Fprintf(w, "  [%d] %s:%d-%d", source.Ref, source.FilePath, source.LineStart, source.LineEnd)
+		if source.Name != "" {
+			fmt.Fprintf(w, " (%s)", source.Name)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func init() {
+	askCmd.Flags().IntVar(&askLimit, "limit", 8, "Number of chunks to give the model as context")
+	askCmd.Flags().BoolVar(&askJSON, "json", false, "Output the answer and sources as JSON")
+	rootCmd.AddCommand(askCmd)
+}
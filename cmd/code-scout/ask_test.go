@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/llm"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// recordingChatClient returns a fixed reply and records the messages it was sent
+type recordingChatClient struct {
+	reply    string
+	messages []llm.Message
+}
+
+func (c *recordingChatClient) CompleteContext(_ context.Context, messages []llm.Message) (string, error) {
+	c.messages = messages
+	return c.reply, nil
+}
+
+func TestRunAsk(t *testing.T) {
+	installFakeEmbeddings(t)
+	store := &memoryStore{rows: []map[string]interface{}{
+		{"chunk_id": "c1", "file_path": "/repo/internal/models/switch.go", "line_start": float64(10), "line_end": float64(20),
+			"language": "go", "code": "func SwitchModel() {}", "embedding_type": "code", "name": "SwitchModel", "_distance": 0.2},
+	}}
+	client := &recordingChatClient{reply: "  Models are switched by SwitchModel (internal/models/switch.go:10).\n"}
+
+	answer, err := runAsk(context.Background(), store, &storage.IndexMetadata{}, client, "how does model switching work?", 5, "/repo")
+	if err != nil {
+		t.Fatalf("runAsk failed: %v", err)
+	}
+
+	if answer.Answer != "Models are switched by SwitchModel (internal/models/switch.go:10)." {
+		t.Errorf("unexpected answer: %q", answer.Answer)
+	}
+	if len(answer.Sources) != 1 || answer.Sources[0].FilePath != "internal/models/switch.go" {
+		t.Fatalf("unexpected sources: %+v", answer.Sources)
+	}
+
+	prompt := client.messages[len(client.messages)-1].Content
+	for _, want := range []string{"[1] internal/models/switch.go:10-20 (SwitchModel)", "func SwitchModel() {}", "how does model switching work?"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	var out bytes.Buffer
+	printAnswer(&out, answer)
+	if !strings.Contains(out.String(), "[1] internal/models/switch.go:10-20 (SwitchModel)") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
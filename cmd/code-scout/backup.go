@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var restoreForce bool
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <file>",
+	Short: "Snapshot the index to an archive",
+	Long: `Write the LanceDB tables and metadata.json from .code-scout/ to a gzipped tar
+archive with SHA-256 checksums for every file. Use 'code-scout restore' to load
+the archive on another machine. Backup holds the index lock, so the snapshot
+never catches a write half done; it fails if another command is writing the
+index unless --wait is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbDir, err := localIndexDir("backup")
+		if err != nil {
+			return err
+		}
+		release, err := acquireIndexLock(cmd.Context(), filepath.Dir(dbDir), "backup", waitForIndexLock)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		manifest, err := storage.CreateBackup(dbDir, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to back up index: %w (have you run 'code-scout index' first?)", err)
+		}
+
+		fmt.Printf("Backed up %d file(s) (%s) to %s\n", len(manifest.Files), formatBytes(manifest.TotalBytes), args[0])
+		fmt.Println("✓ Backup complete!")
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Replace the index with a backup archive",
+	Long: `Extract a backup created by 'code-scout backup' into .code-scout/. Every file
+is verified against the archive's checksums before the current index is
+replaced, so a corrupt archive leaves the existing index untouched. Restore
+holds the index lock, so it fails if another command is writing the index
+unless --wait is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbDir, err := localIndexDir("restore")
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(dbDir); err == nil && !restoreForce {
+			return fmt.Errorf("an index already exists in %s; use --force to replace it", dbDir)
+		}
+		release, err := acquireIndexLock(cmd.Context(), filepath.Dir(dbDir), "restore", waitForIndexLock)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		manifest, err := storage.RestoreBackup(args[0], dbDir)
+		if err != nil {
+			return fmt.Errorf("failed to restore index: %w", err)
+		}
+
+		fmt.Printf("Restored %d file(s) (%s) from backup created %s\n",
+			len(manifest.Files), formatBytes(manifest.TotalBytes), manifest.CreatedAt.Format("2006-01-02 15:04:05"))
+		if err := storage.CheckSchemaVersion(&storage.IndexMetadata{SchemaVersion: manifest.SchemaVersion}); err != nil {
+			fmt.Printf("Note: %v\n", err)
+		}
+		fmt.Println("✓ Restore complete!")
+		return nil
+	},
+}
+
+// localIndexDir returns the project's .code-scout/ directory, or an error if the
+// index lives in a remote store that backup and restore can't snapshot
+func localIndexDir(command string) (string, error) {
+	if globalConfig != nil && (globalConfig.Backend == "qdrant" || globalConfig.LanceDBURI != "" || globalConfig.GlobalIndex) {
+		return "", fmt.Errorf("%s is only supported for per-project lancedb indexes", command)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Join(cwd, storage.DefaultDBDir), nil
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Replace an existing index")
+	for _, cmd := range []*cobra.Command{backupCmd, restoreCmd} {
+		cmd.Flags().BoolVar(&waitForIndexLock, "wait", false, "Wait for a running command to release the index lock instead of failing")
+	}
+
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
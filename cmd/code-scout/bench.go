@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchModels  []string
+	benchSample  int
+	benchQueries int
+	benchJSON    bool
+)
+
+// benchTopK is how deep each benchmark query's results are searched for its chunk
+const benchTopK = 10
+
+// minBenchQueryWords is the shortest doc comment line used as a benchmark query
+const minBenchQueryWords = 3
+
+// newModelEmbeddingClient creates an embedding client for a named model with
+// the configured provider
+var newModelEmbeddingClient = func(model string) embeddings.Client {
+	if globalConfig != nil {
+		return newConfiguredEmbeddingClient(model)
+	}
+	return embeddings.NewClientWithModel(model)
+}
+
+var benchModelsCmd = &cobra.Command{
+	Use:   "bench-models",
+	Short: "Compare embedding models' retrieval quality and latency on this repo",
+	Long: `Index a sample of the repository's code chunks with each model into temporary
+tables, then search them with queries taken from the sample's doc comments. Each
+query should find the chunk it documents; the report shows how often it ranks
+first (hit@1) or in the top 10 (hit@10), the mean reciprocal rank, and embedding
+throughput and query latency, side by side. The project's index is not touched.
+
+Models default to the configured code_model and text_model.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		models := benchModels
+		if len(models) == 0 {
+			models = uniqueStrings([]string{codeModelName(), docsModelName()})
+		}
+		if benchSample < 1 || benchQueries < 1 {
+			return fmt.Errorf("--sample and --queries must be at least 1")
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		chunks, err := chunkRepository(cwd)
+		if err != nil {
+			return err
+		}
+		corpus, queries := selectBenchSample(chunks, benchSample, benchQueries)
+		if len(queries) == 0 {
+			return fmt.Errorf("no documented functions found to build benchmark queries from")
+		}
+		if !benchJSON {
+			fmt.Printf("Benchmarking %d model(s) on %d chunks with %d queries...\n", len(models), len(corpus), len(queries))
+		}
+
+		var reports []benchReport
+		for _, model := range models {
+			report, err := benchModel(cmd.Context(), model, corpus, queries)
+			if err != nil {
+				return fmt.Errorf("failed to benchmark %s: %w", model, err)
+			}
+			reports = append(reports, *report)
+		}
+
+		if benchJSON {
+			jsonBytes, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+		printBenchReports(os.Stdout, reports)
+		return nil
+	},
+}
+
+// benchQuery is a benchmark query and the chunk it should find
+type benchQuery struct {
+	Text    string
+	ChunkID string
+}
+
+// benchReport is one model's benchmark results
+type benchReport struct {
+	Model          string  `json:"model"`
+	Dimension      int     `json:"dimension"`
+	Chunks         int     `json:"chunks"`
+	Queries        int     `json:"queries"`
+	HitAt1         float64 `json:"hit_at_1"`  // Fraction of queries whose chunk ranked first
+	HitAt10        float64 `json:"hit_at_10"` // Fraction of queries whose chunk ranked in the top 10
+	MRR            float64 `json:"mrr"`       // Mean reciprocal rank (0 when outside the top 10)
+	ChunksPerSec   float64 `json:"chunks_per_second"`
+	QueryLatencyMS float64 `json:"query_latency_ms"` // Median time to embed and search a query
+}
+
+// chunkRepository scans and chunks the project in dir like index does, without embedding
+func chunkRepository(dir string) ([]chunker.Chunk, error) {
+	files, err := newScanner(dir).ScanCodeFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan files: %w", err)
+	}
+	semanticChunker, err := chunker.NewSemantic()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create semantic chunker: %w", err)
+	}
+	semanticChunker.SetSectionLimit(maxSectionTokens(), embeddings.CountTokens)
+
+	var chunks []chunker.Chunk
+	for _, f := range files {
+		fileChunks, err := semanticChunker.ChunkFile(f.Path, f.Language)
+		if err != nil {
+			return nil, fmt.Errorf("failed to chunk file %s: %w", f.Path, err)
+		}
+		chunks = append(chunks, fileChunks...)
+	}
+	return splitOversizedChunks(chunks), nil
+}
+
+// selectBenchSample picks up to size code chunks for the benchmark corpus and up
+// to queryCount queries from their doc comments. Documented chunks are picked
+// first, then the rest are spread evenly across the repository, so the result
+// is the same on every run.
+func selectBenchSample(chunks []chunker.Chunk, size, queryCount int) ([]chunker.Chunk, []benchQuery) {
+	var documented, other []chunker.Chunk
+	for _, chunk := range chunks {
+		if chunk.EmbeddingType != "code" {
+			continue
+		}
+		if benchQueryText(chunk) != "" {
+			documented = append(documented, chunk)
+		} else {
+			other = append(other, chunk)
+		}
+	}
+
+	documented = spread(documented, min(queryCount, size))
+	corpus := append(documented, spread(other, size-len(documented))...)
+	queries := make([]benchQuery, len(documented))
+	for i, chunk := range documented {
+		queries[i] = benchQuery{Text: benchQueryText(chunk), ChunkID: chunk.ID}
+	}
+	return corpus, queries
+}
+
+// benchQueryText returns the first line of a chunk's doc comment if it is long
+// enough to be a query, or ""
+func benchQueryText(chunk chunker.Chunk) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(chunk.Metadata["doc_comment"]), "\n")
+	if len(strings.Fields(line)) < minBenchQueryWords {
+		return ""
+	}
+	return strings.TrimSpace(line)
+}
+
+// spread returns n chunks evenly spaced through chunks, or all of them if there are fewer
+func spread(chunks []chunker.Chunk, n int) []chunker.Chunk {
+	if n <= 0 {
+		return nil
+	}
+	if len(chunks) <= n {
+		return chunks
+	}
+	picked := make([]chunker.Chunk, n)
+	for i := range picked {
+		picked[i] = chunks[i*len(chunks)/n]
+	}
+	return picked
+}
+
+// benchModel embeds the corpus with model into a temporary store and runs the queries against it
+func benchModel(ctx context.Context, model string, corpus []chunker.Chunk, queries []benchQuery) (*benchReport, error) {
+	client := newModelEmbeddingClient(model)
+
+	// The code is embedded alone: the queries are the chunks' doc comments,
+	// which the index's chunk_template would put in the embedded text
+	texts := make([]string, len(corpus))
+	for i, chunk := range corpus {
+		texts[i] = chunk.Code
+	}
+
+	start := time.Now()
+	vectors, err := generateEmbeddingsWithDedup(ctx, client, texts, workers, embeddingBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	embedTime := time.Since(start)
+
+	tempDir, err := os.MkdirTemp("", "code-scout-bench-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := storage.NewLanceDBStore(tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp store: %w", err)
+	}
+	defer store.Close()
+	if err := store.StoreChunks(corpus, vectors); err != nil {
+		return nil, fmt.Errorf("failed to store chunks: %w", err)
+	}
+
+	report := &benchReport{Model: model, Chunks: len(corpus), Queries: len(queries)}
+	if len(vectors) > 0 {
+		report.Dimension = len(vectors[0])
+	}
+	if seconds := embedTime.Seconds(); seconds > 0 {
+		report.ChunksPerSec = float64(len(corpus)) / seconds
+	}
+
+	ranks := make([]int, len(queries))
+	latencies := make([]time.Duration, len(queries))
+	for i, query := range queries {
+		queryStart := time.Now()
+		vector, err := embeddings.EmbedQuery(ctx, client, query.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		rows, err := store.Search("code", vector, benchTopK, storage.SearchFilter{})
+		if err != nil {
+			return nil, err
+		}
+		latencies[i] = time.Since(queryStart)
+		ranks[i] = resultRank(formatResults(rows), query.ChunkID)
+	}
+	report.HitAt1, report.HitAt10, report.MRR = rankMetrics(ranks)
+	report.QueryLatencyMS = float64(median(latencies)) / float64(time.Millisecond)
+	return report, nil
+}
+
+// resultRank returns the 1-based rank of a chunk in results, or 0 if it is missing
+func resultRank(results []SearchResult, chunkID string) int {
+	for i, result := range results {
+		if result.ChunkID == chunkID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// rankMetrics returns hit@1, hit@10 and mean reciprocal rank for 1-based ranks (0 for a miss)
+func rankMetrics(ranks []int) (hitAt1, hitAt10, mrr float64) {
+	if len(ranks) == 0 {
+		return 0, 0, 0
+	}
+	for _, rank := range ranks {
+		if rank == 0 {
+			continue
+		}
+		if rank == 1 {
+			hitAt1++
+		}
+		if rank <= benchTopK {
+			hitAt10++
+		}
+		mrr += 1 / float64(rank)
+	}
+	n := float64(len(ranks))
+	return hitAt1 / n, hitAt10 / n, mrr / n
+}
+
+// median returns the median duration, or 0 for none
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// uniqueStrings returns values without repeats, keeping the first occurrence's position
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// printBenchReports writes the reports as a table, one model per row
+func printBenchReports(w io.Writer, reports []benchReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tDIM\tHIT@1\tHIT@10\tMRR\tCHUNKS/S\tQUERY MS")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.3f\t%.1f\t%.1f\n",
+			r.Model, r.Dimension, r.HitAt1, r.HitAt10, r.MRR, r.ChunksPerSec, r.QueryLatencyMS)
+	}
+	tw.Flush()
+}
+
+func init() {
+	benchModelsCmd.Flags().StringSliceVar(&benchModels, "models", nil, "Comma-separated embedding models to compare (default: the configured code and text models)")
+	benchModelsCmd.Flags().IntVar(&benchSample, "sample", 200, "Number of code chunks to index per model")
+	benchModelsCmd.Flags().IntVar(&benchQueries, "queries", 50, "Maximum number of doc comment queries")
+	benchModelsCmd.Flags().BoolVar(&benchJSON, "json", false, "Output the reports as JSON")
+	rootCmd.AddCommand(benchModelsCmd)
+}
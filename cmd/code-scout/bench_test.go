@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+func TestSelectBenchSample(t *testing.T) {
+	var chunks []chunker.Chunk
+	for i := 0; i < 10; i++ {
+		chunk := chunker.Chunk{ID: fmt.Sprintf("c%d", i), EmbeddingType: "code", Metadata: map[string]string{}}
+		if i%3 == 0 {
+			chunk.Metadata["doc_comment"] = fmt.Sprintf("Handle request number %d\nMore detail", i)
+		}
+		chunks = append(chunks, chunk)
+	}
+	chunks = append(chunks,
+		chunker.Chunk{ID: "short", EmbeddingType: "code", Metadata: map[string]string{"doc_comment": "Helper"}},
+		chunker.Chunk{ID: "docs", EmbeddingType: "docs", Metadata: map[string]string{"doc_comment": "Not a code chunk at all"}},
+	)
+
+	corpus, queries := selectBenchSample(chunks, 6, 2)
+	if len(corpus) != 6 || len(queries) != 2 {
+		t.Fatalf("expected 6 chunks and 2 queries, got %d and %d", len(corpus), len(queries))
+	}
+	if queries[0].ChunkID != "c0" || queries[0].Text != "Handle request number 0" || queries[1].ChunkID != "c6" {
+		t.Errorf("unexpected queries: %+v", queries)
+	}
+	for _, chunk := range corpus {
+		if chunk.ID == "docs" {
+			t.Error("expected docs chunks to be left out of the corpus")
+		}
+	}
+}
+
+func TestRankMetrics(t *testing.T) {
+	hitAt1, hitAt10, mrr := rankMetrics([]int{1, 2, 0, 4})
+	if hitAt1 != 0.25 || hitAt10 != 0.75 || mrr != (1+0.5+0.25)/4 {
+		t.Errorf("unexpected metrics: hit@1 %v, hit@10 %v, mrr %v", hitAt1, hitAt10, mrr)
+	}
+}
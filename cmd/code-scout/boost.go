@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/pathglob"
+)
+
+// recencyHalfLife is the file age at which the recency boost falls to half its weight
+const recencyHalfLife = 30 * 24 * time.Hour
+
+// rankingScore is the score results are ordered by: the fused score when
+// rankings were merged, otherwise the normalized score, times any boost
+func rankingScore(result SearchResult) float64 {
+	score := result.NormalizedScore
+	if result.FusedScore > 0 {
+		score = result.FusedScore
+	}
+	if result.Boost > 0 {
+		score *= result.Boost
+	}
+	return score
+}
+
+// applyBoost multiplies each result's boost by factor(result) and re-orders
+// results by their boosted ranking score. Ties keep their previous order.
+func applyBoost(results []SearchResult, factor func(SearchResult) float64) []SearchResult {
+	for i := range results {
+		if results[i].Boost == 0 {
+			results[i].Boost = 1
+		}
+		results[i].Boost *= factor(results[i])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return rankingScore(results[i]) > rankingScore(results[j])
+	})
+	return results
+}
+
+// recencyBoost returns a boost favoring recently modified files: 1 + weight for
+// a file modified at now, decaying by half every recencyHalfLife. Files with no
+// recorded modification time are not boosted.
+func recencyBoost(modTimes map[string]time.Time, weight float64, now time.Time) func(SearchResult) float64 {
+	return func(result SearchResult) float64 {
+		modTime, ok := modTimes[result.FilePath]
+		if !ok {
+			return 1
+		}
+		age := max(now.Sub(modTime), 0)
+		return 1 + weight*math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+	}
+}
+
+// pathBoost returns a boost from the boost config: the product of the factors
+// of every glob matching the result's path relative to root. Results outside
+// root are not boosted.
+func pathBoost(boosts map[string]float64, root string) func(SearchResult) float64 {
+	return func(result SearchResult) float64 {
+		rel, err := filepath.Rel(root, result.FilePath)
+		if err != nil || !filepath.IsLocal(rel) {
+			return 1
+		}
+		rel = filepath.ToSlash(rel)
+
+		factor := 1.0
+		for pattern, boost := range boosts {
+			if pathglob.Match(pattern, rel) {
+				factor *= boost
+			}
+		}
+		return factor
+	}
+}
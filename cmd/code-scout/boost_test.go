@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestApplyBoost_Recency(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	modTimes := map[string]time.Time{
+		"/repo/old.go": now.Add(-365 * 24 * time.Hour),
+		"/repo/new.go": now.Add(-time.Hour),
+	}
+	results := []SearchResult{
+		{ChunkID: "old", FilePath: "/repo/old.go", NormalizedScore: 0.6},
+		{ChunkID: "new", FilePath: "/repo/new.go", NormalizedScore: 0.5},
+		{ChunkID: "untracked", FilePath: "/repo/x.go", NormalizedScore: 0.4},
+	}
+
+	boosted := applyBoost(results, recencyBoost(modTimes, 0.5, now))
+	var ids []string
+	for _, r := range boosted {
+		ids = append(ids, r.ChunkID)
+	}
+	if fmt.Sprint(ids) != "[new old untracked]" {
+		t.Errorf("expected the recent file first, got %v", ids)
+	}
+	if boosted[2].Boost != 1 {
+		t.Errorf("expected no boost without a modification time, got %v", boosted[2].Boost)
+	}
+}
+
+func TestRecencyBoost_HalfLife(t *testing.T) {
+	now := time.Now()
+	boost := recencyBoost(map[string]time.Time{"/a.go": now.Add(-recencyHalfLife)}, 1, now)
+	if got := boost(SearchResult{FilePath: "/a.go"}); math.Abs(got-1.5) > 1e-9 {
+		t.Errorf("expected half the weight after one half-life, got %v", got)
+	}
+}
+
+func TestPathBoost(t *testing.T) {
+	boost := pathBoost(map[string]float64{"internal/core/**": 1.5, "**/testdata/**": 0.5, "**/*.go": 2}, "/repo")
+
+	tests := []struct {
+		path     string
+		expected float64
+	}{
+		{"/repo/internal/core/engine.go", 3},
+		{"/repo/internal/core/testdata/fixture.json", 0.75},
+		{"/repo/README.md", 1},
+		{"/elsewhere/internal/core/x.md", 1},
+	}
+	for _, tt := range tests {
+		if got := boost(SearchResult{FilePath: tt.path}); got != tt.expected {
+			t.Errorf("pathBoost(%s) = %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
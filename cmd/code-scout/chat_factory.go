@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jlanders/code-scout/internal/llm"
+)
+
+// newChatClient creates the chat client for LLM features from the chat_* config
+var newChatClient = func() (llm.Client, error) {
+	if globalConfig == nil || globalConfig.ChatModel == "" {
+		return nil, fmt.Errorf("no chat model configured; set chat_model (and optionally chat_endpoint) in .code-scout.json")
+	}
+	endpoint, apiKey := globalConfig.ChatSettings()
+	return llm.NewClient(endpoint, apiKey, globalConfig.ChatModel), nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/llm"
+	"github.com/jlanders/code-scout/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sourceHashKey is the metadata field of a summary row holding the hash of
+// the code it summarizes, so a summary is only regenerated when its code changes
+const sourceHashKey = "source_hash"
+
+// chunkSummarizer has the chat model summarize large code chunks, so queries
+// about what a big function does can match a short description of it
+type chunkSummarizer struct {
+	client    llm.Client
+	threshold int // Code chunks over this many tokens are summarized
+	text      *chunkText
+}
+
+// newChunkSummarizer returns the summarizer configured by
+// summarize_chunks_over, or nil if summaries are off
+func newChunkSummarizer(text *chunkText) (*chunkSummarizer, error) {
+	if globalConfig == nil || globalConfig.SummarizeChunksOver <= 0 {
+		return nil, nil
+	}
+	client, err := newChatClient()
+	if err != nil {
+		return nil, err
+	}
+	return &chunkSummarizer{client: client, threshold: globalConfig.SummarizeChunksOver, text: text}, nil
+}
+
+// rows returns a docs row describing each code chunk over the threshold,
+// holding its summary. A summary in cached (by code hash) is reused; the chat
+// model is only asked for chunks that are new or changed. Summaries are
+// optional, so a chunk whose summary fails is left without one, with a
+// warning; only cancelling ctx fails.
+func (s *chunkSummarizer) rows(ctx context.Context, chunks []chunker.Chunk, cached map[string]string, numWorkers int) ([]chunker.Chunk, error) {
+	var large []chunker.Chunk
+	var hashes, summaries []string
+	var toSummarize []int
+	for _, chunk := range chunks {
+		if chunk.EmbeddingType != "code" || embeddings.CountTokens(chunk.Code) <= s.threshold {
+			continue
+		}
+		hash := computeContentHash(chunk.Code)
+		if cached[hash] == "" {
+			toSummarize = append(toSummarize, len(large))
+		}
+		large = append(large, chunk)
+		hashes = append(hashes, hash)
+		summaries = append(summaries, cached[hash])
+	}
+	if len(large) == 0 {
+		return nil, nil
+	}
+
+	if len(toSummarize) > 0 {
+		fmt.Printf("Summarizing %d chunk(s) over %d tokens (%d unchanged reused)...\n",
+			len(toSummarize), s.threshold, len(large)-len(toSummarize))
+		ctx, span := startSpan(ctx, "summarize", attribute.Int("code_scout.chunks", len(toSummarize)))
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, max(1, numWorkers))
+		for _, i := range toSummarize {
+			chunk := large[i]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					return
+				}
+				chatCtx, chatSpan := startSpan(ctx, "summarize.chunk", attribute.String("code_scout.path", chunk.FilePath))
+				summary, err := s.client.CompleteContext(chatCtx, s.prompt(chunk))
+				endSpan(chatSpan, err)
+				if err != nil {
+					fmt.Printf("Warning: failed to summarize %s:%d-%d: %v\n", chunk.FilePath, chunk.LineStart, chunk.LineEnd, err)
+					return
+				}
+				summaries[i] = strings.TrimSpace(summary)
+			}()
+		}
+		wg.Wait()
+		err := ctx.Err()
+		endSpan(span, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []chunker.Chunk
+	for i, chunk := range large {
+		if summaries[i] == "" {
+			continue
+		}
+		rows = append(rows, chunker.Chunk{
+			ID:            uuid.New().String(),
+			FilePath:      chunk.FilePath,
+			LineStart:     chunk.LineStart,
+			LineEnd:       chunk.LineEnd,
+			Language:      chunk.Language,
+			Code:          summaries[i],
+			ChunkType:     chunk.ChunkType,
+			Name:          chunk.Name,
+			EmbeddingType: "docs",
+			Metadata:      map[string]string{describesKey: chunk.ID, sourceHashKey: hashes[i]},
+		})
+	}
+	return rows, nil
+}
+
+// cachedSummaries returns the summaries stored for files' code chunks, by the
+// hash of the code each summarizes
+func cachedSummaries(store storage.Store, filePaths []string) (map[string]string, error) {
+	cached := make(map[string]string)
+	for _, path := range filePaths {
+		rows, err := store.FileChunks(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stored summaries: %w", err)
+		}
+		for _, row := range rows {
+			metadata := storage.DecodeChunkMetadata(getStringOrDefault(row, "metadata", ""))
+			if hash := metadata[sourceHashKey]; hash != "" && metadata[describesKey] != "" {
+				cached[hash] = getStringOrDefault(row, "code", "")
+			}
+		}
+	}
+	return cached, nil
+}
+
+// prompt builds the chat messages asking for a chunk's summary
+func (s *chunkSummarizer) prompt(chunk chunker.Chunk) []llm.Message {
+	fields := s.text.fields(chunk)
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "File: %s\n", fields.Path)
+	if fields.Symbol != "" {
+		fmt.Fprintf(&prompt, "Symbol: %s\n", fields.Symbol)
+	}
+	fmt.Fprintf(&prompt, "\n```%s\n%s\n```\n", chunk.Language, chunk.Code)
+
+	return []llm.Message{
+		{Role: "system", Content: "You summarize code for a search index. In one paragraph of plain prose, " +
+			"say what the code does, what it takes and returns, and any notable behavior such as side effects " +
+			"or error handling. Don't restate the code line by line or include code."},
+		{Role: "user", Content: prompt.String()},
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/config"
+	"github.com/jlanders/code-scout/internal/llm"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// failingChatClient fails every completion
+type failingChatClient struct{}
+
+func (failingChatClient) CompleteContext(context.Context, []llm.Message) (string, error) {
+	return "", errors.New("chat server down")
+}
+
+// indexWithSummaries indexes a file with one large function, summarizing it with client
+func indexWithSummaries(t *testing.T, client llm.Client) (store *persistentStore, workDir, output string) {
+	t.Helper()
+	installFakeEmbeddings(t)
+	prevConfig := globalConfig
+	globalConfig = &config.Config{ChatModel: "chat", SummarizeChunksOver: 10}
+	t.Cleanup(func() { globalConfig = prevConfig })
+	prevChat := newChatClient
+	newChatClient = func() (llm.Client, error) { return client, nil }
+	t.Cleanup(func() { newChatClient = prevChat })
+
+	workDir = t.TempDir()
+	writeTestFile(t, workDir, "main.go", "package main\n\nfunc Fib(n int) int {\n\tif n < 2 {\n\t\treturn n\n\t}\n\treturn Fib(n-1) + Fib(n-2)\n}\n")
+	store = &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	output = captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("index failed: %v", err)
+		}
+	})
+	return store, workDir, output
+}
+
+func TestRunIndex_SummarizesLargeChunks(t *testing.T) {
+	client := &recordingChatClient{reply: "  Computes the nth Fibonacci number recursively.\n"}
+	store, _, _ := indexWithSummaries(t, client)
+
+	if len(client.messages) != 2 || !strings.Contains(client.messages[1].Content, "func Fib") {
+		t.Errorf("expected the chunk's code in the prompt, got %+v", client.messages)
+	}
+	var codeID, describes string
+	for _, row := range store.rows {
+		switch row["embedding_type"] {
+		case "code":
+			codeID = row["chunk_id"].(string)
+		case "docs":
+			if row["code"] != "Computes the nth Fibonacci number recursively." {
+				t.Errorf("expected the trimmed summary stored, got %q", row["code"])
+			}
+			describes = storage.DecodeChunkMetadata(row["metadata"].(string))[describesKey]
+		}
+	}
+	if codeID == "" || describes != codeID {
+		t.Errorf("expected the summary to describe the code chunk %q, got %q", codeID, describes)
+	}
+}
+
+func TestRunIndex_FailedSummaryKeepsIndexing(t *testing.T) {
+	store, _, output := indexWithSummaries(t, failingChatClient{})
+
+	if !strings.Contains(output, "Warning: failed to summarize") {
+		t.Errorf("expected a warning about the failed summary, got:\n%s", output)
+	}
+	for _, row := range store.rows {
+		if row["embedding_type"] == "docs" {
+			t.Errorf("expected no summary row, got %v", row)
+		}
+	}
+	if len(store.rows) == 0 {
+		t.Error("expected the code chunk stored")
+	}
+}
+
+// countingChatClient replies with a summary naming the first function in the prompt
+type countingChatClient struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (c *countingChatClient) CompleteContext(_ context.Context, messages []llm.Message) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prompt := messages[len(messages)-1].Content
+	c.prompts = append(c.prompts, prompt)
+	name, _, _ := strings.Cut(prompt[strings.Index(prompt, "func ")+len("func "):], "(")
+	return "Summary of " + name, nil
+}
+
+func TestRunIndex_ReusesSummariesOfUnchangedChunks(t *testing.T) {
+	client := &countingChatClient{}
+	store, workDir, _ := indexWithSummaries(t, client)
+	if len(client.prompts) != 1 {
+		t.Fatalf("expected one summary on the first run, got %d", len(client.prompts))
+	}
+
+	path := filepath.Join(workDir, "main.go")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, workDir, "main.go", string(content)+"\nfunc Fact(n int) int {\n\tif n < 2 {\n\t\treturn 1\n\t}\n\treturn n * Fact(n-1)\n}\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("re-index failed: %v", err)
+		}
+	})
+
+	if len(client.prompts) != 2 || !strings.Contains(client.prompts[1], "func Fact") {
+		t.Fatalf("expected only the new chunk summarized, got prompts %q", client.prompts)
+	}
+	codeIDs := make(map[string]string)
+	summaries := make(map[string]string)
+	for _, row := range store.rows {
+		switch row["embedding_type"] {
+		case "code":
+			codeIDs[row["name"].(string)] = row["chunk_id"].(string)
+		case "docs":
+			summaries[row["code"].(string)] = storage.DecodeChunkMetadata(row["metadata"].(string))[describesKey]
+		}
+	}
+	if summaries["Summary of Fib"] != codeIDs["Fib"] || summaries["Summary of Fact"] != codeIDs["Fact"] {
+		t.Errorf("expected each summary to describe its new code chunk, got summaries %v for chunks %v", summaries, codeIDs)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// defaultChunkTemplate puts a code chunk's context ahead of its code, so its
+// embedding reflects where the code lives and what it's for, not just its body
+const defaultChunkTemplate = `File: {{.Path}}
+{{with .Package}}Package: {{.}}
+{{end}}{{with .Symbol}}Symbol: {{.}}
+{{end}}{{with .Signature}}Signature: {{.}}
+{{end}}{{with .DocComment}}Doc: {{.}}
+{{end}}
+{{.Code}}`
+
+// chunkTextFields are the fields available to chunk_template
+type chunkTextFields struct {
+	Path       string // Relative to the project root, with forward slashes
+	Package    string
+	Language   string
+	Symbol     string // e.g. "Store.Search" for a method
+	Signature  string
+	DocComment string
+	Code       string
+}
+
+// chunkText renders the text embedded for each chunk. Docs chunks are
+// embedded as they are; code chunks through chunk_template.
+type chunkText struct {
+	tmpl *template.Template
+	root string
+}
+
+// newChunkText parses the configured chunk_template, or the default, for a
+// project rooted at root. The template is tried on an empty chunk, so a
+// field that doesn't exist fails here rather than part way through indexing.
+func newChunkText(root string) (*chunkText, error) {
+	source := defaultChunkTemplate
+	if globalConfig != nil && globalConfig.ChunkTemplate != "" {
+		source = globalConfig.ChunkTemplate
+	}
+	tmpl, err := template.New("chunk_template").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chunk_template: %w", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, chunkTextFields{}); err != nil {
+		return nil, fmt.Errorf("invalid chunk_template: %w", err)
+	}
+	return &chunkText{tmpl: tmpl, root: root}, nil
+}
+
+// texts returns the text to embed for each chunk
+func (c *chunkText) texts(chunks []chunker.Chunk) ([]string, error) {
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		if chunk.EmbeddingType != "code" {
+			texts[i] = chunk.Code
+			continue
+		}
+		var text strings.Builder
+		if err := c.tmpl.Execute(&text, c.fields(chunk)); err != nil {
+			return nil, fmt.Errorf("failed to render chunk_template for %s: %w", chunk.FilePath, err)
+		}
+		texts[i] = text.String()
+	}
+	return texts, nil
+}
+
+// fields returns the template fields for a chunk
+func (c *chunkText) fields(chunk chunker.Chunk) chunkTextFields {
+	path := chunk.FilePath
+	if rel, err := filepath.Rel(c.root, path); err == nil && filepath.IsLocal(rel) {
+		path = rel
+	}
+	symbol := chunk.Name
+	if receiver := strings.TrimPrefix(chunk.Metadata["receiver"], "*"); receiver != "" && symbol != "" {
+		symbol = receiver + "." + symbol
+	}
+	return chunkTextFields{
+		Path:       filepath.ToSlash(path),
+		Package:    chunk.Metadata["package"],
+		Language:   chunk.Language,
+		Symbol:     symbol,
+		Signature:  chunk.Metadata["signature"],
+		DocComment: chunk.Metadata["doc_comment"],
+		Code:       chunk.Code,
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/config"
+)
+
+func TestChunkText_DefaultTemplate(t *testing.T) {
+	prevConfig := globalConfig
+	globalConfig = &config.Config{}
+	t.Cleanup(func() { globalConfig = prevConfig })
+
+	root := t.TempDir()
+	text, err := newChunkText(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := []chunker.Chunk{
+		{
+			FilePath:      filepath.Join(root, "internal", "store", "search.go"),
+			Language:      "go",
+			Code:          "func (s *Store) Search(q string) []Result {\n\treturn nil\n}",
+			Name:          "Search",
+			EmbeddingType: "code",
+			Metadata: map[string]string{
+				"package":     "store",
+				"receiver":    "*Store",
+				"signature":   "(q string) []Result",
+				"doc_comment": "Search finds matching results",
+			},
+		},
+		{FilePath: filepath.Join(root, "README.md"), Code: "# Store", EmbeddingType: "docs"},
+	}
+	texts, err := text.texts(chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "File: internal/store/search.go\n" +
+		"Package: store\n" +
+		"Symbol: Store.Search\n" +
+		"Signature: (q string) []Result\n" +
+		"Doc: Search finds matching results\n" +
+		"\n" + chunks[0].Code
+	if texts[0] != want {
+		t.Errorf("code chunk text:\n%s\nwant:\n%s", texts[0], want)
+	}
+	if texts[1] != "# Store" {
+		t.Errorf("expected docs chunks embedded as they are, got %q", texts[1])
+	}
+}
+
+func TestChunkText_ConfiguredTemplate(t *testing.T) {
+	prevConfig := globalConfig
+	t.Cleanup(func() { globalConfig = prevConfig })
+
+	globalConfig = &config.Config{ChunkTemplate: "{{.Code}}"}
+	text, err := newChunkText(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts, err := text.texts([]chunker.Chunk{{Code: "func f() {}", Name: "f", EmbeddingType: "code"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts[0] != "func f() {}" {
+		t.Errorf("expected the code alone, got %q", texts[0])
+	}
+
+	globalConfig = &config.Config{ChunkTemplate: "{{.Module}} {{.Code}}"}
+	if _, err := newChunkText(t.TempDir()); err == nil {
+		t.Error("expected a template with an unknown field to be rejected")
+	}
+}
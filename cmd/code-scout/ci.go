@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of index --ci
+const (
+	ciExitIndexed   = 0         // Files were indexed, renamed or removed
+	ciExitError     = exitError // Or the code for the error's cause (see exitCodeFor)
+	ciExitUnchanged = 2         // The index was already up to date
+)
+
+// Statuses in the index --ci report
+const (
+	ciStatusIndexed   = "indexed"
+	ciStatusUnchanged = "unchanged"
+	ciStatusError     = "error"
+)
+
+// ciReport is the JSON summary index --ci prints to stdout
+type ciReport struct {
+	Status string `json:"status"` // indexed, unchanged or error
+	indexSummary
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// runIndexCI runs an index with its progress output sent to stderr, prints a
+// ciReport to stdout and returns an exitCodeError carrying the status's exit code
+func runIndexCI(ctx context.Context, cmd *cobra.Command, cwd string) error {
+	// The report is the only output; the error is already in it
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	start := time.Now()
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	summary, err := runIndex(ctx, cwd)
+	os.Stdout = stdout
+
+	report, code := newCIReport(summary, err, time.Since(start))
+	if writeErr := writeCIReport(stdout, report); writeErr != nil {
+		return &exitCodeError{code: ciExitError, err: writeErr}
+	}
+	return &exitCodeError{code: code}
+}
+
+// newCIReport builds the report and exit code for an index run's outcome
+func newCIReport(summary indexSummary, err error, elapsed time.Duration) (ciReport, int) {
+	report := ciReport{indexSummary: summary, DurationMS: elapsed.Milliseconds()}
+	switch {
+	case err != nil:
+		report.Status = ciStatusError
+		report.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			report.Error = fmt.Sprintf("index timed out after %s: %v", elapsed.Round(time.Second), err)
+		}
+		return report, exitCodeFor(err)
+	case summary.changed():
+		report.Status = ciStatusIndexed
+		return report, ciExitIndexed
+	default:
+		report.Status = ciStatusUnchanged
+		return report, ciExitUnchanged
+	}
+}
+
+// writeCIReport writes the report as one line of JSON
+func writeCIReport(w io.Writer, report ciReport) error {
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return err
+}
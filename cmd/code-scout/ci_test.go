@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCIReport(t *testing.T) {
+	tests := []struct {
+		name       string
+		summary    indexSummary
+		err        error
+		wantStatus string
+		wantCode   int
+	}{
+		{"indexed", indexSummary{Indexed: 2, Chunks: 9}, nil, ciStatusIndexed, ciExitIndexed},
+		{"deleted only", indexSummary{Deleted: 1}, nil, ciStatusIndexed, ciExitIndexed},
+		{"unchanged", indexSummary{}, nil, ciStatusUnchanged, ciExitUnchanged},
+		{"error", indexSummary{Renamed: 1}, errors.New("embedding server down"), ciStatusError, ciExitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, code := newCIReport(tt.summary, tt.err, time.Second)
+			if report.Status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("expected %s/%d, got %s/%d", tt.wantStatus, tt.wantCode, report.Status, code)
+			}
+			if report.indexSummary != tt.summary || report.DurationMS != 1000 {
+				t.Errorf("expected the summary and duration in the report, got %+v", report)
+			}
+		})
+	}
+
+	report, _ := newCIReport(indexSummary{}, fmt.Errorf("failed to generate code embeddings: %w", context.DeadlineExceeded), 90*time.Second)
+	if !strings.HasPrefix(report.Error, "index timed out after 1m30s") {
+		t.Errorf("expected a timeout message, got %q", report.Error)
+	}
+}
+
+func TestWriteCIReport(t *testing.T) {
+	var buf bytes.Buffer
+	report, _ := newCIReport(indexSummary{Indexed: 1, Chunks: 3}, nil, 1500*time.Millisecond)
+	if err := writeCIReport(&buf, report); err != nil {
+		t.Fatalf("writeCIReport failed: %v", err)
+	}
+	want := `{"status":"indexed","indexed":1,"renamed":0,"deleted":0,"chunks":3,"duration_ms":1500}` + "\n"
+	if buf.String() != want {
+		t.Errorf("expected %s, got %s", want, buf.String())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+)
+
+// compareSide is one index's models and results in search --compare output
+type compareSide struct {
+	CodeModel string         `json:"code_model"`
+	DocsModel string         `json:"docs_model"`
+	Results   []SearchResult `json:"results"`
+}
+
+// compareResponse is the output of search --compare --json
+type compareResponse struct {
+	SchemaVersion int         `json:"schema_version"`
+	Query         string      `json:"query"`
+	Mode          string      `json:"mode"`
+	Main          compareSide `json:"main"`
+	Shadow        compareSide `json:"shadow"`
+	Shared        int         `json:"shared"` // Results found by both indexes
+}
+
+// runCompare runs a search against the main index and the shadow index and
+// prints both result lists
+func runCompare(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, opts searchOptions, format, cwd string) error {
+	models, ok := shadowModels()
+	if !ok {
+		return fmt.Errorf("--compare needs shadow_code_model or shadow_text_model in the config")
+	}
+
+	shadow, err := openShadowStore(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to open shadow index: %w", err)
+	}
+	defer shadow.Close()
+	if err := shadow.OpenTable(); err != nil {
+		return fmt.Errorf("failed to open shadow index: %w (run 'code-scout index' to build it)", err)
+	}
+	shadowMetadata, err := shadow.LoadMetadata()
+	if err != nil {
+		return fmt.Errorf("failed to load shadow metadata: %w", err)
+	}
+	if err := storage.CheckSchemaVersion(shadowMetadata); err != nil {
+		return err
+	}
+
+	mainPage, err := executeSearch(ctx, store, metadata, opts)
+	if err != nil {
+		return err
+	}
+	shadowOpts := opts
+	shadowOpts.Models = &models
+	shadowPage, err := executeSearch(ctx, shadow, shadowMetadata, shadowOpts)
+	if err != nil {
+		return fmt.Errorf("shadow index: %w", err)
+	}
+
+	main := primaryModels()
+	output := compareResponse{
+		SchemaVersion: searchapi.SchemaVersion,
+		Query:         opts.Query,
+		Mode:          string(opts.Mode),
+		Main:          compareSide{CodeModel: main.Code, DocsModel: main.Docs, Results: mainPage.Results},
+		Shadow:        compareSide{CodeModel: models.Code, DocsModel: models.Docs, Results: shadowPage.Results},
+	}
+	for _, result := range output.Main.Results {
+		if rankOf(output.Shadow.Results, result) > 0 {
+			output.Shared++
+		}
+	}
+
+	if format == formatJSON {
+		jsonBytes, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(jsonBytes))
+		return nil
+	}
+	printComparison(os.Stdout, output)
+	return nil
+}
+
+// rankOf returns the 1-based rank of the chunk at result's location in
+// results, or 0 if it is missing. Chunk IDs differ between indexes, so chunks
+// are matched by file and line range.
+func rankOf(results []SearchResult, result SearchResult) int {
+	for i, other := range results {
+		if other.FilePath == result.FilePath && other.LineStart == result.LineStart && other.LineEnd == result.LineEnd {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// printComparison writes both result lists, noting each result's rank in the other list
+func printComparison(w io.Writer, output compareResponse) {
+	fmt.Fprintf(w, "Comparing %s results for: %s\n", output.Mode, output.Query)
+	fmt.Fprintf(w, "Shared results: %d of %d\n", output.Shared, max(len(output.Main.Results), len(output.Shadow.Results)))
+
+	sides := []struct {
+		name, otherName string
+		side, other     compareSide
+	}{
+		{"main", "shadow", output.Main, output.Shadow},
+		{"shadow", "main", output.Shadow, output.Main},
+	}
+	for _, s := range sides {
+		fmt.Fprintf(w, "\n%s index (code: %s, docs: %s):\n", s.name, s.side.CodeModel, s.side.DocsModel)
+		for i, result := range s.side.Results {
+			fmt.Fprintf(w, "%2d. %s:%d-%d (%s)", i+1, result.FilePath, result.LineStart, result.LineEnd, describeScore(result))
+			if rank := rankOf(s.other.Results, result); rank > 0 {
+				fmt.Fprintf(w, " [%s #%d]", s.otherName, rank)
+			} else {
+				fmt.Fprintf(w, " [only %s]", s.name)
+			}
+			if result.Name != "" {
+				fmt.Fprintf(w, " %s", result.Name)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/config"
+)
+
+func TestShadowModels(t *testing.T) {
+	prevConfig := globalConfig
+	t.Cleanup(func() { globalConfig = prevConfig })
+
+	globalConfig = &config.Config{CodeModel: "code-a", TextModel: "text-a"}
+	if _, ok := shadowModels(); ok {
+		t.Error("expected no shadow index without shadow models")
+	}
+
+	globalConfig.ShadowCodeModel = "code-b"
+	models, ok := shadowModels()
+	if !ok || models.Code != "code-b" || models.Docs != "text-a" {
+		t.Errorf("expected code-b with the main text model, got %+v (ok=%v)", models, ok)
+	}
+}
+
+func TestPrintComparison(t *testing.T) {
+	shared := SearchResult{FilePath: "a.go", LineStart: 1, LineEnd: 10, Score: 0.2}
+	output := compareResponse{
+		Query: "parse config",
+		Mode:  "code",
+		Main: compareSide{CodeModel: "code-a", DocsModel: "text-a", Results: []SearchResult{
+			shared,
+			{FilePath: "b.go", LineStart: 5, LineEnd: 9, Score: 0.4},
+		}},
+		Shadow: compareSide{CodeModel: "code-b", DocsModel: "text-a", Results: []SearchResult{
+			{FilePath: "c.go", LineStart: 3, LineEnd: 4, Score: 0.1},
+			shared,
+		}},
+		Shared: 1,
+	}
+
+	var buf bytes.Buffer
+	printComparison(&buf, output)
+	out := buf.String()
+	for _, want := range []string{
+		"Shared results: 1 of 2",
+		"main index (code: code-a, docs: text-a)",
+		" 1. a.go:1-10 (score: 0.2000) [shadow #2]",
+		" 2. b.go:5-9 (score: 0.4000) [only main]",
+		" 1. c.go:3-4 (score: 0.1000) [only shadow]",
+		" 2. a.go:1-10 (score: 0.2000) [main #1]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
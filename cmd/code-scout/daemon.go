@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var daemonSocket string
+
+// daemonSocketFile is the default socket, in the project's .code-scout directory
+const daemonSocketFile = "daemon.sock"
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve the index over a unix socket, staying warm between queries",
+	Long: `Run in the background serving the current project's index over a unix domain
+socket, for editor integrations that query often. The store stays open, and
+embedding connections and loaded models are reused between requests, so a
+query doesn't pay the CLI's startup cost each time.
+
+The API is the same as 'code-scout serve' (GET /search, POST /index,
+GET /status, GET /chunks/{id}), e.g.:
+
+  curl --unix-socket .code-scout/daemon.sock 'http://localhost/search?q=auth'
+
+The store is reopened after an index run, whether the daemon ran it or not.
+The socket is only accessible to the current user.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		path := daemonSocket
+		if path == "" {
+			path = filepath.Join(cwd, storage.DefaultDBDir, daemonSocketFile)
+		}
+		listener, err := listenUnix(path)
+		if err != nil {
+			return err
+		}
+
+		api := newAPIServer(cwd)
+		api.warm = &warmStore{}
+		defer api.warm.close()
+
+		server := &http.Server{
+			Handler:           api.routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-cmd.Context().Done()
+			server.Shutdown(context.Background())
+		}()
+
+		fmt.Printf("Serving %s on unix socket %s\n", cwd, path)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// listenUnix listens on the unix socket at path, which only the current user
+// can connect to. A socket left behind by a daemon that's no longer running is
+// replaced; one that's still being served is an error.
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// warmStore keeps the project's store open between requests. It's replaced
+// when an index run changes the index, since an open table doesn't see writes
+// made through another connection.
+type warmStore struct {
+	mu      sync.Mutex
+	current *sharedStore
+}
+
+// sharedStore is an open store in use by requests. Once replaced, it's closed
+// when its last request is done.
+type sharedStore struct {
+	storage.Store
+	indexed   time.Time // The index's LastIndexTime when the store was opened
+	tableOpen bool
+	users     int
+	replaced  bool
+}
+
+// storeHandle is a request's use of a sharedStore. Closing it ends the use
+// rather than closing the store.
+type storeHandle struct {
+	*sharedStore
+	warm *warmStore
+	once sync.Once
+}
+
+// acquire returns the open store for the project in dir, opening it if it
+// isn't open or the index changed since. The caller must close the handle.
+func (w *warmStore) acquire(dir string) (storage.Store, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current != nil && w.current.stale() {
+		w.retire(w.current)
+		w.current = nil
+	}
+	if w.current == nil {
+		store, err := openStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		shared := &sharedStore{Store: store}
+		if metadata, err := store.LoadMetadata(); err == nil {
+			shared.indexed = metadata.LastIndexTime
+		}
+		w.current = shared
+	}
+	w.current.users++
+	return &storeHandle{sharedStore: w.current, warm: w}, nil
+}
+
+// stale reports whether the index was written since the store was opened
+func (s *sharedStore) stale() bool {
+	metadata, err := s.Store.LoadMetadata()
+	return err != nil || !metadata.LastIndexTime.Equal(s.indexed)
+}
+
+// retire marks a store as replaced, closing it if no request is using it.
+// Must be called with w.mu held.
+func (w *warmStore) retire(s *sharedStore) {
+	s.replaced = true
+	if s.users == 0 {
+		s.Store.Close()
+	}
+}
+
+// close closes the open store once the requests using it are done
+func (w *warmStore) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.current != nil {
+		w.retire(w.current)
+		w.current = nil
+	}
+}
+
+// OpenTable opens the store's table the first time it's needed
+func (h *storeHandle) OpenTable() error {
+	h.warm.mu.Lock()
+	defer h.warm.mu.Unlock()
+	if h.tableOpen {
+		return nil
+	}
+	if err := h.Store.OpenTable(); err != nil {
+		return err
+	}
+	h.tableOpen = true
+	return nil
+}
+
+// Close ends the request's use of the store
+func (h *storeHandle) Close() error {
+	h.once.Do(func() {
+		h.warm.mu.Lock()
+		defer h.warm.mu.Unlock()
+		h.users--
+		if h.replaced && h.users == 0 {
+			h.Store.Close()
+		}
+	})
+	return nil
+}
+
+func init() {
+	daemonCmd.Flags().StringVar(&daemonSocket, "socket", "", "Unix socket to listen on (default .code-scout/daemon.sock)")
+	rootCmd.AddCommand(daemonCmd)
+}
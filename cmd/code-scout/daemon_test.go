@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// closeCountingStore is a persistentStore that counts how often it's closed
+type closeCountingStore struct {
+	persistentStore
+	closed int
+}
+
+func (s *closeCountingStore) Close() error {
+	s.closed++
+	return nil
+}
+
+func TestWarmStore_ReopensAfterIndexRun(t *testing.T) {
+	var opened []*closeCountingStore
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) {
+		store := &closeCountingStore{persistentStore: persistentStore{metadata: &storage.IndexMetadata{LastIndexTime: time.Unix(100, 0)}}}
+		opened = append(opened, store)
+		return store, nil
+	}
+	t.Cleanup(func() { openStore = prevOpen })
+
+	warm := &warmStore{}
+	first, err := warm.acquire("/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := warm.acquire("/repo")
+	first.Close()
+	second.Close()
+	if len(opened) != 1 || opened[0].closed != 0 {
+		t.Fatalf("expected one store kept open between requests, opened %d", len(opened))
+	}
+
+	// An index run, by the daemon or anyone else, makes the open store stale
+	inUse, _ := warm.acquire("/repo")
+	opened[0].metadata = &storage.IndexMetadata{LastIndexTime: time.Unix(200, 0)}
+	fresh, _ := warm.acquire("/repo")
+	if len(opened) != 2 {
+		t.Fatalf("expected the store to be reopened after an index run, opened %d", len(opened))
+	}
+	if opened[0].closed != 0 {
+		t.Error("expected the stale store to stay open while a request uses it")
+	}
+	inUse.Close()
+	if opened[0].closed != 1 {
+		t.Errorf("expected the stale store closed once unused, closed %d times", opened[0].closed)
+	}
+
+	fresh.Close()
+	warm.close()
+	if opened[1].closed != 1 {
+		t.Errorf("expected close to close the open store, closed %d times", opened[1].closed)
+	}
+}
+
+func TestDaemon_ServesOverUnixSocket(t *testing.T) {
+	api := newTestAPIServer(t)
+	api.warm = &warmStore{}
+
+	path := filepath.Join(t.TempDir(), "daemon.sock")
+	listener, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("listenUnix failed: %v", err)
+	}
+	server := &http.Server{Handler: api.routes()}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	if _, err := listenUnix(path); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("expected a second daemon to be refused, got %v", err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://localhost/search?q=add&mode=code")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// diversityPoolFactor is how many candidates per returned result are ranked
+// when diversifying, so there are alternatives to promote past near-duplicates
+const diversityPoolFactor = 3
+
+// codeTokenPattern matches identifiers and words in code
+var codeTokenPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// diversify re-orders results by maximal marginal relevance: each position goes
+// to the result that best balances relevance against similarity to the results
+// already placed above it. diversity is in [0, 1]; 0 keeps the ranking as is
+// and 1 ranks purely by how different a result is from those above it.
+func diversify(results []SearchResult, diversity float64) []SearchResult {
+	if diversity <= 0 || len(results) < 3 {
+		return results
+	}
+
+	relevance := relevanceScores(results)
+	tokens := make([]map[string]bool, len(results))
+	for i, result := range results {
+		tokens[i] = codeTokens(result.Code)
+	}
+
+	// maxSimilarity[i] is result i's highest similarity to any selected result
+	maxSimilarity := make([]float64, len(results))
+	selected := make([]bool, len(results))
+	ordered := make([]SearchResult, 0, len(results))
+
+	for len(ordered) < len(results) {
+		best, bestScore := -1, 0.0
+		for i := range results {
+			if selected[i] {
+				continue
+			}
+			score := (1-diversity)*relevance[i] - diversity*maxSimilarity[i]
+			if best == -1 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		selected[best] = true
+		ordered = append(ordered, results[best])
+		for i := range results {
+			if !selected[i] {
+				maxSimilarity[i] = max(maxSimilarity[i], jaccard(tokens[i], tokens[best]))
+			}
+		}
+	}
+	return ordered
+}
+
+// relevanceScores returns each result's ranking score scaled to [0, 1]
+// relative to the top result
+func relevanceScores(results []SearchResult) []float64 {
+	scores := make([]float64, len(results))
+	top := 0.0
+	for i, result := range results {
+		scores[i] = rankingScore(result)
+		top = max(top, scores[i])
+	}
+	if top > 0 {
+		for i := range scores {
+			scores[i] /= top
+		}
+	}
+	return scores
+}
+
+// codeTokens returns the set of lowercased identifiers in code
+func codeTokens(code string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, token := range codeTokenPattern.FindAllString(code, -1) {
+		tokens[strings.ToLower(token)] = true
+	}
+	return tokens
+}
+
+// jaccard returns the Jaccard similarity of two token sets
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
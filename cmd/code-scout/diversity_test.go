@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiversify(t *testing.T) {
+	results := []SearchResult{
+		{ChunkID: "copy1", Code: "func sum(a, b int) int { return a + b }", NormalizedScore: 0.9},
+		{ChunkID: "copy2", Code: "func sum(a, b int) int { return a + b }", NormalizedScore: 0.88},
+		{ChunkID: "other", Code: "type Config struct { Path string }", NormalizedScore: 0.8},
+	}
+
+	ids := func(results []SearchResult) string {
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.ChunkID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	if got := ids(diversify(results, 0)); got != "[copy1 copy2 other]" {
+		t.Errorf("diversity 0 should keep the ranking, got %s", got)
+	}
+	if got := ids(diversify(results, 0.5)); got != "[copy1 other copy2]" {
+		t.Errorf("expected the near-duplicate demoted, got %s", got)
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	a := codeTokens("func Load(path string)")
+	b := codeTokens("func load(dir string)")
+	if got := jaccard(a, b); got != 0.6 {
+		t.Errorf("expected 0.6, got %v", got)
+	}
+	if got := jaccard(a, codeTokens("")); got != 0 {
+		t.Errorf("expected 0 for empty code, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+const (
+	// describesKey is the metadata field of a docs row holding the ID of the
+	// code chunk it describes. Search returns the code chunk in its place.
+	describesKey = "describes"
+	// minDocRowWords is the shortest doc comment given a docs row of its own;
+	// shorter ones, like "// Close closes", say no more than the name
+	minDocRowWords = 3
+)
+
+// docCommentRows returns a docs row for each code chunk with a doc comment,
+// holding the comment, so natural-language queries embedded with the text
+// model match the code through its documentation
+func docCommentRows(chunks []chunker.Chunk) []chunker.Chunk {
+	var rows []chunker.Chunk
+	for _, chunk := range chunks {
+		doc := strings.TrimSpace(chunk.Metadata["doc_comment"])
+		if chunk.EmbeddingType != "code" || len(strings.Fields(doc)) < minDocRowWords {
+			continue
+		}
+		rows = append(rows, chunker.Chunk{
+			ID:            uuid.New().String(),
+			FilePath:      chunk.FilePath,
+			LineStart:     chunk.LineStart,
+			LineEnd:       chunk.LineEnd,
+			Language:      chunk.Language,
+			Code:          doc,
+			ChunkType:     chunk.ChunkType,
+			Name:          chunk.Name,
+			EmbeddingType: "docs",
+			Metadata:      map[string]string{describesKey: chunk.ID},
+		})
+	}
+	return rows
+}
+
+// resolveDescribingRows replaces each row describing a code chunk with that
+// chunk, keeping the row's score. Rows whose chunk is gone are dropped.
+func resolveDescribingRows(store storage.Store, rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	chunkIDs := make([]string, len(rows))
+	var lookups []string
+	for i, row := range rows {
+		chunkIDs[i] = storage.DecodeChunkMetadata(getStringOrDefault(row, "metadata", ""))[describesKey]
+		if chunkIDs[i] != "" {
+			lookups = append(lookups, chunkIDs[i])
+		}
+	}
+	if len(lookups) == 0 {
+		return rows, nil
+	}
+	chunks, err := store.GetChunks(lookups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up described chunks: %w", err)
+	}
+
+	resolved := make([]map[string]interface{}, 0, len(rows))
+	for i, row := range rows {
+		if chunkIDs[i] == "" {
+			resolved = append(resolved, row)
+			continue
+		}
+		chunk := chunks[chunkIDs[i]]
+		if chunk == nil {
+			continue
+		}
+		described := make(map[string]interface{}, len(chunk)+2)
+		for key, value := range chunk {
+			described[key] = value
+		}
+		for _, key := range []string{"_distance", "_score"} {
+			if value, ok := row[key]; ok {
+				described[key] = value
+			}
+		}
+		resolved = append(resolved, described)
+	}
+	return resolved, nil
+}
+
+// isDescribingRow reports whether a result is a docs row describing a code chunk
+func isDescribingRow(result SearchResult) bool {
+	return result.Metadata[describesKey] != ""
+}
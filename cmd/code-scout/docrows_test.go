@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestDocCommentRows(t *testing.T) {
+	chunks := []chunker.Chunk{
+		{ID: "add", Name: "Add", Code: "func Add() {}", EmbeddingType: "code", ChunkType: "function",
+			Metadata: map[string]string{"doc_comment": "Add sums two numbers without overflow checks"}},
+		{ID: "close", Name: "Close", Code: "func Close() {}", EmbeddingType: "code",
+			Metadata: map[string]string{"doc_comment": "Close closes"}},
+		{ID: "readme", Code: "# Usage", EmbeddingType: "docs",
+			Metadata: map[string]string{"doc_comment": "not a code chunk at all"}},
+	}
+
+	rows := docCommentRows(chunks)
+	if len(rows) != 1 {
+		t.Fatalf("expected one row, for the documented code chunk, got %d", len(rows))
+	}
+	row := rows[0]
+	if row.EmbeddingType != "docs" || row.Code != chunks[0].Metadata["doc_comment"] || row.Metadata[describesKey] != "add" {
+		t.Errorf("expected a docs row of the doc comment describing the chunk, got %+v", row)
+	}
+	if row.ID == "add" || row.ChunkType != "function" || row.Name != "Add" {
+		t.Errorf("expected the row to have its own ID and the chunk's type and name, got %+v", row)
+	}
+}
+
+func TestRunIndex_DocCommentsMatchCode(t *testing.T) {
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "main.go", "package main\n\n// Add sums two numbers without overflow checks\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("index failed: %v", err)
+		}
+	})
+
+	results, _, err := runSingleModeSearch(context.Background(), store, store.metadata, primaryModels(),
+		"adding numbers", 10, modeDocs, storage.SearchFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the doc comment to match one chunk, got %d", len(results))
+	}
+	if results[0].EmbeddingType != "code" || results[0].Name != "Add" || isDescribingRow(results[0]) {
+		t.Errorf("expected the documented code chunk in place of its doc comment, got %+v", results[0])
+	}
+}
+
+func TestResolveDescribingRows(t *testing.T) {
+	store := &memoryStore{rows: []map[string]interface{}{
+		{"chunk_id": "add", "name": "Add", "embedding_type": "code"},
+		{"chunk_id": "sub", "name": "Sub", "embedding_type": "code"},
+	}}
+	rows := []map[string]interface{}{
+		{"chunk_id": "d1", "metadata": `{"describes":"add"}`, "_distance": 0.1},
+		{"chunk_id": "readme", "name": "Usage", "_distance": 0.2},
+		{"chunk_id": "d2", "metadata": `{"describes":"gone"}`, "_distance": 0.3},
+		{"chunk_id": "d3", "metadata": `{"describes":"sub"}`, "_distance": 0.4},
+	}
+
+	resolved, err := resolveDescribingRows(store, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.chunkLookups != 1 {
+		t.Errorf("expected the described chunks fetched in one lookup, got %d", store.chunkLookups)
+	}
+	var names []string
+	for _, row := range resolved {
+		names = append(names, row["name"].(string))
+	}
+	if strings.Join(names, ",") != "Add,Usage,Sub" {
+		t.Errorf("expected the described chunks in place, in order, without the missing one, got %v", names)
+	}
+	if resolved[2]["_distance"] != 0.4 {
+		t.Errorf("expected the describing row's distance kept, got %v", resolved[2]["_distance"])
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/config"
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// doctorTimeout limits each network check, so an unresponsive server doesn't hang doctor
+const doctorTimeout = 30 * time.Second
+
+// doctorCheck is the outcome of one diagnostic
+type doctorCheck struct {
+	name   string
+	detail string // What was found, when the check passes
+	err    error
+	fix    string // How to fix the problem, when the check fails
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration, embedding, and storage problems",
+	Long: `Check that the configuration is valid, the embedding endpoint responds, both
+embedding models are available and match the index's vector dimensions, and the
+vector store opens. Each failed check comes with a suggested fix.`,
+	Args: cobra.NoArgs,
+	// The configuration is checked by doctor itself, so an invalid one is
+	// reported like any other problem instead of stopping the command
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		endpoint, _ := cmd.Flags().GetString("endpoint")
+
+		failed := 0
+		for _, check := range runDoctor(cmd.Context(), cwd, endpoint) {
+			check.print()
+			if check.err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		fmt.Println("✓ All checks passed")
+		return nil
+	},
+}
+
+// runDoctor runs every check for the project in dir. Checks that depend on a
+// failed one are left out: nothing else runs without a valid config, and the
+// models aren't checked if the endpoint is unreachable.
+func runDoctor(ctx context.Context, dir, endpoint string) []doctorCheck {
+	cfg, check := checkConfig(endpoint)
+	checks := []doctorCheck{check}
+	if check.err != nil {
+		return checks
+	}
+	globalConfig = cfg
+
+	metadata, check := checkStore(dir)
+	checks = append(checks, check)
+
+	check = checkEndpoint(ctx, cfg)
+	checks = append(checks, check)
+	if check.err != nil {
+		return checks
+	}
+
+	for _, space := range []struct {
+		embeddingType, field, model string
+		newClient                   func() embeddings.Client
+	}{
+		{"code", "code_model", cfg.CodeModel, newCodeEmbeddingClient},
+		{"docs", "text_model", cfg.TextModel, newDocsEmbeddingClient},
+	} {
+		dimension, check := checkModel(ctx, cfg, space.embeddingType, space.field, space.model, space.newClient())
+		checks = append(checks, check)
+		if check.err == nil && metadata != nil {
+			checks = append(checks, checkDimension(metadata, space.embeddingType, space.model, dimension))
+		}
+	}
+	return checks
+}
+
+// checkConfig loads and validates the configuration, with the --endpoint override
+func checkConfig(endpoint string) (*config.Config, doctorCheck) {
+	check := doctorCheck{name: "Config"}
+	sources, err := config.Sources()
+	if err != nil {
+		check.err = err
+		check.fix = "Fix the JSON syntax of the file (see \"Configuration Format\" in the README)"
+		return nil, check
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		check.err = fmt.Errorf("failed to load config: %w", err)
+		return nil, check
+	}
+	if endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	if err := cfg.Validate(); err != nil {
+		check.err = fmt.Errorf("invalid configuration: %w", err)
+		check.fix = "Correct the setting in " + describeSources(sources) + " (see \"Configuration Format\" in the README)"
+		return nil, check
+	}
+
+	provider := cfg.Provider
+	if provider == "" {
+		provider = "openai"
+	}
+	check.detail = fmt.Sprintf("%s, %s provider", describeSources(sources), provider)
+	return cfg, check
+}
+
+// describeSources names the config files in use, or the defaults if there are none
+func describeSources(sources []string) string {
+	if len(sources) == 0 {
+		return "defaults (no config file)"
+	}
+	return strings.Join(sources, ", ")
+}
+
+// checkStore opens the vector store and loads the index metadata. A local
+// index that hasn't been created yet passes with nil metadata, and isn't
+// created by the check.
+func checkStore(dir string) (*storage.IndexMetadata, doctorCheck) {
+	check := doctorCheck{name: "Vector store"}
+	local := globalConfig.Backend != "qdrant" && globalConfig.LanceDBURI == "" && !globalConfig.GlobalIndex
+	if local {
+		if _, err := os.Stat(filepath.Join(dir, storage.DefaultDBDir)); os.IsNotExist(err) {
+			check.detail = "no index yet (run 'code-scout index' to create one)"
+			return nil, check
+		}
+	}
+
+	store, err := openStore(dir)
+	if err != nil {
+		check.err = fmt.Errorf("failed to open database: %w", err)
+		check.fix = storeFix(local)
+		return nil, check
+	}
+	defer store.Close()
+
+	metadata, err := store.LoadMetadata()
+	if err != nil {
+		check.err = fmt.Errorf("failed to load metadata: %w", err)
+		check.fix = storeFix(local)
+		return nil, check
+	}
+	if err := storage.CheckSchemaVersion(metadata); err != nil {
+		check.err = err
+		check.fix = "Run 'code-scout index' to migrate the index"
+		return nil, check
+	}
+	if metadata.LastIndexTime.IsZero() {
+		check.detail = "opened; nothing indexed yet"
+	} else {
+		check.detail = fmt.Sprintf("opened; %d file(s) indexed %s", len(metadata.FileModTimes), metadata.LastIndexTime.Format(time.RFC3339))
+	}
+	return metadata, check
+}
+
+// storeFix suggests how to fix a store that fails to open
+func storeFix(local bool) string {
+	switch {
+	case local:
+		return fmt.Sprintf("Check the permissions of %s/, or delete it and run 'code-scout index' to rebuild the index", storage.DefaultDBDir)
+	case globalConfig.Backend == "qdrant":
+		return "Check that Qdrant is running at qdrant_url and that qdrant_api_key is correct"
+	case globalConfig.LanceDBURI != "":
+		return "Check the lancedb_uri bucket and the credentials in storage_options or the provider's environment variables"
+	default:
+		return "Check the permissions of ~/.code-scout/global/"
+	}
+}
+
+// checkEndpoint checks that the embedding endpoint accepts connections. Any
+// HTTP response counts: the check is for reachability, and the models are
+// checked separately.
+func checkEndpoint(ctx context.Context, cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Embedding endpoint"}
+	if cfg.Provider == "onnx" {
+		check.detail = "not used by the onnx provider"
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.Endpoint, nil)
+	if err != nil {
+		check.err = fmt.Errorf("failed to create request: %w", err)
+		return check
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		check.err = fmt.Errorf("%s is unreachable: %w", cfg.Endpoint, err)
+		check.fix = "Start the embedding server (e.g. 'ollama serve'), or point endpoint in the config or --endpoint at a running one"
+		return check
+	}
+	resp.Body.Close()
+	check.detail = fmt.Sprintf("%s responded (HTTP %d)", cfg.Endpoint, resp.StatusCode)
+	return check
+}
+
+// checkModel embeds a short text with a model, returning its vector dimension
+func checkModel(ctx context.Context, cfg *config.Config, embeddingType, field, model string, client embeddings.Client) (int, doctorCheck) {
+	check := doctorCheck{name: fmt.Sprintf("%s model", strings.ToUpper(embeddingType[:1])+embeddingType[1:])}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	vector, err := client.Embed(ctx, "func main() {}")
+	if err == nil && len(vector) == 0 {
+		err = fmt.Errorf("returned an empty vector")
+	}
+	if err != nil {
+		check.err = fmt.Errorf("%s failed to embed: %w", model, err)
+		switch cfg.Provider {
+		case "onnx":
+			check.fix = fmt.Sprintf("Check that %s is an exported ONNX model directory (see \"Offline Embeddings (ONNX)\" in the README), or set %s", model, field)
+		case "cohere", "voyage":
+			check.fix = fmt.Sprintf("Check api_key and that %s is a %s model, or set %s", model, cfg.Provider, field)
+		default:
+			check.fix = fmt.Sprintf("Create or pull %s on the server (see \"Setting Up Custom Models\" in the README; 'ollama list' shows Ollama's models), or set %s", model, field)
+		}
+		return 0, check
+	}
+	if err := checkConfiguredDimension(embeddingType, model, len(vector)); err != nil {
+		check.err = err
+		check.fix = fmt.Sprintf("Set %s to %d, or configure a model with the expected dimension", dimensionField(embeddingType), len(vector))
+		return 0, check
+	}
+	check.detail = fmt.Sprintf("%s (%d dimensions)", model, len(vector))
+	return len(vector), check
+}
+
+// checkDimension checks that a model matches the one its embedding space was indexed with
+func checkDimension(metadata *storage.IndexMetadata, embeddingType, model string, dimension int) doctorCheck {
+	check := doctorCheck{name: fmt.Sprintf("Index %s dimensions", embeddingType)}
+	if err := metadata.ValidateEmbeddingModel(embeddingType, model, dimension); err != nil {
+		check.err = err
+		check.fix = "Configure the model the index was built with, or rebuild the index with the new one"
+		return check
+	}
+	if recorded, ok := metadata.EmbeddingModels[embeddingType]; ok {
+		check.detail = fmt.Sprintf("match the index (%d)", recorded.Dimension)
+	} else {
+		check.detail = "nothing indexed with a recorded model yet"
+	}
+	return check
+}
+
+// print writes the check's result, with the fix for a failure
+func (c doctorCheck) print() {
+	if c.err != nil {
+		fmt.Printf("✗ %s: %v\n", c.name, c.err)
+		if c.fix != "" {
+			fmt.Printf("  Fix: %s\n", c.fix)
+		}
+		return
+	}
+	fmt.Printf("✓ %s: %s\n", c.name, c.detail)
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
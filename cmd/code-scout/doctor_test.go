@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// setupDoctor runs doctor from an empty project with no user config
+func setupDoctor(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Chdir(dir)
+	prevConfig := globalConfig
+	t.Cleanup(func() { globalConfig = prevConfig })
+	return dir
+}
+
+func TestRunDoctor(t *testing.T) {
+	dir := setupDoctor(t)
+	installFakeEmbeddings(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	checks := runDoctor(context.Background(), dir, server.URL)
+	if len(checks) != 5 {
+		t.Fatalf("expected 5 checks, got %d: %+v", len(checks), checks)
+	}
+	for _, check := range checks {
+		if check.err != nil {
+			t.Errorf("%s failed: %v", check.name, check.err)
+		}
+	}
+	if checks[3].detail != "code-scout-code (3584 dimensions)" {
+		t.Errorf("unexpected code model detail: %q", checks[3].detail)
+	}
+}
+
+func TestRunDoctor_UnreachableEndpoint(t *testing.T) {
+	dir := setupDoctor(t)
+	installFakeEmbeddings(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	checks := runDoctor(context.Background(), dir, server.URL)
+	last := checks[len(checks)-1]
+	if last.name != "Embedding endpoint" || last.err == nil || last.fix == "" {
+		t.Errorf("expected a failed endpoint check with a fix to end the checks, got %+v", last)
+	}
+}
+
+func TestRunDoctor_InvalidConfig(t *testing.T) {
+	dir := setupDoctor(t)
+	if err := os.WriteFile(".code-scout.json", []byte(`{"provider": "bogus"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := runDoctor(context.Background(), dir, "")
+	if len(checks) != 1 || checks[0].err == nil || checks[0].fix == "" {
+		t.Errorf("expected only a failed config check with a fix, got %+v", checks)
+	}
+}
+
+func TestCheckDimension(t *testing.T) {
+	metadata := &storage.IndexMetadata{}
+	if check := checkDimension(metadata, "code", "code-scout-code", 3584); check.err != nil {
+		t.Errorf("expected an unrecorded space to pass, got %v", check.err)
+	}
+
+	metadata.RecordEmbeddingModel("code", "code-scout-code", 3584)
+	if check := checkDimension(metadata, "code", "code-scout-code", 3584); check.err != nil || check.detail != "match the index (3584)" {
+		t.Errorf("expected matching dimensions to pass, got %+v", check)
+	}
+	if check := checkDimension(metadata, "code", "code-scout-code", 768); check.err == nil || check.fix == "" {
+		t.Errorf("expected a dimension mismatch to fail with a fix, got %+v", check)
+	}
+}
@@ -1,6 +1,13 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
 	"github.com/jlanders/code-scout/internal/config"
 	"github.com/jlanders/code-scout/internal/embeddings"
 )
@@ -11,14 +18,268 @@ var (
 
 	newCodeEmbeddingClient = func() embeddings.Client {
 		if globalConfig != nil {
-			return embeddings.NewClientWithConfig(globalConfig.Endpoint, globalConfig.APIKey, globalConfig.CodeModel)
+			return newConfiguredEmbeddingClient(globalConfig.CodeModel)
 		}
 		return embeddings.NewClient()
 	}
 	newDocsEmbeddingClient = func() embeddings.Client {
 		if globalConfig != nil {
-			return embeddings.NewClientWithConfig(globalConfig.Endpoint, globalConfig.APIKey, globalConfig.TextModel)
+			return newConfiguredEmbeddingClient(globalConfig.TextModel)
 		}
 		return embeddings.NewClientWithModel(embeddings.DefaultTextModel)
 	}
 )
+
+// newConfiguredEmbeddingClient creates a client for the configured provider and
+// the given model, limited by the configured rate limit
+func newConfiguredEmbeddingClient(model string) embeddings.Client {
+	var client embeddings.Client
+	switch globalConfig.Provider {
+	case "cohere":
+		client = embeddings.NewCohereClient(globalConfig.Endpoint, globalConfig.APIKey, model)
+	case "voyage":
+		client = embeddings.NewVoyageClient(globalConfig.Endpoint, globalConfig.APIKey, model)
+	case "llamacpp":
+		client = embeddings.NewLlamaCppClient(globalConfig.Endpoint, globalConfig.APIKey, model)
+	case "onnx":
+		return embeddings.WithPrefixes(onnxEmbeddingClient(model), modelPrefixes(model))
+	default:
+		client = embeddings.NewClientWithConfig(globalConfig.Endpoint, globalConfig.APIKey, model)
+	}
+	client = embeddings.WithPrefixes(client, modelPrefixes(model))
+
+	if retrier, ok := client.(interface{ SetRetryPolicy(embeddings.RetryPolicy) }); ok {
+		retrier.SetRetryPolicy(embeddingRetryPolicy())
+	}
+	if limiter := embeddingRateLimiter(); limiter != nil {
+		return embeddings.WithRateLimit(client, limiter)
+	}
+	return client
+}
+
+// modelPrefixes returns the instruction prefixes configured for model in
+// model_prefixes, or else the ones built in for it
+func modelPrefixes(model string) embeddings.Prefixes {
+	if globalConfig != nil {
+		if prefixes, ok := globalConfig.ModelPrefixes[model]; ok {
+			return embeddings.Prefixes{Document: prefixes.Document, Query: prefixes.Query}
+		}
+	}
+	prefixes, _ := embeddings.KnownPrefixes(model)
+	return prefixes
+}
+
+// embeddingRetryPolicy returns the default retry policy with the configured
+// overrides; the config has already validated the durations
+func embeddingRetryPolicy() embeddings.RetryPolicy {
+	policy := embeddings.DefaultRetryPolicy()
+	if timeout, err := time.ParseDuration(globalConfig.EmbeddingTimeout); err == nil {
+		policy.Timeout = timeout
+	}
+	if globalConfig.MaxRetries != nil {
+		policy.MaxRetries = *globalConfig.MaxRetries
+	}
+	if backoff := globalConfig.Backoff; backoff != nil {
+		if initial, err := time.ParseDuration(backoff.Initial); err == nil {
+			policy.InitialBackoff = initial
+		}
+		if maxBackoff, err := time.ParseDuration(backoff.Max); err == nil {
+			policy.MaxBackoff = maxBackoff
+		}
+		if backoff.Multiplier > 0 {
+			policy.Multiplier = backoff.Multiplier
+		}
+	}
+	return policy
+}
+
+var (
+	// rateLimiter is shared by every embedding client, so the limit applies across all workers
+	rateLimiter     *embeddings.RateLimiter
+	rateLimiterOnce sync.Once
+)
+
+// embeddingRateLimiter returns the configured rate limiter, or nil if none is configured
+func embeddingRateLimiter() *embeddings.RateLimiter {
+	rateLimiterOnce.Do(func() {
+		if limit := globalConfig.RateLimit; limit != nil && (limit.RequestsPerSecond > 0 || limit.TokensPerMinute > 0) {
+			rateLimiter = embeddings.NewRateLimiter(limit.RequestsPerSecond, limit.TokensPerMinute)
+		}
+	})
+	return rateLimiter
+}
+
+var (
+	// onnxClients caches loaded ONNX models by directory, since loading one is slow
+	onnxClientsMu sync.Mutex
+	onnxClients   = make(map[string]embeddings.Client)
+)
+
+// onnxEmbeddingClient returns the in-process client for the model in modelDir.
+// If the model can't be loaded, the returned client fails every request with the reason.
+func onnxEmbeddingClient(modelDir string) embeddings.Client {
+	onnxClientsMu.Lock()
+	defer onnxClientsMu.Unlock()
+
+	if client, ok := onnxClients[modelDir]; ok {
+		return client
+	}
+	client, err := embeddings.NewONNXClient(modelDir)
+	if err != nil {
+		return unavailableEmbeddingClient{err: fmt.Errorf("failed to load ONNX model %s: %w", modelDir, err)}
+	}
+	onnxClients[modelDir] = client
+	return client
+}
+
+// queryCacheEntries is how many query embeddings are cached in memory, and on disk
+const queryCacheEntries = 1000
+
+// queryEmbeddingCache returns the cache of search query embeddings, or nil
+// if no configuration is loaded
+var queryEmbeddingCache = sync.OnceValue(func() *embeddings.QueryCache {
+	if globalConfig == nil {
+		return nil
+	}
+	dir := ""
+	if globalConfig.QueryCache == nil || *globalConfig.QueryCache {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".code-scout", "query-cache")
+		}
+	}
+	return embeddings.NewQueryCache(dir, queryCacheEntries)
+})
+
+// queryCacheModel identifies a model in the query cache. The provider and
+// endpoint are included, since servers may serve different models by the same
+// name, and so is the model's query prefix.
+func queryCacheModel(model string) string {
+	if globalConfig == nil {
+		return model
+	}
+	key := globalConfig.Provider + " " + globalConfig.Endpoint + " " + model
+	// A query embedded with another prefix is a different embedding
+	if prefix := modelPrefixes(model).Query; prefix != "" {
+		key += " " + prefix
+	}
+	return key
+}
+
+// unavailableEmbeddingClient is an embeddings.Client whose provider couldn't be set up
+type unavailableEmbeddingClient struct {
+	err error
+}
+
+func (c unavailableEmbeddingClient) Embed(context.Context, string) ([]float64, error) {
+	return nil, c.err
+}
+
+func (c unavailableEmbeddingClient) EmbedMany(context.Context, []string) ([][]float64, error) {
+	return nil, c.err
+}
+
+// embeddingModels are the models one index is built and searched with, and
+// constructors for their clients
+type embeddingModels struct {
+	Code, Docs    string
+	newCodeClient func() embeddings.Client
+	newDocsClient func() embeddings.Client
+}
+
+// primaryModels returns the configured code_model and text_model
+func primaryModels() embeddingModels {
+	return embeddingModels{
+		Code:          codeModelName(),
+		Docs:          docsModelName(),
+		newCodeClient: newCodeEmbeddingClient,
+		newDocsClient: newDocsEmbeddingClient,
+	}
+}
+
+// shadowModels returns the shadow index's models, and false if no shadow
+// model is configured. An unset shadow model falls back to the main model.
+func shadowModels() (embeddingModels, bool) {
+	if globalConfig == nil || (globalConfig.ShadowCodeModel == "" && globalConfig.ShadowTextModel == "") {
+		return embeddingModels{}, false
+	}
+	models := primaryModels()
+	if model := globalConfig.ShadowCodeModel; model != "" {
+		models.Code = model
+		models.newCodeClient = func() embeddings.Client { return newModelEmbeddingClient(model) }
+	}
+	if model := globalConfig.ShadowTextModel; model != "" {
+		models.Docs = model
+		models.newDocsClient = func() embeddings.Client { return newModelEmbeddingClient(model) }
+	}
+	return models, true
+}
+
+// codeModelName returns the configured code embedding model
+func codeModelName() string {
+	if globalConfig != nil {
+		return globalConfig.CodeModel
+	}
+	return embeddings.DefaultCodeModel
+}
+
+// maxInputTokens returns the input limit of the model for an embedding type
+// ("code" or "docs"): the configured limit, or the model's known limit
+func maxInputTokens(embeddingType string) int {
+	if embeddingType == "docs" {
+		if globalConfig != nil && globalConfig.TextMaxTokens > 0 {
+			return globalConfig.TextMaxTokens
+		}
+		return embeddings.MaxTokens(docsModelName())
+	}
+	if globalConfig != nil && globalConfig.CodeMaxTokens > 0 {
+		return globalConfig.CodeMaxTokens
+	}
+	return embeddings.MaxTokens(codeModelName())
+}
+
+// checkConfiguredDimension returns an error if the configured model's
+// embeddings don't have the dimension configured for their embedding type
+// ("code" or "docs"). Without a configured dimension, or for other models
+// (e.g. shadow models), any is accepted.
+func checkConfiguredDimension(embeddingType, model string, dimension int) error {
+	if globalConfig == nil {
+		return nil
+	}
+	want, configured := globalConfig.CodeDimension, globalConfig.CodeModel
+	if embeddingType == "docs" {
+		want, configured = globalConfig.TextDimension, globalConfig.TextModel
+	}
+	if want > 0 && model == configured && dimension != want {
+		return fmt.Errorf("%s model %q returned %d-dimensional embeddings but %s is %d",
+			embeddingType, model, dimension, dimensionField(embeddingType), want)
+	}
+	return nil
+}
+
+// dimensionField returns the config field setting an embedding type's dimension
+func dimensionField(embeddingType string) string {
+	if embeddingType == "docs" {
+		return "text_dimension"
+	}
+	return "code_dimension"
+}
+
+// defaultMaxSectionTokens is the markdown section size over which sections are
+// split at paragraphs, unless max_section_tokens is set
+const defaultMaxSectionTokens = 1024
+
+// maxSectionTokens returns the configured markdown section limit
+func maxSectionTokens() int {
+	if globalConfig != nil && globalConfig.MaxSectionTokens > 0 {
+		return globalConfig.MaxSectionTokens
+	}
+	return defaultMaxSectionTokens
+}
+
+// docsModelName returns the configured documentation embedding model
+func docsModelName() string {
+	if globalConfig != nil {
+		return globalConfig.TextModel
+	}
+	return embeddings.DefaultTextModel
+}
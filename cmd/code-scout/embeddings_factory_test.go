@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/config"
+	"github.com/jlanders/code-scout/internal/embeddings"
+)
+
+func TestModelPrefixes(t *testing.T) {
+	prevConfig := globalConfig
+	t.Cleanup(func() { globalConfig = prevConfig })
+	globalConfig = &config.Config{ModelPrefixes: map[string]config.ModelPrefixes{
+		"my-model":         {Document: "doc: ", Query: "query: "},
+		"nomic-embed-text": {},
+	}}
+
+	if got := modelPrefixes("my-model"); got != (embeddings.Prefixes{Document: "doc: ", Query: "query: "}) {
+		t.Errorf("expected the configured prefixes, got %+v", got)
+	}
+	if got := modelPrefixes("nomic-embed-text"); got != (embeddings.Prefixes{}) {
+		t.Errorf("expected an empty entry to turn off the built-in prefixes, got %+v", got)
+	}
+	if got := modelPrefixes("mxbai-embed-large"); got.Query == "" {
+		t.Error("expected the built-in prefixes for an unconfigured model")
+	}
+}
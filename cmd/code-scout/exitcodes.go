@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/config"
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// Exit codes by cause of failure, so scripts can branch on them. 2 is left
+// to index --ci, which exits 2 when nothing changed.
+const (
+	exitError               = 1 // Any other failure
+	exitConfigError         = 3 // The configuration is invalid
+	exitEndpointUnreachable = 4 // The embedding endpoint can't be connected to
+	exitIndexMissing        = 5 // Nothing has been indexed yet
+	exitModelMismatch       = 6 // The configured model differs from the one the index was built with
+	exitParseError          = 7 // A code file couldn't be parsed into chunks
+)
+
+// exitCodeFor returns the exit code for a command's error
+func exitCodeFor(err error) int {
+	var (
+		configErr      *config.Error
+		unreachableErr *embeddings.UnreachableError
+		missingErr     *storage.IndexMissingError
+		mismatchErr    *storage.ModelMismatchError
+		parseErr       *chunker.ParseError
+	)
+	switch {
+	case errors.As(err, &configErr):
+		return exitConfigError
+	case errors.As(err, &unreachableErr):
+		return exitEndpointUnreachable
+	case errors.As(err, &missingErr):
+		return exitIndexMissing
+	case errors.As(err, &mismatchErr):
+		return exitModelMismatch
+	case errors.As(err, &parseErr):
+		return exitParseError
+	default:
+		return exitError
+	}
+}
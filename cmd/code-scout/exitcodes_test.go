@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/config"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unclassified", errors.New("boom"), exitError},
+		{"config error", fmt.Errorf("invalid configuration: %w", (&config.Config{}).Validate()), exitConfigError},
+		{"missing index", fmt.Errorf("failed to open table: %w", &storage.IndexMissingError{Location: ".code-scout"}), exitIndexMissing},
+		{"model mismatch", fmt.Errorf("failed to generate code query embedding: %w", &storage.ModelMismatchError{IndexedModel: "a", Model: "b"}), exitModelMismatch},
+		{"parse error", fmt.Errorf("failed to chunk file main.go: %w", &chunker.ParseError{Path: "main.go", Err: errors.New("bad")}), exitParseError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeFor(tt.err); got != tt.want {
+				t.Errorf("exitCodeFor(%v) = %d, expected %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateEmbeddingModel_ExitCode(t *testing.T) {
+	metadata := &storage.IndexMetadata{EmbeddingModels: map[string]storage.EmbeddingModel{"code": {Model: "old-model"}}}
+	err := metadata.ValidateEmbeddingModel("code", "new-model", 0)
+	if got := exitCodeFor(err); got != exitModelMismatch {
+		t.Errorf("expected a changed model to exit %d, got %d (%v)", exitModelMismatch, got, err)
+	}
+}
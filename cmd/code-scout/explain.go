@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/llm"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// summaryChunkType marks chunks holding cached explain summaries
+	summaryChunkType = "summary"
+	// maxExplainReferences caps the chunks listed as referencing an explained symbol
+	maxExplainReferences = 10
+	// maxExplainCallees caps the called names listed for explained code
+	maxExplainCallees = 20
+)
+
+var (
+	explainRefresh bool
+	explainJSON    bool
+)
+
+// callPattern matches an identifier followed by an opening parenthesis
+var callPattern = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+
+// callKeywords are words followed by "(" that aren't calls
+var callKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "func": true,
+	"function": true, "def": true, "catch": true, "elif": true, "print": true, "len": true,
+	"make": true, "append": true, "new": true, "sizeof": true, "super": true, "fn": true,
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <path|symbol>",
+	Short: "Summarize a file or symbol with the configured chat model",
+	Long: `Gather the indexed chunks of a file or symbol, along with the code that
+references it and the functions it calls, and ask the chat model (chat_model in
+config) for a summary.
+
+Summaries are cached in the index as docs chunks, so later runs reuse them and
+semantic searches can match them. A cached summary is regenerated when its code
+changes, when its file is re-indexed, or with --refresh.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newChatClient()
+		if err != nil {
+			return err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		if err := store.OpenTable(); err != nil {
+			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
+		}
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
+			return err
+		}
+
+		explanation, err := runExplain(cmd.Context(), store, metadata, client, args[0], cwd, explainRefresh)
+		if err != nil {
+			return err
+		}
+
+		if explainJSON {
+			jsonBytes, err := json.MarshalIndent(explanation, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+
+		fmt.Printf("%s", explanation.Target)
+		if explanation.Cached {
+			fmt.Print(" (cached summary)")
+		}
+		fmt.Printf("\n\n%s\n\nBased on:\n", explanation.Summary)
+		for _, source := range explanation.Sources {
+			fmt.Printf("  %s:%d-%d", source.FilePath, source.LineStart, source.LineEnd)
+			if source.Name != "" {
+				fmt.Printf(" (%s)", source.Name)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+// explanation is the output of the explain command
+type explanation struct {
+	Target  string      `json:"target"` // File path or symbol name
+	Summary string      `json:"summary"`
+	Cached  bool        `json:"cached"` // Summary was reused from the index
+	Sources []askSource `json:"sources"`
+}
+
+// runExplain summarizes the file or symbol named by arg, reusing the summary
+// cached in the store unless its code changed or refresh is set
+func runExplain(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, client llm.Client, arg, cwd string, refresh bool) (*explanation, error) {
+	target, chunks, isSymbol, err := resolveExplainTarget(store, arg, cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]askSource, len(chunks))
+	for i, chunk := range chunks {
+		sources[i] = askSource{Ref: i + 1, FilePath: displayPath(chunk.FilePath, cwd), LineStart: chunk.LineStart, LineEnd: chunk.LineEnd, Name: chunk.Name}
+	}
+	result := &explanation{Target: displayPath(target, cwd), Sources: sources}
+
+	summaryID := summaryChunkID(target)
+	sourceHash := hashChunkCode(chunks)
+	if !refresh {
+		row, err := store.GetChunk(summaryID)
+		if err != nil {
+			return nil, err
+		}
+		if row != nil && storage.DecodeChunkMetadata(getStringOrDefault(row, "metadata", ""))[sourceHashKey] == sourceHash {
+			result.Summary, result.Cached = getStringOrDefault(row, "code", ""), true
+			return result, nil
+		}
+	}
+
+	var references []SearchResult
+	if isSymbol {
+		if references, err = findReferences(store, target, chunks, cwd); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := client.CompleteContext(ctx, buildExplainPrompt(result.Target, chunks, sources, references, findCallees(chunks), cwd))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+	result.Summary = strings.TrimSpace(reply)
+
+	// Caching writes the index, so it needs the index lock; a summary that
+	// can't be cached is still returned
+	release, err := acquireIndexLock(ctx, cwd, "explain", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Note: summary not cached: %v\n", err)
+		return result, nil
+	}
+	defer release()
+	// Reload the metadata under the lock, so saving it keeps any index run that finished meanwhile
+	current, err := store.LoadMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata: %w", err)
+	}
+	*metadata = *current
+	if err := cacheSummary(ctx, store, metadata, summaryID, target, sourceHash, result.Summary, chunks); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveExplainTarget finds the chunks to explain: every chunk of arg if it's a
+// file, otherwise the chunks whose symbol name is arg. Returns the target (the
+// absolute file path or symbol name) and its chunks in file and line order.
+func resolveExplainTarget(store storage.Store, arg, cwd string) (string, []SearchResult, bool, error) {
+	path := arg
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		rows, err := store.FileChunks(path)
+		if err != nil {
+			return "", nil, false, err
+		}
+		chunks := sourceChunks(formatResults(rows))
+		if len(chunks) == 0 {
+			return "", nil, false, fmt.Errorf("no indexed chunks for %s (run 'code-scout index' first)", arg)
+		}
+		return path, chunks, false, nil
+	}
+
+	rows, err := store.FullTextSearch(arg, 50, "", storage.SearchFilter{Project: currentProject(cwd)})
+	if err != nil {
+		return "", nil, false, fmt.Errorf("%w (run 'code-scout index' or 'code-scout optimize' to build it)", err)
+	}
+	var chunks []SearchResult
+	for _, chunk := range sourceChunks(formatResults(rows)) {
+		if chunk.Name == arg {
+			chunks = append(chunks, chunk)
+		}
+	}
+	if len(chunks) == 0 {
+		return "", nil, false, fmt.Errorf("no indexed file or symbol named %s", arg)
+	}
+	return arg, chunks, true, nil
+}
+
+// sourceChunks drops cached summaries and rows describing code chunks, and
+// orders chunks by file and line
+func sourceChunks(results []SearchResult) []SearchResult {
+	var chunks []SearchResult
+	for _, result := range results {
+		if result.ChunkType != summaryChunkType && !isDescribingRow(result) {
+			chunks = append(chunks, result)
+		}
+	}
+	sort.SliceStable(chunks, func(i, j int) bool {
+		if chunks[i].FilePath != chunks[j].FilePath {
+			return chunks[i].FilePath < chunks[j].FilePath
+		}
+		return chunks[i].LineStart < chunks[j].LineStart
+	})
+	return chunks
+}
+
+// findReferences returns other chunks that mention symbol, most relevant first
+func findReferences(store storage.Store, symbol string, own []SearchResult, cwd string) ([]SearchResult, error) {
+	rows, err := store.FullTextSearch(symbol, maxExplainReferences+len(own), "code", storage.SearchFilter{Project: currentProject(cwd)})
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool)
+	for _, chunk := range own {
+		skip[chunk.ChunkID] = true
+	}
+	var references []SearchResult
+	for _, result := range sourceChunks(formatResults(rows)) {
+		if !skip[result.ChunkID] && len(references) < maxExplainReferences {
+			references = append(references, result)
+		}
+	}
+	return references, nil
+}
+
+// findCallees returns the distinct names called from chunks, excluding the chunks' own names
+func findCallees(chunks []SearchResult) []string {
+	own := make(map[string]bool)
+	for _, chunk := range chunks {
+		own[chunk.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	var callees []string
+	for _, chunk := range chunks {
+		for _, match := range callPattern.FindAllStringSubmatch(chunk.Code, -1) {
+			name := match[1]
+			if callKeywords[name] || own[name] || seen[name] {
+				continue
+			}
+			seen[name] = true
+			callees = append(callees, name)
+			if len(callees) == maxExplainCallees {
+				return callees
+			}
+		}
+	}
+	return callees
+}
+
+// buildExplainPrompt builds the chat messages asking for a summary of target
+func buildExplainPrompt(target string, chunks []SearchResult, sources []askSource, references []SearchResult, callees []string, cwd string) []llm.Message {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Explain %s.\n\nCode:\n\n", target)
+	for i, chunk := range chunks {
+		code := chunk.Code
+		if len(code) > maxContextChars {
+			code = code[:maxContextChars] + "\n..."
+		}
+		fmt.Fprintf(&prompt, "%s:%d-%d\n```%s\n%s\n```\n\n", sources[i].FilePath, chunk.LineStart, chunk.LineEnd, chunk.Language, code)
+	}
+	if len(references) > 0 {
+		prompt.WriteString("Referenced by:\n")
+		for _, ref := range references {
+			fmt.Fprintf(&prompt, "- %s:%d", displayPath(ref.FilePath, cwd), ref.LineStart)
+			if ref.Name != "" {
+				fmt.Fprintf(&prompt, " (%s)", ref.Name)
+			}
+			prompt.WriteString("\n")
+		}
+		prompt.WriteString("\n")
+	}
+	if len(callees) > 0 {
+		fmt.Fprintf(&prompt, "Calls: %s\n", strings.Join(callees, ", "))
+	}
+
+	return []llm.Message{
+		{Role: "system", Content: "You explain code to developers new to a codebase. In a few short paragraphs, " +
+			"summarize what the code does, its main functions and types, and how it relates to the code that " +
+			"references it and the functions it calls. Don't restate the code line by line."},
+		{Role: "user", Content: prompt.String()},
+	}
+}
+
+// cacheSummary stores a summary as a docs chunk with a stable ID, replacing any
+// previous summary of the same target. It's stored under the first chunk's file,
+// so re-indexing that file clears it.
+func cacheSummary(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, id, target, sourceHash, summary string, chunks []SearchResult) error {
+	embedding, err := newDocsEmbeddingClient().Embed(ctx, summary)
+	if err != nil {
+		return fmt.Errorf("failed to embed summary: %w", err)
+	}
+	if err := recordEmbeddingModel(metadata, "docs", docsModelName(), [][]float64{embedding}); err != nil {
+		return err
+	}
+
+	first := chunks[0]
+	chunk := chunker.Chunk{
+		ID:            id,
+		FilePath:      first.FilePath,
+		LineStart:     first.LineStart,
+		LineEnd:       first.LineEnd,
+		Language:      first.Language,
+		Code:          summary,
+		ChunkType:     summaryChunkType,
+		Name:          filepath.Base(target),
+		EmbeddingType: "docs",
+		Metadata:      map[string]string{"summary_of": target, sourceHashKey: sourceHash},
+	}
+	if err := store.DeleteChunks([]string{id}); err != nil {
+		return err
+	}
+	if err := store.StoreChunks([]chunker.Chunk{chunk}, [][]float64{embedding}); err != nil {
+		return fmt.Errorf("failed to cache summary: %w", err)
+	}
+	return store.SaveMetadata(metadata)
+}
+
+// summaryChunkID returns the stable chunk ID of a target's cached summary
+func summaryChunkID(target string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("code-scout:summary:"+target)).String()
+}
+
+// hashChunkCode fingerprints the code a summary was generated from
+func hashChunkCode(chunks []SearchResult) string {
+	hash := sha256.New()
+	for _, chunk := range chunks {
+		fmt.Fprintf(hash, "%s:%d\x00%s\x00", chunk.FilePath, chunk.LineStart, chunk.Code)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// displayPath returns path relative to cwd when it's inside it
+func displayPath(path, cwd string) string {
+	if rel, err := filepath.Rel(cwd, path); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
+
+func init() {
+	explainCmd.Flags().BoolVar(&explainRefresh, "refresh", false, "Regenerate the summary even if a cached one is current")
+	explainCmd.Flags().BoolVar(&explainJSON, "json", false, "Output the summary and sources as JSON")
+	rootCmd.AddCommand(explainCmd)
+}
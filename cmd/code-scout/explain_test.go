@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestRunExplain_CachesSummary(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	cwd := t.TempDir()
+	path := filepath.Join(cwd, "switch.go")
+	if err := os.WriteFile(path, []byte("package models\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := &memoryStore{rows: []map[string]interface{}{
+		{"chunk_id": "c2", "file_path": path, "line_start": float64(8), "line_end": float64(12), "language": "go",
+			"code": "func Switch() { load(); Validate(cfg) }", "embedding_type": "code", "name": "Switch"},
+		{"chunk_id": "c1", "file_path": path, "line_start": float64(1), "line_end": float64(6), "language": "go",
+			"code": "type Model struct{}", "embedding_type": "code", "name": "Model"},
+	}}
+	metadata := &storage.IndexMetadata{}
+	client := &recordingChatClient{reply: "Switches models.\n"}
+
+	result, err := runExplain(context.Background(), store, metadata, client, "switch.go", cwd, false)
+	if err != nil {
+		t.Fatalf("runExplain failed: %v", err)
+	}
+	if result.Summary != "Switches models." || result.Cached || result.Target != "switch.go" {
+		t.Errorf("unexpected explanation: %+v", result)
+	}
+	if len(result.Sources) != 2 || result.Sources[0].Name != "Model" {
+		t.Errorf("expected sources in line order, got %+v", result.Sources)
+	}
+	prompt := client.messages[1].Content
+	if !strings.Contains(prompt, "switch.go:8-12") || !strings.Contains(prompt, "Calls: load, Validate") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+
+	cached := store.rows[len(store.rows)-1]
+	if cached["chunk_type"] != summaryChunkType || cached["embedding_type"] != "docs" || metadata.EmbeddingModels["docs"].Model == "" {
+		t.Errorf("expected summary cached as a docs chunk, got %v", cached)
+	}
+
+	client.messages = nil
+	result, err = runExplain(context.Background(), store, metadata, client, "switch.go", cwd, false)
+	if err != nil {
+		t.Fatalf("runExplain failed: %v", err)
+	}
+	if !result.Cached || result.Summary != "Switches models." || client.messages != nil {
+		t.Errorf("expected the cached summary to be reused, got %+v", result)
+	}
+
+	client.reply = "Regenerated."
+	if result, err = runExplain(context.Background(), store, metadata, client, "switch.go", cwd, true); err != nil || result.Summary != "Regenerated." {
+		t.Fatalf("expected --refresh to regenerate, got %+v, %v", result, err)
+	}
+	if len(store.rows) != 3 {
+		t.Errorf("expected the old summary to be replaced, got %d rows", len(store.rows))
+	}
+}
+
+func TestFindCallees(t *testing.T) {
+	chunks := []SearchResult{{Name: "run", Code: "func run() {\n\tif ok(x) {\n\t\tstore.Save(run())\n\t}\n\tfmt.Println(len(x))\n}"}}
+	if got := findCallees(chunks); !reflect.DeepEqual(got, []string{"ok", "Save", "Println"}) {
+		t.Errorf("unexpected callees: %v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var gcDryRun bool
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove orphaned chunks from the index",
+	Long: `Scan the vector database for chunks whose source file no longer exists or
+whose content no longer matches the indexed version, delete them, and prune
+stale entries from the index metadata. Like index, gc holds the index lock
+while it writes, so it fails if another command is writing the index unless
+--wait is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		if !gcDryRun {
+			release, err := acquireIndexLock(cmd.Context(), cwd, "gc", waitForIndexLock)
+			if err != nil {
+				return err
+			}
+			defer release()
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+
+		storedPaths, err := store.ListFilePaths()
+		if err != nil {
+			return err
+		}
+
+		orphaned, stale, err := findOrphanedFiles(storedPaths, metadata)
+		if err != nil {
+			return err
+		}
+
+		// Metadata entries for files that are gone from disk (with or without chunks)
+		var missing []string
+		for filePath := range metadata.FileModTimes {
+			if _, err := os.Stat(filePath); os.IsNotExist(err) {
+				missing = append(missing, filePath)
+			}
+		}
+
+		fmt.Printf("Scanned %d indexed file(s)\n", len(storedPaths))
+		for _, path := range orphaned {
+			fmt.Printf("  - orphaned: %s\n", path)
+		}
+		for _, path := range stale {
+			fmt.Printf("  - stale: %s\n", path)
+		}
+
+		toDelete := append(append([]string{}, orphaned...), stale...)
+		if len(toDelete) == 0 && len(missing) == 0 {
+			fmt.Println("✓ No orphaned chunks found.")
+			return nil
+		}
+
+		if gcDryRun {
+			fmt.Printf("Dry run: would remove chunks for %d file(s) and prune %d metadata entries\n",
+				len(toDelete), len(missing)+len(stale))
+			return nil
+		}
+
+		if err := store.DeleteChunksByFilePath(toDelete); err != nil {
+			return fmt.Errorf("failed to delete orphaned chunks: %w", err)
+		}
+		identifiers, err := store.LoadIdentifiers()
+		if err != nil {
+			return fmt.Errorf("failed to load identifier index: %w", err)
+		}
+		if identifiers != nil {
+			identifiers.RemoveFiles(toDelete)
+			if err := store.SaveIdentifiers(identifiers); err != nil {
+				return err
+			}
+		}
+
+		// Drop metadata for missing files, and for stale files so the next index run re-embeds them
+		for _, path := range append(missing, stale...) {
+			delete(metadata.FileModTimes, path)
+			delete(metadata.FileHashes, path)
+		}
+		for path := range metadata.FileHashes {
+			if _, tracked := metadata.FileModTimes[path]; !tracked {
+				delete(metadata.FileHashes, path)
+			}
+		}
+
+		if err := store.SaveMetadata(metadata); err != nil {
+			return fmt.Errorf("failed to save metadata: %w", err)
+		}
+
+		fmt.Printf("✓ Removed chunks for %d file(s), pruned %d metadata entries\n",
+			len(toDelete), len(missing)+len(stale))
+		if len(stale) > 0 {
+			fmt.Println("Run 'code-scout index' to re-embed stale files.")
+		}
+
+		return nil
+	},
+}
+
+// findOrphanedFiles classifies stored file paths. Orphaned files no longer exist on disk
+// or are not tracked in metadata; stale files exist but their content hash no longer
+// matches the hash recorded at index time.
+func findOrphanedFiles(storedPaths []string, metadata *storage.IndexMetadata) (orphaned, stale []string, err error) {
+	for _, path := range storedPaths {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			orphaned = append(orphaned, path)
+			continue
+		}
+
+		if _, tracked := metadata.FileModTimes[path]; !tracked {
+			orphaned = append(orphaned, path)
+			continue
+		}
+
+		expected := metadata.FileHashes[path]
+		if expected == "" {
+			// Indexed before content hashes were recorded, can't verify
+			continue
+		}
+		actual, hashErr := computeFileHash(path)
+		if hashErr != nil {
+			return nil, nil, fmt.Errorf("failed to hash file %s: %w", path, hashErr)
+		}
+		if actual != expected {
+			stale = append(stale, path)
+		}
+	}
+
+	return orphaned, stale, nil
+}
+
+func init() {
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Report orphaned chunks without deleting them")
+	gcCmd.Flags().BoolVar(&waitForIndexLock, "wait", false, "Wait for a running command to release the index lock instead of failing")
+	rootCmd.AddCommand(gcCmd)
+}
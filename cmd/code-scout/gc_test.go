@@ -0,0 +1,45 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestFindOrphanedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "current.go", "package main\n")
+	writeTestFile(t, dir, "changed.go", "package main\n\nfunc Changed() {}\n")
+	writeTestFile(t, dir, "untracked.go", "package main\n")
+
+	current := filepath.Join(dir, "current.go")
+	changed := filepath.Join(dir, "changed.go")
+	untracked := filepath.Join(dir, "untracked.go")
+	deleted := filepath.Join(dir, "deleted.go")
+
+	metadata := &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{
+			current: time.Now(),
+			changed: time.Now(),
+			deleted: time.Now(),
+		},
+		FileHashes: map[string]string{
+			current: computeContentHash("package main\n"),
+			changed: computeContentHash("package main\n"),
+		},
+	}
+
+	orphaned, stale, err := findOrphanedFiles([]string{current, changed, untracked, deleted}, metadata)
+	if err != nil {
+		t.Fatalf("findOrphanedFiles failed: %v", err)
+	}
+
+	if len(orphaned) != 2 || orphaned[0] != untracked || orphaned[1] != deleted {
+		t.Errorf("expected untracked and deleted files to be orphaned, got %v", orphaned)
+	}
+	if len(stale) != 1 || stale[0] != changed {
+		t.Errorf("expected changed file to be stale, got %v", stale)
+	}
+}
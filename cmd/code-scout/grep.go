@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepJSON  bool
+	grepLimit int
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <term>",
+	Short: "Keyword search over indexed code and symbol names",
+	Long: `Search the full-text index of chunk code and symbol names. Unlike search,
+grep needs no embedding service, so exact identifier lookups work offline and
+rank by BM25 keyword relevance.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		term := args[0]
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		if err := store.OpenTable(); err != nil {
+			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
+		}
+
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
+			return err
+		}
+
+		rawResults, err := store.FullTextSearch(term, grepLimit, "", storage.SearchFilter{Project: currentProject(cwd)})
+		if err != nil {
+			return fmt.Errorf("%w (run 'code-scout index' or 'code-scout optimize' to build it)", err)
+		}
+		results := formatResults(rawResults)
+
+		if grepJSON {
+			output := searchapi.GrepResponse{
+				SchemaVersion: searchapi.SchemaVersion,
+				Query:         term,
+				Returned:      len(results),
+				Results:       results,
+			}
+			jsonBytes, err := json.MarshalIndent(output, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+
+		fmt.Printf("Found %d matches for: %s\n\n", len(results), term)
+		for i, result := range results {
+			fmt.Printf("%d. %s:%d-%d (bm25: %.4f)\n",
+				i+1, result.FilePath, result.LineStart, result.LineEnd, result.LexicalScore)
+			if result.Name != "" {
+				fmt.Printf("   Name: %s\n", result.Name)
+			}
+			if offset, line, ok := firstMatchingLine(result.Code, term); ok {
+				fmt.Printf("   %d: %s\n", result.LineStart+offset, line)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+// firstMatchingLine returns the first line of code containing any word of term
+// (case-insensitive), along with its zero-based offset from the start of the chunk
+func firstMatchingLine(code, term string) (int, string, bool) {
+	words := strings.Fields(strings.ToLower(term))
+	for i, line := range strings.Split(code, "\n") {
+		lower := strings.ToLower(line)
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				return i, strings.TrimSpace(line), true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+func init() {
+	grepCmd.Flags().BoolVar(&grepJSON, "json", false, "Output results as JSON")
+	grepCmd.Flags().IntVar(&grepLimit, "limit", 20, "Maximum number of results to return")
+	rootCmd.AddCommand(grepCmd)
+}
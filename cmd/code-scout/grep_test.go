@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestFirstMatchingLine(t *testing.T) {
+	code := "func Load() error {\n\tcfg := parseConfig()\n\treturn nil\n}"
+
+	offset, line, ok := firstMatchingLine(code, "ParseConfig")
+	if !ok {
+		t.Fatal("expected a matching line")
+	}
+	if offset != 1 || line != "cfg := parseConfig()" {
+		t.Errorf("unexpected match: offset=%d line=%q", offset, line)
+	}
+
+	if _, _, ok := firstMatchingLine(code, "missing"); ok {
+		t.Error("expected no match")
+	}
+}
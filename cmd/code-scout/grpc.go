@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/codescoutpb"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultWatchInterval is how often Watch polls the project when the client doesn't say
+	defaultWatchInterval = 2 * time.Second
+	// minWatchInterval bounds how aggressively a client can make the server rescan
+	minWatchInterval = 100 * time.Millisecond
+)
+
+// rpcServer implements the CodeScout gRPC service on top of an apiServer, so
+// gRPC and HTTP clients share one index lock
+type rpcServer struct {
+	codescoutpb.UnimplementedCodeScoutServer
+	api *apiServer
+}
+
+// newGRPCServer creates a gRPC server exposing the CodeScout service for api
+func newGRPCServer(api *apiServer) *grpc.Server {
+	server := grpc.NewServer()
+	codescoutpb.RegisterCodeScoutServer(server, &rpcServer{api: api})
+	return server
+}
+
+// Search runs a semantic search over the index
+func (s *rpcServer) Search(ctx context.Context, req *codescoutpb.SearchRequest) (*codescoutpb.SearchResponse, error) {
+	response, err := s.api.search(ctx, searchOptions{
+		Query:     req.GetQuery(),
+		Mode:      searchMode(req.GetMode()),
+		Limit:     int(req.GetLimit()),
+		Offset:    int(req.GetOffset()),
+		MinScore:  req.GetMinScore(),
+		Diversity: req.GetDiversity(),
+		DedupFile: req.GetDedupFile(),
+		Lexical:   req.GetLexical(),
+		Filter: storage.SearchFilter{
+			Language:     req.GetLanguage(),
+			ChunkType:    req.GetChunkType(),
+			ExcludeTests: req.GetNoTests(),
+			OnlyTests:    req.GetOnlyTests(),
+		},
+	}, req.GetCursor())
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	results := make([]*codescoutpb.Result, len(response.Results))
+	for i := range response.Results {
+		results[i] = toProtoResult(&response.Results[i])
+	}
+	return &codescoutpb.SearchResponse{
+		SchemaVersion: int32(response.SchemaVersion),
+		Query:         response.Query,
+		Mode:          response.Mode,
+		TotalResults:  int32(response.TotalResults),
+		Offset:        int32(response.Offset),
+		NextCursor:    response.NextCursor,
+		Results:       results,
+		Index:         toProtoIndexState(response.Index),
+	}, nil
+}
+
+// Index runs an incremental index, streaming a started event and then a
+// completed or failed event
+func (s *rpcServer) Index(req *codescoutpb.IndexRequest, stream grpc.ServerStreamingServer[codescoutpb.IndexEvent]) error {
+	var sendErr error
+	elapsed, err := s.api.index(stream.Context(), func() {
+		sendErr = stream.Send(&codescoutpb.IndexEvent{Phase: codescoutpb.IndexEvent_PHASE_STARTED})
+	})
+	if errors.Is(err, errIndexBusy) {
+		return rpcError(err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return stream.Send(&codescoutpb.IndexEvent{
+			Phase:      codescoutpb.IndexEvent_PHASE_FAILED,
+			Error:      err.Error(),
+			DurationMs: elapsed.Milliseconds(),
+		})
+	}
+
+	event := &codescoutpb.IndexEvent{
+		Phase:      codescoutpb.IndexEvent_PHASE_COMPLETED,
+		DurationMs: elapsed.Milliseconds(),
+	}
+	if state, err := s.api.status(); err == nil {
+		event.Index = toProtoIndexState(&state)
+	}
+	return stream.Send(event)
+}
+
+// Watch polls the project for files the index hasn't caught up with, sending
+// an event each time the set of pending changes grows or changes. With
+// auto_index set, each change is followed by an index run.
+func (s *rpcServer) Watch(req *codescoutpb.WatchRequest, stream grpc.ServerStreamingServer[codescoutpb.WatchEvent]) error {
+	interval := defaultWatchInterval
+	if req.GetIntervalMs() > 0 {
+		interval = max(time.Duration(req.GetIntervalMs())*time.Millisecond, minWatchInterval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var reported []string
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		changes, err := s.api.pendingChanges()
+		if err != nil {
+			return rpcError(err)
+		}
+		if len(changes) == 0 || slices.Equal(changes, reported) {
+			reported = changes
+			continue
+		}
+
+		reported = changes
+		if err := stream.Send(&codescoutpb.WatchEvent{
+			Kind:         codescoutpb.WatchEvent_KIND_CHANGED,
+			ChangedFiles: changes,
+		}); err != nil {
+			return err
+		}
+
+		if !req.GetAutoIndex() {
+			continue
+		}
+
+		_, err = s.api.index(stream.Context(), nil)
+		switch {
+		case errors.Is(err, errIndexBusy):
+			// Another client is indexing; the next poll sees its result
+			continue
+		case err != nil:
+			err = stream.Send(&codescoutpb.WatchEvent{
+				Kind:  codescoutpb.WatchEvent_KIND_FAILED,
+				Error: err.Error(),
+			})
+		default:
+			reported = nil
+			event := &codescoutpb.WatchEvent{Kind: codescoutpb.WatchEvent_KIND_INDEXED, ChangedFiles: changes}
+			if state, statusErr := s.api.status(); statusErr == nil {
+				event.Index = toProtoIndexState(&state)
+			}
+			err = stream.Send(event)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// pendingChanges lists files that were added, modified, or deleted since the
+// last index run, sorted by path
+func (s *apiServer) pendingChanges() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metadata, err := s.loadMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := newScanner(s.dir).ScanCodeFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan files: %w", err)
+	}
+
+	var changes []string
+	scanned := make(map[string]bool, len(files))
+	for _, f := range files {
+		scanned[f.Path] = true
+		lastModTime, exists := metadata.FileModTimes[f.Path]
+		if !exists || f.ModTime.After(lastModTime) {
+			changes = append(changes, f.Path)
+		}
+	}
+	for filePath := range metadata.FileModTimes {
+		if !scanned[filePath] {
+			changes = append(changes, filePath)
+		}
+	}
+	sort.Strings(changes)
+	return changes, nil
+}
+
+// rpcError maps an apiServer error to a gRPC status
+func rpcError(err error) error {
+	switch {
+	case errors.Is(err, errInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, errIndexBusy):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, errIndexUnavailable):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toProtoResult converts a search result to its protobuf form
+func toProtoResult(result *searchapi.Result) *codescoutpb.Result {
+	return &codescoutpb.Result{
+		ChunkId:         result.ChunkID,
+		FilePath:        result.FilePath,
+		LineStart:       int32(result.LineStart),
+		LineEnd:         int32(result.LineEnd),
+		Language:        result.Language,
+		Code:            result.Code,
+		Score:           result.Score,
+		NormalizedScore: result.NormalizedScore,
+		LexicalScore:    result.LexicalScore,
+		FusedScore:      result.FusedScore,
+		Boost:           result.Boost,
+		EmbeddingType:   result.EmbeddingType,
+		Project:         result.Project,
+		ChunkType:       result.ChunkType,
+		Name:            result.Name,
+		IsTest:          result.IsTest,
+		Signature:       result.Signature,
+		DocComment:      result.DocComment,
+		Heading:         result.Heading,
+		HeadingLevel:    result.HeadingLevel,
+		ParentHeading:   result.ParentHeading,
+		Metadata:        result.Metadata,
+	}
+}
+
+// toProtoIndexState converts index state to its protobuf form
+func toProtoIndexState(state *searchapi.IndexState) *codescoutpb.IndexState {
+	if state == nil {
+		return nil
+	}
+
+	converted := &codescoutpb.IndexState{
+		FilesIndexed:  int32(state.FilesIndexed),
+		GitCommit:     state.GitCommit,
+		GitDirty:      state.GitDirty,
+		CurrentCommit: state.CurrentCommit,
+		CurrentDirty:  state.CurrentDirty,
+		Stale:         state.Stale,
+		Project:       state.Project,
+	}
+	if !state.LastIndexTime.IsZero() {
+		converted.LastIndexTime = timestamppb.New(state.LastIndexTime)
+	}
+	if len(state.EmbeddingModels) > 0 {
+		converted.EmbeddingModels = make(map[string]*codescoutpb.EmbeddingModel, len(state.EmbeddingModels))
+		for embeddingType, model := range state.EmbeddingModels {
+			converted.EmbeddingModels[embeddingType] = &codescoutpb.EmbeddingModel{
+				Model:     model.Model,
+				Dimension: int32(model.Dimension),
+			}
+		}
+	}
+	return converted
+}
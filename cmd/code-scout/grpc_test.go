@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jlanders/code-scout/pkg/codescoutpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCServer_Search(t *testing.T) {
+	server := &rpcServer{api: newTestAPIServer(t)}
+
+	resp, err := server.Search(context.Background(), &codescoutpb.SearchRequest{Query: "add", Mode: "code"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if resp.GetMode() != "code" || len(resp.GetResults()) != 1 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	result := resp.GetResults()[0]
+	if result.GetChunkId() != "c1" || result.GetName() != "Add" || result.GetLineStart() != 3 {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	_, err = server.Search(context.Background(), &codescoutpb.SearchRequest{Query: "add", Mode: "fuzzy"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for bad mode, got %v", err)
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected codes.Code
+	}{
+		{fmt.Errorf("%w: bad", errInvalidRequest), codes.InvalidArgument},
+		{errIndexBusy, codes.Aborted},
+		{fmt.Errorf("%w: no table", errIndexUnavailable), codes.FailedPrecondition},
+		{fmt.Errorf("boom"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(rpcError(tt.err)); got != tt.expected {
+			t.Errorf("rpcError(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
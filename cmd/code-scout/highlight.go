@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"golang.org/x/term"
+)
+
+// highlightStyle is the chroma color scheme used for terminal output
+const highlightStyle = "monokai"
+
+// colorEnabled reports whether human-readable output should be colorized:
+// stdout must be a terminal, and neither --no-color nor NO_COLOR may be set
+func colorEnabled(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// highlightCode colorizes code for a 256-color terminal using the lexer for
+// language. Code in languages chroma doesn't know is returned unchanged.
+func highlightCode(code, language string) string {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		return code
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return code
+	}
+
+	var highlighted strings.Builder
+	if err := formatters.TTY256.Format(&highlighted, styles.Get(highlightStyle), iterator); err != nil {
+		return code
+	}
+	return highlighted.String()
+}
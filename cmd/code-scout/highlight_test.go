@@ -0,0 +1,34 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestHighlightCode(t *testing.T) {
+	code := "func Add(a, b int) int { return a + b }"
+
+	highlighted := highlightCode(code, "go")
+	if highlighted == code {
+		t.Fatal("expected Go code to be colorized")
+	}
+	if stripped := ansiEscape.ReplaceAllString(highlighted, ""); stripped != code {
+		t.Errorf("highlighting changed the text: %q", stripped)
+	}
+
+	if got := highlightCode(code, "no-such-language"); got != code {
+		t.Errorf("expected unknown language to be returned unchanged, got %q", got)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	if colorEnabled(true) {
+		t.Error("expected --no-color to disable color")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(false) {
+		t.Error("expected NO_COLOR to disable color")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/gitinfo"
+	"github.com/spf13/cobra"
+)
+
+var hooksForce bool
+
+// indexHooks are the git hooks that re-index after the working tree changes
+var indexHooks = []string{"post-commit", "post-merge", "post-checkout"}
+
+// hookMarker identifies hooks written by code-scout, so they can be replaced
+// and uninstalled without touching anyone else's
+const hookMarker = "# Installed by code-scout hooks install"
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks that keep the index fresh",
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install git hooks that re-index after commits, merges and checkouts",
+	Long: `Write post-commit, post-merge and post-checkout hooks that run an incremental
+'code-scout index' in the background, keeping the index fresh without a daemon.
+Existing hooks not written by code-scout are left alone unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		hooksDir, prefix, err := gitinfo.HooksDir(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to find git hooks directory: %w", err)
+		}
+		executable, err := hookExecutable()
+		if err != nil {
+			return err
+		}
+
+		installed, err := installHooks(hooksDir, hookScript(executable, prefix), hooksForce)
+		if err != nil {
+			return err
+		}
+		for _, path := range installed {
+			fmt.Printf("  - %s\n", path)
+		}
+		fmt.Printf("✓ Installed %d hook(s); the index now updates after commits, merges and checkouts\n", len(installed))
+		return nil
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the git hooks installed by 'code-scout hooks install'",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		hooksDir, _, err := gitinfo.HooksDir(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to find git hooks directory: %w", err)
+		}
+
+		removed, err := uninstallHooks(hooksDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed %d hook(s)\n", len(removed))
+		return nil
+	},
+}
+
+// hookExecutable returns the command hooks run: code-scout from PATH when it's
+// there, so upgrades are picked up, otherwise this executable
+func hookExecutable() (string, error) {
+	if _, err := exec.LookPath("code-scout"); err == nil {
+		return "code-scout", nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the code-scout executable: %w", err)
+	}
+	return executable, nil
+}
+
+// hookScript returns a hook that indexes the project at prefix (relative to the
+// repository root, where git runs hooks) in the background, so git isn't slowed
+// down. --wait queues it behind an index run that's already in progress.
+func hookScript(executable, prefix string) string {
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString(hookMarker + ": keeps the code-scout index fresh.\n")
+	if prefix != "" {
+		fmt.Fprintf(&script, "cd %s || exit 0\n", shellQuote(prefix))
+	}
+	fmt.Fprintf(&script, "%s index --wait >/dev/null 2>&1 &\n", shellQuote(executable))
+	return script.String()
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// installHooks writes script as each of indexHooks in dir, returning the paths
+// written. A hook that exists and wasn't written by code-scout is an error
+// unless force is set.
+func installHooks(dir, script string, force bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	for _, name := range indexHooks {
+		path := filepath.Join(dir, name)
+		if !force && isForeignHook(path) {
+			return nil, fmt.Errorf("%s already exists; use --force to replace it, or add 'code-scout index --wait &' to it yourself", path)
+		}
+	}
+
+	var installed []string
+	for _, name := range indexHooks {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			return installed, fmt.Errorf("failed to write %s hook: %w", name, err)
+		}
+		// WriteFile keeps an existing file's mode
+		if err := os.Chmod(path, 0755); err != nil {
+			return installed, fmt.Errorf("failed to make %s hook executable: %w", name, err)
+		}
+		installed = append(installed, path)
+	}
+	return installed, nil
+}
+
+// uninstallHooks removes the hooks in dir written by code-scout, returning their paths
+func uninstallHooks(dir string) ([]string, error) {
+	var removed []string
+	for _, name := range indexHooks {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(content), hookMarker) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s hook: %w", name, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// isForeignHook reports whether a hook exists at path that code-scout didn't write
+func isForeignHook(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return !strings.Contains(string(content), hookMarker)
+}
+
+func init() {
+	hooksInstallCmd.Flags().BoolVar(&hooksForce, "force", false, "Replace existing hooks not written by code-scout")
+	hooksCmd.AddCommand(hooksInstallCmd, hooksUninstallCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
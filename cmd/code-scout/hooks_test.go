@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookScript(t *testing.T) {
+	script := hookScript("/opt/code scout/bin", "tools/")
+	if !strings.HasPrefix(script, "#!/bin/sh\n"+hookMarker) {
+		t.Errorf("expected a marked shell script, got:\n%s", script)
+	}
+	if !strings.Contains(script, "cd 'tools/' || exit 0\n") || !strings.Contains(script, "'/opt/code scout/bin' index --wait >/dev/null 2>&1 &\n") {
+		t.Errorf("expected the hook to index the project in the background, got:\n%s", script)
+	}
+}
+
+func TestInstallAndUninstallHooks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+	script := hookScript("code-scout", "")
+
+	installed, err := installHooks(dir, script, false)
+	if err != nil {
+		t.Fatalf("installHooks failed: %v", err)
+	}
+	if len(installed) != len(indexHooks) {
+		t.Fatalf("expected %d hooks, got %v", len(indexHooks), installed)
+	}
+	info, err := os.Stat(filepath.Join(dir, "post-commit"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("expected an executable post-commit hook, got %v, %v", info, err)
+	}
+	// Reinstalling over our own hooks needs no --force
+	if _, err := installHooks(dir, script, false); err != nil {
+		t.Errorf("expected reinstalling to succeed, got %v", err)
+	}
+
+	// Someone else's hook is kept unless forced, and never uninstalled
+	foreign := filepath.Join(dir, "post-merge")
+	if err := os.WriteFile(foreign, []byte("#!/bin/sh\nmake deps\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installHooks(dir, script, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected an existing hook to need --force, got %v", err)
+	}
+	removed, err := uninstallHooks(dir)
+	if err != nil {
+		t.Fatalf("uninstallHooks failed: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected 2 hooks removed, got %v", removed)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("expected the foreign hook to be kept, got %v", err)
+	}
+}
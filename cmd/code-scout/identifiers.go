@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// identifierFetchFactor is how many identifier index hits are read per wanted
+// result, since hits outside the search filter are dropped
+const identifierFetchFactor = 2
+
+// identifierSearch ranks the chunks whose identifiers match the symbol names in
+// query, using the store's identifier index. It finds nothing for queries
+// without symbol-like words, or for stores indexed before the index existed.
+func identifierSearch(store storage.Store, query string, limit int, filter storage.SearchFilter) ([]SearchResult, error) {
+	if len(storage.QueryIdentifiers(query)) == 0 {
+		return nil, nil
+	}
+	idx, err := store.LoadIdentifiers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load identifier index: %w", err)
+	}
+	if idx == nil {
+		return nil, nil
+	}
+
+	var results []SearchResult
+	for _, hit := range idx.Lookup(query, limit*identifierFetchFactor) {
+		if len(results) >= limit {
+			break
+		}
+		row, err := store.GetChunk(hit.ChunkID)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil || !filter.Matches(row) {
+			continue
+		}
+		result := formatResults([]map[string]interface{}{row})[0]
+		result.IdentifierScore = hit.Score
+		result.IdentifierMatches = hit.Matched
+		// Identifier-only matches have no vector distance to normalize
+		result.NormalizedScore = hit.Score / (1 + hit.Score)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// loadIdentifierIndex loads the store's identifier index for updating. An index
+// built before the identifier index existed has it built from the stored chunks.
+func loadIdentifierIndex(store storage.Store, metadata *storage.IndexMetadata) (*storage.IdentifierIndex, error) {
+	idx, err := store.LoadIdentifiers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load identifier index: %w", err)
+	}
+	if idx != nil {
+		return idx, nil
+	}
+	if len(metadata.FileModTimes) == 0 {
+		return storage.NewIdentifierIndex(), nil
+	}
+
+	fmt.Println("Building identifier index from existing chunks...")
+	idx, err = storage.BuildIdentifierIndex(store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build identifier index: %w", err)
+	}
+	return idx, nil
+}
@@ -1,240 +1,753 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/jlanders/code-scout/internal/chunker"
 	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/gitinfo"
 	"github.com/jlanders/code-scout/internal/scanner"
 	"github.com/jlanders/code-scout/internal/storage"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
 	workers            int
 	embeddingBatchSize int
+	waitForIndexLock   bool
+	indexPlanOnly      bool
+	indexSince         string
+	indexCI            bool
+	indexTimeout       time.Duration
 )
 
+// storeBatchChunks is about how many chunks index embeds and stores at a time;
+// a batch closes at the first file that takes it past this many
+var storeBatchChunks = 1000
+
 // computeContentHash generates a SHA256 hash of the content
 func computeContentHash(content string) string {
 	hash := sha256.Sum256([]byte(content))
 	return hex.EncodeToString(hash[:])
 }
 
+// computeFileHash generates a SHA256 hash of a file's content
+func computeFileHash(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return computeContentHash(string(content)), nil
+}
+
+// detectRenames matches new files against deleted files by content hash and extension.
+// Returns a map of new path -> old path for every detected rename.
+func detectRenames(filesToIndex []scanner.FileInfo, deletedFiles []string, metadata *storage.IndexMetadata, fileHashes map[string]string) map[string]string {
+	if len(deletedFiles) == 0 {
+		return nil
+	}
+
+	// Index deleted files by content hash (files indexed before hashes were recorded can't match)
+	deletedByHash := make(map[string][]string)
+	for _, oldPath := range deletedFiles {
+		if hash := metadata.FileHashes[oldPath]; hash != "" {
+			deletedByHash[hash] = append(deletedByHash[hash], oldPath)
+		}
+	}
+	if len(deletedByHash) == 0 {
+		return nil
+	}
+
+	renames := make(map[string]string)
+	for _, f := range filesToIndex {
+		if _, exists := metadata.FileModTimes[f.Path]; exists {
+			// Modified in place, not a rename target
+			continue
+		}
+		candidates := deletedByHash[fileHashes[f.Path]]
+		for i, oldPath := range candidates {
+			// Only treat as a rename if the language (extension) is unchanged,
+			// otherwise the file would need to be chunked differently
+			if filepath.Ext(oldPath) != filepath.Ext(f.Path) {
+				continue
+			}
+			renames[f.Path] = oldPath
+			deletedByHash[fileHashes[f.Path]] = append(candidates[:i:i], candidates[i+1:]...)
+			break
+		}
+	}
+
+	return renames
+}
+
 var indexCmd = &cobra.Command{
 	Use:   "index",
 	Short: "Index the current directory for semantic search",
 	Long: `Scan the current directory for code files, chunk them, generate embeddings,
 and store them in a local LanceDB vector database (.code-scout/).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Indexing codebase...")
-
 		// Get current working directory
 		cwd, err := os.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 
-		// Initialize storage and load metadata
-		store, err := storage.NewLanceDBStore(cwd)
-		if err != nil {
-			return fmt.Errorf("failed to create LanceDB store: %w", err)
+		ctx := cmd.Context()
+		if indexTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, indexTimeout)
+			defer cancel()
+		}
+		if indexCI {
+			return runIndexCI(ctx, cmd, cwd)
 		}
-		defer store.Close()
 
-		metadata, err := store.LoadMetadata()
-		if err != nil {
-			return fmt.Errorf("failed to load metadata: %w", err)
+		_, err = runIndex(ctx, cwd)
+		return err
+	},
+}
+
+// indexSummary counts the files an index run changed in the main index
+type indexSummary struct {
+	Indexed int `json:"indexed"` // New and modified files chunked and embedded
+	Renamed int `json:"renamed"`
+	Deleted int `json:"deleted"`
+	Chunks  int `json:"chunks"` // Chunks stored for the indexed files
+}
+
+// changed reports whether the run changed the index
+func (s indexSummary) changed() bool {
+	return s.Indexed+s.Renamed+s.Deleted > 0
+}
+
+// runIndex incrementally indexes the project in cwd, embedding new and changed
+// files and removing deleted ones, then does the same for the shadow index if
+// shadow models are configured. It holds the index lock for the whole run.
+// Cancelling ctx aborts embedding generation.
+func runIndex(ctx context.Context, cwd string) (indexSummary, error) {
+	fmt.Println("Indexing codebase...")
+
+	release, err := acquireIndexLock(ctx, cwd, "index", waitForIndexLock)
+	if err != nil {
+		return indexSummary{}, err
+	}
+	defer release()
+
+	var changed map[string]bool
+	if indexSince != "" {
+		if changed, err = changedSince(cwd, indexSince); err != nil {
+			return indexSummary{}, err
 		}
+		fmt.Printf("Only indexing the %d file(s) changed since %s\n", len(changed), indexSince)
+	}
+
+	store, err := openStore(cwd)
+	if err != nil {
+		return indexSummary{}, fmt.Errorf("failed to create LanceDB store: %w", err)
+	}
+	defer store.Close()
+
+	summary, err := indexInto(ctx, cwd, store, primaryModels(), changed)
+	if err != nil {
+		return summary, err
+	}
+
+	// The shadow index is brought up to date the same way, with the shadow models
+	models, ok := shadowModels()
+	if !ok || indexPlanOnly {
+		return summary, nil
+	}
+	fmt.Printf("\nUpdating shadow index (code: %s, docs: %s)...\n", models.Code, models.Docs)
+	shadow, err := openShadowStore(cwd)
+	if err != nil {
+		return summary, fmt.Errorf("failed to open shadow index: %w", err)
+	}
+	defer shadow.Close()
+	if _, err := indexInto(ctx, cwd, shadow, models, changed); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// changedSince returns the absolute paths of the files under cwd that differ
+// from ref in git, for index --since
+func changedSince(cwd, ref string) (map[string]bool, error) {
+	files, err := gitinfo.ChangedFiles(cwd, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed since %s: %w", ref, err)
+	}
+	changed := make(map[string]bool, len(files))
+	for _, file := range files {
+		changed[filepath.Join(cwd, filepath.FromSlash(file))] = true
+	}
+	return changed, nil
+}
+
+// indexInto brings store up to date with the files in cwd, embedding new and
+// changed files with models. A non-nil changed set limits the run to those
+// files, re-indexing them whatever their modification times and leaving every
+// other file as it is in the index.
+func indexInto(ctx context.Context, cwd string, store storage.Store, models embeddingModels, changed map[string]bool) (summary indexSummary, err error) {
+	ctx, span := startSpan(ctx, "index",
+		attribute.String("code_scout.code_model", models.Code),
+		attribute.String("code_scout.docs_model", models.Docs))
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("code_scout.files_indexed", summary.Indexed),
+			attribute.Int("code_scout.chunks", summary.Chunks))
+		endSpan(span, err)
+	}()
 
-		// Scan for code files
-		s := scanner.New(cwd)
-		allFiles, err := s.ScanCodeFiles()
+	metadata, err := store.LoadMetadata()
+	if err != nil {
+		return summary, fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	// Bring existing indexes up to the current schema before adding new rows.
+	// A plan changes nothing, so it leaves the schema as it is.
+	var applied []string
+	if !indexPlanOnly {
+		previousVersion := metadata.SchemaVersion
+		err = traceStore(ctx, "migrate", func() (err error) {
+			applied, err = store.Migrate(metadata)
+			return err
+		})
 		if err != nil {
-			return fmt.Errorf("failed to scan files: %w", err)
-		}
-
-		// Determine which files need indexing
-		var filesToIndex []scanner.FileInfo
-		var filesToDelete []string
-		now := time.Now()
-
-		for _, f := range allFiles {
-			lastModTime, exists := metadata.FileModTimes[f.Path]
-			if !exists || f.ModTime.After(lastModTime) {
-				// File is new or has been modified
-				filesToIndex = append(filesToIndex, f)
-				if exists {
-					// File was previously indexed, mark for deletion
-					filesToDelete = append(filesToDelete, f.Path)
-				}
+			return summary, fmt.Errorf("failed to migrate index: %w", err)
+		}
+		for _, description := range applied {
+			fmt.Printf("Migrated index schema (%s)\n", description)
+		}
+		if len(applied) > 0 || metadata.SchemaVersion != previousVersion {
+			if err := store.SaveMetadata(metadata); err != nil {
+				return summary, fmt.Errorf("failed to save metadata: %w", err)
 			}
 		}
+	}
 
-		// Check for deleted files (files in metadata but not in scan)
-		for filePath := range metadata.FileModTimes {
-			found := false
-			for _, f := range allFiles {
-				if f.Path == filePath {
-					found = true
-					break
-				}
-			}
-			if !found {
-				// File was deleted, mark for deletion
-				filesToDelete = append(filesToDelete, filePath)
-			}
+	// Scan for code files
+	fileScanner := newScanner(cwd)
+	_, scanSpan := startSpan(ctx, "scan")
+	allFiles, err := fileScanner.ScanCodeFiles()
+	scanSpan.SetAttributes(attribute.Int("code_scout.files", len(allFiles)))
+	endSpan(scanSpan, err)
+	if err != nil {
+		return summary, fmt.Errorf("failed to scan files: %w", err)
+	}
+
+	// Determine which files need indexing
+	var filesToIndex []scanner.FileInfo
+	var deletedFiles []string
+	now := time.Now()
+
+	scannedPaths := make(map[string]bool, len(allFiles))
+	for _, f := range allFiles {
+		scannedPaths[f.Path] = true
+		if changed != nil && !changed[f.Path] {
+			continue
+		}
+		lastModTime, exists := metadata.FileModTimes[f.Path]
+		// A fresh checkout gives every file a new modification time, so files
+		// in the changed set are re-indexed regardless
+		if !exists || f.ModTime.After(lastModTime) || changed != nil {
+			// File is new or has been modified
+			filesToIndex = append(filesToIndex, f)
 		}
+	}
 
-		// Delete old chunks for changed/deleted files
-		if len(filesToDelete) > 0 {
-			fmt.Printf("Removing %d changed/deleted file(s) from index...\n", len(filesToDelete))
-			if err := store.DeleteChunksByFilePath(filesToDelete); err != nil {
-				return fmt.Errorf("failed to delete old chunks: %w", err)
-			}
+	// Check for deleted files (files in metadata but not in scan)
+	for filePath := range metadata.FileModTimes {
+		if !scannedPaths[filePath] && (changed == nil || changed[filePath]) {
+			deletedFiles = append(deletedFiles, filePath)
 		}
+	}
 
-		// If nothing to index, we're done
-		if len(filesToIndex) == 0 {
-			fmt.Printf("✓ All files up to date. Indexing complete!\n")
-			return nil
+	// Hash new and modified files so renames can be detected and recorded
+	fileHashes := make(map[string]string, len(filesToIndex))
+	for _, f := range filesToIndex {
+		hash, err := computeFileHash(f.Path)
+		if err != nil {
+			return summary, fmt.Errorf("failed to hash file %s: %w", f.Path, err)
 		}
+		fileHashes[f.Path] = hash
+	}
 
-		// Count files by language
-		langCounts := make(map[string]int)
+	// Renamed files keep their embeddings: rewrite the stored path instead of re-embedding
+	renames := detectRenames(filesToIndex, deletedFiles, metadata, fileHashes)
+	if indexPlanOnly {
+		var toEmbed []scanner.FileInfo
 		for _, f := range filesToIndex {
-			langCounts[f.Language]++
+			if _, renamed := renames[f.Path]; !renamed {
+				toEmbed = append(toEmbed, f)
+			}
 		}
+		plan, err := newIndexPlan(toEmbed, len(renames), len(deletedFiles)-len(renames), fileScanner.Skipped())
+		if err != nil {
+			return summary, err
+		}
+		plan.print(embeddingBatchSize)
+		return summary, nil
+	}
 
-		fmt.Printf("Indexing %d file(s)", len(filesToIndex))
-		if len(langCounts) > 0 {
-			fmt.Print(" (")
-			first := true
-			for lang, count := range langCounts {
-				if !first {
-					fmt.Print(", ")
-				}
-				fmt.Printf("%d %s", count, lang)
-				first = false
+	identifiers, err := loadIdentifierIndex(store, metadata)
+	if err != nil {
+		return summary, err
+	}
+	if len(renames) > 0 {
+		fmt.Printf("Detected %d renamed file(s), updating paths in index...\n", len(renames))
+		for _, f := range filesToIndex {
+			oldPath, renamed := renames[f.Path]
+			if !renamed {
+				continue
 			}
-			fmt.Print(")")
+			if err := traceStore(ctx, "update_file_path", func() error { return store.UpdateFilePath(oldPath, f.Path) }); err != nil {
+				return summary, fmt.Errorf("failed to update renamed file %s: %w", f.Path, err)
+			}
+			fmt.Printf("  - %s -> %s\n", oldPath, f.Path)
+			identifiers.RenameFile(oldPath, f.Path)
+			metadata.FileModTimes[f.Path] = f.ModTime
+			metadata.FileHashes[f.Path] = fileHashes[f.Path]
+			delete(metadata.FileModTimes, oldPath)
+			delete(metadata.FileHashes, oldPath)
 		}
-		fmt.Println()
-
-		// Chunk files that need indexing using semantic chunker
-		semanticChunker, err := chunker.NewSemantic()
-		if err != nil {
-			return fmt.Errorf("failed to create semantic chunker: %w", err)
+		// Persist immediately so the table and metadata agree even if a later step fails
+		if err := store.SaveMetadata(metadata); err != nil {
+			return summary, fmt.Errorf("failed to save metadata: %w", err)
+		}
+		if err := store.SaveIdentifiers(identifiers); err != nil {
+			return summary, err
 		}
+		summary.Renamed = len(renames)
 
-		var allChunks []chunker.Chunk
+		remaining := filesToIndex[:0]
 		for _, f := range filesToIndex {
-			chunks, err := semanticChunker.ChunkFile(f.Path, f.Language)
-			if err != nil {
-				return fmt.Errorf("failed to chunk file %s: %w", f.Path, err)
+			if _, renamed := renames[f.Path]; !renamed {
+				remaining = append(remaining, f)
 			}
-			allChunks = append(allChunks, chunks...)
-			fmt.Printf("  - %s: %d chunks\n", f.Path, len(chunks))
 		}
+		filesToIndex = remaining
+	}
 
-		fmt.Printf("Total chunks: %d\n", len(allChunks))
+	var removedFiles []string
+	for _, filePath := range deletedFiles {
+		if _, exists := metadata.FileModTimes[filePath]; exists {
+			// File was deleted, mark for deletion
+			removedFiles = append(removedFiles, filePath)
+		}
+	}
+	deleted := len(removedFiles)
+
+	// If nothing to index, drop deleted files, record the current git state and we're done
+	if len(filesToIndex) == 0 {
+		if len(removedFiles) > 0 {
+			fmt.Printf("Removing %d deleted file(s) from index...\n", len(removedFiles))
+			if err := traceStore(ctx, "delete_chunks", func() error { return store.DeleteChunksByFilePath(removedFiles) }); err != nil {
+				return summary, fmt.Errorf("failed to delete old chunks: %w", err)
+			}
+		}
+		for _, filePath := range removedFiles {
+			delete(metadata.FileModTimes, filePath)
+			delete(metadata.FileHashes, filePath)
+		}
+		identifiers.RemoveFiles(removedFiles)
+		metadata.LastIndexTime = now
+		recordGitState(metadata, cwd)
+		if err := store.SaveMetadata(metadata); err != nil {
+			return summary, fmt.Errorf("failed to save metadata: %w", err)
+		}
+		if err := store.SaveIdentifiers(identifiers); err != nil {
+			return summary, err
+		}
+		// A migration rewrites the table, which drops the full-text index
+		if len(applied) > 0 {
+			if err := store.OpenTable(); err != nil {
+				return summary, err
+			}
+			if err := traceStore(ctx, "create_text_index", store.CreateTextIndex); err != nil {
+				return summary, err
+			}
+		}
+		fmt.Printf("✓ All files up to date. Indexing complete!\n")
+		summary.Deleted = deleted
+		return summary, nil
+	}
 
-		// Separate chunks by embedding type
-		var codeChunks, docsChunks []chunker.Chunk
-		var codeIndices, docsIndices []int
+	plan, err := newIndexPlan(filesToIndex, len(renames), len(deletedFiles)-len(renames), fileScanner.Skipped())
+	if err != nil {
+		return summary, err
+	}
+	plan.print(embeddingBatchSize)
 
-		for i, chunk := range allChunks {
-			if chunk.EmbeddingType == "code" {
-				codeChunks = append(codeChunks, chunk)
-				codeIndices = append(codeIndices, i)
-			} else if chunk.EmbeddingType == "docs" {
-				docsChunks = append(docsChunks, chunk)
-				docsIndices = append(docsIndices, i)
-			}
+	// Chunk files that need indexing using semantic chunker
+	semanticChunker, err := chunker.NewSemantic()
+	if err != nil {
+		return summary, fmt.Errorf("failed to create semantic chunker: %w", err)
+	}
+	semanticChunker.SetSectionLimit(maxSectionTokens(), embeddings.CountTokens)
+	text, err := newChunkText(cwd)
+	if err != nil {
+		return summary, err
+	}
+	summarizer, err := newChunkSummarizer(text)
+	if err != nil {
+		return summary, err
+	}
+
+	// Deleted files don't wait on any embedding, so they're removed first
+	if len(removedFiles) > 0 {
+		fmt.Printf("Removing %d deleted file(s) from index...\n", len(removedFiles))
+		if err := traceStore(ctx, "delete_chunks", func() error { return store.DeleteChunksByFilePath(removedFiles) }); err != nil {
+			return summary, fmt.Errorf("failed to delete old chunks: %w", err)
+		}
+		for _, filePath := range removedFiles {
+			delete(metadata.FileModTimes, filePath)
+			delete(metadata.FileHashes, filePath)
 		}
+		identifiers.RemoveFiles(removedFiles)
+		if err := saveIndexState(store, metadata, identifiers); err != nil {
+			return summary, err
+		}
+		summary.Deleted = deleted
+	}
 
-		fmt.Printf("Code chunks: %d, Docs chunks: %d\n", len(codeChunks), len(docsChunks))
+	// Files are chunked, embedded and stored a batch at a time, so memory use
+	// is bounded by the batch rather than the size of the repo. Each stored
+	// batch is recorded in the metadata, so a run that fails or is interrupted
+	// (Ctrl-C or --timeout) keeps the files already stored, and the next run
+	// picks up the rest.
+	batcher := &batchIndexer{store: store, metadata: metadata, identifiers: identifiers, models: models, text: text, summarizer: summarizer, fileHashes: fileHashes}
+	var failed error
+	var batchFiles []scanner.FileInfo
+	var batchChunks []chunker.Chunk
+	for i, f := range filesToIndex {
+		_, fileSpan := startSpan(ctx, "chunk.file",
+			attribute.String("code_scout.path", f.Path),
+			attribute.String("code_scout.language", f.Language))
+		chunks, err := semanticChunker.ChunkFile(f.Path, f.Language)
+		fileSpan.SetAttributes(attribute.Int("code_scout.chunks", len(chunks)))
+		endSpan(fileSpan, err)
+		if err != nil {
+			return summary, fmt.Errorf("failed to chunk file %s: %w", f.Path, err)
+		}
+		fmt.Printf("  - %s: %d chunks\n", f.Path, len(chunks))
+		batchFiles = append(batchFiles, f)
+		batchChunks = append(batchChunks, chunks...)
+		if len(batchChunks) < storeBatchChunks && i < len(filesToIndex)-1 {
+			continue
+		}
 
-		// Initialize all embeddings array
-		allEmbeddings := make([][]float64, len(allChunks))
+		files, chunkCount, err := batcher.index(ctx, batchFiles, batchChunks)
+		summary.Indexed += files
+		summary.Chunks += chunkCount
+		if err != nil {
+			failed = err
+			break
+		}
+		batchFiles, batchChunks = nil, nil
+	}
 
-		// TWO-PASS EMBEDDING GENERATION
+	interrupted := failed != nil && ctx.Err() != nil
+	if failed != nil && summary.Indexed == 0 && len(removedFiles) == 0 {
+		if !interrupted {
+			return summary, failed
+		}
+		fmt.Println("\nInterrupted before any file was fully embedded; nothing was saved")
+		return summary, fmt.Errorf("indexing interrupted: %w", failed)
+	}
 
-		// PASS 1: Code chunks with code-scout-code model
-		if len(codeChunks) > 0 {
-			fmt.Println("\nPass 1: Generating code embeddings...")
-			codeClient := newCodeEmbeddingClient()
+	// The files stored before a failure are made searchable like any others
+	fmt.Println("\nBuilding full-text index...")
+	if err := traceStore(ctx, "create_text_index", store.CreateTextIndex); err != nil {
+		return summary, err
+	}
 
-			codeEmbeddings, err := generateEmbeddingsWithDedup(codeClient, codeChunks, workers, embeddingBatchSize)
-			if err != nil {
-				return fmt.Errorf("failed to generate code embeddings: %w", err)
-			}
+	metadata.LastIndexTime = now
+	recordGitState(metadata, cwd)
+	if err := store.SaveMetadata(metadata); err != nil {
+		return summary, fmt.Errorf("failed to save metadata: %w", err)
+	}
 
-			// Map code embeddings back to allEmbeddings
-			for i, embedding := range codeEmbeddings {
-				allEmbeddings[codeIndices[i]] = embedding
-			}
+	if interrupted {
+		fmt.Printf("Interrupted after saving %d of %d file(s); run 'code-scout index' again to index the remaining %d\n",
+			summary.Indexed, len(filesToIndex), len(filesToIndex)-summary.Indexed)
+		return summary, fmt.Errorf("indexing interrupted: %w", failed)
+	}
+	if failed != nil {
+		fmt.Printf("Failed after saving %d of %d file(s); run 'code-scout index' again to index the remaining %d\n",
+			summary.Indexed, len(filesToIndex), len(filesToIndex)-summary.Indexed)
+		return summary, fmt.Errorf("indexing failed: %w", failed)
+	}
+	fmt.Printf("Total chunks: %d\n", summary.Chunks)
+	fmt.Println("✓ Indexing complete!")
+
+	return summary, nil
+}
+
+// batchIndexer embeds and stores the batches of an index run
+type batchIndexer struct {
+	store       storage.Store
+	metadata    *storage.IndexMetadata
+	identifiers *storage.IdentifierIndex
+	models      embeddingModels
+	text        *chunkText
+	summarizer  *chunkSummarizer // nil unless summarize_chunks_over is set
+	fileHashes  map[string]string
+}
+
+// index embeds a batch of files' chunks, stores them in place of the files'
+// old chunks, and records the files in the metadata. It returns the number of
+// files and chunks stored. If embedding fails or ctx is cancelled, the files
+// whose chunks were all embedded are still stored, and the error is returned.
+func (b *batchIndexer) index(ctx context.Context, files []scanner.FileInfo, chunks []chunker.Chunk) (stored, storedChunks int, err error) {
+	ctx, span := startSpan(ctx, "batch", attribute.Int("code_scout.files", len(files)))
+	defer func() {
+		span.SetAttributes(attribute.Int("code_scout.chunks", storedChunks))
+		endSpan(span, err)
+	}()
+
+	// Doc comments, and summaries of large chunks, are also embedded on their
+	// own with the text model
+	described := docCommentRows(chunks)
+	if b.summarizer != nil {
+		// Summaries of chunks that haven't changed are reused from the old rows
+		cached, err := cachedSummaries(b.store, b.replacedFiles(files))
+		if err != nil {
+			return 0, 0, err
 		}
+		summaries, err := b.summarizer.rows(ctx, chunks, cached, workers)
+		if err != nil {
+			return 0, 0, err
+		}
+		described = append(described, summaries...)
+	}
+	chunks = splitOversizedChunks(append(chunks, described...))
 
-		// PASS 2: Docs chunks with code-scout-text model
-		if len(docsChunks) > 0 {
-			fmt.Println("\nPass 2: Generating documentation embeddings...")
-			textClient := newDocsEmbeddingClient()
+	// Separate chunks by embedding type
+	var codeChunks, docsChunks []chunker.Chunk
+	var codeIndices, docsIndices []int
+	for i, chunk := range chunks {
+		if chunk.EmbeddingType == "code" {
+			codeChunks = append(codeChunks, chunk)
+			codeIndices = append(codeIndices, i)
+		} else if chunk.EmbeddingType == "docs" {
+			docsChunks = append(docsChunks, chunk)
+			docsIndices = append(docsIndices, i)
+		}
+	}
+	fmt.Printf("\nEmbedding %d file(s): %d code chunks, %d docs chunks\n", len(files), len(codeChunks), len(docsChunks))
+
+	// Code chunks are embedded with the code model, then docs chunks with the
+	// text model; docs vectors keep their native dimension in their own table
+	vectors := make([][]float64, len(chunks))
+	var failed error
+	if len(codeChunks) > 0 {
+		fmt.Println("Generating code embeddings...")
+		failed = b.embed(ctx, "code", b.models.Code, b.models.newCodeClient(), codeChunks, codeIndices, vectors)
+	}
+	if len(docsChunks) > 0 && failed == nil {
+		fmt.Println("Generating documentation embeddings...")
+		failed = b.embed(ctx, "docs", b.models.Docs, b.models.newDocsClient(), docsChunks, docsIndices, vectors)
+	}
+	if failed != nil {
+		files, chunks, vectors = completedFiles(files, chunks, vectors)
+		if len(files) == 0 {
+			return 0, 0, failed
+		}
+		if ctx.Err() != nil {
+			fmt.Printf("Interrupted; saving the %d file(s) already embedded...\n", len(files))
+		} else {
+			fmt.Printf("Embedding failed; saving the %d file(s) already embedded...\n", len(files))
+		}
+	}
 
-			docsEmbeddings, err := generateEmbeddingsWithDedup(textClient, docsChunks, workers, embeddingBatchSize)
-			if err != nil {
-				return fmt.Errorf("failed to generate docs embeddings: %w", err)
-			}
+	// Old chunks of changed files are only removed once their new chunks are
+	// embedded, so a failed batch leaves them in the index
+	replaced := b.replacedFiles(files)
+	fmt.Printf("Storing %d chunks...\n", len(chunks))
+	if err := traceStore(ctx, "replace_chunks", func() error { return b.store.ReplaceChunks(replaced, chunks, vectors) }); err != nil {
+		return 0, 0, fmt.Errorf("failed to store chunks: %w", err)
+	}
+	b.identifiers.RemoveFiles(replaced)
+	b.identifiers.Add(chunks)
+	for _, f := range files {
+		b.metadata.FileModTimes[f.Path] = f.ModTime
+		b.metadata.FileHashes[f.Path] = b.fileHashes[f.Path]
+	}
+	if err := saveIndexState(b.store, b.metadata, b.identifiers); err != nil {
+		return 0, 0, err
+	}
+	return len(files), len(chunks), failed
+}
 
-			// Pad docs embeddings to match code embedding dimensions (3584)
-			// nomic-embed-text produces 768-dim vectors, pad with zeros
-			const targetDim = 3584
-			for i, embedding := range docsEmbeddings {
-				if len(embedding) < targetDim {
-					padded := make([]float64, targetDim)
-					copy(padded, embedding)
-					docsEmbeddings[i] = padded
-				}
-				allEmbeddings[docsIndices[i]] = docsEmbeddings[i]
-			}
+// replacedFiles returns the paths of the files already in the index, whose
+// old chunks the batch replaces
+func (b *batchIndexer) replacedFiles(files []scanner.FileInfo) []string {
+	var replaced []string
+	for _, f := range files {
+		if _, indexed := b.metadata.FileModTimes[f.Path]; indexed {
+			replaced = append(replaced, f.Path)
 		}
+	}
+	return replaced
+}
+
+// embed generates embeddings for one embedding space's chunks with model,
+// placing each in vectors at the chunk's index. After a failure, the
+// embeddings generated so far are still placed.
+func (b *batchIndexer) embed(ctx context.Context, embeddingType, model string, client embeddings.Client, chunks []chunker.Chunk, indices []int, vectors [][]float64) error {
+	if err := b.metadata.ValidateEmbeddingModel(embeddingType, model, 0); err != nil {
+		return err
+	}
 
-		fmt.Println("\nAll embeddings generated successfully!")
+	texts, err := b.text.texts(chunks)
+	if err != nil {
+		return err
+	}
+	embedCtx, span := startSpan(ctx, "embed",
+		attribute.String("code_scout.embedding_type", embeddingType),
+		attribute.String("code_scout.model", model),
+		attribute.Int("code_scout.chunks", len(chunks)))
+	generated, genErr := generateEmbeddingsWithDedup(embedCtx, client, texts, workers, embeddingBatchSize)
+	endSpan(span, genErr)
+	if err := recordEmbeddingModel(b.metadata, embeddingType, model, generated); err != nil {
+		return err
+	}
+	for i, embedding := range generated {
+		vectors[indices[i]] = embedding
+	}
+	if genErr != nil {
+		return fmt.Errorf("failed to generate %s embeddings: %w", embeddingType, genErr)
+	}
+	return nil
+}
 
-		// Store chunks and embeddings in LanceDB
-		fmt.Println("Storing in vector database...")
-		if err := store.StoreChunks(allChunks, allEmbeddings); err != nil {
-			return fmt.Errorf("failed to store chunks: %w", err)
+// saveIndexState saves the metadata and identifier index, so they agree with
+// the chunks stored so far
+func saveIndexState(store storage.Store, metadata *storage.IndexMetadata, identifiers *storage.IdentifierIndex) error {
+	if err := store.SaveMetadata(metadata); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return store.SaveIdentifiers(identifiers)
+}
+
+// completedFiles narrows a failed or interrupted batch to the files whose
+// chunks were all embedded, returning them with their chunks and embeddings
+func completedFiles(files []scanner.FileInfo, chunks []chunker.Chunk, vectors [][]float64) ([]scanner.FileInfo, []chunker.Chunk, [][]float64) {
+	incomplete := make(map[string]bool)
+	for i, chunk := range chunks {
+		if vectors[i] == nil {
+			incomplete[chunk.FilePath] = true
 		}
+	}
 
-		// Update metadata with new file modification times
-		metadata.LastIndexTime = now
-		for _, f := range filesToIndex {
-			metadata.FileModTimes[f.Path] = f.ModTime
+	var keptFiles []scanner.FileInfo
+	for _, f := range files {
+		if !incomplete[f.Path] {
+			keptFiles = append(keptFiles, f)
 		}
-		// Remove deleted files from metadata
-		for _, filePath := range filesToDelete {
-			delete(metadata.FileModTimes, filePath)
+	}
+	var keptChunks []chunker.Chunk
+	var keptVectors [][]float64
+	for i, chunk := range chunks {
+		if !incomplete[chunk.FilePath] {
+			keptChunks = append(keptChunks, chunk)
+			keptVectors = append(keptVectors, vectors[i])
 		}
+	}
+	return keptFiles, keptChunks, keptVectors
+}
 
-		if err := store.SaveMetadata(metadata); err != nil {
-			return fmt.Errorf("failed to save metadata: %w", err)
-		}
+// newScanner creates a scanner for root with the configured skip rules
+func newScanner(root string) *scanner.Scanner {
+	s := scanner.New(root)
+	if globalConfig != nil {
+		s.SetSkipRules(scanner.SkipRules{
+			Skip:      globalConfig.Skip,
+			Include:   globalConfig.Include,
+			Languages: globalConfig.Languages,
+		})
+	}
+	return s
+}
 
-		fmt.Println("✓ Indexing complete!")
+// splitOversizedChunks splits chunks longer than their embedding model
+// accepts, warning with each one's location, rather than leaving the server
+// to silently truncate or reject them
+func splitOversizedChunks(chunks []chunker.Chunk) []chunker.Chunk {
+	var result []chunker.Chunk
+	for _, chunk := range chunks {
+		limit := maxInputTokens(chunk.EmbeddingType)
+		parts, truncated := chunker.SplitOversized(chunk, limit, embeddings.CountTokens)
+		if len(parts) > 1 || truncated {
+			location := fmt.Sprintf("%s:%d-%d", chunk.FilePath, chunk.LineStart, chunk.LineEnd)
+			if chunk.Name != "" {
+				location += " (" + chunk.Name + ")"
+			}
+			fmt.Printf("Warning: %s is about %d tokens, over the %d-token model limit; split into %d chunks\n",
+				location, embeddings.CountTokens(chunk.Code), limit, len(parts))
+			if truncated {
+				fmt.Printf("Warning: %s has a line over the limit; it was truncated\n", location)
+			}
+		}
+		result = append(result, parts...)
+	}
+	return result
+}
 
+// recordEmbeddingModel checks that newly generated embeddings match the
+// configured dimension and the model and dimension recorded for their
+// embedding space, then records them in metadata
+func recordEmbeddingModel(metadata *storage.IndexMetadata, embeddingType, model string, vectors [][]float64) error {
+	// An interrupted run leaves some vectors nil
+	var dimension int
+	for _, vector := range vectors {
+		if vector != nil {
+			dimension = len(vector)
+			break
+		}
+	}
+	if dimension == 0 {
 		return nil
-	},
+	}
+	if err := checkConfiguredDimension(embeddingType, model, dimension); err != nil {
+		return err
+	}
+	if err := metadata.ValidateEmbeddingModel(embeddingType, model, dimension); err != nil {
+		return err
+	}
+	metadata.RecordEmbeddingModel(embeddingType, model, dimension)
+	return nil
 }
 
-// generateEmbeddingsWithDedup generates embeddings for chunks with content deduplication
-func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chunk, numWorkers, batchSize int) ([][]float64, error) {
-	if len(chunks) == 0 {
+// recordGitState stores the HEAD commit and dirty state of the working tree in metadata
+func recordGitState(metadata *storage.IndexMetadata, dir string) {
+	info, err := gitinfo.Inspect(dir)
+	if err != nil {
+		fmt.Printf("Warning: could not read git state: %v\n", err)
+	}
+	if info == nil {
+		metadata.GitCommit = ""
+		metadata.GitDirty = false
+		return
+	}
+	metadata.GitCommit = info.Commit
+	metadata.GitDirty = info.Dirty
+}
+
+// generateEmbeddingsWithDedup generates embeddings for texts with content
+// deduplication. On failure, the embeddings generated so far are returned with
+// the error, nil for the texts left without one. Once ctx is cancelled, no
+// further requests are sent.
+func generateEmbeddingsWithDedup(ctx context.Context, client embeddings.Client, texts []string, numWorkers, batchSize int) ([][]float64, error) {
+	if len(texts) == 0 {
 		return nil, nil
 	}
 
@@ -247,11 +760,11 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 	}
 
 	// Compute content hashes for deduplication
-	chunkHashes := make([]string, len(chunks))
+	chunkHashes := make([]string, len(texts))
 	hashToFirstIndex := make(map[string]int)
 
-	for i, chunk := range chunks {
-		hash := computeContentHash(chunk.Code)
+	for i, text := range texts {
+		hash := computeContentHash(text)
 		chunkHashes[i] = hash
 
 		if _, exists := hashToFirstIndex[hash]; !exists {
@@ -260,7 +773,7 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 	}
 
 	uniqueCount := len(hashToFirstIndex)
-	duplicateCount := len(chunks) - uniqueCount
+	duplicateCount := len(texts) - uniqueCount
 
 	if duplicateCount > 0 {
 		fmt.Printf("Found %d duplicate chunks (will skip %d embeddings)\n", duplicateCount, duplicateCount)
@@ -269,7 +782,7 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 	fmt.Printf("Using %d concurrent workers\n", numWorkers)
 
 	// Generate embeddings for unique chunks only
-	allEmbeddings := make([][]float64, len(chunks))
+	allEmbeddings := make([][]float64, len(texts))
 
 	type job struct {
 		index int
@@ -295,11 +808,19 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 				if len(buffer) == 0 {
 					return true
 				}
+				if err := ctx.Err(); err != nil {
+					for _, jb := range buffer {
+						results <- result{index: jb.index, err: err}
+					}
+					return false
+				}
 				texts := make([]string, len(buffer))
 				for i, jb := range buffer {
 					texts[i] = jb.text
 				}
-				embeddings, err := client.EmbedMany(texts)
+				_, span := startSpan(ctx, "embed.batch", attribute.Int("code_scout.texts", len(texts)))
+				embeddings, err := client.EmbedMany(ctx, texts)
+				endSpan(span, err)
 				if err != nil {
 					for _, jb := range buffer {
 						results <- result{index: jb.index, err: err}
@@ -329,7 +850,7 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 	for _, firstIdx := range hashToFirstIndex {
 		jobs <- job{
 			index: firstIdx,
-			text:  chunks[firstIdx].Code,
+			text:  texts[firstIdx],
 		}
 	}
 	close(jobs)
@@ -360,11 +881,7 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 		}
 	}
 
-	if firstErr != nil {
-		return nil, fmt.Errorf("failed to generate embeddings: %w", firstErr)
-	}
-
-	// Copy embeddings to duplicate chunks
+	// Copy embeddings to duplicate chunks, including those generated before a failure
 	if duplicateCount > 0 {
 		fmt.Printf("Copying embeddings to %d duplicate chunks...\n", duplicateCount)
 		for i, hash := range chunkHashes {
@@ -375,6 +892,9 @@ func generateEmbeddingsWithDedup(client embeddings.Client, chunks []chunker.Chun
 		}
 	}
 
+	if firstErr != nil {
+		return allEmbeddings, fmt.Errorf("failed to generate embeddings: %w", firstErr)
+	}
 	return allEmbeddings, nil
 }
 
@@ -382,4 +902,9 @@ func init() {
 	rootCmd.AddCommand(indexCmd)
 	indexCmd.Flags().IntVarP(&workers, "workers", "w", 10, "Number of concurrent workers for embedding generation (default: 10)")
 	indexCmd.Flags().IntVar(&embeddingBatchSize, "batch-size", 8, "Number of chunks per embedding request (default: 8)")
+	indexCmd.Flags().BoolVar(&waitForIndexLock, "wait", false, "Wait for a running index to finish instead of failing")
+	indexCmd.Flags().StringVar(&indexSince, "since", "", "Only (re)index files changed since this git ref (git diff --name-only <ref>), e.g. origin/main")
+	indexCmd.Flags().BoolVar(&indexCI, "ci", false, "CI mode: print a JSON summary to stdout (progress goes to stderr) and exit 0 when files were indexed, 2 when nothing changed, and 1 or a cause-specific code (3-7) on errors")
+	indexCmd.Flags().DurationVar(&indexTimeout, "timeout", 0, "Abort indexing after this long, e.g. 10m (default: no limit)")
+	indexCmd.Flags().BoolVar(&indexPlanOnly, "plan", false, "Print what indexing would embed (files, estimated chunks, requests and tokens) and exit without indexing")
 }
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/config"
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/scanner"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestDetectRenames(t *testing.T) {
+	metadata := &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{
+			"old/util.go":  time.Now(),
+			"old/notes.md": time.Now(),
+			"kept.go":      time.Now(),
+		},
+		FileHashes: map[string]string{
+			"old/util.go":  "hash-util",
+			"old/notes.md": "hash-notes",
+			"kept.go":      "hash-kept",
+		},
+	}
+
+	filesToIndex := []scanner.FileInfo{
+		{Path: "new/util.go", Language: "go"},
+		{Path: "new/notes.txt", Language: "text"}, // same content, different extension
+		{Path: "kept.go", Language: "go"},         // modified in place
+		{Path: "fresh.go", Language: "go"},
+	}
+	fileHashes := map[string]string{
+		"new/util.go":   "hash-util",
+		"new/notes.txt": "hash-notes",
+		"kept.go":       "hash-util",
+		"fresh.go":      "hash-fresh",
+	}
+
+	renames := detectRenames(filesToIndex, []string{"old/util.go", "old/notes.md"}, metadata, fileHashes)
+
+	if len(renames) != 1 {
+		t.Fatalf("expected 1 rename, got %d: %v", len(renames), renames)
+	}
+	if renames["new/util.go"] != "old/util.go" {
+		t.Errorf("expected new/util.go to be renamed from old/util.go, got %q", renames["new/util.go"])
+	}
+}
+
+func TestDetectRenames_NoHashesRecorded(t *testing.T) {
+	metadata := &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{"old.go": time.Now()},
+		FileHashes:   map[string]string{},
+	}
+	filesToIndex := []scanner.FileInfo{{Path: "new.go", Language: "go"}}
+
+	renames := detectRenames(filesToIndex, []string{"old.go"}, metadata, map[string]string{"new.go": "hash"})
+	if len(renames) != 0 {
+		t.Errorf("expected no renames without recorded hashes, got %v", renames)
+	}
+}
+
+func TestRecordEmbeddingModel(t *testing.T) {
+	metadata := &storage.IndexMetadata{}
+
+	if err := recordEmbeddingModel(metadata, "docs", "code-scout-text", [][]float64{make([]float64, 768)}); err != nil {
+		t.Fatalf("unexpected error recording model: %v", err)
+	}
+	if got := metadata.EmbeddingModels["docs"]; got.Model != "code-scout-text" || got.Dimension != 768 {
+		t.Fatalf("unexpected recorded model: %+v", got)
+	}
+
+	if err := recordEmbeddingModel(metadata, "docs", "other-model", [][]float64{make([]float64, 768)}); err == nil {
+		t.Error("expected error when the model changes")
+	}
+	if err := recordEmbeddingModel(metadata, "docs", "code-scout-text", [][]float64{make([]float64, 1024)}); err == nil {
+		t.Error("expected error when the dimension changes")
+	}
+	if err := metadata.ValidateEmbeddingModel("code", "anything", 3584); err != nil {
+		t.Errorf("expected unrecorded embedding space to pass, got %v", err)
+	}
+}
+
+func TestRecordEmbeddingModel_ConfiguredDimension(t *testing.T) {
+	prevConfig := globalConfig
+	globalConfig = &config.Config{CodeModel: "code-scout-code", CodeDimension: 768}
+	t.Cleanup(func() { globalConfig = prevConfig })
+
+	metadata := &storage.IndexMetadata{}
+	err := recordEmbeddingModel(metadata, "code", "code-scout-code", [][]float64{make([]float64, 3584)})
+	if err == nil || !strings.Contains(err.Error(), "code_dimension is 768") {
+		t.Errorf("expected a configured dimension error, got %v", err)
+	}
+	if _, ok := metadata.EmbeddingModels["code"]; ok {
+		t.Error("expected a rejected model not to be recorded")
+	}
+	if err := recordEmbeddingModel(metadata, "code", "code-scout-code", [][]float64{make([]float64, 768)}); err != nil {
+		t.Errorf("expected the configured dimension to be accepted, got %v", err)
+	}
+	if err := recordEmbeddingModel(metadata, "docs", "code-scout-text", [][]float64{make([]float64, 1024)}); err != nil {
+		t.Errorf("expected docs without text_dimension to accept any dimension, got %v", err)
+	}
+}
+
+func TestSplitOversizedChunks(t *testing.T) {
+	prevConfig := globalConfig
+	globalConfig = &config.Config{CodeModel: "code-scout-code", TextModel: "code-scout-text", CodeMaxTokens: 8}
+	t.Cleanup(func() { globalConfig = prevConfig })
+
+	chunks := []chunker.Chunk{
+		{ID: "big", FilePath: "big.go", LineStart: 1, LineEnd: 3, EmbeddingType: "code", Code: "one two\nthree four\nfive six"},
+		{ID: "doc", FilePath: "README.md", LineStart: 1, LineEnd: 3, EmbeddingType: "docs", Code: "one two\nthree four\nfive six"},
+	}
+	result := splitOversizedChunks(chunks)
+
+	// The code chunk is over its 8-token limit; the docs chunk is within the text model's 8192
+	if len(result) != 4 || result[3].ID != "doc" {
+		t.Fatalf("expected the code chunk split into 3 parts and the docs chunk kept, got %+v", result)
+	}
+	if result[1].LineStart != 2 || result[1].Code != "three four" {
+		t.Errorf("unexpected second part: %+v", result[1])
+	}
+}
+
+// persistentStore is a memoryStore that keeps the metadata it is given
+type persistentStore struct {
+	memoryStore
+	metadata *storage.IndexMetadata
+}
+
+func (p *persistentStore) LoadMetadata() (*storage.IndexMetadata, error) { return p.metadata, nil }
+func (p *persistentStore) SaveMetadata(metadata *storage.IndexMetadata) error {
+	p.metadata = metadata
+	return nil
+}
+
+type failingEmbeddingClient struct{}
+
+func (failingEmbeddingClient) Embed(context.Context, string) ([]float64, error) {
+	return nil, errors.New("embedding server down")
+}
+func (failingEmbeddingClient) EmbedMany(context.Context, []string) ([][]float64, error) {
+	return nil, errors.New("embedding server down")
+}
+
+func TestRunIndex_FailedEmbeddingKeepsOldChunks(t *testing.T) {
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "README.md", "# Docs\n\nOriginal text.\n")
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Errorf("first index failed: %v", err)
+		}
+	})
+	indexed := len(store.rows)
+	if indexed == 0 {
+		t.Fatal("expected the first run to store chunks")
+	}
+
+	path := filepath.Join(workDir, "README.md")
+	writeTestFile(t, workDir, "README.md", "# Docs\n\nChanged text.\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	prevDocs := newDocsEmbeddingClient
+	newDocsEmbeddingClient = func() embeddings.Client { return failingEmbeddingClient{} }
+	t.Cleanup(func() { newDocsEmbeddingClient = prevDocs })
+
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err == nil {
+			t.Error("expected the second index to fail")
+		}
+	})
+	if len(store.rows) != indexed {
+		t.Errorf("expected the %d previously stored chunks to be kept, got %d", indexed, len(store.rows))
+	}
+}
+
+func TestRunIndex_RemovesDeletedFilesChunks(t *testing.T) {
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "a.md", "# A\n\nFirst file.\n")
+	writeTestFile(t, workDir, "b.md", "# B\n\nSecond file.\n")
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("first index failed: %v", err)
+		}
+	})
+
+	// Nothing else changed, so the second run has no files to index
+	deleted := filepath.Join(workDir, "b.md")
+	if err := os.Remove(deleted); err != nil {
+		t.Fatal(err)
+	}
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("second index failed: %v", err)
+		}
+	})
+
+	if len(store.rows) == 0 {
+		t.Fatal("expected a.md's chunks to be kept")
+	}
+	for _, row := range store.rows {
+		if row["file_path"] == deleted {
+			t.Errorf("expected the deleted file's chunks removed, got %v", row)
+		}
+	}
+	if _, ok := store.metadata.FileModTimes[deleted]; ok {
+		t.Error("expected the deleted file dropped from the metadata")
+	}
+}
+
+// interruptingEmbeddingClient embeds texts until it sees one containing
+// marker, which it holds briefly so other requests finish, then interrupts the run
+type interruptingEmbeddingClient struct {
+	fakeEmbeddingClient
+	marker string
+	cancel context.CancelFunc
+}
+
+func (c *interruptingEmbeddingClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	for _, text := range texts {
+		if strings.Contains(text, c.marker) {
+			time.Sleep(100 * time.Millisecond)
+			c.cancel()
+			return nil, ctx.Err()
+		}
+	}
+	return c.fakeEmbeddingClient.EmbedMany(ctx, texts)
+}
+
+func TestRunIndex_InterruptSavesCompletedFiles(t *testing.T) {
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "a.go", "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeTestFile(t, workDir, "b.go", "package main\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n")
+	writeTestFile(t, workDir, "c.go", "package main\n\nfunc SlowToEmbed(a, b int) int {\n\treturn a * b\n}\n")
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	// One chunk per request, so the other files' requests finish first
+	prevBatchSize := embeddingBatchSize
+	embeddingBatchSize = 1
+	t.Cleanup(func() { embeddingBatchSize = prevBatchSize })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prevCode := newCodeEmbeddingClient
+	newCodeEmbeddingClient = func() embeddings.Client {
+		return &interruptingEmbeddingClient{fakeEmbeddingClient: fakeEmbeddingClient{offset: 1}, marker: "SlowToEmbed", cancel: cancel}
+	}
+	t.Cleanup(func() { newCodeEmbeddingClient = prevCode })
+
+	var err error
+	output := captureStdout(t, func() { _, err = runIndex(ctx, workDir) })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the run to fail as cancelled, got %v", err)
+	}
+	if !strings.Contains(output, "run 'code-scout index' again") {
+		t.Errorf("expected a hint to resume, got:\n%s", output)
+	}
+	saved := func(name string) bool {
+		_, ok := store.metadata.FileModTimes[filepath.Join(workDir, name)]
+		return ok
+	}
+	if !saved("a.go") || !saved("b.go") || saved("c.go") {
+		t.Errorf("expected only the embedded files in metadata, got %v", store.metadata.FileModTimes)
+	}
+	for _, row := range store.rows {
+		if strings.HasSuffix(row["file_path"].(string), "c.go") {
+			t.Error("expected no chunks stored for the file left unembedded")
+		}
+	}
+
+	newCodeEmbeddingClient = prevCode
+	captureStdout(t, func() { _, err = runIndex(context.Background(), workDir) })
+	if err != nil {
+		t.Fatalf("resumed index failed: %v", err)
+	}
+	if !saved("c.go") {
+		t.Error("expected the next run to index the remaining file")
+	}
+}
+
+// markerFailingEmbeddingClient fails any request with a text containing marker
+type markerFailingEmbeddingClient struct {
+	fakeEmbeddingClient
+	marker string
+}
+
+func (c *markerFailingEmbeddingClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	for _, text := range texts {
+		if strings.Contains(text, c.marker) {
+			return nil, errors.New("embedding server down")
+		}
+	}
+	return c.fakeEmbeddingClient.EmbedMany(ctx, texts)
+}
+
+func TestRunIndex_StoresEachBatch(t *testing.T) {
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "a.go", "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeTestFile(t, workDir, "b.go", "package main\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n")
+	writeTestFile(t, workDir, "c.go", "package main\n\nfunc Broken(a, b int) int {\n\treturn a * b\n}\n")
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	// Each file makes a batch of its own
+	prevBatchChunks := storeBatchChunks
+	storeBatchChunks = 1
+	t.Cleanup(func() { storeBatchChunks = prevBatchChunks })
+	prevCode := newCodeEmbeddingClient
+	newCodeEmbeddingClient = func() embeddings.Client {
+		return &markerFailingEmbeddingClient{fakeEmbeddingClient: fakeEmbeddingClient{offset: 1}, marker: "Broken"}
+	}
+	t.Cleanup(func() { newCodeEmbeddingClient = prevCode })
+
+	var err error
+	captureStdout(t, func() { _, err = runIndex(context.Background(), workDir) })
+	if err == nil {
+		t.Fatal("expected the run to fail on the last batch")
+	}
+	saved := func(name string) bool {
+		_, ok := store.metadata.FileModTimes[filepath.Join(workDir, name)]
+		return ok
+	}
+	if !saved("a.go") || !saved("b.go") || saved("c.go") {
+		t.Errorf("expected the batches before the failure in metadata, got %v", store.metadata.FileModTimes)
+	}
+	stored := make(map[string]bool)
+	for _, row := range store.rows {
+		stored[filepath.Base(row["file_path"].(string))] = true
+	}
+	if !stored["a.go"] || !stored["b.go"] || stored["c.go"] {
+		t.Errorf("expected chunks stored for the batches before the failure, got %v", stored)
+	}
+}
+
+// textIndexingStore only finds, by full-text search, the rows stored when
+// the full-text index was last built
+type textIndexingStore struct {
+	persistentStore
+	textIndexed []map[string]interface{}
+}
+
+func (s *textIndexingStore) CreateTextIndex() error {
+	s.textIndexed = append([]map[string]interface{}(nil), s.rows...)
+	return nil
+}
+
+func (s *textIndexingStore) FullTextSearch(query string, limit int, embeddingType string, filter storage.SearchFilter) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+	for _, row := range s.textIndexed {
+		if strings.Contains(row["code"].(string), query) && len(results) < limit {
+			results = append(results, row)
+		}
+	}
+	return results, nil
+}
+
+func TestRunIndex_FailedBatchKeepsEarlierBatchesSearchable(t *testing.T) {
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "a.go", "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeTestFile(t, workDir, "b.go", "package main\n\nfunc Broken(a, b int) int {\n\treturn a * b\n}\n")
+
+	store := &textIndexingStore{persistentStore: persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	prevBatchChunks := storeBatchChunks
+	storeBatchChunks = 1
+	t.Cleanup(func() { storeBatchChunks = prevBatchChunks })
+	prevCode := newCodeEmbeddingClient
+	newCodeEmbeddingClient = func() embeddings.Client {
+		return &markerFailingEmbeddingClient{fakeEmbeddingClient: fakeEmbeddingClient{offset: 1}, marker: "Broken"}
+	}
+	t.Cleanup(func() { newCodeEmbeddingClient = prevCode })
+
+	var err error
+	output := captureStdout(t, func() { _, err = runIndex(context.Background(), workDir) })
+	if err == nil || !strings.Contains(err.Error(), "embedding server down") {
+		t.Fatalf("expected the second batch's embedding error, got %v", err)
+	}
+	if !strings.Contains(output, "run 'code-scout index' again") {
+		t.Errorf("expected a hint to resume, got:\n%s", output)
+	}
+	results, _ := store.FullTextSearch("Add", 10, "", storage.SearchFilter{})
+	if len(results) == 0 {
+		t.Error("expected the first batch to be found by full-text search")
+	}
+	if _, ok := store.metadata.FileModTimes[filepath.Join(workDir, "a.go")]; !ok {
+		t.Errorf("expected the first batch in metadata, got %v", store.metadata.FileModTimes)
+	}
+	if _, ok := store.metadata.FileModTimes[filepath.Join(workDir, "b.go")]; ok {
+		t.Error("expected the failed batch left out of metadata")
+	}
+	if store.metadata.LastIndexTime.IsZero() {
+		t.Error("expected the last index time to be recorded")
+	}
+}
+
+func TestRunIndex_SinceOnlyIndexesChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "a.md", "# A\n\nFirst file.\n")
+	writeTestFile(t, workDir, "b.md", "# B\n\nSecond file.\n")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-q", "--allow-empty", "-m", "base"},
+		{"add", "."},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-q", "-m", "files"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = workDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("base index failed: %v", err)
+		}
+	})
+
+	// Like a fresh checkout: both files look modified, but only b.md changed since HEAD
+	later := time.Now().Add(time.Minute)
+	writeTestFile(t, workDir, "b.md", "# B\n\nChanged file.\n")
+	for _, name := range []string{"a.md", "b.md"} {
+		if err := os.Chtimes(filepath.Join(workDir, name), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexSince = "HEAD"
+	t.Cleanup(func() { indexSince = "" })
+
+	out := captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("index --since failed: %v", err)
+		}
+	})
+	if !strings.Contains(out, "b.md") || strings.Contains(out, "a.md") {
+		t.Errorf("expected only b.md to be re-indexed, got:\n%s", out)
+	}
+	if got := store.metadata.FileModTimes[filepath.Join(workDir, "a.md")]; got.Equal(later) {
+		t.Error("expected a.md's recorded modification time to be left as it was")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"os"
@@ -16,15 +17,15 @@ type fakeEmbeddingClient struct {
 	offset float64
 }
 
-func (f *fakeEmbeddingClient) Embed(text string) ([]float64, error) {
-	vecs, err := f.EmbedMany([]string{text})
+func (f *fakeEmbeddingClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	vecs, err := f.EmbedMany(ctx, []string{text})
 	if err != nil {
 		return nil, err
 	}
 	return vecs[0], nil
 }
 
-func (f *fakeEmbeddingClient) EmbedMany(texts []string) ([][]float64, error) {
+func (f *fakeEmbeddingClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
 	vectors := make([][]float64, len(texts))
 	for i, text := range texts {
 		vectors[i] = fakeVector(text, f.offset)
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// indexLockFile is the lock file, in the project's .code-scout directory, held
+// by every command that writes the index
+const indexLockFile = storage.IndexLockFile
+
+// lockPollInterval is how often a waiting command checks whether the lock was released
+var lockPollInterval = time.Second
+
+// lockWriteGrace is how long after its creation an unreadable lock file is
+// taken to be still being written by its holder, rather than corrupt
+var lockWriteGrace = 10 * time.Second
+
+// indexLock is the content of the lock file, identifying the run holding it
+type indexLock struct {
+	PID     int       `json:"pid"`
+	Command string    `json:"command,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+// same reports whether two lock files were written by the same acquisition
+func (l indexLock) same(other indexLock) bool {
+	return l.PID == other.PID && l.Command == other.Command && l.Started.Equal(other.Started)
+}
+
+// describe names the holder for messages
+func (l indexLock) describe() string {
+	command := l.Command
+	if command == "" {
+		command = "index"
+	}
+	return fmt.Sprintf("code-scout %s (pid %d, started %s)", command, l.PID, l.Started.Format(time.RFC3339))
+}
+
+// acquireIndexLock takes the index lock for the project in dir on behalf of
+// command, so two commands never write metadata.json and the tables at once.
+// If a live process holds the lock, it fails unless wait is set, in which case
+// it waits until the lock is released or ctx is cancelled. A lock left by a
+// process that no longer exists is taken over. The returned function releases
+// the lock, if this process still holds it.
+func acquireIndexLock(ctx context.Context, dir, command string, wait bool) (func(), error) {
+	lockDir := filepath.Join(dir, storage.DefaultDBDir)
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	path := filepath.Join(lockDir, indexLockFile)
+	own := indexLock{PID: os.Getpid(), Command: command, Started: time.Now().Round(0)}
+
+	waiting := false
+	for {
+		err := createIndexLock(path, own)
+		if err == nil {
+			return func() { releaseIndexLock(path, own) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create index lock: %w", err)
+		}
+
+		holder, err := readIndexLock(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // Released between our create and read
+		}
+		if err != nil {
+			// Lock files are linked into place whole, but one written by an
+			// older build may be caught mid-write, so only an old one is corrupt
+			if info, statErr := os.Stat(path); statErr != nil || time.Since(info.ModTime()) > lockWriteGrace {
+				return nil, fmt.Errorf("failed to read index lock %s (remove it if no code-scout command is running): %w", path, err)
+			}
+			holder = indexLock{}
+		} else if !processAlive(holder.PID) {
+			fmt.Printf("Removing stale index lock left by process %d\n", holder.PID)
+			if err := removeStaleIndexLock(path, holder); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !wait {
+			if holder.PID == 0 {
+				return nil, errors.New("another code-scout command is taking the index lock; use --wait to wait for it")
+			}
+			return nil, fmt.Errorf("another %s is in progress; use --wait to wait for it", holder.describe())
+		}
+		if !waiting {
+			if holder.PID == 0 {
+				fmt.Println("Waiting for another code-scout command to finish...")
+			} else {
+				fmt.Printf("Waiting for %s to finish...\n", holder.describe())
+			}
+			waiting = true
+		}
+		if err := sleepOrDone(ctx, lockPollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// createIndexLock writes lock to a temporary file and links it into place, so
+// the lock file never exists without its content. It fails with os.ErrExist
+// if the lock is already held.
+func createIndexLock(path string, lock indexLock) error {
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, lock.PID)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := os.Link(tmp, path); err != nil {
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) && errors.Is(linkErr.Err, os.ErrExist) {
+			return os.ErrExist
+		}
+		return err
+	}
+	return nil
+}
+
+// readIndexLock reads the lock file's holder
+func readIndexLock(path string) (indexLock, error) {
+	var holder indexLock
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return holder, err
+	}
+	err = json.Unmarshal(data, &holder)
+	return holder, err
+}
+
+// removeStaleIndexLock removes a lock file left by the dead holder. The file
+// is first moved aside and checked, so a lock another process took over in
+// the meantime is put back rather than deleted.
+func removeStaleIndexLock(path string, holder indexLock) error {
+	aside := fmt.Sprintf("%s.stale-%d", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // Another process already removed it
+		}
+		return fmt.Errorf("failed to remove stale index lock: %w", err)
+	}
+	moved, err := readIndexLock(aside)
+	if err == nil && moved.same(holder) {
+		os.Remove(aside)
+		return nil
+	}
+	// Not the lock we checked: put it back for its holder
+	linkErr := os.Link(aside, path)
+	os.Remove(aside)
+	if linkErr != nil {
+		return errors.New("the index lock changed hands while removing a stale lock; try again")
+	}
+	return nil
+}
+
+// releaseIndexLock removes the lock file if it is still the one lock wrote,
+// so a lock that was taken over or swapped out by a restore is left alone
+func releaseIndexLock(path string, lock indexLock) {
+	if holder, err := readIndexLock(path); err == nil && holder.same(lock) {
+		os.Remove(path)
+	}
+}
+
+// sleepOrDone waits for d, returning early with ctx's error if it is cancelled
+func sleepOrDone(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestAcquireIndexLock(t *testing.T) {
+	dir := t.TempDir()
+	release, err := acquireIndexLock(context.Background(), dir, "index", false)
+	if err != nil {
+		t.Fatalf("acquireIndexLock failed: %v", err)
+	}
+
+	path := filepath.Join(dir, storage.DefaultDBDir, indexLockFile)
+	holder, err := readIndexLock(path)
+	if err != nil || holder.PID != os.Getpid() {
+		t.Fatalf("expected lock held by this process, got %+v (%v)", holder, err)
+	}
+
+	if _, err := acquireIndexLock(context.Background(), dir, "index", false); err == nil || !strings.Contains(err.Error(), "in progress") {
+		t.Fatalf("expected a second acquire to fail fast, got %v", err)
+	}
+
+	release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected release to remove the lock file, got %v", err)
+	}
+}
+
+func TestAcquireIndexLock_Stale(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, storage.DefaultDBDir, indexLockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A PID above the kernel's pid_max can't belong to a running process
+	data, _ := json.Marshal(indexLock{PID: 1 << 30, Started: time.Now()})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var release func()
+	captureStdout(t, func() {
+		var err error
+		release, err = acquireIndexLock(context.Background(), dir, "index", false)
+		if err != nil {
+			t.Errorf("expected the stale lock to be taken over, got %v", err)
+		}
+	})
+	if release != nil {
+		release()
+	}
+}
+
+func TestAcquireIndexLock_Wait(t *testing.T) {
+	prevInterval := lockPollInterval
+	lockPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { lockPollInterval = prevInterval })
+
+	dir := t.TempDir()
+	release, err := acquireIndexLock(context.Background(), dir, "index", false)
+	if err != nil {
+		t.Fatalf("acquireIndexLock failed: %v", err)
+	}
+	time.AfterFunc(50*time.Millisecond, release)
+
+	captureStdout(t, func() {
+		second, err := acquireIndexLock(context.Background(), dir, "index", true)
+		if err != nil {
+			t.Errorf("expected waiting acquire to succeed once released, got %v", err)
+			return
+		}
+		second()
+	})
+
+	release, err = acquireIndexLock(context.Background(), dir, "index", false)
+	if err != nil {
+		t.Fatalf("acquireIndexLock failed: %v", err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	captureStdout(t, func() {
+		if _, err := acquireIndexLock(ctx, dir, "index", true); err != context.DeadlineExceeded {
+			t.Errorf("expected waiting to stop when ctx is done, got %v", err)
+		}
+	})
+}
+
+func TestAcquireIndexLock_PartialFileIsHeld(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, storage.DefaultDBDir, indexLockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A holder caught between creating the file and writing it
+	if err := os.WriteFile(path, []byte(`{"pid":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := acquireIndexLock(context.Background(), dir, "gc", false); err == nil || !strings.Contains(err.Error(), "--wait") {
+		t.Fatalf("expected a young unreadable lock to count as held, got %v", err)
+	}
+
+	old := time.Now().Add(-2 * lockWriteGrace)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireIndexLock(context.Background(), dir, "gc", false); err == nil || !strings.Contains(err.Error(), "failed to read index lock") {
+		t.Fatalf("expected an old unreadable lock to be reported, got %v", err)
+	}
+}
+
+func TestAcquireIndexLock_ReleaseKeepsOtherHoldersLock(t *testing.T) {
+	dir := t.TempDir()
+	release, err := acquireIndexLock(context.Background(), dir, "index", false)
+	if err != nil {
+		t.Fatalf("acquireIndexLock failed: %v", err)
+	}
+
+	// Another process took the lock over, e.g. after a restore swapped ours out
+	path := filepath.Join(dir, storage.DefaultDBDir, indexLockFile)
+	other := indexLock{PID: os.Getpid(), Command: "optimize", Started: time.Now().Add(time.Minute)}
+	data, _ := json.Marshal(other)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+	if holder, err := readIndexLock(path); err != nil || !holder.same(other) {
+		t.Errorf("expected release to leave the other holder's lock, got %+v (%v)", holder, err)
+	}
+}
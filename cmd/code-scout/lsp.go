@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+var lspLimit int
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server providing semantic workspace symbol search",
+	Long: `Run a minimal language server over stdio. It answers workspace/symbol
+requests with semantic search results from the index, so any editor with LSP
+support can search the codebase by meaning through its "go to symbol in
+workspace" picker.
+
+The workspace root comes from the client's initialize request, falling back
+to the current directory. The index must already exist; run 'code-scout index'
+first.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// stdout carries the protocol, so send anything else printed to stderr
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = out }()
+
+		return runLSP(cmd.Context(), os.Stdin, out, cwd)
+	},
+}
+
+// lspServer answers LSP requests for one workspace
+type lspServer struct {
+	dir string
+	api *apiServer // nil until initialize
+}
+
+// runLSP serves LSP requests read from in until the client sends exit or closes the stream
+func runLSP(ctx context.Context, in io.Reader, out io.Writer, dir string) error {
+	server := &lspServer{dir: dir}
+	reader := bufio.NewReader(in)
+
+	for {
+		body, err := lsp.ReadMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		var msg lsp.Message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			response := lsp.Response{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &lsp.ResponseError{Code: lsp.CodeParseError, Message: err.Error()},
+			}
+			if err := lsp.WriteMessage(out, response); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := server.handle(ctx, &msg)
+		if msg.IsNotification() {
+			continue
+		}
+		if err := lsp.WriteMessage(out, lsp.Response{JSONRPC: "2.0", ID: msg.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or notification to its handler
+func (s *lspServer) handle(ctx context.Context, msg *lsp.Message) (interface{}, *lsp.ResponseError) {
+	switch msg.Method {
+	case "initialize":
+		var params lsp.InitializeParams
+		if len(msg.Params) > 0 {
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: err.Error()}
+			}
+		}
+		if dir := params.RootDir(); dir != "" {
+			s.dir = dir
+		}
+		s.api = newAPIServer(s.dir)
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{"workspaceSymbolProvider": true},
+			"serverInfo":   map[string]string{"name": "code-scout"},
+		}, nil
+
+	case "shutdown":
+		return lsp.NullResult, nil
+
+	case "workspace/symbol":
+		if s.api == nil {
+			return nil, &lsp.ResponseError{Code: lsp.CodeServerNotInitialized, Message: "server not initialized"}
+		}
+		var params lsp.WorkspaceSymbolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: err.Error()}
+		}
+		return s.workspaceSymbol(ctx, params.Query)
+
+	default:
+		// Notifications we don't handle (initialized, didOpen, ...) are ignored
+		return nil, &lsp.ResponseError{Code: lsp.CodeMethodNotFound, Message: "method not supported: " + msg.Method}
+	}
+}
+
+// workspaceSymbol runs a semantic search and returns the matches as symbols
+func (s *lspServer) workspaceSymbol(ctx context.Context, query string) (interface{}, *lsp.ResponseError) {
+	symbols := []lsp.SymbolInformation{}
+	// Clients query with an empty string when the picker opens; a semantic
+	// search for nothing isn't useful
+	if strings.TrimSpace(query) == "" {
+		return symbols, nil
+	}
+
+	response, err := s.api.search(ctx, searchOptions{Query: query, Limit: lspLimit}, "")
+	if err != nil {
+		code := lsp.CodeInternalError
+		if errors.Is(err, errInvalidRequest) {
+			code = lsp.CodeInvalidParams
+		}
+		return nil, &lsp.ResponseError{Code: code, Message: err.Error()}
+	}
+
+	for i := range response.Results {
+		symbols = append(symbols, resultToSymbol(&response.Results[i]))
+	}
+	return symbols, nil
+}
+
+// resultToSymbol converts a search result to an LSP symbol spanning the chunk's lines
+func resultToSymbol(result *SearchResult) lsp.SymbolInformation {
+	name := result.Name
+	if name == "" {
+		name = result.Heading
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s:%d", filepath.Base(result.FilePath), result.LineStart)
+	}
+
+	container := result.Metadata["receiver"]
+	if container == "" {
+		container = result.ParentHeading
+	}
+	if container == "" {
+		container = result.Metadata["package"]
+	}
+
+	return lsp.SymbolInformation{
+		Name: name,
+		Kind: symbolKind(result.ChunkType),
+		Location: lsp.Location{
+			URI: lsp.PathToURI(result.FilePath),
+			Range: lsp.Range{
+				Start: lsp.Position{Line: max(result.LineStart-1, 0)},
+				// Line numbers are 1-indexed and inclusive, so LineEnd is the
+				// zero-based line after the chunk
+				End: lsp.Position{Line: max(result.LineEnd, 0)},
+			},
+		},
+		ContainerName: container,
+	}
+}
+
+// symbolKind maps a chunk type to the closest LSP symbol kind
+func symbolKind(chunkType string) lsp.SymbolKind {
+	switch chunkType {
+	case "function":
+		return lsp.SymbolKindFunction
+	case "method":
+		return lsp.SymbolKindMethod
+	case "struct":
+		return lsp.SymbolKindStruct
+	case "interface":
+		return lsp.SymbolKindInterface
+	case "class", "impl":
+		return lsp.SymbolKindClass
+	case "enum":
+		return lsp.SymbolKindEnum
+	case "const":
+		return lsp.SymbolKindConstant
+	case "var":
+		return lsp.SymbolKindVariable
+	case "module":
+		return lsp.SymbolKindModule
+	case "section", "document":
+		return lsp.SymbolKindString
+	default:
+		return lsp.SymbolKindFile
+	}
+}
+
+func init() {
+	lspCmd.Flags().IntVar(&lspLimit, "limit", 50, "Maximum number of symbols returned per query")
+	rootCmd.AddCommand(lspCmd)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/lsp"
+)
+
+func TestRunLSP_WorkspaceSymbol(t *testing.T) {
+	newTestAPIServer(t)
+
+	var in bytes.Buffer
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"file:///repo"}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"workspace/symbol","params":{"query":"add numbers"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		if err := lsp.WriteMessage(&in, json.RawMessage(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runLSP(context.Background(), &in, &out, t.TempDir()); err != nil {
+		t.Fatalf("runLSP failed: %v", err)
+	}
+
+	var responses []map[string]json.RawMessage
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := lsp.ReadMessage(reader)
+		if err != nil {
+			break
+		}
+		var response map[string]json.RawMessage
+		if err := json.Unmarshal(body, &response); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses (notifications get none), got %d", len(responses))
+	}
+
+	var symbols []lsp.SymbolInformation
+	if err := json.Unmarshal(responses[1]["result"], &symbols); err != nil {
+		t.Fatalf("failed to parse symbols: %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].Name != "Add" || symbols[0].Location.URI != "file:///repo/main.go" {
+		t.Errorf("unexpected symbols: %+v", symbols)
+	}
+	if symbols[0].Location.Range.Start.Line != 2 || symbols[0].Location.Range.End.Line != 5 {
+		t.Errorf("unexpected range: %+v", symbols[0].Location.Range)
+	}
+
+	if _, ok := responses[2]["error"]; !ok {
+		t.Error("expected an error for an unsupported method")
+	}
+	if string(responses[3]["result"]) != "null" {
+		t.Errorf("expected null shutdown result, got %s", responses[3]["result"])
+	}
+}
+
+func TestSymbolKind(t *testing.T) {
+	tests := map[string]lsp.SymbolKind{
+		"function": lsp.SymbolKindFunction,
+		"method":   lsp.SymbolKindMethod,
+		"impl":     lsp.SymbolKindClass,
+		"section":  lsp.SymbolKindString,
+		"":         lsp.SymbolKindFile,
+	}
+	for chunkType, expected := range tests {
+		if got := symbolKind(chunkType); got != expected {
+			t.Errorf("symbolKind(%q) = %d, expected %d", chunkType, got, expected)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/jlanders/code-scout/internal/config"
 	"github.com/spf13/cobra"
@@ -33,6 +37,14 @@ code and documentation into a local vector database.`,
 		}
 
 		globalConfig = cfg
+
+		if cfg.Tracing != nil {
+			ctx, err := startTracing(cmd.Context(), cfg.Tracing, cmd.CommandPath())
+			if err != nil {
+				return err
+			}
+			cmd.SetContext(ctx)
+		}
 		return nil
 	},
 }
@@ -41,8 +53,44 @@ func main() {
 	// Add global flags
 	rootCmd.PersistentFlags().String("endpoint", "", "Embedding API endpoint (overrides config file)")
 
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the command's context, aborting in-flight embedding requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// After the first, signals are no longer caught, so a second Ctrl-C quits
+	// at once instead of waiting for index to save its progress
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	finishTracing(err)
+	if err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintln(os.Stderr, exitErr.err)
+			}
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCodeFor(err))
+	}
+}
+
+// exitCodeError makes the process exit with a specific code. Its err, if any,
+// is printed first; a nil err exits silently.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
 	}
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var optimizeCmd = &cobra.Command{
+	Use:   "optimize",
+	Short: "Compact the vector database and rebuild the vector index",
+	Long: `Repeated incremental indexing fragments the LanceDB dataset and leaves old
+versions on disk. Optimize rewrites the table into a single compact dataset,
+discards old versions, rebuilds the vector index tuned to the table's size, and
+reports reclaimed space. It holds the index lock for the whole run, so it fails
+if another command is writing the index unless --wait is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		release, err := acquireIndexLock(cmd.Context(), cwd, "optimize", waitForIndexLock)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		store, err := openLanceDBStore(cwd, "optimize")
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		fmt.Println("Optimizing vector database...")
+		result, err := store.Optimize()
+		if err != nil {
+			return fmt.Errorf("failed to optimize: %w (have you run 'code-scout index' first?)", err)
+		}
+
+		fmt.Printf("Compacted %d rows (table was at version %d)\n", result.Rows, result.VersionsBefore)
+		for _, index := range result.VectorIndexes {
+			if index.Type == storage.VectorIndexNone {
+				fmt.Printf("Skipped %s vector index (%d rows, too few to train)\n", index.EmbeddingType, index.Rows)
+				continue
+			}
+			fmt.Printf("Rebuilt %s vector index: %s\n", index.EmbeddingType, index)
+		}
+		for _, table := range result.Uncompacted {
+			fmt.Printf("Warning: %s table not compacted (%d rows): %s; delete .code-scout/ and re-index to compact it\n",
+				table.EmbeddingType, table.Rows, table.Reason)
+		}
+		if result.SizeBefore > 0 {
+			fmt.Printf("Size: %s -> %s (reclaimed %s)\n",
+				formatBytes(result.SizeBefore), formatBytes(result.SizeAfter), formatBytes(result.Reclaimed()))
+		}
+		if len(result.Uncompacted) > 0 {
+			fmt.Printf("Optimize finished with %d table(s) not compacted\n", len(result.Uncompacted))
+			return nil
+		}
+		fmt.Println("✓ Optimize complete!")
+
+		return nil
+	},
+}
+
+// formatBytes formats a byte count in human-readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < 0 {
+		return "-" + formatBytes(-n)
+	}
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	optimizeCmd.Flags().BoolVar(&waitForIndexLock, "wait", false, "Wait for a running command to release the index lock instead of failing")
+	rootCmd.AddCommand(optimizeCmd)
+}
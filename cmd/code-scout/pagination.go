@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// searchCursor is the decoded form of a --cursor token. Tokens are opaque to
+// callers: base64-encoded JSON that resumes a search at Offset.
+type searchCursor struct {
+	Offset int `json:"o"`
+	// Fingerprint identifies the query, mode, and filters the cursor was issued for
+	Fingerprint string `json:"f"`
+	// IndexTime is the index's last build time; results shift when it's rebuilt
+	IndexTime int64 `json:"t"`
+}
+
+// searchFingerprint hashes the options that determine a search's ranking, so a
+// cursor can't be replayed against a different search
+func searchFingerprint(opts searchOptions) string {
+	key := fmt.Sprintf("%s\x00%s\x00%t\x00%g\x00%g\x00%g\x00%t\x00%s\x00%s\x00%s\x00%s\x00%t\x00%t\x00%d",
+		opts.Query, opts.Mode, opts.Lexical, opts.MinScore, opts.Diversity, opts.Recency, opts.DedupFile,
+		opts.Filter.Language, opts.Filter.ChunkType, opts.Filter.Project, opts.Filter.PathPrefix,
+		opts.Filter.ExcludeTests, opts.Filter.OnlyTests,
+		len(opts.Expansions))
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// encodeCursor returns the token for the page starting at offset
+func encodeCursor(opts searchOptions, offset int, indexTime time.Time) string {
+	data, _ := json.Marshal(searchCursor{
+		Offset:      offset,
+		Fingerprint: searchFingerprint(opts),
+		IndexTime:   indexTime.UnixNano(),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor validates a token against the search it's used with and
+// returns the offset it resumes at
+func decodeCursor(token string, opts searchOptions, indexTime time.Time) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var cursor searchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if cursor.Offset < 0 {
+		return 0, fmt.Errorf("invalid cursor: negative offset")
+	}
+	if cursor.Fingerprint != searchFingerprint(opts) {
+		return 0, fmt.Errorf("cursor was issued for a different query, mode, or filters")
+	}
+	if cursor.IndexTime != indexTime.UnixNano() {
+		return 0, fmt.Errorf("cursor is stale: the index was rebuilt since it was issued; re-run the search")
+	}
+	return cursor.Offset, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/scanner"
+)
+
+// estimatedChunkTokens is the typical size of a chunk, used to estimate the
+// chunk count before files are chunked
+const estimatedChunkTokens = 256
+
+// indexPlan estimates what an index run will embed, before chunking
+type indexPlan struct {
+	files   map[string]int // Files to index by language
+	bytes   int64
+	chunks  map[string]int // Estimated chunks by embedding type ("code", "docs")
+	tokens  map[string]int // Estimated tokens by embedding type
+	renamed int
+	deleted int
+	skipped map[string]int // Skipped directories and files by rule
+}
+
+// newIndexPlan estimates the cost of indexing files. Each file is estimated
+// at one chunk per estimatedChunkTokens of its content, and at least one.
+func newIndexPlan(files []scanner.FileInfo, renamed, deleted int, skipped map[string]int) (*indexPlan, error) {
+	plan := &indexPlan{
+		files:   make(map[string]int),
+		chunks:  make(map[string]int),
+		tokens:  make(map[string]int),
+		renamed: renamed,
+		deleted: deleted,
+		skipped: skipped,
+	}
+	for _, f := range files {
+		content, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", f.Path, err)
+		}
+		embeddingType := embeddingTypeForLanguage(f.Language)
+		tokens := embeddings.CountTokens(string(content))
+
+		plan.files[f.Language]++
+		plan.bytes += int64(len(content))
+		plan.tokens[embeddingType] += tokens
+		plan.chunks[embeddingType] += max(1, (tokens+estimatedChunkTokens-1)/estimatedChunkTokens)
+	}
+	return plan, nil
+}
+
+// embeddingTypeForLanguage returns the embedding space a language's chunks
+// are embedded in: "docs" for documentation, otherwise "code"
+func embeddingTypeForLanguage(language string) string {
+	switch language {
+	case "markdown", "text", "rst":
+		return "docs"
+	default:
+		return "code"
+	}
+}
+
+// print writes the plan to stdout. Requests assume batchSize chunks per request.
+func (p *indexPlan) print(batchSize int) {
+	batchSize = max(batchSize, 1)
+
+	total := 0
+	for _, count := range p.files {
+		total += count
+	}
+	fmt.Println("Index plan:")
+	fmt.Printf("  Files:      %d (%s), %s\n", total, formatCounts(p.files), formatBytes(p.bytes))
+	for _, space := range []struct{ embeddingType, label, model string }{
+		{"code", "Code:", codeModelName()},
+		{"docs", "Docs:", docsModelName()},
+	} {
+		chunks := p.chunks[space.embeddingType]
+		if chunks == 0 {
+			continue
+		}
+		fmt.Printf("  %-11s ~%d chunks, ~%d requests, ~%d tokens (%s)\n",
+			space.label, chunks, (chunks+batchSize-1)/batchSize, p.tokens[space.embeddingType], space.model)
+	}
+	if p.renamed > 0 {
+		fmt.Printf("  Renamed:    %d file(s), not re-embedded\n", p.renamed)
+	}
+	if p.deleted > 0 {
+		fmt.Printf("  Deleted:    %d file(s), removed from the index\n", p.deleted)
+	}
+	if len(p.skipped) > 0 {
+		fmt.Printf("  Skipped:    %s\n", formatCounts(p.skipped))
+	}
+}
+
+// formatCounts formats counts by name, largest first: "3 go, 1 markdown"
+func formatCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	return strings.Join(parts, ", ")
+}
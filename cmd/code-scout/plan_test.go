@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/scanner"
+)
+
+func TestNewIndexPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) scanner.FileInfo {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return scanner.FileInfo{Path: path}
+	}
+
+	small := write("small.go", "package main")
+	small.Language = "go"
+	largeContent := strings.Repeat("word ", 600)
+	large := write("large.go", largeContent)
+	large.Language = "go"
+	readme := write("README.md", "# Title")
+	readme.Language = "markdown"
+
+	plan, err := newIndexPlan([]scanner.FileInfo{small, large, readme}, 1, 2, map[string]int{"vendor/": 1})
+	if err != nil {
+		t.Fatalf("newIndexPlan failed: %v", err)
+	}
+
+	if plan.files["go"] != 2 || plan.files["markdown"] != 1 {
+		t.Errorf("Unexpected file counts: %v", plan.files)
+	}
+	if want := int64(len("package main") + 3000 + len("# Title")); plan.bytes != want {
+		t.Errorf("Expected %d bytes, got %d", want, plan.bytes)
+	}
+	// One chunk for the small file, and one per estimatedChunkTokens of the large one
+	largeTokens := embeddings.CountTokens(largeContent)
+	wantChunks := 1 + (largeTokens+estimatedChunkTokens-1)/estimatedChunkTokens
+	if plan.chunks["code"] != wantChunks || plan.chunks["docs"] != 1 {
+		t.Errorf("Expected %d code chunks and 1 docs chunk, got %v", wantChunks, plan.chunks)
+	}
+	if plan.tokens["code"] != largeTokens+embeddings.CountTokens("package main") {
+		t.Errorf("Unexpected token estimates: %v", plan.tokens)
+	}
+	if plan.renamed != 1 || plan.deleted != 2 || plan.skipped["vendor/"] != 1 {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+
+	if _, err := newIndexPlan([]scanner.FileInfo{{Path: filepath.Join(tmpDir, "missing.go")}}, 0, 0, nil); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestFormatCounts(t *testing.T) {
+	got := formatCounts(map[string]int{"markdown": 1, "go": 3, "python": 1})
+	if want := "3 go, 1 markdown, 1 python"; got != want {
+		t.Errorf("formatCounts() = %q, want %q", got, want)
+	}
+}
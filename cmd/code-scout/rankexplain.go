@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+)
+
+// minExplainTermLength is the shortest query word reported as a matched term,
+// so words like "a" and "is" don't match every chunk
+const minExplainTermLength = 3
+
+// explainRankings attaches an Explanation to each result, re-deriving the boost
+// factors executeSearch applied at now
+func explainRankings(results []SearchResult, opts searchOptions, metadata *storage.IndexMetadata, now time.Time) {
+	for i := range results {
+		result := &results[i]
+		explanation := &searchapi.Explanation{
+			LexicalScore:      result.LexicalScore,
+			MatchedTerms:      matchedTerms(opts.Query, *result),
+			IdentifierScore:   result.IdentifierScore,
+			IdentifierMatches: result.IdentifierMatches,
+			FusedScore:        result.FusedScore,
+			FinalScore:        rankingScore(*result),
+		}
+		// Keyword and identifier matches have no vector distance unless a vector search also found them
+		if result.Score > 0 || (result.LexicalScore == 0 && result.IdentifierScore == 0) {
+			explanation.VectorSimilarity = 1 / (1 + max(result.Score, 0))
+		}
+
+		boosts := make(map[string]float64)
+		if opts.Recency > 0 {
+			boosts["recency"] = recencyBoost(metadata.FileModTimes, opts.Recency, now)(*result)
+		}
+		if len(opts.PathBoosts) > 0 {
+			boosts["path"] = pathBoost(opts.PathBoosts, opts.Root)(*result)
+		}
+		for name, factor := range boosts {
+			if factor == 1 {
+				delete(boosts, name)
+			}
+		}
+		if len(boosts) > 0 {
+			explanation.Boosts = boosts
+		}
+
+		result.Explanation = explanation
+	}
+}
+
+// matchedTerms returns the distinct query words found in a result's name or
+// code (case-insensitive), in query order
+func matchedTerms(query string, result SearchResult) []string {
+	text := strings.ToLower(result.Name + "\n" + result.Code)
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), isQuerySeparator) {
+		if len(word) < minExplainTermLength || seen[word] {
+			continue
+		}
+		seen[word] = true
+		if strings.Contains(text, word) {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// isQuerySeparator reports whether r separates words of a query
+func isQuerySeparator(r rune) bool {
+	return !(r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+}
+
+// describeExplanation renders an Explanation on one line for text output
+func describeExplanation(e *searchapi.Explanation) string {
+	var parts []string
+	if e.VectorSimilarity > 0 {
+		parts = append(parts, fmt.Sprintf("vector %.4f", e.VectorSimilarity))
+	}
+	if e.LexicalScore > 0 {
+		parts = append(parts, fmt.Sprintf("bm25 %.4f", e.LexicalScore))
+	}
+	if len(e.MatchedTerms) > 0 {
+		parts = append(parts, "terms "+strings.Join(e.MatchedTerms, ", "))
+	}
+	if e.IdentifierScore > 0 {
+		parts = append(parts, fmt.Sprintf("identifiers %s (%.4f)", strings.Join(e.IdentifierMatches, ", "), e.IdentifierScore))
+	}
+	names := make([]string, 0, len(e.Boosts))
+	for name := range e.Boosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s boost x%.3f", name, e.Boosts[name]))
+	}
+	if e.FusedScore > 0 {
+		parts = append(parts, fmt.Sprintf("rrf %.4f", e.FusedScore))
+	}
+	parts = append(parts, fmt.Sprintf("final %.4f", e.FinalScore))
+	return strings.Join(parts, " | ")
+}
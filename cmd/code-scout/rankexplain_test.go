@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestExplainRankings(t *testing.T) {
+	results := []SearchResult{
+		{FilePath: "/repo/internal/auth.go", Name: "checkToken", Code: "func checkToken(token string) bool", Score: 0.25, FusedScore: 0.8, Boost: 2},
+		{FilePath: "/repo/README.md", Code: "Tokens expire", LexicalScore: 3, IdentifierScore: 1.5, IdentifierMatches: []string{"token"}, FusedScore: 0.5},
+	}
+	opts := searchOptions{Query: "check the token", PathBoosts: map[string]float64{"internal/**": 2}, Root: "/repo"}
+
+	explainRankings(results, opts, &storage.IndexMetadata{}, time.Now())
+
+	first := results[0].Explanation
+	if first.VectorSimilarity != 0.8 || first.FinalScore != 1.6 || first.Boosts["path"] != 2 {
+		t.Errorf("unexpected explanation for the vector match: %+v", first)
+	}
+	if strings.Join(first.MatchedTerms, ",") != "check,token" {
+		t.Errorf("expected matched terms check and token, got %v", first.MatchedTerms)
+	}
+
+	second := results[1].Explanation
+	if second.VectorSimilarity != 0 || second.Boosts != nil || second.LexicalScore != 3 || second.IdentifierScore != 1.5 {
+		t.Errorf("unexpected explanation for the keyword match: %+v", second)
+	}
+
+	line := describeExplanation(first)
+	for _, part := range []string{"vector 0.8000", "terms check, token", "path boost x2.000", "rrf 0.8000", "final 1.6000"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("expected %q in %q", part, line)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	relatedDiff  string
+	relatedLimit int
+	relatedJSON  bool
+)
+
+var relatedCmd = &cobra.Command{
+	Use:   "related --diff <patch>",
+	Short: "Find existing code and docs related to a diff",
+	Long: `Embed each hunk of a unified diff (git diff output) and search the index for
+the code and documentation chunks most similar to the change, to find areas it
+affects or duplicates. Chunks covering the changed lines themselves are left
+out. Read the diff from stdin with --diff -.
+
+Paths in the diff are resolved against the current directory, so run it from
+the repository root.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if relatedDiff == "" {
+			return fmt.Errorf("--diff is required (a patch file, or - for stdin)")
+		}
+		if relatedLimit < 1 {
+			return fmt.Errorf("--limit must be at least 1, got: %d", relatedLimit)
+		}
+
+		var input io.Reader = os.Stdin
+		if relatedDiff != "-" {
+			file, err := os.Open(relatedDiff)
+			if err != nil {
+				return fmt.Errorf("failed to open diff: %w", err)
+			}
+			defer file.Close()
+			input = file
+		}
+		hunks, err := parseUnifiedDiff(input)
+		if err != nil {
+			return err
+		}
+		if len(hunks) == 0 {
+			return fmt.Errorf("no changed hunks found in %s", relatedDiff)
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		if err := store.OpenTable(); err != nil {
+			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
+		}
+
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
+			return err
+		}
+
+		results, err := findRelated(cmd.Context(), store, metadata, hunks, cwd, relatedLimit)
+		if err != nil {
+			return err
+		}
+
+		if relatedJSON {
+			output := relatedResponse{
+				SchemaVersion: searchapi.SchemaVersion,
+				Hunks:         len(hunks),
+				Returned:      len(results),
+				Results:       results,
+			}
+			jsonBytes, err := json.MarshalIndent(output, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+
+		fmt.Printf("Found %d chunks related to %d changed hunk(s)\n\n", len(results), len(hunks))
+		snippet := snippetOptions{Lines: 3, Color: colorEnabled(false)}
+		for i, result := range results {
+			fmt.Printf("%d. %s:%d-%d (%s)", i+1, displayPath(result.FilePath, cwd), result.LineStart, result.LineEnd, describeScore(result.SearchResult))
+			if result.Name != "" {
+				fmt.Printf(" %s", result.Name)
+			}
+			fmt.Println()
+			fmt.Printf("   Related to: %s\n", strings.Join(result.Hunks, ", "))
+			fmt.Printf("%s\n", renderSnippet(result.SearchResult, snippet, "   "))
+		}
+		return nil
+	},
+}
+
+// diffHunk is one hunk of a unified diff
+type diffHunk struct {
+	FilePath  string // Path in the new version, or the old one for a deleted file
+	LineStart int    // First line of the hunk in the new version
+	LineEnd   int
+	Text      string // The hunk's context, removed and added lines, without their markers
+}
+
+// location returns the hunk's "path:start-end"
+func (h diffHunk) location() string {
+	return fmt.Sprintf("%s:%d-%d", h.FilePath, h.LineStart, h.LineEnd)
+}
+
+// relatedResult is a chunk related to a diff, with the hunks it was found for
+type relatedResult struct {
+	SearchResult
+	Hunks []string `json:"hunks"` // Locations of the hunks this chunk is similar to
+}
+
+// relatedResponse is the output of related --json
+type relatedResponse struct {
+	SchemaVersion int             `json:"schema_version"`
+	Hunks         int             `json:"hunks"`
+	Returned      int             `json:"returned"`
+	Results       []relatedResult `json:"results"`
+}
+
+// parseUnifiedDiff reads the hunks of a unified diff. Hunks that only add or
+// remove blank lines carry no meaning to search for and are skipped.
+func parseUnifiedDiff(r io.Reader) ([]diffHunk, error) {
+	var hunks []diffHunk
+	var oldPath, newPath string
+	var current *diffHunk
+	var text strings.Builder
+	changed := false
+
+	flush := func() {
+		if current != nil && changed {
+			current.Text = text.String()
+			hunks = append(hunks, *current)
+		}
+		current = nil
+		text.Reset()
+		changed = false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			flush()
+			oldPath, newPath = "", ""
+		case current == nil && strings.HasPrefix(line, "--- "):
+			oldPath = diffPath(line[4:])
+		case current == nil && strings.HasPrefix(line, "+++ "):
+			newPath = diffPath(line[4:])
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			start, count, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			path := newPath
+			if path == "" {
+				path = oldPath
+			}
+			current = &diffHunk{FilePath: path, LineStart: start, LineEnd: start + max(count, 1) - 1}
+		case current != nil && line != "" && strings.ContainsRune(" +-", rune(line[0])):
+			if line[0] != ' ' && strings.TrimSpace(line[1:]) != "" {
+				changed = true
+			}
+			text.WriteString(line[1:])
+			text.WriteByte('\n')
+		case current != nil && line == "":
+			// Some tools strip the space from blank context lines
+			text.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+	flush()
+	return hunks, nil
+}
+
+// diffPath strips the a/ or b/ prefix and any trailing timestamp from a
+// ---/+++ path, returning "" for /dev/null
+func diffPath(field string) string {
+	path, _, _ := strings.Cut(field, "\t")
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// parseHunkHeader returns the new-version start line and line count of an
+// "@@ -a,b +c,d @@" hunk header
+func parseHunkHeader(line string) (start, count int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, fmt.Errorf("invalid hunk header: %q", line)
+	}
+	startText, countText, hasCount := strings.Cut(fields[2][1:], ",")
+	if start, err = strconv.Atoi(startText); err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header: %q", line)
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header: %q", line)
+		}
+	}
+	return start, count, nil
+}
+
+// findRelated searches the index with each hunk and merges the results,
+// keeping each chunk's best score and the hunks it was found for. Chunks that
+// overlap a hunk's own lines are dropped.
+func findRelated(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, hunks []diffHunk, cwd string, limit int) ([]relatedResult, error) {
+	byChunk := make(map[string]*relatedResult)
+	filter := storage.SearchFilter{Project: currentProject(cwd)}
+	for _, hunk := range hunks {
+		page, err := executeSearch(ctx, store, metadata, searchOptions{
+			Query:  hunk.Text,
+			Mode:   modeHybrid,
+			Limit:  limit,
+			Filter: filter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for %s: %w", hunk.location(), err)
+		}
+		hunkPath := filepath.Join(cwd, filepath.FromSlash(hunk.FilePath))
+		for _, result := range page.Results {
+			if result.FilePath == hunkPath && result.LineStart <= hunk.LineEnd && result.LineEnd >= hunk.LineStart {
+				continue
+			}
+			related, ok := byChunk[result.ChunkID]
+			if !ok {
+				related = &relatedResult{SearchResult: result}
+				byChunk[result.ChunkID] = related
+			} else if rankingScore(result) > rankingScore(related.SearchResult) {
+				related.SearchResult = result
+			}
+			related.Hunks = append(related.Hunks, hunk.location())
+		}
+	}
+
+	results := make([]relatedResult, 0, len(byChunk))
+	for _, related := range byChunk {
+		results = append(results, *related)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if si, sj := rankingScore(results[i].SearchResult), rankingScore(results[j].SearchResult); si != sj {
+			return si > sj
+		}
+		return results[i].ChunkID < results[j].ChunkID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func init() {
+	relatedCmd.Flags().StringVar(&relatedDiff, "diff", "", "Unified diff to find related code for (a file, or - for stdin)")
+	relatedCmd.Flags().IntVar(&relatedLimit, "limit", 10, "Maximum number of related chunks to return")
+	relatedCmd.Flags().BoolVar(&relatedJSON, "json", false, "Output results as JSON")
+	rootCmd.AddCommand(relatedCmd)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+const testDiff = `diff --git a/internal/cache.go b/internal/cache.go
+index 1111111..2222222 100644
+--- a/internal/cache.go
++++ b/internal/cache.go
+@@ -10,3 +10,4 @@ func Get(key string) string {
+ 	mu.Lock()
++	defer mu.Unlock()
+ 	return items[key]
+ }
+@@ -40,2 +41,3 @@ func Set(key, value string) {
+ 	items[key] = value
++
+ }
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-func Old() {}
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	hunks, err := parseUnifiedDiff(strings.NewReader(testDiff))
+	if err != nil {
+		t.Fatalf("parseUnifiedDiff failed: %v", err)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks (the blank-line hunk skipped), got %+v", hunks)
+	}
+	if hunks[0].location() != "internal/cache.go:10-13" {
+		t.Errorf("unexpected first hunk location %q", hunks[0].location())
+	}
+	if want := "\tmu.Lock()\n\tdefer mu.Unlock()\n\treturn items[key]\n}\n"; hunks[0].Text != want {
+		t.Errorf("expected hunk text %q, got %q", want, hunks[0].Text)
+	}
+	if hunks[1].FilePath != "old.go" || hunks[1].Text != "package old\nfunc Old() {}\n" {
+		t.Errorf("expected the deleted file's hunk under its old path, got %+v", hunks[1])
+	}
+
+	if _, err := parseUnifiedDiff(strings.NewReader("--- a/x\n+++ b/x\n@@ bad @@\n")); err == nil {
+		t.Error("expected an error for an invalid hunk header")
+	}
+}
+
+func TestFindRelated_SkipsChangedLines(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	store := &memoryStore{rows: []map[string]interface{}{
+		{"chunk_id": "edited", "file_path": "/repo/internal/cache.go", "line_start": 8, "line_end": 14, "code": "func Get(key string) string {}", "embedding_type": "code", "_distance": 0.1},
+		{"chunk_id": "similar", "file_path": "/repo/internal/store.go", "line_start": 1, "line_end": 9, "code": "func Load(key string) string {}", "embedding_type": "code", "_distance": 0.2},
+	}}
+	hunks := []diffHunk{
+		{FilePath: "internal/cache.go", LineStart: 10, LineEnd: 13, Text: "defer mu.Unlock()\n"},
+		{FilePath: "internal/cache.go", LineStart: 41, LineEnd: 43, Text: "items[key] = value\n"},
+	}
+
+	results, err := findRelated(context.Background(), store, &storage.IndexMetadata{}, hunks, "/repo", 10)
+	if err != nil {
+		t.Fatalf("findRelated failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ChunkID != "edited" || results[1].ChunkID != "similar" {
+		t.Fatalf("expected the edited chunk only for the hunk outside it, got %+v", results)
+	}
+	if got := strings.Join(results[0].Hunks, ","); got != "internal/cache.go:41-43" {
+		t.Errorf("expected the edited chunk related to the second hunk only, got %q", got)
+	}
+	if got := strings.Join(results[1].Hunks, ","); got != "internal/cache.go:10-13,internal/cache.go:41-43" {
+		t.Errorf("expected the similar chunk related to both hunks, got %q", got)
+	}
+}
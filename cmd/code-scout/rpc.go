@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/jlanders/code-scout/internal/lsp"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// JSON-RPC error codes for index errors, in the range reserved for servers
+const (
+	rpcCodeIndexUnavailable = -32000
+	rpcCodeIndexBusy        = -32001
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Serve the index over JSON-RPC on stdin/stdout for editor plugins",
+	Long: `Speak JSON-RPC 2.0 over stdio, one message per line, so an editor plugin can
+keep a single code-scout process running instead of spawning the CLI per query.
+
+Methods:
+  search   Search (params: query, mode, limit, offset, cursor, min_score,
+           diversity, dedup_file, language, chunk_type, lexical, explain,
+           no_tests, only_tests); returns the same document as 'search --json'
+  index    Run an incremental index; returns {"status", "duration_ms"}
+  status   Index freshness (same as 'status --json')
+  cancel   Cancel an in-flight request (params: {"id": <request id>})
+
+Requests run concurrently and responses may arrive out of order; match them by
+id. A cancelled request fails with code -32800. Once stdin is closed, the
+server answers the requests still running and exits.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// stdout carries the protocol, so send anything else printed to stderr
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = out }()
+
+		return runRPC(cmd.Context(), os.Stdin, out, cwd)
+	},
+}
+
+// rpcSearchParams are the params of a search request
+type rpcSearchParams struct {
+	Query     string  `json:"query"`
+	Mode      string  `json:"mode"`
+	Limit     int     `json:"limit"`
+	Offset    int     `json:"offset"`
+	Cursor    string  `json:"cursor"`
+	MinScore  float64 `json:"min_score"`
+	Diversity float64 `json:"diversity"`
+	DedupFile bool    `json:"dedup_file"`
+	Language  string  `json:"language"`
+	ChunkType string  `json:"chunk_type"`
+	Lexical   bool    `json:"lexical"`
+	Explain   bool    `json:"explain"`
+	NoTests   bool    `json:"no_tests"`
+	OnlyTests bool    `json:"only_tests"`
+}
+
+// rpcCancelParams are the params of a cancel request
+type rpcCancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// jsonRPCServer answers JSON-RPC requests for one project, each in its own goroutine
+type jsonRPCServer struct {
+	api *apiServer
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	mu       sync.Mutex
+	inFlight map[string]context.CancelFunc // By request ID
+}
+
+// runRPC serves requests read from in, one per line, until in is closed.
+// Cancelling ctx cancels every in-flight request.
+func runRPC(ctx context.Context, in io.Reader, out io.Writer, dir string) error {
+	api := newAPIServer(dir)
+	api.warm = &warmStore{}
+	defer api.warm.close()
+	server := &jsonRPCServer{api: api, out: out, inFlight: make(map[string]context.CancelFunc)}
+
+	// Requests still running when in is closed finish and are answered
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg lsp.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			if err := server.write(lsp.Response{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &lsp.ResponseError{Code: lsp.CodeParseError, Message: err.Error()},
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Cancellation is handled inline, so it isn't queued behind the request it cancels
+		if msg.Method == "cancel" {
+			result, rpcErr := server.cancel(msg.Params)
+			if !msg.IsNotification() {
+				if err := server.write(lsp.Response{JSONRPC: "2.0", ID: msg.ID, Result: result, Error: rpcErr}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		reqCtx, reqCancel := context.WithCancel(ctx)
+		if !msg.IsNotification() {
+			server.track(msg.ID, reqCancel)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer reqCancel()
+			result, rpcErr := server.handle(reqCtx, &msg)
+			if msg.IsNotification() {
+				return
+			}
+			server.untrack(msg.ID)
+			server.write(lsp.Response{JSONRPC: "2.0", ID: msg.ID, Result: result, Error: rpcErr})
+		}()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	return nil
+}
+
+// handle dispatches a request to its handler
+func (s *jsonRPCServer) handle(ctx context.Context, msg *lsp.Message) (interface{}, *lsp.ResponseError) {
+	switch msg.Method {
+	case "search":
+		var params rpcSearchParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: err.Error()}
+		}
+		response, err := s.api.search(ctx, searchOptions{
+			Query:     params.Query,
+			Mode:      searchMode(params.Mode),
+			Limit:     params.Limit,
+			Offset:    params.Offset,
+			MinScore:  params.MinScore,
+			Diversity: params.Diversity,
+			DedupFile: params.DedupFile,
+			Lexical:   params.Lexical,
+			Explain:   params.Explain,
+			Filter: storage.SearchFilter{
+				Language:     params.Language,
+				ChunkType:    params.ChunkType,
+				ExcludeTests: params.NoTests,
+				OnlyTests:    params.OnlyTests,
+			},
+		}, params.Cursor)
+		if err != nil {
+			return nil, jsonRPCError(ctx, err)
+		}
+		return response, nil
+
+	case "index":
+		elapsed, err := s.api.index(ctx, nil)
+		if err != nil {
+			return nil, jsonRPCError(ctx, err)
+		}
+		return map[string]interface{}{"status": "ok", "duration_ms": elapsed.Milliseconds()}, nil
+
+	case "status":
+		state, err := s.api.status()
+		if err != nil {
+			return nil, jsonRPCError(ctx, err)
+		}
+		return state, nil
+
+	default:
+		return nil, &lsp.ResponseError{Code: lsp.CodeMethodNotFound, Message: "method not supported: " + msg.Method}
+	}
+}
+
+// cancel cancels the in-flight request named in params, reporting whether it was found
+func (s *jsonRPCServer) cancel(raw json.RawMessage) (interface{}, *lsp.ResponseError) {
+	var params rpcCancelParams
+	if err := json.Unmarshal(raw, &params); err != nil || len(params.ID) == 0 {
+		return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: "cancel requires the id of the request to cancel"}
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.inFlight[string(params.ID)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return map[string]bool{"cancelled": ok}, nil
+}
+
+// track records a request's cancel function by its ID
+func (s *jsonRPCServer) track(id json.RawMessage, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight[string(id)] = cancel
+}
+
+// untrack forgets a finished request
+func (s *jsonRPCServer) untrack(id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, string(id))
+}
+
+// write sends a response as a single line
+func (s *jsonRPCServer) write(response lsp.Response) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
+
+// jsonRPCError maps an apiServer error to a JSON-RPC error
+func jsonRPCError(ctx context.Context, err error) *lsp.ResponseError {
+	code := lsp.CodeInternalError
+	switch {
+	case errors.Is(err, errInvalidRequest):
+		code = lsp.CodeInvalidParams
+	case errors.Is(err, errIndexBusy):
+		code = rpcCodeIndexBusy
+	case errors.Is(err, errIndexUnavailable):
+		code = rpcCodeIndexUnavailable
+	case ctx.Err() != nil:
+		code = lsp.CodeRequestCancelled
+	}
+	return &lsp.ResponseError{Code: code, Message: err.Error()}
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/lsp"
+)
+
+// rpcReply is a decoded JSON-RPC response
+type rpcReply struct {
+	ID     json.RawMessage    `json:"id"`
+	Result json.RawMessage    `json:"result"`
+	Error  *lsp.ResponseError `json:"error"`
+}
+
+// blockingEmbeddingClient waits until its request is cancelled
+type blockingEmbeddingClient struct {
+	started chan struct{}
+}
+
+func (c *blockingEmbeddingClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	close(c.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *blockingEmbeddingClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunRPC(t *testing.T) {
+	api := newTestAPIServer(t)
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"search","params":{"query":"add","mode":"code"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"search","params":{"query":""}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"bogus"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":4,"method":"cancel","params":{"id":99}}`,
+	}, "\n")
+	var out strings.Builder
+	if err := runRPC(context.Background(), strings.NewReader(input), &out, api.dir); err != nil {
+		t.Fatalf("runRPC failed: %v", err)
+	}
+
+	replies := make(map[string]rpcReply)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var reply rpcReply
+		if err := json.Unmarshal([]byte(line), &reply); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		replies[string(reply.ID)] = reply
+	}
+	if len(replies) != 5 {
+		t.Fatalf("expected 5 responses, got %d:\n%s", len(replies), out.String())
+	}
+
+	var search struct {
+		Results []SearchResult `json:"results"`
+	}
+	if err := json.Unmarshal(replies["1"].Result, &search); err != nil || len(search.Results) != 1 || search.Results[0].Name != "Add" {
+		t.Errorf("unexpected search result: %s (%v)", replies["1"].Result, replies["1"].Error)
+	}
+	if reply := replies["2"]; reply.Error == nil || reply.Error.Code != lsp.CodeInvalidParams {
+		t.Errorf("expected invalid params for an empty query, got %+v", reply.Error)
+	}
+	if reply := replies["3"]; reply.Error == nil || reply.Error.Code != lsp.CodeMethodNotFound {
+		t.Errorf("expected method not found, got %+v", reply.Error)
+	}
+	if reply := replies["null"]; reply.Error == nil || reply.Error.Code != lsp.CodeParseError {
+		t.Errorf("expected a parse error, got %+v", reply.Error)
+	}
+	if reply := replies["4"]; string(reply.Result) != `{"cancelled":false}` {
+		t.Errorf("expected cancelling an unknown request to report false, got %s", reply.Result)
+	}
+}
+
+func TestRunRPC_Cancel(t *testing.T) {
+	api := newTestAPIServer(t)
+	client := &blockingEmbeddingClient{started: make(chan struct{})}
+	newCodeEmbeddingClient = func() embeddings.Client { return client }
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- runRPC(context.Background(), inReader, outWriter, api.dir) }()
+
+	io.WriteString(inWriter, `{"jsonrpc":"2.0","id":"slow","method":"search","params":{"query":"add","mode":"code"}}`+"\n")
+	<-client.started
+	io.WriteString(inWriter, `{"jsonrpc":"2.0","id":2,"method":"cancel","params":{"id":"slow"}}`+"\n")
+
+	lines := bufio.NewScanner(outReader)
+	replies := make(map[string]rpcReply)
+	for len(replies) < 2 && lines.Scan() {
+		var reply rpcReply
+		if err := json.Unmarshal(lines.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		replies[string(reply.ID)] = reply
+	}
+	inWriter.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("runRPC failed: %v", err)
+	}
+
+	if string(replies["2"].Result) != `{"cancelled":true}` {
+		t.Errorf("expected the cancel to find the request, got %s", replies["2"].Result)
+	}
+	if reply := replies[`"slow"`]; reply.Error == nil || reply.Error.Code != lsp.CodeRequestCancelled {
+		t.Errorf("expected the search to fail as cancelled, got %+v", reply.Error)
+	}
+}
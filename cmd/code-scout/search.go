@@ -1,26 +1,76 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/jlanders/code-scout/internal/config"
 	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/queryexpand"
 	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
 	jsonOutput bool
+	formatFlag string
 	limitFlag  int
+	offsetFlag int
+	minScore   float64
+	diversity  float64
+	recency    float64
+	dedupFile  bool
+	groupBy    string
+	noColor    bool
+	cursorFlag string
+	timingFlag bool
 	codeMode   bool
 	docsMode   bool
 	hybridMode bool
+	lexical    bool
+	explain    bool
+	compare    bool
+	like       string
+	expand     bool
+	expansions int
+
+	snippetLines int
+	fullChunks   bool
+
+	languageFilter  string
+	chunkTypeFilter string
+	noTests         bool
+	onlyTests       bool
+	inDir           string
+
+	projectFilter string
+	allProjects   bool
 )
 
+// rrfK dampens the contribution of top ranks in reciprocal rank fusion
+const rrfK = 60
+
 type searchMode string
 
+// Output formats for search results
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatGrep = "grep"
+)
+
+// groupByFile nests results under their file in search output
+const groupByFile = "file"
+
 const (
 	modeCode   searchMode = "code"
 	modeDocs   searchMode = "docs"
@@ -28,18 +78,55 @@ const (
 )
 
 var searchCmd = &cobra.Command{
-	Use:   "search [query]",
+	Use:   "search [query | -]",
 	Short: "Search the codebase semantically",
 	Long: `Search the indexed codebase using semantic similarity.
-Returns relevant code chunks with file paths, line numbers, and relevance scores.`,
-	Args: cobra.ExactArgs(1),
+Returns relevant code chunks with file paths, line numbers, and relevance scores.
+
+Pass - as the query to read it from stdin, e.g. for multi-line queries:
+  echo "where do we retry failed uploads" | code-scout search -
+
+With --like, search for code similar to a snippet read from a file, or from
+stdin with --like -. It searches code embeddings unless --docs or --hybrid is given.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		query := args[0]
+		query, err := resolveQuery(args, like, os.Stdin)
+		if err != nil {
+			return err
+		}
 
 		mode, err := resolveSearchMode()
 		if err != nil {
 			return err
 		}
+		if like != "" && !docsMode && !hybridMode {
+			mode = modeCode
+		}
+		format, err := resolveOutputFormat()
+		if err != nil {
+			return err
+		}
+		if expand && expansions < 1 {
+			return fmt.Errorf("--expansions must be at least 1, got: %d", expansions)
+		}
+		if snippetLines < 1 {
+			return fmt.Errorf("--snippet-lines must be at least 1, got: %d", snippetLines)
+		}
+		switch groupBy {
+		case "":
+		case groupByFile:
+			if format == formatGrep {
+				return fmt.Errorf("--group-by is not supported with --format grep")
+			}
+		default:
+			return fmt.Errorf("unsupported --group-by %q (expected file)", groupBy)
+		}
+		if compare && (format == formatGrep || groupBy != "") {
+			return fmt.Errorf("--compare is not supported with --format grep or --group-by")
+		}
+		if compare && timingFlag {
+			return fmt.Errorf("--timing is not supported with --compare")
+		}
 
 		// Get current working directory
 		cwd, err := os.Getwd()
@@ -48,7 +135,7 @@ Returns relevant code chunks with file paths, line numbers, and relevance scores
 		}
 
 		// Open existing LanceDB store
-		store, err := storage.NewLanceDBStore(cwd)
+		store, scopeProject, err := openSearchStore(cwd)
 		if err != nil {
 			return fmt.Errorf("failed to open database: %w", err)
 		}
@@ -59,67 +146,192 @@ Returns relevant code chunks with file paths, line numbers, and relevance scores
 			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
 		}
 
-		var (
-			results      []SearchResult
-			totalMatches int
-		)
-
-		switch mode {
-		case modeHybrid:
-			results, totalMatches, err = runHybridSearch(store, query, limitFlag)
-		default:
-			results, totalMatches, err = runSingleModeSearch(store, query, limitFlag, mode)
-		}
+		metadata, err := store.LoadMetadata()
 		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
 			return err
 		}
+		state := loadIndexState(metadata, cwd)
+
+		opts := searchOptions{
+			Query:     query,
+			Mode:      mode,
+			Limit:     limitFlag,
+			Offset:    offsetFlag,
+			MinScore:  minScore,
+			Diversity: diversity,
+			Recency:   recency,
+			DedupFile: dedupFile,
+			Lexical:   lexical,
+			Explain:   explain,
+			Filter: storage.SearchFilter{
+				Language:     languageFilter,
+				ChunkType:    chunkTypeFilter,
+				Project:      scopeProject,
+				ExcludeTests: noTests,
+				OnlyTests:    onlyTests,
+			},
+		}
+		if globalConfig != nil {
+			if !cmd.Flags().Changed("recency-weight") {
+				opts.Recency = globalConfig.RecencyWeight
+			}
+			opts.PathBoosts, opts.Root = globalConfig.Boost, cwd
+		}
+		if inDir != "" {
+			if opts.Filter.PathPrefix, err = resolveSearchDir(cwd, inDir); err != nil {
+				return err
+			}
+		}
+		if expand {
+			opts.Expansions = expandQuery(query, expansions)
+		}
+		if cursorFlag != "" {
+			if cmd.Flags().Changed("offset") {
+				return fmt.Errorf("flags --offset and --cursor are mutually exclusive")
+			}
+			if opts.Offset, err = decodeCursor(cursorFlag, opts, metadata.LastIndexTime); err != nil {
+				return err
+			}
+		}
+		if opts.Offset < 0 {
+			return fmt.Errorf("--offset must not be negative")
+		}
+		if opts.MinScore < 0 || opts.MinScore > 1 {
+			return fmt.Errorf("--min-score must be between 0 and 1, got: %g", opts.MinScore)
+		}
+		if opts.Diversity < 0 || opts.Diversity > 1 {
+			return fmt.Errorf("--diversity must be between 0 and 1, got: %g", opts.Diversity)
+		}
+		if opts.Recency < 0 {
+			return fmt.Errorf("--recency-weight must not be negative, got: %g", opts.Recency)
+		}
 
-		if len(results) > limitFlag && limitFlag > 0 {
-			results = results[:limitFlag]
+		if compare {
+			return runCompare(cmd.Context(), store, metadata, opts, format, cwd)
 		}
 
-		// Format output
-		output := map[string]interface{}{
-			"query":         query,
-			"mode":          string(mode),
-			"total_results": totalMatches,
-			"returned":      len(results),
-			"results":       results,
+		page, err := executeSearch(cmd.Context(), store, metadata, opts)
+		if err != nil {
+			return err
+		}
+		results := page.Results
+		if inDir != "" {
+			relativizePaths(results, cwd, format == formatJSON)
 		}
+		nextCursor := page.nextCursor(opts, metadata.LastIndexTime)
 
-		if jsonOutput {
+		switch format {
+		case formatJSON:
+			output := searchapi.Response{
+				SchemaVersion: searchapi.SchemaVersion,
+				Query:         query,
+				Mode:          string(mode),
+				TotalResults:  page.TotalMatches,
+				Returned:      len(results),
+				Offset:        opts.Offset,
+				NextCursor:    nextCursor,
+				Expansions:    opts.Expansions,
+				Results:       results,
+				Index:         &state,
+			}
+			if groupBy == groupByFile {
+				output.Files = groupResultsByFile(results)
+				output.Results = []SearchResult{}
+			}
+			if timingFlag {
+				output.Timing = &page.Timing
+			}
 			jsonBytes, err := json.MarshalIndent(output, "", "  ")
 			if err != nil {
 				return fmt.Errorf("failed to marshal JSON: %w", err)
 			}
 			fmt.Println(string(jsonBytes))
-		} else {
-			fmt.Printf("Found %d unique %s results (from %d total) for: %s\n\n",
-				len(results), string(mode), totalMatches, query)
-			for i, result := range results {
-				fmt.Printf("%d. %s:%d-%d (score: %.4f)\n",
-					i+1, result.FilePath, result.LineStart, result.LineEnd, result.Score)
-				fmt.Printf("   Language: %s | Source: %s", result.Language, result.EmbeddingType)
-				if result.ChunkType != "" {
-					fmt.Printf(" | Chunk: %s", result.ChunkType)
+		case formatGrep:
+			for _, result := range results {
+				fmt.Println(formatGrepLine(result))
+			}
+			// Kept off stdout, so editors can still parse it
+			if timingFlag {
+				fmt.Fprintln(os.Stderr, describeTiming(page.Timing))
+			}
+		default:
+			fmt.Printf("Found %d unique %s results (from %d total) for: %s\n",
+				len(results), string(mode), page.TotalMatches, describeQuery(query))
+			if opts.Offset > 0 {
+				fmt.Printf("Showing results %d-%d\n", opts.Offset+1, opts.Offset+len(results))
+			}
+			if len(opts.Expansions) > 0 {
+				fmt.Printf("Expanded queries: %s\n", strings.Join(opts.Expansions, "; "))
+			}
+			if state.GitCommit != "" {
+				fmt.Printf("Index built from commit %s", describeCommit(state.GitCommit, state.GitDirty))
+				if state.Stale {
+					fmt.Printf(" (stale: HEAD is now %s)", describeCommit(state.CurrentCommit, state.CurrentDirty))
 				}
 				fmt.Println()
-				if result.Heading != "" {
-					fmt.Printf("   Heading: %s", result.Heading)
-					if result.HeadingLevel != "" {
-						fmt.Printf(" (level %s)", result.HeadingLevel)
+			}
+			fmt.Println()
+			snippet := snippetOptions{Lines: snippetLines, Full: fullChunks, Color: colorEnabled(noColor), Query: query}
+			if groupBy == groupByFile {
+				printFileGroups(results, opts.Offset, snippet)
+			} else {
+				for i, result := range results {
+					fmt.Printf("%d. %s:%d-%d (%s)\n",
+						opts.Offset+i+1, result.FilePath, result.LineStart, result.LineEnd, describeScore(result))
+					fmt.Printf("   Language: %s | Source: %s", result.Language, result.EmbeddingType)
+					if result.Project != "" {
+						fmt.Printf(" | Project: %s", result.Project)
 					}
-					if result.ParentHeading != "" {
-						fmt.Printf(" | Parents: %s", result.ParentHeading)
+					if result.ChunkType != "" {
+						fmt.Printf(" | Chunk: %s", result.ChunkType)
+					}
+					if result.Name != "" {
+						fmt.Printf(" | Name: %s", result.Name)
 					}
 					fmt.Println()
+					if result.Explanation != nil {
+						fmt.Printf("   Explain: %s\n", describeExplanation(result.Explanation))
+					}
+					if result.Signature != "" {
+						fmt.Printf("   Signature: %s\n", result.Signature)
+					}
+					if doc := summarizeDocComment(result.DocComment); doc != "" {
+						fmt.Printf("   Doc: %s\n", doc)
+					}
+					if result.Heading != "" {
+						fmt.Printf("   Heading: %s", result.Heading)
+						if result.HeadingLevel != "" {
+							fmt.Printf(" (level %s)", result.HeadingLevel)
+						}
+						if result.ParentHeading != "" {
+							fmt.Printf(" | Parents: %s", result.ParentHeading)
+						}
+						fmt.Println()
+					}
+					if anchor := result.Metadata["anchor"]; anchor != "" {
+						fmt.Printf("   Link: %s%s", result.FilePath, anchor)
+						if breadcrumb := result.Metadata["breadcrumb"]; breadcrumb != "" {
+							fmt.Printf(" (%s)", breadcrumb)
+						}
+						fmt.Println()
+					}
+					if title := result.Metadata["title"]; title != "" {
+						fmt.Printf("   Document: %s\n", title)
+					}
+					if tags := result.Metadata["tags"]; tags != "" {
+						fmt.Printf("   Tags: %s\n", tags)
+					}
+					fmt.Printf("%s\n", renderSnippet(result, snippet, "   "))
 				}
-				// Show first 100 chars of code
-				code := result.Code
-				if len(code) > 100 {
-					code = code[:100] + "..."
-				}
-				fmt.Printf("   %s\n\n", code)
+			}
+			if nextCursor != "" {
+				fmt.Printf("More results available: add --offset %d or --cursor %s\n", opts.Offset+len(results), nextCursor)
+			}
+			if timingFlag {
+				fmt.Println(describeTiming(page.Timing))
 			}
 		}
 
@@ -127,19 +339,385 @@ Returns relevant code chunks with file paths, line numbers, and relevance scores
 	},
 }
 
-type SearchResult struct {
-	ChunkID       string  `json:"chunk_id"`
-	FilePath      string  `json:"file_path"`
-	LineStart     int     `json:"line_start"`
-	LineEnd       int     `json:"line_end"`
-	Language      string  `json:"language"`
-	Code          string  `json:"code"`
-	Score         float64 `json:"score"`
-	EmbeddingType string  `json:"embedding_type"`
-	ChunkType     string  `json:"chunk_type,omitempty"`
-	Heading       string  `json:"heading,omitempty"`
-	HeadingLevel  string  `json:"heading_level,omitempty"`
-	ParentHeading string  `json:"parent_heading,omitempty"`
+// SearchResult is a single result in search and grep output
+type SearchResult = searchapi.Result
+
+// searchOptions describes a search request, from the CLI or the HTTP server
+type searchOptions struct {
+	Query    string
+	Mode     searchMode
+	Limit    int
+	Offset   int     // Number of ranked results to skip, for pagination
+	MinScore float64 // Drop results whose normalized score is below this (0 keeps everything)
+	// Diversity trades relevance for variety among results (0 keeps the ranking; see diversify)
+	Diversity float64
+	// Recency boosts results from recently modified files by up to this fraction (see recencyBoost)
+	Recency float64
+	// PathBoosts multiplies the ranking of results whose path relative to Root matches a glob
+	PathBoosts map[string]float64
+	Root       string
+	// DedupFile keeps only the best-ranked chunk from each file
+	DedupFile bool
+	Lexical   bool // Blend full-text keyword matches into the ranking
+	Explain   bool // Attach a breakdown of each result's ranking
+	Filter    storage.SearchFilter
+	// Models embed the query; nil uses the configured models
+	Models *embeddingModels
+	// Expansions are paraphrases of Query searched alongside it, with their
+	// rankings fused into the original's
+	Expansions []string
+}
+
+// searchPage is one page of ranked search results
+type searchPage struct {
+	Results      []SearchResult
+	TotalMatches int  // Raw matches fetched before deduplication
+	HasMore      bool // Results exist beyond this page
+	Timing       searchapi.Timing
+}
+
+// nextCursor returns the cursor for the following page, or "" on the last page
+func (p *searchPage) nextCursor(opts searchOptions, indexTime time.Time) string {
+	if !p.HasMore {
+		return ""
+	}
+	return encodeCursor(opts, opts.Offset+len(p.Results), indexTime)
+}
+
+// executeSearch runs a search against an open store. It returns up to opts.Limit
+// results ordered by relevance, starting opts.Offset results into the ranking.
+func executeSearch(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, opts searchOptions) (*searchPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	ctx, span := startSpan(ctx, "search",
+		attribute.String("code_scout.mode", string(opts.Mode)),
+		attribute.Int("code_scout.limit", opts.Limit))
+	defer span.End()
+	start := time.Now()
+	timer := &searchTimer{}
+	ctx = withSearchTimer(ctx, timer)
+	// Languages disabled since the last index stay out of the results
+	if globalConfig != nil {
+		opts.Filter.ExcludeLanguages = globalConfig.DisabledLanguages()
+	}
+	// Rank everything up to the end of the page, plus one result to tell
+	// whether another page exists
+	fetch := opts.Offset + opts.Limit + 1
+	if opts.Diversity > 0 {
+		fetch *= diversityPoolFactor
+	}
+
+	results, totalMatches, err := runModeSearch(ctx, store, metadata, opts.Query, fetch, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Expansions) > 0 {
+		rankings := [][]SearchResult{results}
+		for _, variant := range opts.Expansions {
+			variantResults, variantMatches, err := runModeSearch(ctx, store, metadata, variant, fetch, opts)
+			if err != nil {
+				return nil, err
+			}
+			rankings = append(rankings, variantResults)
+			totalMatches += variantMatches
+		}
+		results = fuseRankings(rankings...)
+	}
+
+	weights := fusionWeights()
+	rankings := [][]SearchResult{results}
+	rankingWeights := []float64{vectorWeight(opts.Mode, weights)}
+	if opts.Lexical {
+		done := timePhase(ctx, phaseKeywordSearch)
+		rawLexical, err := store.FullTextSearch(opts.Query, fetch, embeddingTypeForMode(opts.Mode), opts.Filter)
+		done()
+		if err != nil {
+			return nil, fmt.Errorf("%w (run 'code-scout index' or 'code-scout optimize' to build it)", err)
+		}
+		rawLexical, err = resolveDescribingRows(store, rawLexical)
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, formatResults(rawLexical))
+		rankingWeights = append(rankingWeights, weights.Lexical)
+	}
+	// Symbol names in the query are matched exactly against the identifier
+	// index, which only covers code chunks
+	if opts.Mode != modeDocs {
+		done := timePhase(ctx, phaseKeywordSearch)
+		identifierResults, err := identifierSearch(store, opts.Query, fetch, opts.Filter)
+		done()
+		if err != nil {
+			return nil, err
+		}
+		if len(identifierResults) > 0 {
+			rankings = append(rankings, identifierResults)
+			rankingWeights = append(rankingWeights, weights.Identifier)
+		}
+	}
+	if len(rankings) > 1 {
+		results = fuseWeightedRankings(rankings, rankingWeights)
+	}
+
+	if opts.MinScore > 0 {
+		results = filterByScore(results, opts.MinScore)
+	}
+	now := time.Now()
+	if opts.Recency > 0 {
+		results = applyBoost(results, recencyBoost(metadata.FileModTimes, opts.Recency, now))
+	}
+	if len(opts.PathBoosts) > 0 {
+		results = applyBoost(results, pathBoost(opts.PathBoosts, opts.Root))
+	}
+	if opts.DedupFile {
+		results = bestPerFile(results)
+	}
+	results = diversify(results, opts.Diversity)
+
+	first := min(opts.Offset, len(results))
+	end := min(opts.Offset+opts.Limit, len(results))
+	if opts.Explain {
+		explainRankings(results[first:end], opts, metadata, now)
+	}
+	return &searchPage{
+		Results:      results[first:end],
+		TotalMatches: totalMatches,
+		HasMore:      len(results) > end,
+		Timing:       timer.report(time.Since(start)),
+	}, nil
+}
+
+// runModeSearch runs the vector search for query in opts.Mode
+func runModeSearch(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, query string, limit int, opts searchOptions) ([]SearchResult, int, error) {
+	models := primaryModels()
+	if opts.Models != nil {
+		models = *opts.Models
+	}
+	if opts.Mode == modeHybrid {
+		return runHybridSearch(ctx, store, metadata, models, query, limit, opts.Filter)
+	}
+	return runSingleModeSearch(ctx, store, metadata, models, query, limit, opts.Mode, opts.Filter)
+}
+
+// expandQuery returns up to n paraphrases of query. It asks the configured chat
+// model when chat_model is set, and falls back to rule-based synonym expansion.
+func expandQuery(query string, n int) []string {
+	if client, err := newChatClient(); err == nil {
+		variants, err := queryexpand.LLMExpander{Client: client}.Expand(query, n)
+		if err == nil {
+			return variants
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; using rule-based expansion\n", err)
+	}
+	variants, _ := queryexpand.RuleExpander{}.Expand(query, n)
+	return variants
+}
+
+// filterByScore drops results whose normalized score is below minScore, keeping order
+func filterByScore(results []SearchResult, minScore float64) []SearchResult {
+	kept := results[:0]
+	for _, result := range results {
+		if result.NormalizedScore >= minScore {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// bestPerFile keeps the first (best-ranked) result from each file, keeping order
+func bestPerFile(results []SearchResult) []SearchResult {
+	seen := make(map[string]bool)
+	kept := results[:0]
+	for _, result := range results {
+		if seen[result.FilePath] {
+			continue
+		}
+		seen[result.FilePath] = true
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+// groupResultsByFile nests results under their files. Files are ordered by
+// their best-ranked result, and results keep their rank order within a file.
+func groupResultsByFile(results []SearchResult) []searchapi.FileGroup {
+	var groups []searchapi.FileGroup
+	index := make(map[string]int)
+	for _, result := range results {
+		i, ok := index[result.FilePath]
+		if !ok {
+			i = len(groups)
+			index[result.FilePath] = i
+			groups = append(groups, searchapi.FileGroup{FilePath: result.FilePath, Language: result.Language})
+		}
+		groups[i].Results = append(groups[i].Results, result)
+	}
+	return groups
+}
+
+// printFileGroups prints results grouped by file in human-readable form.
+// Results keep their overall rank numbers, starting after offset.
+func printFileGroups(results []SearchResult, offset int, snippet snippetOptions) {
+	rank := make(map[string]int, len(results))
+	for i, result := range results {
+		rank[result.ChunkID] = offset + i + 1
+	}
+
+	for _, group := range groupResultsByFile(results) {
+		fmt.Printf("%s (%s, %d chunks)\n", group.FilePath, group.Language, len(group.Results))
+		for _, result := range group.Results {
+			fmt.Printf("  %d. lines %d-%d (%s)", rank[result.ChunkID], result.LineStart, result.LineEnd, describeScore(result))
+			if result.ChunkType != "" {
+				fmt.Printf(" %s", result.ChunkType)
+			}
+			if result.Name != "" {
+				fmt.Printf(" %s", result.Name)
+			} else if result.Heading != "" {
+				fmt.Printf(" %s", result.Heading)
+			}
+			fmt.Println()
+			if result.Signature != "" {
+				fmt.Printf("     %s\n", result.Signature)
+			}
+			if doc := summarizeDocComment(result.DocComment); doc != "" {
+				fmt.Printf("     // %s\n", doc)
+			}
+			fmt.Print(renderSnippet(result, snippet, "     "))
+		}
+		fmt.Println()
+	}
+}
+
+// openSearchStore opens the store to search and returns the project to scope
+// results to. --project and --all-projects search the global index from any
+// directory; a project configured with global_index searches only itself by default.
+func openSearchStore(cwd string) (storage.Store, string, error) {
+	if allProjects && projectFilter != "" {
+		return nil, "", fmt.Errorf("flags --project and --all-projects are mutually exclusive")
+	}
+	if allProjects || projectFilter != "" {
+		store, err := storage.NewGlobalLanceDBStore("")
+		return store, projectFilter, err
+	}
+
+	store, err := openStore(cwd)
+	if err != nil {
+		return nil, "", err
+	}
+	return store, currentProject(cwd), nil
+}
+
+// resolveSearchDir returns the absolute path of the --in directory, resolved against cwd
+func resolveSearchDir(cwd, dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cwd, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to access --in directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("--in must be a directory: %s", dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// relativizePaths rewrites result file paths relative to dir, for output that
+// editors can open directly. JSON output keeps the absolute file_path, so the
+// schema's meaning is unchanged, and adds the relative one as relative_path.
+// Paths that can't be made relative are left as is.
+func relativizePaths(results []SearchResult, dir string, keepAbsolute bool) {
+	for i := range results {
+		rel, err := filepath.Rel(dir, results[i].FilePath)
+		if err != nil {
+			continue
+		}
+		if keepAbsolute {
+			results[i].RelativePath = rel
+		} else {
+			results[i].FilePath = rel
+		}
+	}
+}
+
+// resolveOutputFormat returns the output format from --format, with --json as
+// a shorthand for --format json
+func resolveOutputFormat() (string, error) {
+	switch formatFlag {
+	case formatText, formatJSON, formatGrep:
+	default:
+		return "", fmt.Errorf("unsupported --format %q (expected text, json, or grep)", formatFlag)
+	}
+	if jsonOutput {
+		if formatFlag != formatText && formatFlag != formatJSON {
+			return "", fmt.Errorf("flags --json and --format %s are mutually exclusive", formatFlag)
+		}
+		return formatJSON, nil
+	}
+	return formatFlag, nil
+}
+
+// formatGrepLine renders a result as "path:line:col: snippet", the format used by
+// grep -n, ripgrep --vimgrep, and Vim's quickfix list. The location points at
+// the first non-blank line of the chunk.
+func formatGrepLine(result SearchResult) string {
+	line, col, snippet := result.LineStart, 1, ""
+	for offset, text := range strings.Split(result.Code, "\n") {
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			continue
+		}
+		line = result.LineStart + offset
+		col = len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		snippet = trimmed
+		break
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", result.FilePath, line, col, snippet)
+}
+
+// resolveQuery returns the search query: the argument, stdin when the
+// argument is -, or the snippet named by like (a file, or - for stdin)
+func resolveQuery(args []string, like string, stdin io.Reader) (string, error) {
+	source := "-"
+	switch {
+	case like != "" && len(args) > 0:
+		return "", fmt.Errorf("--like and a query argument are mutually exclusive")
+	case like != "":
+		source = like
+	case len(args) == 0:
+		return "", fmt.Errorf("requires a query, - to read it from stdin, or --like")
+	case args[0] != "-":
+		return args[0], nil
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if source == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read query: %w", err)
+	}
+	query := strings.TrimSpace(string(data))
+	if query == "" {
+		return "", fmt.Errorf("the query is empty")
+	}
+	return query, nil
+}
+
+// describeQuery shortens a multi-line query, such as a snippet, to its first line
+func describeQuery(query string) string {
+	first, rest, multiline := strings.Cut(query, "\n")
+	if !multiline {
+		return query
+	}
+	return fmt.Sprintf("%s (+%d more lines)", strings.TrimSpace(first), strings.Count(rest, "\n")+1)
 }
 
 func resolveSearchMode() (searchMode, error) {
@@ -168,78 +746,131 @@ func resolveSearchMode() (searchMode, error) {
 	return selected, nil
 }
 
-func runSingleModeSearch(store *storage.LanceDBStore, query string, limit int, mode searchMode) ([]SearchResult, int, error) {
+func runSingleModeSearch(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, models embeddingModels, query string, limit int, mode searchMode, filter storage.SearchFilter) ([]SearchResult, int, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
-	queryEmbedding, err := embedQueryForMode(query, mode)
+	queryEmbedding, err := embedQueryForMode(ctx, metadata, models, query, mode)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	filter := filterForMode(mode)
-	rawResults, err := store.Search(queryEmbedding, limit, filter)
+	done := timePhase(ctx, phaseVectorSearch)
+	rawResults, err := store.Search(string(mode), queryEmbedding, limit, filter)
+	done()
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search %s embeddings: %w", mode, err)
 	}
+	rawResults, err = resolveDescribingRows(store, rawResults)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	deduplicated := deduplicateResults(formatResults(rawResults))
 	return deduplicated, len(rawResults), nil
 }
 
-func runHybridSearch(store *storage.LanceDBStore, query string, limit int) ([]SearchResult, int, error) {
+func runHybridSearch(ctx context.Context, store storage.Store, metadata *storage.IndexMetadata, models embeddingModels, query string, limit int, filter storage.SearchFilter) ([]SearchResult, int, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
-	codeEmbedding, err := embedQueryForMode(query, modeCode)
+	codeEmbedding, err := embedQueryForMode(ctx, metadata, models, query, modeCode)
 	if err != nil {
 		return nil, 0, err
 	}
-	docsEmbedding, err := embedQueryForMode(query, modeDocs)
+	docsEmbedding, err := embedQueryForMode(ctx, metadata, models, query, modeDocs)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	codeResults, err := store.Search(codeEmbedding, limit, filterForMode(modeCode))
+	done := timePhase(ctx, phaseVectorSearch)
+	codeResults, err := store.Search(string(modeCode), codeEmbedding, limit, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search code embeddings: %w", err)
 	}
 
-	docsResults, err := store.Search(docsEmbedding, limit, filterForMode(modeDocs))
+	docsResults, err := store.Search(string(modeDocs), docsEmbedding, limit, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search documentation embeddings: %w", err)
 	}
+	docsResults, err = resolveDescribingRows(store, docsResults)
+	if err != nil {
+		return nil, 0, err
+	}
+	done()
 
-	formatted := append(formatResults(codeResults), formatResults(docsResults)...)
-	deduplicated := deduplicateResults(formatted)
+	// Code and docs live in separate embedding spaces whose distances aren't
+	// comparable, so merge the two rankings by rank instead of by score
+	weights := fusionWeights()
+	merged := fuseWeightedRankings(
+		[][]SearchResult{
+			deduplicateResults(formatResults(codeResults)),
+			deduplicateResults(formatResults(docsResults)),
+		},
+		[]float64{weights.Code, weights.Docs},
+	)
 
-	return deduplicated, len(codeResults) + len(docsResults), nil
+	return merged, len(codeResults) + len(docsResults), nil
 }
 
-func embedQueryForMode(query string, mode searchMode) ([]float64, error) {
-	var client embeddings.Client
+// embedQueryForMode embeds the query with the mode's model and checks that the model
+// and dimension match the ones the index was built with
+func embedQueryForMode(ctx context.Context, metadata *storage.IndexMetadata, models embeddingModels, query string, mode searchMode) ([]float64, error) {
+	var (
+		client embeddings.Client
+		model  string
+	)
 	switch mode {
 	case modeDocs:
-		client = newDocsEmbeddingClient()
+		client = models.newDocsClient()
+		model = models.Docs
 	default:
-		client = newCodeEmbeddingClient()
+		client = models.newCodeClient()
+		model = models.Code
+	}
+
+	if err := metadata.ValidateEmbeddingModel(string(mode), model, 0); err != nil {
+		return nil, err
 	}
 
-	embedding, err := client.Embed(query)
+	done := timePhase(ctx, phaseEmbedding)
+	embedding, err := cachedQueryEmbedding(ctx, client, model, query)
+	done()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate %s query embedding: %w", mode, err)
 	}
+
+	if err := metadata.ValidateEmbeddingModel(string(mode), model, len(embedding)); err != nil {
+		return nil, err
+	}
+	return embedding, nil
+}
+
+// cachedQueryEmbedding embeds a query with model, reusing the embedding from
+// an earlier search for the same query if it's cached
+func cachedQueryEmbedding(ctx context.Context, client embeddings.Client, model, query string) ([]float64, error) {
+	cache := queryEmbeddingCache()
+	if cache == nil {
+		return embeddings.EmbedQuery(ctx, client, query)
+	}
+	if embedding, ok := cache.Get(queryCacheModel(model), query); ok {
+		return embedding, nil
+	}
+	embedding, err := embeddings.EmbedQuery(ctx, client, query)
+	if err != nil {
+		return nil, err
+	}
+	cache.Put(queryCacheModel(model), query, embedding)
 	return embedding, nil
 }
 
-func filterForMode(mode searchMode) string {
+// embeddingTypeForMode returns the embedding space searched by a mode ("" for both)
+func embeddingTypeForMode(mode searchMode) string {
 	switch mode {
-	case modeCode:
-		return "embedding_type = 'code'"
-	case modeDocs:
-		return "embedding_type = 'docs'"
+	case modeCode, modeDocs:
+		return string(mode)
 	default:
 		return ""
 	}
@@ -248,6 +879,7 @@ func filterForMode(mode searchMode) string {
 func formatResults(results []map[string]interface{}) []SearchResult {
 	formatted := make([]SearchResult, len(results))
 	for i, r := range results {
+		metadata := storage.DecodeChunkMetadata(getStringOrDefault(r, "metadata", ""))
 		formatted[i] = SearchResult{
 			ChunkID:       getStringOrDefault(r, "chunk_id", ""),
 			FilePath:      getStringOrDefault(r, "file_path", ""),
@@ -256,12 +888,22 @@ func formatResults(results []map[string]interface{}) []SearchResult {
 			Language:      getStringOrDefault(r, "language", ""),
 			Code:          getStringOrDefault(r, "code", ""),
 			Score:         getFloat64OrDefault(r, "_distance", 0.0),
+			LexicalScore:  getFloat64OrDefault(r, "_score", 0.0),
 			EmbeddingType: getStringOrDefault(r, "embedding_type", ""),
+			Project:       getStringOrDefault(r, "project", ""),
 			ChunkType:     getStringOrDefault(r, "chunk_type", ""),
+			Name:          getStringOrDefault(r, "name", ""),
+			IsTest:        getBoolOrDefault(r, "is_test", false),
+			Signature:     metadata["signature"],
+			DocComment:    metadata["doc_comment"],
 			Heading:       getStringOrDefault(r, "heading", ""),
 			HeadingLevel:  getStringOrDefault(r, "heading_level", ""),
 			ParentHeading: getStringOrDefault(r, "parent_heading", ""),
 		}
+		formatted[i].NormalizedScore = searchapi.NormalizeScore(formatted[i].Score, formatted[i].LexicalScore)
+		if len(metadata) > 0 {
+			formatted[i].Metadata = metadata
+		}
 	}
 	return formatted
 }
@@ -310,6 +952,103 @@ func deduplicateResults(results []SearchResult) []SearchResult {
 	return deduplicated
 }
 
+// fusionWeights returns the configured hybrid_weights
+func fusionWeights() config.HybridWeights {
+	if globalConfig == nil {
+		return config.Default().FusionWeights()
+	}
+	return globalConfig.FusionWeights()
+}
+
+// vectorWeight returns the fusion weight of a mode's vector ranking. A hybrid
+// ranking already blends code and docs, so it carries both their weights.
+func vectorWeight(mode searchMode, weights config.HybridWeights) float64 {
+	switch mode {
+	case modeCode:
+		return weights.Code
+	case modeDocs:
+		return weights.Docs
+	default:
+		return weights.Code + weights.Docs
+	}
+}
+
+// fuseRankings merges equally weighted rankings with reciprocal rank fusion
+func fuseRankings(rankings ...[]SearchResult) []SearchResult {
+	weights := make([]float64, len(rankings))
+	for i := range weights {
+		weights[i] = 1
+	}
+	return fuseWeightedRankings(rankings, weights)
+}
+
+// fuseWeightedRankings merges rankings (e.g. vector and keyword results) with
+// weighted reciprocal rank fusion. Each result scores weight/(rrfK+rank) per
+// ranking it appears in, so chunks found by several searches rise to the top.
+// Fused scores are divided by the best possible score (first in every
+// ranking), putting them in (0, 1] whatever the weights. Results are ordered
+// by fused score.
+func fuseWeightedRankings(rankings [][]SearchResult, weights []float64) []SearchResult {
+	fused := make(map[string]*SearchResult)
+	var order []string
+
+	totalWeight := 0.0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	best := totalWeight / float64(rrfK+1)
+
+	for i, ranking := range rankings {
+		for rank, result := range ranking {
+			contribution := weights[i] / float64(rrfK+rank+1) / best
+			if existing, ok := fused[result.ChunkID]; ok {
+				existing.FusedScore += contribution
+				if result.LexicalScore > 0 {
+					existing.LexicalScore = result.LexicalScore
+				}
+				if result.IdentifierScore > 0 {
+					existing.IdentifierScore = result.IdentifierScore
+					existing.IdentifierMatches = result.IdentifierMatches
+				}
+				continue
+			}
+			r := result
+			r.FusedScore = contribution
+			fused[r.ChunkID] = &r
+			order = append(order, r.ChunkID)
+		}
+	}
+
+	merged := make([]SearchResult, 0, len(order))
+	for _, id := range order {
+		merged = append(merged, *fused[id])
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].FusedScore > merged[j].FusedScore
+	})
+
+	return merged
+}
+
+// summarizeDocComment returns the first line of a doc comment, truncated for
+// human-readable output
+func summarizeDocComment(doc string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(doc), "\n")
+	line = strings.TrimSpace(line)
+	if len(line) > 100 {
+		line = line[:100] + "..."
+	}
+	return line
+}
+
+// describeScore formats the ranking score shown in human-readable output
+func describeScore(result SearchResult) string {
+	if result.FusedScore > 0 {
+		return fmt.Sprintf("rrf: %.4f", result.FusedScore)
+	}
+	return fmt.Sprintf("score: %.4f", result.Score)
+}
+
 func getStringOrDefault(m map[string]interface{}, key string, defaultVal string) string {
 	if val, ok := m[key]; ok {
 		if str, ok := val.(string); ok {
@@ -344,11 +1083,46 @@ func getFloat64OrDefault(m map[string]interface{}, key string, defaultVal float6
 	return defaultVal
 }
 
+func getBoolOrDefault(m map[string]interface{}, key string, defaultVal bool) bool {
+	if val, ok := m[key]; ok {
+		if b, ok := val.(bool); ok {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 func init() {
 	searchCmd.Flags().BoolVarP(&codeMode, "code", "c", false, "Search code embeddings only")
 	searchCmd.Flags().BoolVarP(&docsMode, "docs", "d", false, "Search documentation embeddings only")
 	searchCmd.Flags().BoolVar(&hybridMode, "hybrid", false, "Search both code and documentation embeddings (default)")
-	searchCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output results as JSON")
+	searchCmd.Flags().BoolVar(&lexical, "lexical", false, "Blend full-text keyword matches into the ranking")
+	searchCmd.Flags().BoolVar(&explain, "explain", false, "Show how each result was ranked: vector similarity, keyword and identifier hits, boosts, and fused score")
+	searchCmd.Flags().StringVar(&like, "like", "", "Search for code similar to the snippet in this file (- for stdin)")
+	searchCmd.Flags().BoolVar(&compare, "compare", false, "Also search the shadow index (shadow_code_model/shadow_text_model) and show both result lists")
+	searchCmd.Flags().BoolVar(&expand, "expand", false, "Also search paraphrases of the query and fuse the rankings (uses chat_model if configured)")
+	searchCmd.Flags().IntVar(&expansions, "expansions", 3, "Number of paraphrases to generate with --expand")
+	searchCmd.Flags().StringVar(&languageFilter, "language", "", "Only return chunks in this language (e.g. go, python, markdown)")
+	searchCmd.Flags().StringVar(&chunkTypeFilter, "chunk-type", "", "Only return chunks of this type (e.g. function, method, section)")
+	searchCmd.Flags().BoolVar(&noTests, "no-tests", false, "Exclude chunks from test files (e.g. *_test.go, test_*.py, __tests__/)")
+	searchCmd.Flags().BoolVar(&onlyTests, "only-tests", false, "Only return chunks from test files")
+	searchCmd.Flags().StringVar(&inDir, "in", "", "Only search files under this directory, printing paths relative to the current directory")
+	searchCmd.Flags().StringVar(&projectFilter, "project", "", "Search this project in the global index")
+	searchCmd.Flags().BoolVar(&allProjects, "all-projects", false, "Search every project in the global index")
+	searchCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output results as JSON (same as --format json)")
+	searchCmd.Flags().StringVar(&formatFlag, "format", formatText, "Output format: text, json, or grep (path:line:col: snippet)")
 	searchCmd.Flags().IntVar(&limitFlag, "limit", 10, "Maximum number of results to return")
+	searchCmd.Flags().IntVar(&offsetFlag, "offset", 0, "Skip this many ranked results (for paging)")
+	searchCmd.Flags().Float64Var(&minScore, "min-score", 0, "Drop results whose normalized score (0-1, higher is better) is below this")
+	searchCmd.Flags().Float64Var(&diversity, "diversity", 0, "Re-rank for variety with maximal marginal relevance (0 = pure relevance, 1 = most diverse)")
+	searchCmd.Flags().Float64Var(&recency, "recency-weight", 0, "Boost results from recently modified files by up to this fraction (default: recency_weight config)")
+	searchCmd.Flags().BoolVar(&dedupFile, "dedup-file", false, "Keep only the best-ranked chunk from each file")
+	searchCmd.Flags().StringVar(&groupBy, "group-by", "", "Group results in output: file (nest chunks under their file)")
+	searchCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable syntax highlighting (also disabled by NO_COLOR or when output isn't a terminal)")
+	searchCmd.Flags().IntVar(&snippetLines, "snippet-lines", 3, "Lines of code to show per result, centered on the lines matching the query")
+	searchCmd.Flags().BoolVar(&fullChunks, "full", false, "Show each result's complete code instead of a snippet")
+	searchCmd.Flags().BoolVar(&timingFlag, "timing", false, "Report time spent embedding the query, searching, and reranking")
+	searchCmd.Flags().StringVar(&cursorFlag, "cursor", "", "Resume from the next_cursor of a previous page")
+	searchCmd.MarkFlagsMutuallyExclusive("no-tests", "only-tests")
 	rootCmd.AddCommand(searchCmd)
 }
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/embeddings"
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+func TestFuseRankings(t *testing.T) {
+	vector := []SearchResult{
+		{ChunkID: "a", Score: 0.1},
+		{ChunkID: "b", Score: 0.2},
+	}
+	lexicalResults := []SearchResult{
+		{ChunkID: "b", LexicalScore: 7.5},
+		{ChunkID: "c", LexicalScore: 3.0},
+	}
+
+	fused := fuseRankings(vector, lexicalResults)
+	if len(fused) != 3 {
+		t.Fatalf("expected 3 fused results, got %d", len(fused))
+	}
+
+	// b appears in both lists and should outrank a (vector only) and c (lexical only)
+	if fused[0].ChunkID != "b" {
+		t.Errorf("expected b first, got %s", fused[0].ChunkID)
+	}
+	if fused[0].Score != 0.2 || fused[0].LexicalScore != 7.5 {
+		t.Errorf("expected b to keep both scores, got %+v", fused[0])
+	}
+	if fused[1].ChunkID != "a" || fused[2].ChunkID != "c" {
+		t.Errorf("unexpected order: %s, %s", fused[1].ChunkID, fused[2].ChunkID)
+	}
+}
+
+func TestFuseWeightedRankings(t *testing.T) {
+	code := []SearchResult{{ChunkID: "code1"}, {ChunkID: "code2"}}
+	docs := []SearchResult{{ChunkID: "docs1"}, {ChunkID: "docs2"}}
+
+	fused := fuseWeightedRankings([][]SearchResult{code, docs}, []float64{1, 3})
+	var ids []string
+	for _, r := range fused {
+		ids = append(ids, r.ChunkID)
+	}
+	if fmt.Sprint(ids) != "[docs1 docs2 code1 code2]" {
+		t.Errorf("expected the heavier docs ranking first, got %v", ids)
+	}
+
+	// A result first in every ranking gets the maximum fused score of 1
+	fused = fuseWeightedRankings([][]SearchResult{code, code}, []float64{2, 0.5})
+	if math.Abs(fused[0].FusedScore-1) > 1e-9 {
+		t.Errorf("expected normalized score 1, got %v", fused[0].FusedScore)
+	}
+}
+
+func TestFormatGrepLine(t *testing.T) {
+	result := SearchResult{
+		FilePath:  "/repo/internal/server.go",
+		LineStart: 10,
+		Code:      "\n\t// Start runs the server\n\tfunc Start() error {\n\t}",
+	}
+	expected := "/repo/internal/server.go:11:2: // Start runs the server"
+	if got := formatGrepLine(result); got != expected {
+		t.Errorf("formatGrepLine() = %q, expected %q", got, expected)
+	}
+
+	empty := SearchResult{FilePath: "/repo/a.go", LineStart: 3}
+	if got := formatGrepLine(empty); got != "/repo/a.go:3:1: " {
+		t.Errorf("unexpected line for empty chunk: %q", got)
+	}
+}
+
+func TestResolveOutputFormat(t *testing.T) {
+	defer func() { formatFlag, jsonOutput = formatText, false }()
+
+	formatFlag, jsonOutput = formatText, true
+	if format, err := resolveOutputFormat(); err != nil || format != formatJSON {
+		t.Errorf("expected --json to select json, got %q (err %v)", format, err)
+	}
+
+	formatFlag, jsonOutput = formatGrep, true
+	if _, err := resolveOutputFormat(); err == nil {
+		t.Error("expected --json with --format grep to fail")
+	}
+
+	formatFlag, jsonOutput = "xml", false
+	if _, err := resolveOutputFormat(); err == nil {
+		t.Error("expected unknown format to fail")
+	}
+}
+
+func TestExecuteSearch_Pagination(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	store := &memoryStore{}
+	for i := 0; i < 5; i++ {
+		store.rows = append(store.rows, map[string]interface{}{
+			"chunk_id":       fmt.Sprintf("c%d", i),
+			"code":           fmt.Sprintf("func F%d() {}", i),
+			"embedding_type": "code",
+			"_distance":      float64(i),
+		})
+	}
+	metadata := &storage.IndexMetadata{}
+
+	var seen []string
+	opts := searchOptions{Query: "f", Mode: modeCode, Limit: 2}
+	for page := 0; ; page++ {
+		result, err := executeSearch(context.Background(), store, metadata, opts)
+		if err != nil {
+			t.Fatalf("executeSearch failed: %v", err)
+		}
+		for _, r := range result.Results {
+			seen = append(seen, r.ChunkID)
+		}
+		if !result.HasMore {
+			break
+		}
+		if page > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		opts.Offset += len(result.Results)
+	}
+
+	if fmt.Sprint(seen) != "[c0 c1 c2 c3 c4]" {
+		t.Errorf("expected every result exactly once in rank order, got %v", seen)
+	}
+
+	opts.Offset = 10
+	result, err := executeSearch(context.Background(), store, metadata, opts)
+	if err != nil {
+		t.Fatalf("executeSearch failed: %v", err)
+	}
+	if len(result.Results) != 0 || result.HasMore {
+		t.Errorf("expected an empty last page past the end, got %+v", result)
+	}
+}
+
+func TestExecuteSearch_BlendsIdentifierHits(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	store := &memoryStore{identifiers: storage.NewIdentifierIndex()}
+	for i := 0; i < 5; i++ {
+		store.rows = append(store.rows, map[string]interface{}{
+			"chunk_id":       fmt.Sprintf("c%d", i),
+			"code":           fmt.Sprintf("func F%d() {}", i),
+			"embedding_type": "code",
+			"_distance":      float64(i),
+		})
+	}
+	// The vector search fetches 3 rows, so the chunk defining the symbol is only found by name
+	store.rows[4]["code"] = "func parseHTTPHeader() {}"
+	store.identifiers.Add(formatChunks(store.rows))
+	metadata := &storage.IndexMetadata{}
+
+	page, err := executeSearch(context.Background(), store, metadata, searchOptions{Query: "where is parseHTTPHeader", Mode: modeCode, Limit: 2})
+	if err != nil {
+		t.Fatalf("executeSearch failed: %v", err)
+	}
+	if len(page.Results) != 2 || page.Results[1].ChunkID != "c4" {
+		t.Fatalf("expected the identifier hit blended into the top results, got %+v", page.Results)
+	}
+	if page.Results[1].IdentifierScore <= 0 || !strings.Contains(strings.Join(page.Results[1].IdentifierMatches, ","), "parsehttpheader") {
+		t.Errorf("expected identifier match details, got %+v", page.Results[1])
+	}
+
+	page, err = executeSearch(context.Background(), store, metadata, searchOptions{Query: "where is parseHTTPHeader", Mode: modeDocs, Limit: 2})
+	if err != nil {
+		t.Fatalf("executeSearch failed: %v", err)
+	}
+	if len(page.Results) != 0 {
+		t.Errorf("expected docs mode to skip the code identifier index, got %+v", page.Results)
+	}
+}
+
+// formatChunks converts stored rows back into chunks
+func formatChunks(rows []map[string]interface{}) []chunker.Chunk {
+	chunks := make([]chunker.Chunk, len(rows))
+	for i, row := range rows {
+		chunks[i] = chunker.Chunk{ID: row["chunk_id"].(string), Code: row["code"].(string), EmbeddingType: row["embedding_type"].(string)}
+	}
+	return chunks
+}
+
+func TestDecodeCursor(t *testing.T) {
+	opts := searchOptions{Query: "auth", Mode: modeCode, Limit: 10}
+	indexTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	token := encodeCursor(opts, 20, indexTime)
+
+	offset, err := decodeCursor(token, opts, indexTime)
+	if err != nil || offset != 20 {
+		t.Fatalf("expected offset 20, got %d (%v)", offset, err)
+	}
+
+	// Page size may change between pages
+	resized := opts
+	resized.Limit = 5
+	if _, err := decodeCursor(token, resized, indexTime); err != nil {
+		t.Errorf("expected cursor to accept a different limit: %v", err)
+	}
+
+	otherQuery := opts
+	otherQuery.Query = "login"
+	if _, err := decodeCursor(token, otherQuery, indexTime); err == nil {
+		t.Error("expected error for cursor used with a different query")
+	}
+	if _, err := decodeCursor(token, opts, indexTime.Add(time.Minute)); err == nil {
+		t.Error("expected error for cursor from before a reindex")
+	}
+	if _, err := decodeCursor("not a cursor!", opts, indexTime); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+}
+
+func TestFilterByScore(t *testing.T) {
+	results := []SearchResult{
+		{ChunkID: "a", NormalizedScore: 0.9},
+		{ChunkID: "b", NormalizedScore: 0.3},
+		{ChunkID: "c", NormalizedScore: 0.5},
+	}
+
+	kept := filterByScore(results, 0.5)
+	if len(kept) != 2 || kept[0].ChunkID != "a" || kept[1].ChunkID != "c" {
+		t.Errorf("expected a and c in order, got %+v", kept)
+	}
+}
+
+func TestBestPerFile(t *testing.T) {
+	results := []SearchResult{
+		{ChunkID: "a1", FilePath: "a.go"},
+		{ChunkID: "b1", FilePath: "b.go"},
+		{ChunkID: "a2", FilePath: "a.go"},
+	}
+
+	kept := bestPerFile(results)
+	if len(kept) != 2 || kept[0].ChunkID != "a1" || kept[1].ChunkID != "b1" {
+		t.Errorf("expected the first chunk from each file, got %+v", kept)
+	}
+}
+
+func TestGroupResultsByFile(t *testing.T) {
+	results := []SearchResult{
+		{ChunkID: "b1", FilePath: "b.go"},
+		{ChunkID: "a1", FilePath: "a.go"},
+		{ChunkID: "b2", FilePath: "b.go"},
+	}
+
+	groups := groupResultsByFile(results)
+	if len(groups) != 2 || groups[0].FilePath != "b.go" || groups[1].FilePath != "a.go" {
+		t.Fatalf("expected files ordered by best rank, got %+v", groups)
+	}
+	if len(groups[0].Results) != 2 || groups[0].Results[1].ChunkID != "b2" {
+		t.Errorf("expected b.go to hold b1 and b2 in order, got %+v", groups[0].Results)
+	}
+}
+
+func TestSummarizeDocComment(t *testing.T) {
+	tests := map[string]string{
+		"":                                  "",
+		"Embed generates an embedding":      "Embed generates an embedding",
+		"  First line.\nSecond line.\n":     "First line.",
+		strings.Repeat("x", 120) + "\nmore": strings.Repeat("x", 100) + "...",
+	}
+	for input, expected := range tests {
+		if got := summarizeDocComment(input); got != expected {
+			t.Errorf("summarizeDocComment(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+// queryRecordingStore records which embedding space each search queried, and with which vector
+type queryRecordingStore struct {
+	memoryStore
+	queries map[string][]float64
+}
+
+func (s *queryRecordingStore) Search(embeddingType string, queryVector []float64, limit int, filter storage.SearchFilter) ([]map[string]interface{}, error) {
+	s.queries[embeddingType] = queryVector
+	return s.memoryStore.Search(embeddingType, queryVector, limit, filter)
+}
+
+func TestExecuteSearch_ModesUsePerModeModels(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	store := &queryRecordingStore{queries: make(map[string][]float64)}
+	store.rows = []map[string]interface{}{
+		{"chunk_id": "code1", "code": "func A() {}", "embedding_type": "code", "_distance": 0.1},
+		{"chunk_id": "docs1", "code": "# A", "embedding_type": "docs", "_distance": 0.2},
+	}
+	metadata := &storage.IndexMetadata{}
+	query := "what does A do"
+	codeVector := fakeVector(query, 1)
+	docsVector := fakeVector(query, 1000)
+
+	tests := []struct {
+		mode        searchMode
+		expectedIDs string
+		queried     map[string][]float64
+	}{
+		{modeCode, "[code1]", map[string][]float64{"code": codeVector}},
+		{modeDocs, "[docs1]", map[string][]float64{"docs": docsVector}},
+		{modeHybrid, "[code1 docs1]", map[string][]float64{"code": codeVector, "docs": docsVector}},
+	}
+	for _, tt := range tests {
+		store.queries = make(map[string][]float64)
+		page, err := executeSearch(context.Background(), store, metadata, searchOptions{Query: query, Mode: tt.mode, Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: executeSearch failed: %v", tt.mode, err)
+		}
+
+		var ids []string
+		for _, result := range page.Results {
+			ids = append(ids, result.ChunkID)
+		}
+		if fmt.Sprint(ids) != tt.expectedIDs {
+			t.Errorf("%s: expected %s, got %v", tt.mode, tt.expectedIDs, ids)
+		}
+
+		if len(store.queries) != len(tt.queried) {
+			t.Errorf("%s: expected %d embedding spaces queried, got %d", tt.mode, len(tt.queried), len(store.queries))
+		}
+		for embeddingType, vector := range tt.queried {
+			if got := store.queries[embeddingType]; len(got) == 0 || got[0] != vector[0] {
+				t.Errorf("%s: %s space was not queried with the %s model's embedding", tt.mode, embeddingType, embeddingType)
+			}
+		}
+	}
+}
+
+func TestExecuteSearch_RejectsMismatchedQueryModel(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	store := &memoryStore{}
+	metadata := &storage.IndexMetadata{}
+	metadata.RecordEmbeddingModel("docs", "some-other-model", 3584)
+
+	if _, err := executeSearch(context.Background(), store, metadata, searchOptions{Query: "q", Mode: modeDocs}); err == nil {
+		t.Error("expected docs search to fail when the index was built with a different docs model")
+	}
+	if _, err := executeSearch(context.Background(), store, metadata, searchOptions{Query: "q", Mode: modeCode}); err != nil {
+		t.Errorf("expected code search to be unaffected by the docs model, got %v", err)
+	}
+}
+
+// sequenceStore answers each vector search with the next ranking in turn
+type sequenceStore struct {
+	memoryStore
+	rankings [][]map[string]interface{}
+	calls    int
+}
+
+func (s *sequenceStore) Search(string, []float64, int, storage.SearchFilter) ([]map[string]interface{}, error) {
+	ranking := s.rankings[s.calls%len(s.rankings)]
+	s.calls++
+	return ranking, nil
+}
+
+func TestExecuteSearch_ExpansionsFuseRankings(t *testing.T) {
+	installFakeEmbeddings(t)
+
+	row := func(id string, distance float64) map[string]interface{} {
+		return map[string]interface{}{"chunk_id": id, "code": id, "embedding_type": "code", "_distance": distance}
+	}
+	store := &sequenceStore{rankings: [][]map[string]interface{}{
+		{row("a", 0.1), row("b", 0.2)},
+		{row("c", 0.1), row("b", 0.2)},
+	}}
+
+	page, err := executeSearch(context.Background(), store, &storage.IndexMetadata{}, searchOptions{
+		Query: "delete user", Mode: modeCode, Limit: 10, Expansions: []string{"remove user"},
+	})
+	if err != nil {
+		t.Fatalf("executeSearch failed: %v", err)
+	}
+	if store.calls != 2 {
+		t.Errorf("expected one search per query, got %d", store.calls)
+	}
+	var ids []string
+	for _, r := range page.Results {
+		ids = append(ids, r.ChunkID)
+	}
+	if fmt.Sprint(ids) != "[b a c]" {
+		t.Errorf("expected chunk found by both queries first, got %v", ids)
+	}
+}
+
+// countingEmbeddingClient counts the texts it embeds
+type countingEmbeddingClient struct {
+	fakeEmbeddingClient
+	calls int
+}
+
+func (c *countingEmbeddingClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	c.calls++
+	return c.fakeEmbeddingClient.Embed(ctx, text)
+}
+
+func TestCachedQueryEmbedding(t *testing.T) {
+	cache := embeddings.NewQueryCache("", 10)
+	prevCache := queryEmbeddingCache
+	queryEmbeddingCache = func() *embeddings.QueryCache { return cache }
+	t.Cleanup(func() { queryEmbeddingCache = prevCache })
+
+	client := &countingEmbeddingClient{fakeEmbeddingClient: fakeEmbeddingClient{offset: 1}}
+	ctx := context.Background()
+	first, err := cachedQueryEmbedding(ctx, client, "code-a", "parse config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := cachedQueryEmbedding(ctx, client, "code-a", "parse config")
+	if client.calls != 1 || first[0] != second[0] {
+		t.Errorf("expected a repeated query to be served from the cache, embedded %d times", client.calls)
+	}
+	if cachedQueryEmbedding(ctx, client, "code-b", "parse config"); client.calls != 2 {
+		t.Errorf("expected another model to embed the query again, embedded %d times", client.calls)
+	}
+}
+
+func TestResolveSearchDir(t *testing.T) {
+	cwd := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cwd, "internal", "storage"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := resolveSearchDir(cwd, "./internal/storage/")
+	if err != nil {
+		t.Fatalf("resolveSearchDir failed: %v", err)
+	}
+	if dir != filepath.Join(cwd, "internal", "storage") {
+		t.Errorf("unexpected directory: %s", dir)
+	}
+
+	if _, err := resolveSearchDir(cwd, "missing"); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestResolveQuery(t *testing.T) {
+	snippet := filepath.Join(t.TempDir(), "snippet.go")
+	if err := os.WriteFile(snippet, []byte("func retry() {\n\tbackoff()\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		args    []string
+		like    string
+		stdin   string
+		want    string
+		wantErr bool
+	}{
+		{args: []string{"auth flow"}, want: "auth flow"},
+		{args: []string{"-"}, stdin: "where do we\nretry uploads\n", want: "where do we\nretry uploads"},
+		{like: "-", stdin: "  x := 1\n", want: "x := 1"},
+		{like: snippet, want: "func retry() {\n\tbackoff()\n}"},
+		{args: []string{"-"}, stdin: "\n", wantErr: true},
+		{args: []string{"q"}, like: "-", wantErr: true},
+		{wantErr: true},
+	} {
+		got, err := resolveQuery(tc.args, tc.like, strings.NewReader(tc.stdin))
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("resolveQuery(%q, %q) = %q, %v; want %q (error: %v)", tc.args, tc.like, got, err, tc.want, tc.wantErr)
+		}
+	}
+
+	if got := describeQuery("func retry() {\n\tbackoff()\n}"); got != "func retry() { (+2 more lines)" {
+		t.Errorf("unexpected multi-line query description: %q", got)
+	}
+}
+
+func TestRelativizePaths(t *testing.T) {
+	results := []SearchResult{{FilePath: "/repo/internal/storage/store.go"}, {FilePath: "/other/x.go"}}
+	relativizePaths(results, "/repo", false)
+	if results[0].FilePath != "internal/storage/store.go" {
+		t.Errorf("unexpected relative path: %s", results[0].FilePath)
+	}
+	if results[1].FilePath != "../other/x.go" {
+		t.Errorf("unexpected relative path: %s", results[1].FilePath)
+	}
+
+	// JSON output keeps the absolute path
+	results = []SearchResult{{FilePath: "/repo/internal/storage/store.go"}}
+	relativizePaths(results, "/repo", true)
+	if results[0].FilePath != "/repo/internal/storage/store.go" || results[0].RelativePath != "internal/storage/store.go" {
+		t.Errorf("expected file_path kept and relative_path set, got %+v", results[0])
+	}
+}
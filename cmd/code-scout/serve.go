@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	servePort     int
+	serveGRPCPort int
+	serveHost     string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the index over a local HTTP API",
+	Long: `Start an HTTP server over the current project's index so IDE extensions and
+other processes can query it without shelling out to the CLI.
+
+Endpoints:
+  GET  /search?q=...   Search (params: mode, limit, offset, cursor, min_score,
+                       diversity, dedup_file, language, chunk_type, lexical,
+                       explain, no_tests, only_tests)
+  POST /index          Run an incremental index
+  GET  /status         Index freshness (same as 'status --json')
+  GET  /chunks/{id}    A single chunk by ID
+
+Responses are JSON; search results use the same schema as 'search --json'.
+
+With --grpc-port, the same index is also served over gRPC (Search, Index, and
+Watch; see proto/codescout/v1/codescout.proto).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		api := newAPIServer(cwd)
+		errs := make(chan error, 2)
+
+		if serveGRPCPort > 0 {
+			grpcAddr := net.JoinHostPort(serveHost, strconv.Itoa(serveGRPCPort))
+			listener, err := net.Listen("tcp", grpcAddr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", grpcAddr, err)
+			}
+			fmt.Printf("Serving gRPC on %s\n", grpcAddr)
+			go func() {
+				if err := newGRPCServer(api).Serve(listener); err != nil {
+					errs <- fmt.Errorf("gRPC server failed: %w", err)
+				}
+			}()
+		}
+
+		addr := net.JoinHostPort(serveHost, strconv.Itoa(servePort))
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           api.routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Printf("Serving %s on http://%s\n", cwd, addr)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errs <- fmt.Errorf("server failed: %w", err)
+			}
+		}()
+		return <-errs
+	},
+}
+
+var (
+	// errInvalidRequest marks errors caused by bad request parameters
+	errInvalidRequest = errors.New("invalid request")
+	// errIndexBusy is returned when an index run is requested while one is in progress
+	errIndexBusy = errors.New("indexing already in progress")
+	// errIndexUnavailable marks errors opening the index, e.g. before the first index run
+	errIndexUnavailable = errors.New("index unavailable")
+)
+
+// apiServer serves the index of one project directory over HTTP
+type apiServer struct {
+	dir string
+	// mu lets searches run concurrently while an index run has exclusive access
+	mu sync.RWMutex
+	// warm, if set, keeps the store open between requests
+	warm *warmStore
+}
+
+// newAPIServer creates a server for the project in dir
+func newAPIServer(dir string) *apiServer {
+	return &apiServer{dir: dir}
+}
+
+// routes returns the server's HTTP handler
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("POST /index", s.handleIndex)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /chunks/{id}", s.handleChunk)
+	return mux
+}
+
+// handleSearch runs a search using query parameters
+func (s *apiServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	opts := searchOptions{
+		Query: params.Get("q"),
+		Mode:  searchMode(params.Get("mode")),
+		Filter: storage.SearchFilter{
+			Language:  params.Get("language"),
+			ChunkType: params.Get("chunk_type"),
+		},
+	}
+
+	if value := params.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("limit must be a positive integer, got: %s", value))
+			return
+		}
+		opts.Limit = parsed
+	}
+
+	if value := params.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("offset must be a non-negative integer, got: %s", value))
+			return
+		}
+		opts.Offset = parsed
+	}
+
+	for name, target := range map[string]*float64{"min_score": &opts.MinScore, "diversity": &opts.Diversity} {
+		if value := params.Get(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Errorf("%s must be a number, got: %s", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	for name, target := range map[string]*bool{
+		"lexical":    &opts.Lexical,
+		"explain":    &opts.Explain,
+		"dedup_file": &opts.DedupFile,
+		"no_tests":   &opts.Filter.ExcludeTests,
+		"only_tests": &opts.Filter.OnlyTests,
+	} {
+		if value := params.Get(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Errorf("%s must be a boolean, got: %s", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	response, err := s.search(r.Context(), opts, params.Get("cursor"))
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleIndex runs an incremental index. Only one index run happens at a time.
+func (s *apiServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	elapsed, err := s.index(r.Context(), nil)
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "ok",
+		"duration_ms": elapsed.Milliseconds(),
+	})
+}
+
+// handleStatus reports index freshness
+func (s *apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	state, err := s.status()
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// handleChunk returns a single chunk by ID
+func (s *apiServer) handleChunk(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	store, _, err := s.openIndex()
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	defer store.Close()
+
+	id := r.PathValue("id")
+	row, err := store.GetChunk(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if row == nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("chunk not found: %s", id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, formatResults([]map[string]interface{}{row})[0])
+}
+
+// search validates opts, filling in defaults, and runs the search. A non-empty
+// cursor from a previous response's next_cursor overrides opts.Offset.
+func (s *apiServer) search(ctx context.Context, opts searchOptions, cursor string) (*searchapi.Response, error) {
+	if opts.Query == "" {
+		return nil, fmt.Errorf("%w: missing required parameter: q", errInvalidRequest)
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = modeHybrid
+	case modeCode, modeDocs, modeHybrid:
+	default:
+		return nil, fmt.Errorf("%w: mode must be code, docs, or hybrid, got: %s", errInvalidRequest, opts.Mode)
+	}
+	if opts.Limit < 0 {
+		return nil, fmt.Errorf("%w: limit must be a positive integer, got: %d", errInvalidRequest, opts.Limit)
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 10
+	}
+	if opts.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative, got: %d", errInvalidRequest, opts.Offset)
+	}
+	if opts.MinScore < 0 || opts.MinScore > 1 {
+		return nil, fmt.Errorf("%w: min_score must be between 0 and 1, got: %g", errInvalidRequest, opts.MinScore)
+	}
+	if opts.Filter.ExcludeTests && opts.Filter.OnlyTests {
+		return nil, fmt.Errorf("%w: no_tests and only_tests are mutually exclusive", errInvalidRequest)
+	}
+	if opts.Diversity < 0 || opts.Diversity > 1 {
+		return nil, fmt.Errorf("%w: diversity must be between 0 and 1, got: %g", errInvalidRequest, opts.Diversity)
+	}
+	opts.Filter.Project = currentProject(s.dir)
+	if globalConfig != nil {
+		opts.Recency = globalConfig.RecencyWeight
+		opts.PathBoosts, opts.Root = globalConfig.Boost, s.dir
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	store, metadata, err := s.openIndex()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	if cursor != "" {
+		if opts.Offset, err = decodeCursor(cursor, opts, metadata.LastIndexTime); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+		}
+	}
+
+	page, err := executeSearch(ctx, store, metadata, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	state := loadIndexState(metadata, s.dir)
+	return &searchapi.Response{
+		SchemaVersion: searchapi.SchemaVersion,
+		Query:         opts.Query,
+		Mode:          string(opts.Mode),
+		TotalResults:  page.TotalMatches,
+		Returned:      len(page.Results),
+		Offset:        opts.Offset,
+		NextCursor:    page.nextCursor(opts, metadata.LastIndexTime),
+		Results:       page.Results,
+		Index:         &state,
+	}, nil
+}
+
+// index runs an incremental index, failing with errIndexBusy if one is already
+// running. If set, started is called once the run has begun.
+func (s *apiServer) index(ctx context.Context, started func()) (time.Duration, error) {
+	if !s.mu.TryLock() {
+		return 0, errIndexBusy
+	}
+	defer s.mu.Unlock()
+
+	if started != nil {
+		started()
+	}
+
+	start := time.Now()
+	if _, err := runIndex(ctx, s.dir); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// status reports index freshness
+func (s *apiServer) status() (indexState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metadata, err := s.loadMetadata()
+	if err != nil {
+		return indexState{}, err
+	}
+
+	state := loadIndexState(metadata, s.dir)
+	state.Project = currentProject(s.dir)
+	return state, nil
+}
+
+// loadMetadata loads the project's index metadata without opening its tables
+func (s *apiServer) loadMetadata() (*storage.IndexMetadata, error) {
+	store, err := s.openStore()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open database: %w", errIndexUnavailable, err)
+	}
+	defer store.Close()
+
+	metadata, err := store.LoadMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// openIndex opens the project's store for reading and loads its metadata.
+// The caller must close the store.
+func (s *apiServer) openIndex() (storage.Store, *storage.IndexMetadata, error) {
+	store, err := s.openStore()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to open database: %w", errIndexUnavailable, err)
+	}
+	if err := store.OpenTable(); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("%w: failed to open table: %w (run 'code-scout index' first)", errIndexUnavailable, err)
+	}
+	metadata, err := store.LoadMetadata()
+	if err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("failed to load metadata: %w", err)
+	}
+	if err := storage.CheckSchemaVersion(metadata); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("%w: %w", errIndexUnavailable, err)
+	}
+	return store, metadata, nil
+}
+
+// openStore opens the project's store, or takes the one kept open by the
+// daemon. The caller must close it.
+func (s *apiServer) openStore() (storage.Store, error) {
+	if s.warm != nil {
+		return s.warm.acquire(s.dir)
+	}
+	return openStore(s.dir)
+}
+
+// httpStatus maps an apiServer error to an HTTP status code
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, errIndexBusy):
+		return http.StatusConflict
+	case errors.Is(err, errIndexUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// writeJSONError writes an error as a JSON response
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func init() {
+	serveCmd.Flags().IntVar(&servePort, "port", 7777, "Port to listen on")
+	serveCmd.Flags().IntVar(&serveGRPCPort, "grpc-port", 0, "Also serve the gRPC API on this port (0 to disable)")
+	serveCmd.Flags().StringVar(&serveHost, "host", "127.0.0.1", "Address to bind (use 0.0.0.0 to expose beyond localhost)")
+	rootCmd.AddCommand(serveCmd)
+}
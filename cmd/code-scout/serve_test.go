@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+)
+
+// memoryStore is a storage.Store serving fixed rows, for testing handlers without LanceDB
+type memoryStore struct {
+	rows        []map[string]interface{}
+	identifiers *storage.IdentifierIndex
+	// chunkLookups counts GetChunks calls
+	chunkLookups int
+}
+
+func (m *memoryStore) LoadMetadata() (*storage.IndexMetadata, error) {
+	return &storage.IndexMetadata{SchemaVersion: storage.CurrentSchemaVersion}, nil
+}
+func (m *memoryStore) SaveMetadata(*storage.IndexMetadata) error        { return nil }
+func (m *memoryStore) Migrate(*storage.IndexMetadata) ([]string, error) { return nil, nil }
+func (m *memoryStore) OpenTable() error                                 { return nil }
+func (m *memoryStore) UpdateFilePath(string, string) error              { return nil }
+func (m *memoryStore) ListFilePaths() ([]string, error)                 { return nil, nil }
+func (m *memoryStore) CreateTextIndex() error                           { return nil }
+func (m *memoryStore) Close() error                                     { return nil }
+func (m *memoryStore) LoadIdentifiers() (*storage.IdentifierIndex, error) {
+	return m.identifiers, nil
+}
+func (m *memoryStore) SaveIdentifiers(idx *storage.IdentifierIndex) error {
+	m.identifiers = idx
+	return nil
+}
+func (m *memoryStore) FullTextSearch(string, int, string, storage.SearchFilter) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (m *memoryStore) Search(embeddingType string, queryVector []float64, limit int, filter storage.SearchFilter) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+	for _, row := range m.rows {
+		if row["embedding_type"] == embeddingType && len(results) < limit {
+			results = append(results, row)
+		}
+	}
+	return results, nil
+}
+
+func (m *memoryStore) GetChunk(chunkID string) (map[string]interface{}, error) {
+	for _, row := range m.rows {
+		if row["chunk_id"] == chunkID {
+			return row, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) GetChunks(chunkIDs []string) (map[string]map[string]interface{}, error) {
+	m.chunkLookups++
+	found := make(map[string]map[string]interface{})
+	for _, id := range chunkIDs {
+		if row, _ := m.GetChunk(id); row != nil {
+			found[id] = row
+		}
+	}
+	return found, nil
+}
+
+// StoreChunks adds chunks as rows with the columns search reads
+func (m *memoryStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float64) error {
+	for _, chunk := range chunks {
+		metadata, _ := json.Marshal(chunk.Metadata)
+		m.rows = append(m.rows, map[string]interface{}{
+			"chunk_id": chunk.ID, "file_path": chunk.FilePath, "line_start": float64(chunk.LineStart),
+			"line_end": float64(chunk.LineEnd), "language": chunk.Language, "code": chunk.Code,
+			"chunk_type": chunk.ChunkType, "name": chunk.Name, "embedding_type": chunk.EmbeddingType,
+			"metadata": string(metadata),
+		})
+	}
+	return nil
+}
+
+// ReplaceChunks drops the rows of filePaths, then adds chunks
+func (m *memoryStore) ReplaceChunks(filePaths []string, chunks []chunker.Chunk, embeddings [][]float64) error {
+	if err := m.DeleteChunksByFilePath(filePaths); err != nil {
+		return err
+	}
+	return m.StoreChunks(chunks, embeddings)
+}
+
+// DeleteChunksByFilePath drops the rows of filePaths
+func (m *memoryStore) DeleteChunksByFilePath(filePaths []string) error {
+	deleted := make(map[string]bool)
+	for _, path := range filePaths {
+		deleted[path] = true
+	}
+	kept := m.rows[:0]
+	for _, row := range m.rows {
+		if path, _ := row["file_path"].(string); !deleted[path] {
+			kept = append(kept, row)
+		}
+	}
+	m.rows = kept
+	return nil
+}
+
+func (m *memoryStore) FileChunks(filePath string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	for _, row := range m.rows {
+		if row["file_path"] == filePath {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (m *memoryStore) DeleteChunks(chunkIDs []string) error {
+	deleted := make(map[string]bool)
+	for _, id := range chunkIDs {
+		deleted[id] = true
+	}
+	kept := m.rows[:0]
+	for _, row := range m.rows {
+		if id, _ := row["chunk_id"].(string); !deleted[id] {
+			kept = append(kept, row)
+		}
+	}
+	m.rows = kept
+	return nil
+}
+
+func newTestAPIServer(t *testing.T) *apiServer {
+	t.Helper()
+	installFakeEmbeddings(t)
+
+	store := &memoryStore{rows: []map[string]interface{}{
+		{"chunk_id": "c1", "file_path": "/repo/main.go", "line_start": float64(3), "line_end": float64(5),
+			"language": "go", "code": "func Add() {}", "embedding_type": "code", "name": "Add", "_distance": 0.5},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	return newAPIServer(t.TempDir())
+}
+
+func TestAPIServer_Search(t *testing.T) {
+	handler := newTestAPIServer(t).routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=add&mode=code&limit=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp searchapi.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.SchemaVersion != searchapi.SchemaVersion || resp.Mode != "code" {
+		t.Errorf("unexpected response header fields: %+v", resp)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Name != "Add" || resp.Results[0].LineStart != 3 {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+}
+
+func TestAPIServer_BadRequests(t *testing.T) {
+	handler := newTestAPIServer(t).routes()
+
+	for _, path := range []string{"/search", "/search?q=x&mode=fuzzy", "/search?q=x&limit=0"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestAPIServer_Chunk(t *testing.T) {
+	handler := newTestAPIServer(t).routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chunks/c1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result SearchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse chunk: %v", err)
+	}
+	if result.ChunkID != "c1" || result.FilePath != "/repo/main.go" {
+		t.Errorf("unexpected chunk: %+v", result)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chunks/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown chunk, got %d", rec.Code)
+	}
+}
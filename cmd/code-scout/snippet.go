@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSnippetLineLength caps each line of a snippet so minified or generated
+// code doesn't flood the terminal
+const maxSnippetLineLength = 160
+
+// snippetOptions controls how much of a chunk human-readable output shows
+type snippetOptions struct {
+	Lines int    // Lines of code to show (ignored with Full)
+	Full  bool   // Show the whole chunk
+	Color bool   // Syntax highlight the code
+	Query string // Used to pick the most relevant lines
+}
+
+// renderSnippet formats part of a result's code with line numbers, each line
+// prefixed by indent. Unless opts.Full is set, it shows the opts.Lines-line
+// window that best matches the query, marking omitted lines with "...".
+func renderSnippet(result SearchResult, opts snippetOptions, indent string) string {
+	lines := strings.Split(strings.TrimRight(result.Code, "\n"), "\n")
+	start, end := 0, len(lines)
+	if !opts.Full && opts.Lines > 0 && opts.Lines < len(lines) {
+		start = bestSnippetWindow(lines, queryTerms(opts.Query), opts.Lines)
+		end = start + opts.Lines
+	}
+
+	window := lines[start:end]
+	if !opts.Full {
+		window = append([]string(nil), window...)
+		for i, line := range window {
+			if len(line) > maxSnippetLineLength {
+				window[i] = line[:maxSnippetLineLength] + "..."
+			}
+		}
+	}
+
+	text := strings.Join(window, "\n")
+	if opts.Color {
+		text = highlightCode(text, result.Language)
+	}
+	rendered := strings.Split(text, "\n")
+	// The highlighter may end with a newline followed by escape codes; keep
+	// them on the last line
+	if len(rendered) > len(window) {
+		rendered[len(window)-1] += strings.Join(rendered[len(window):], "")
+		rendered = rendered[:len(window)]
+	}
+
+	var out strings.Builder
+	if start > 0 {
+		fmt.Fprintf(&out, "%s...\n", indent)
+	}
+	width := len(strconv.Itoa(result.LineStart + end - 1))
+	for i, line := range rendered {
+		fmt.Fprintf(&out, "%s%*d  %s\n", indent, width, result.LineStart+start+i, line)
+	}
+	if end < len(lines) {
+		fmt.Fprintf(&out, "%s...\n", indent)
+	}
+	return out.String()
+}
+
+// bestSnippetWindow returns the first line of the size-line window containing
+// the most query term matches, centered on the best-matching line when several
+// windows tie. Without any matches, the window starts at the first non-blank line.
+func bestSnippetWindow(lines []string, terms []string, size int) int {
+	scores := make([]int, len(lines))
+	peak := 0
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				scores[i]++
+			}
+		}
+		if scores[i] > scores[peak] {
+			peak = i
+		}
+	}
+
+	best, bestScore, bestDistance := -1, 0, 0
+	windowScore := 0
+	for i := range lines {
+		windowScore += scores[i]
+		if i >= size {
+			windowScore -= scores[i-size]
+		}
+		if i < size-1 {
+			continue
+		}
+		start := i - size + 1
+		// Twice the distance from the window's center to the peak, to stay in integers
+		distance := 2*start + size - 1 - 2*peak
+		if distance < 0 {
+			distance = -distance
+		}
+		if windowScore > bestScore || (windowScore == bestScore && windowScore > 0 && distance < bestDistance) {
+			best, bestScore, bestDistance = start, windowScore, distance
+		}
+	}
+	if best >= 0 {
+		return best
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return min(i, len(lines)-size)
+		}
+	}
+	return 0
+}
+
+// queryTerms splits a query into lowercase words for matching against code
+func queryTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(word) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBestSnippetWindow(t *testing.T) {
+	lines := []string{
+		"",
+		"func handle(w http.ResponseWriter) {",
+		"\tctx := context.Background()",
+		"\ttoken, err := parseAuthToken(r)",
+		"\tif err != nil { return authError(err) }",
+		"\twrite(w)",
+		"}",
+	}
+
+	if got := bestSnippetWindow(lines, queryTerms("auth token"), 2); got != 3 {
+		t.Errorf("expected window at the auth lines (3), got %d", got)
+	}
+	if got := bestSnippetWindow(lines, queryTerms("database"), 2); got != 1 {
+		t.Errorf("expected window at the first non-blank line without matches, got %d", got)
+	}
+	if got := bestSnippetWindow(lines, nil, 7); got != 0 {
+		t.Errorf("expected window covering all lines to start at 0, got %d", got)
+	}
+}
+
+func TestRenderSnippet(t *testing.T) {
+	var code []string
+	for i := 1; i <= 10; i++ {
+		code = append(code, fmt.Sprintf("line%d", i))
+	}
+	code[6] = "validateToken()"
+	result := SearchResult{Code: strings.Join(code, "\n"), LineStart: 20}
+
+	snippet := renderSnippet(result, snippetOptions{Lines: 3, Query: "validate token"}, "  ")
+	expected := "  ...\n  25  line6\n  26  validateToken()\n  27  line8\n  ...\n"
+	if snippet != expected {
+		t.Errorf("unexpected snippet:\n%s\nexpected:\n%s", snippet, expected)
+	}
+
+	full := renderSnippet(result, snippetOptions{Lines: 2, Full: true}, "")
+	if strings.Contains(full, "...") || strings.Count(full, "\n") != 10 {
+		t.Errorf("expected all 10 lines with --full, got:\n%s", full)
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	got := queryTerms("How does Auth-token validation work? a auth")
+	expected := []string{"how", "does", "auth", "token", "validation", "work"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("queryTerms = %v, expected %v", got, expected)
+	}
+}
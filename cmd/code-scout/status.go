@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/gitinfo"
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"github.com/spf13/cobra"
+)
+
+var statusJSON bool
+
+// indexState describes how fresh the index is relative to the working tree
+type indexState = searchapi.IndexState
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show index freshness relative to the working tree",
+	Long: `Show when the index was last built, which git commit it was built from,
+and whether the working tree has changed since.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+
+		state := loadIndexState(metadata, cwd)
+		state.Project = currentProject(cwd)
+
+		if statusJSON {
+			jsonBytes, err := json.MarshalIndent(state, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+
+		if state.LastIndexTime.IsZero() {
+			fmt.Println("No index found. Run 'code-scout index' to create one.")
+			return nil
+		}
+
+		if state.Project != "" {
+			fmt.Printf("Project: %s (global index)\n", state.Project)
+		}
+		fmt.Printf("Last indexed: %s\n", state.LastIndexTime.Format(time.RFC3339))
+		fmt.Printf("Files indexed: %d\n", state.FilesIndexed)
+		for _, embeddingType := range storage.EmbeddingTypes {
+			if model, ok := state.EmbeddingModels[embeddingType]; ok {
+				fmt.Printf("Model (%s): %s (%d dims)\n", embeddingType, model.Model, model.Dimension)
+			}
+		}
+		if state.GitCommit == "" {
+			fmt.Println("Git commit: (not a git repository)")
+			return nil
+		}
+		fmt.Printf("Git commit: %s\n", describeCommit(state.GitCommit, state.GitDirty))
+		if state.CurrentCommit != "" {
+			fmt.Printf("Current HEAD: %s\n", describeCommit(state.CurrentCommit, state.CurrentDirty))
+		}
+		if state.Stale {
+			fmt.Println("Index is stale: HEAD has moved since the last index. Run 'code-scout index' to update.")
+		} else if state.CurrentDirty {
+			fmt.Println("Index matches HEAD, but the working tree has uncommitted changes.")
+		} else {
+			fmt.Println("Index is up to date with HEAD.")
+		}
+
+		return nil
+	},
+}
+
+// loadIndexState compares stored index metadata with the current git state of dir
+func loadIndexState(metadata *storage.IndexMetadata, dir string) indexState {
+	state := indexState{
+		LastIndexTime: metadata.LastIndexTime,
+		FilesIndexed:  len(metadata.FileModTimes),
+		GitCommit:     metadata.GitCommit,
+		GitDirty:      metadata.GitDirty,
+	}
+	if len(metadata.EmbeddingModels) > 0 {
+		state.EmbeddingModels = make(map[string]searchapi.EmbeddingModel, len(metadata.EmbeddingModels))
+		for embeddingType, model := range metadata.EmbeddingModels {
+			state.EmbeddingModels[embeddingType] = searchapi.EmbeddingModel{Model: model.Model, Dimension: model.Dimension}
+		}
+	}
+
+	if info, err := gitinfo.Inspect(dir); err == nil && info != nil {
+		state.CurrentCommit = info.Commit
+		state.CurrentDirty = info.Dirty
+		state.Stale = state.GitCommit != "" && state.GitCommit != info.Commit
+	}
+
+	return state
+}
+
+// describeCommit formats a commit hash with an optional dirty marker
+func describeCommit(commit string, dirty bool) string {
+	info := gitinfo.Info{Commit: commit}
+	if dirty {
+		return info.ShortCommit() + " (dirty)"
+	}
+	return info.ShortCommit()
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output status as JSON")
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jlanders/code-scout/internal/storage"
+)
+
+// openStore opens the vector store backend configured for the project in dir.
+// File paths are stored relative to dir, except in the global index, which
+// spans projects.
+var openStore = func(dir string) (storage.Store, error) {
+	if globalConfig != nil && globalConfig.Backend == "qdrant" {
+		store, err := storage.NewQdrantStore(dir, globalConfig.QdrantURL, globalConfig.QdrantAPIKey, globalConfig.QdrantCollection)
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewRootedStore(store, dir), nil
+	}
+
+	store, err := newLanceDBStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	if globalConfig != nil && globalConfig.GlobalIndex {
+		return store, nil
+	}
+	return storage.NewRootedStore(store, dir), nil
+}
+
+// openShadowStore opens the project's shadow index, which is always a local
+// LanceDB store in the project's index directory
+var openShadowStore = func(dir string) (storage.Store, error) {
+	store, err := storage.NewShadowLanceDBStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewRootedStore(store, dir), nil
+}
+
+// openLanceDBStore opens the project's LanceDB store for commands that only the
+// LanceDB backend supports
+func openLanceDBStore(dir, command string) (*storage.LanceDBStore, error) {
+	if globalConfig != nil && globalConfig.Backend == "qdrant" {
+		return nil, fmt.Errorf("%s is only supported by the lancedb backend", command)
+	}
+	return newLanceDBStore(dir)
+}
+
+// newLanceDBStore opens the LanceDB store at the configured lancedb_uri, in the
+// global index when global_index is set, or in the project directory otherwise
+func newLanceDBStore(dir string) (*storage.LanceDBStore, error) {
+	if globalConfig != nil && globalConfig.GlobalIndex {
+		return storage.NewGlobalLanceDBStore(projectName(dir))
+	}
+	if globalConfig == nil || globalConfig.LanceDBURI == "" {
+		return storage.NewLanceDBStore(dir)
+	}
+
+	storageOptions, err := storage.ObjectStoreOptions(globalConfig.LanceDBURI, globalConfig.StorageOptions)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewRemoteLanceDBStore(globalConfig.LanceDBURI, storageOptions)
+}
+
+// projectName returns the name of the project in dir within the global index
+func projectName(dir string) string {
+	configured := ""
+	if globalConfig != nil {
+		configured = globalConfig.Project
+	}
+	return storage.ProjectName(dir, configured)
+}
+
+// currentProject returns the project that searches from dir are scoped to: its
+// name when the project uses the global index, or "" for a per-project index
+func currentProject(dir string) string {
+	if globalConfig != nil && globalConfig.GlobalIndex {
+		return projectName(dir)
+	}
+	return ""
+}
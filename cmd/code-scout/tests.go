@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testsJSON  bool
+	testsLimit int
+)
+
+// testsFetchFactor is how many keyword matches are fetched per requested
+// test, since tests named after the symbol are picked out of them
+const testsFetchFactor = 5
+
+var testsCmd = &cobra.Command{
+	Use:   "tests <symbol>",
+	Short: "Find the tests for a function, type, or module",
+	Long: `Find test chunks that exercise a symbol. Tests named after it by their
+language's convention (TestFoo and TestFoo_case for Foo in Go, test_foo for foo
+in Python, foo.test.js for foo) come first, followed by other tests that mention
+it. Like grep, tests uses the full-text index and needs no embedding service.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		symbol := args[0]
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		if err := store.OpenTable(); err != nil {
+			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
+		}
+
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
+			return err
+		}
+
+		filter := storage.SearchFilter{Project: currentProject(cwd), OnlyTests: true}
+		rawResults, err := store.FullTextSearch(symbolName(symbol), testsLimit*testsFetchFactor, "code", filter)
+		if err != nil {
+			return fmt.Errorf("%w (run 'code-scout index' or 'code-scout optimize' to build it)", err)
+		}
+		results := rankTestsFor(formatResults(rawResults), symbol)
+		if len(results) > testsLimit {
+			results = results[:testsLimit]
+		}
+
+		if testsJSON {
+			output := searchapi.GrepResponse{
+				SchemaVersion: searchapi.SchemaVersion,
+				Query:         symbol,
+				Returned:      len(results),
+				Results:       results,
+			}
+			jsonBytes, err := json.MarshalIndent(output, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+
+		fmt.Printf("Found %d tests for: %s\n\n", len(results), symbol)
+		for i, result := range results {
+			fmt.Printf("%d. %s:%d-%d\n", i+1, result.FilePath, result.LineStart, result.LineEnd)
+			if result.Name != "" {
+				fmt.Printf("   Name: %s\n", result.Name)
+			}
+			if testsSymbol(result, symbol) {
+				fmt.Printf("   Tests: %s\n", result.Metadata["test_target"])
+			} else if offset, line, ok := firstMatchingLine(result.Code, symbolName(symbol)); ok {
+				fmt.Printf("   %d: %s\n", result.LineStart+offset, line)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+// symbolName returns the last component of a qualified symbol: "Scanner.Scan" gives "Scan"
+func symbolName(symbol string) string {
+	if i := strings.LastIndexAny(symbol, ".:"); i >= 0 {
+		return symbol[i+1:]
+	}
+	return symbol
+}
+
+// testsSymbol reports whether a test chunk is named after symbol: its
+// test_target is the symbol, or the symbol's last component (case-insensitive)
+func testsSymbol(result SearchResult, symbol string) bool {
+	target := result.Metadata["test_target"]
+	return target != "" && (strings.EqualFold(target, symbol) || strings.EqualFold(target, symbolName(symbol)))
+}
+
+// rankTestsFor moves the tests named after symbol ahead of the tests that
+// only mention it, keeping keyword relevance order within each group
+func rankTestsFor(results []SearchResult, symbol string) []SearchResult {
+	var named, mentioning []SearchResult
+	for _, result := range results {
+		if testsSymbol(result, symbol) {
+			named = append(named, result)
+		} else {
+			mentioning = append(mentioning, result)
+		}
+	}
+	return append(named, mentioning...)
+}
+
+func init() {
+	testsCmd.Flags().BoolVar(&testsJSON, "json", false, "Output results as JSON")
+	testsCmd.Flags().IntVar(&testsLimit, "limit", 10, "Maximum number of tests to return")
+	rootCmd.AddCommand(testsCmd)
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestRankTestsFor(t *testing.T) {
+	results := []SearchResult{
+		{Name: "TestIndex", Metadata: map[string]string{"test_target": "Index"}},
+		{Name: "TestScanCodeFiles_SkipRules", Metadata: map[string]string{"test_target": "ScanCodeFiles"}},
+		{Name: "writeTree"},
+		{Name: "TestScanCodeFiles", Metadata: map[string]string{"test_target": "ScanCodeFiles"}},
+	}
+
+	ranked := rankTestsFor(results, "Scanner.ScanCodeFiles")
+	want := []string{"TestScanCodeFiles_SkipRules", "TestScanCodeFiles", "TestIndex", "writeTree"}
+	for i, name := range want {
+		if ranked[i].Name != name {
+			t.Errorf("ranked[%d] = %s, want %s", i, ranked[i].Name, name)
+		}
+	}
+}
+
+func TestSymbolName(t *testing.T) {
+	for symbol, want := range map[string]string{
+		"ScanCodeFiles":         "ScanCodeFiles",
+		"Scanner.ScanCodeFiles": "ScanCodeFiles",
+		"Config::load":          "load",
+	} {
+		if got := symbolName(symbol); got != want {
+			t.Errorf("symbolName(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+}
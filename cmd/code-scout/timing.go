@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jlanders/code-scout/pkg/searchapi"
+)
+
+// searchPhase is a part of a search that's timed separately
+type searchPhase int
+
+const (
+	phaseEmbedding     searchPhase = iota // Embedding the query
+	phaseVectorSearch                     // Nearest neighbor lookups in the store
+	phaseKeywordSearch                    // Full-text and identifier lookups
+	searchPhases
+)
+
+// phaseSpans names the span traced for each phase
+var phaseSpans = [searchPhases]string{
+	phaseEmbedding:     "search.embed_query",
+	phaseVectorSearch:  "store.search",
+	phaseKeywordSearch: "store.keyword_search",
+}
+
+// searchTimer accumulates the time a search spends in each phase
+type searchTimer struct {
+	phases [searchPhases]time.Duration
+}
+
+type searchTimerKey struct{}
+
+// withSearchTimer returns a context whose searches are timed by timer
+func withSearchTimer(ctx context.Context, timer *searchTimer) context.Context {
+	return context.WithValue(ctx, searchTimerKey{}, timer)
+}
+
+// timePhase starts timing and tracing a phase of the search timed by ctx.
+// Calling the returned function adds the time since to the phase.
+func timePhase(ctx context.Context, phase searchPhase) func() {
+	_, span := startSpan(ctx, phaseSpans[phase])
+	timer, ok := ctx.Value(searchTimerKey{}).(*searchTimer)
+	if !ok {
+		return func() { span.End() }
+	}
+	start := time.Now()
+	return func() {
+		timer.phases[phase] += time.Since(start)
+		span.End()
+	}
+}
+
+// report returns the timing of a search that took total. Whatever isn't
+// spent in a timed phase is reranking: fusing, filtering, boosting and
+// diversifying the results.
+func (t *searchTimer) report(total time.Duration) searchapi.Timing {
+	rerank := total
+	for _, spent := range t.phases {
+		rerank -= spent
+	}
+	return searchapi.Timing{
+		EmbeddingMS:     milliseconds(t.phases[phaseEmbedding]),
+		VectorSearchMS:  milliseconds(t.phases[phaseVectorSearch]),
+		KeywordSearchMS: milliseconds(t.phases[phaseKeywordSearch]),
+		RerankMS:        milliseconds(max(rerank, 0)),
+		TotalMS:         milliseconds(total),
+	}
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// describeTiming formats a search's timing for text output
+func describeTiming(t searchapi.Timing) string {
+	return fmt.Sprintf("Timing: embedding %.1fms, vector search %.1fms, keyword search %.1fms, rerank %.1fms, total %.1fms",
+		t.EmbeddingMS, t.VectorSearchMS, t.KeywordSearchMS, t.RerankMS, t.TotalMS)
+}
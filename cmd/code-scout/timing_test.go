@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSearchTimer(t *testing.T) {
+	timer := &searchTimer{}
+	timer.phases[phaseEmbedding] = 120 * time.Millisecond
+	timer.phases[phaseVectorSearch] = 8 * time.Millisecond
+	timer.phases[phaseKeywordSearch] = 2 * time.Millisecond
+
+	report := timer.report(131 * time.Millisecond)
+	if report.EmbeddingMS != 120 || report.VectorSearchMS != 8 || report.KeywordSearchMS != 2 || report.RerankMS != 1 || report.TotalMS != 131 {
+		t.Errorf("unexpected timing: %+v", report)
+	}
+	want := "Timing: embedding 120.0ms, vector search 8.0ms, keyword search 2.0ms, rerank 1.0ms, total 131.0ms"
+	if got := describeTiming(report); got != want {
+		t.Errorf("describeTiming() = %q, want %q", got, want)
+	}
+
+	// Phases outside a timed search are ignored
+	timePhase(context.Background(), phaseEmbedding)()
+}
+
+func TestExecuteSearch_ReportsTiming(t *testing.T) {
+	api := newTestAPIServer(t)
+	store, metadata, err := api.openIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	page, err := executeSearch(context.Background(), store, metadata, searchOptions{Query: "add", Mode: modeCode, Limit: 5})
+	if err != nil {
+		t.Fatalf("executeSearch failed: %v", err)
+	}
+	timing := page.Timing
+	if timing.TotalMS <= 0 || timing.EmbeddingMS+timing.VectorSearchMS+timing.KeywordSearchMS+timing.RerankMS > timing.TotalMS+0.01 {
+		t.Errorf("expected phases adding up to a positive total, got %+v", timing)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"github.com/jlanders/code-scout/pkg/searchapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	todosJSON  bool
+	todosLimit int
+)
+
+// todoChunkType is the chunk type of extracted TODO, FIXME and HACK comments
+const todoChunkType = "todo"
+
+// todoMarkers are the comment markers extracted as todo chunks
+var todoMarkers = []string{"TODO", "FIXME", "HACK"}
+
+var todosCmd = &cobra.Command{
+	Use:   "todos [query]",
+	Short: "List or search TODO, FIXME and HACK comments",
+	Long: `Search the TODO, FIXME and HACK comments extracted during indexing. With a
+query, work items are ranked by semantic similarity to it ("todos retry logic");
+without one, they are listed by file and line using the full-text index.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		store, err := openStore(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer store.Close()
+
+		if err := store.OpenTable(); err != nil {
+			return fmt.Errorf("failed to open table: %w (have you run 'code-scout index' first?)", err)
+		}
+
+		metadata, err := store.LoadMetadata()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", err)
+		}
+		if err := storage.CheckSchemaVersion(metadata); err != nil {
+			return err
+		}
+
+		filter := storage.SearchFilter{ChunkType: todoChunkType, Project: currentProject(cwd)}
+		var query string
+		var results []SearchResult
+		if len(args) == 1 {
+			query = args[0]
+			page, err := executeSearch(cmd.Context(), store, metadata, searchOptions{
+				Query:  query,
+				Mode:   modeDocs,
+				Limit:  todosLimit,
+				Filter: filter,
+			})
+			if err != nil {
+				return err
+			}
+			results = page.Results
+		} else {
+			results, err = listTodos(store, todosLimit, filter)
+			if err != nil {
+				return err
+			}
+		}
+
+		if todosJSON {
+			output := searchapi.GrepResponse{
+				SchemaVersion: searchapi.SchemaVersion,
+				Query:         query,
+				Returned:      len(results),
+				Results:       results,
+			}
+			jsonBytes, err := json.MarshalIndent(output, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(jsonBytes))
+			return nil
+		}
+
+		fmt.Printf("Found %d work items\n\n", len(results))
+		for i, result := range results {
+			fmt.Printf("%d. %s:%d\n", i+1, displayPath(result.FilePath, cwd), result.LineStart)
+			fmt.Printf("   %s\n", result.Code)
+			if owner := result.Metadata["owner"]; owner != "" {
+				fmt.Printf("   Owner: %s\n", owner)
+			}
+			if function := result.Metadata["function"]; function != "" {
+				fmt.Printf("   In: %s\n", function)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+// listTodos returns up to limit todo chunks matching filter, ordered by file and line
+func listTodos(store storage.Store, limit int, filter storage.SearchFilter) ([]SearchResult, error) {
+	seen := make(map[string]bool)
+	var results []SearchResult
+	for _, marker := range todoMarkers {
+		rawResults, err := store.FullTextSearch(marker, limit, "docs", filter)
+		if err != nil {
+			return nil, fmt.Errorf("%w (run 'code-scout index' or 'code-scout optimize' to build it)", err)
+		}
+		for _, result := range formatResults(rawResults) {
+			// A doc comment mentioning a marker isn't a work item
+			if !seen[result.ChunkID] && !isDescribingRow(result) {
+				seen[result.ChunkID] = true
+				results = append(results, result)
+			}
+		}
+	}
+
+	sortByLocation(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// sortByLocation orders results by file path, then starting line
+func sortByLocation(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].FilePath != results[j].FilePath {
+			return results[i].FilePath < results[j].FilePath
+		}
+		return results[i].LineStart < results[j].LineStart
+	})
+}
+
+func init() {
+	todosCmd.Flags().BoolVar(&todosJSON, "json", false, "Output results as JSON")
+	todosCmd.Flags().IntVar(&todosLimit, "limit", 20, "Maximum number of work items to return")
+	rootCmd.AddCommand(todosCmd)
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestSortByLocation(t *testing.T) {
+	results := []SearchResult{
+		{FilePath: "b.go", LineStart: 3},
+		{FilePath: "a.go", LineStart: 40},
+		{FilePath: "a.go", LineStart: 7},
+	}
+
+	sortByLocation(results)
+	want := []struct {
+		path string
+		line int
+	}{{"a.go", 7}, {"a.go", 40}, {"b.go", 3}}
+	for i, w := range want {
+		if results[i].FilePath != w.path || results[i].LineStart != w.line {
+			t.Errorf("results[%d] = %s:%d, want %s:%d", i, results[i].FilePath, results[i].LineStart, w.path, w.line)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName names the instrumentation in exported spans
+	tracerName = "github.com/jlanders/code-scout"
+	// tracingShutdownTimeout bounds flushing spans when a command exits
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// finishTracing ends the command's span and flushes spans to the collector.
+// It's replaced by startTracing when tracing is configured.
+var finishTracing = func(err error) {}
+
+// startTracing exports spans to the OTLP collector configured by cfg and
+// starts a span for the command named name. The returned context carries the
+// span, so everything the command traces is part of it.
+func startTracing(ctx context.Context, cfg *config.Tracing, name string) (context.Context, error) {
+	var options []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	// Creating the exporter doesn't connect, so an unreachable collector only
+	// loses spans; it never fails the command
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "code-scout"))),
+	)
+	otel.SetTracerProvider(provider)
+
+	ctx, span := startSpan(ctx, name)
+	finishTracing = func(err error) {
+		endSpan(span, err)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		provider.Shutdown(shutdownCtx)
+	}
+	return ctx, nil
+}
+
+// startSpan starts a span as a child of any span in ctx. Without tracing
+// configured, it's a no-op.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed if err is non-nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceStore runs a storage operation in its own span
+func traceStore(ctx context.Context, operation string, fn func() error) error {
+	_, span := startSpan(ctx, "store."+operation)
+	err := fn()
+	endSpan(span, err)
+	return err
+}
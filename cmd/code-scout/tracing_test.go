@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/storage"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRunIndex_TracesEachStage(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	installFakeEmbeddings(t)
+	workDir := t.TempDir()
+	writeTestFile(t, workDir, "main.go", "package main\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	writeTestFile(t, workDir, "README.md", "# Docs\n\nHow to add numbers.\n")
+
+	store := &persistentStore{metadata: &storage.IndexMetadata{
+		FileModTimes: map[string]time.Time{},
+		FileHashes:   map[string]string{},
+	}}
+	prevOpen := openStore
+	openStore = func(dir string) (storage.Store, error) { return store, nil }
+	t.Cleanup(func() { openStore = prevOpen })
+
+	captureStdout(t, func() {
+		if _, err := runIndex(context.Background(), workDir); err != nil {
+			t.Fatalf("index failed: %v", err)
+		}
+	})
+
+	counts := make(map[string]int)
+	var root sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		counts[span.Name()]++
+		if span.Name() == "index" {
+			root = span
+		}
+	}
+	for _, name := range []string{"index", "scan", "batch", "store.replace_chunks", "store.create_text_index"} {
+		if counts[name] != 1 {
+			t.Errorf("expected one %q span, got %d", name, counts[name])
+		}
+	}
+	if counts["chunk.file"] != 2 {
+		t.Errorf("expected a chunk.file span per file, got %d", counts["chunk.file"])
+	}
+	if counts["embed"] != 2 || counts["embed.batch"] < 2 {
+		t.Errorf("expected an embed span per model with batches under it, got %d embed and %d embed.batch", counts["embed"], counts["embed.batch"])
+	}
+	if root == nil {
+		t.Fatal("missing index span")
+	}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("expected %q to be part of the index trace", span.Name())
+		}
+	}
+}
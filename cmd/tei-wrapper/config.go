@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// modelConfig is the --config file: friendly model names, per-model TEI
+// arguments and GPU devices. It is YAML, so JSON files work too.
+//
+//	models:
+//	  code-scout-code:
+//	    model: nomic-ai/nomic-embed-code
+//	    args: ["--dtype", "float16", "--max-batch-tokens", "4096"]
+//	    devices: "1"
+//	  BAAI/bge-reranker-base:
+//	    args: ["--max-batch-tokens", "8192"]
+type modelConfig struct {
+	Models map[string]modelEntry `yaml:"models"`
+}
+
+// modelEntry configures one model name. Model is the HuggingFace model ID the
+// name is an alias for; if empty, the name is itself the model ID.
+type modelEntry struct {
+	Model   string   `yaml:"model"`
+	Args    []string `yaml:"args"`    // TEI arguments for this model, overriding the global tuning flags
+	Devices string   `yaml:"devices"` // CUDA_VISIBLE_DEVICES for this model's TEI process
+}
+
+// loadModelConfig reads a model configuration file
+func loadModelConfig(path string) (*modelConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config modelConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	for name := range config.Models {
+		if name == "" {
+			return nil, fmt.Errorf("config %s has a model with an empty name", path)
+		}
+	}
+	return &config, nil
+}
+
+// apply adds the configured aliases, per-model arguments and devices to the server.
+// Aliases already set, from --alias flags, take precedence over the file.
+func (c *modelConfig) apply(s *Server) {
+	for name, entry := range c.Models {
+		model := name
+		if entry.Model != "" && entry.Model != name {
+			model = entry.Model
+			if _, ok := s.aliases[name]; !ok {
+				s.aliases[name] = model
+			}
+		}
+		if len(entry.Args) > 0 {
+			s.modelArgs[model] = entry.Args
+		}
+		if entry.Devices != "" {
+			s.modelDevices[model] = entry.Devices
+		}
+	}
+}
+
+// mergeArgs returns base with override appended, dropping each flag in base
+// that override sets again, since TEI rejects repeated flags
+func mergeArgs(base, override []string) []string {
+	overridden := make(map[string]bool)
+	for _, arg := range override {
+		if name, ok := flagName(arg); ok {
+			overridden[name] = true
+		}
+	}
+
+	merged := make([]string, 0, len(base)+len(override))
+	skipping := false
+	for _, arg := range base {
+		if name, ok := flagName(arg); ok {
+			skipping = overridden[name]
+		}
+		if !skipping {
+			merged = append(merged, arg)
+		}
+	}
+	return append(merged, override...)
+}
+
+// flagName returns the name of a --flag or --flag=value argument
+func flagName(arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+		return "", false
+	}
+	name, _, _ := strings.Cut(arg[2:], "=")
+	return name, true
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadModelConfig(t *testing.T) {
+	yamlPath := writeConfig(t, "models.yaml", `
+models:
+  code-scout-code:
+    model: nomic-ai/nomic-embed-code
+    args: ["--dtype", "float16", "--max-batch-tokens", "4096"]
+  BAAI/bge-reranker-base:
+    args: ["--max-batch-tokens=8192"]
+`)
+	jsonPath := writeConfig(t, "models.json", `{
+  "models": {
+    "code-scout-code": {"model": "nomic-ai/nomic-embed-code", "args": ["--dtype", "float16", "--max-batch-tokens", "4096"]},
+    "BAAI/bge-reranker-base": {"args": ["--max-batch-tokens=8192"]}
+  }
+}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		config, err := loadModelConfig(path)
+		if err != nil {
+			t.Fatalf("%s: load failed: %v", path, err)
+		}
+
+		server := newServer(8080, "text-embeddings-router", "model-a")
+		server.aliases["code-scout-text"] = "nomic-ai/nomic-embed-text-v1.5"
+		server.teiArgs = []string{"--max-batch-tokens", "16384", "--huggingface-hub-cache", "/models"}
+		config.apply(server)
+
+		if server.resolveModel("code-scout-code") != "nomic-ai/nomic-embed-code" || server.resolveModel("code-scout-text") != "nomic-ai/nomic-embed-text-v1.5" {
+			t.Errorf("%s: expected file and flag aliases to be merged, got %v", path, server.aliases)
+		}
+		if _, ok := server.aliases["BAAI/bge-reranker-base"]; ok {
+			t.Errorf("%s: expected an entry without a model not to become an alias", path)
+		}
+
+		got := strings.Join(server.teiCommandArgs(&teiProcess{model: "nomic-ai/nomic-embed-code", port: 8081}), " ")
+		want := "--model-id nomic-ai/nomic-embed-code --port 8081 --huggingface-hub-cache /models --dtype float16 --max-batch-tokens 4096"
+		if got != want {
+			t.Errorf("%s: expected args %q, got %q", path, want, got)
+		}
+		got = strings.Join(server.teiCommandArgs(&teiProcess{model: "BAAI/bge-reranker-base", port: 8082}), " ")
+		want = "--model-id BAAI/bge-reranker-base --port 8082 --huggingface-hub-cache /models --max-batch-tokens=8192"
+		if got != want {
+			t.Errorf("%s: expected args %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestModelConfigDevices(t *testing.T) {
+	config, err := loadModelConfig(writeConfig(t, "models.yaml", `
+models:
+  code-scout-code:
+    model: nomic-ai/nomic-embed-code
+    devices: 1
+  code-scout-text:
+    model: nomic-ai/nomic-embed-text-v1.5
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	server := newServer(8080, "", "model-a")
+	server.devices = "0"
+	config.apply(server)
+
+	if got := server.teiDevices("nomic-ai/nomic-embed-code"); got != "1" {
+		t.Errorf("Expected the code model on device 1, got %q", got)
+	}
+	if got := server.teiDevices("nomic-ai/nomic-embed-text-v1.5"); got != "0" {
+		t.Errorf("Expected the text model on the default device 0, got %q", got)
+	}
+
+	server.devices = ""
+	if got := server.teiDevices("other-model"); got != "" {
+		t.Errorf("Expected no device override without --devices, got %q", got)
+	}
+}
+
+func TestDeviceFlag(t *testing.T) {
+	devices := deviceFlag{}
+	if err := devices.Set("code-scout-code=0,1"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if devices["code-scout-code"] != "0,1" {
+		t.Errorf("Unexpected devices: %v", devices)
+	}
+	if err := devices.Set("code-scout-code"); err == nil {
+		t.Error("Expected an error for a value without '='")
+	}
+}
+
+func TestModelConfigFlagAliasPrecedence(t *testing.T) {
+	config, err := loadModelConfig(writeConfig(t, "models.yaml", "models:\n  code:\n    model: from-file\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	server := newServer(8080, "", "model-a")
+	server.aliases["code"] = "from-flag"
+	config.apply(server)
+	if server.resolveModel("code") != "from-flag" {
+		t.Errorf("Expected the --alias flag to override the config file, got %q", server.resolveModel("code"))
+	}
+
+	if _, err := loadModelConfig(writeConfig(t, "bad.yaml", "models: [")); err == nil {
+		t.Error("Expected an error for an invalid config file")
+	}
+}
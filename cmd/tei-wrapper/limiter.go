@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter is a semaphore bounding the requests forwarded to TEI at once.
+// Waiters get slots in arrival order, so a burst from one client can't starve
+// requests that arrived before it.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	inUse   int
+	waiters []chan struct{} // Closed to hand the waiter a slot, oldest first
+}
+
+// newLimiter returns a limiter allowing limit requests at once, or nil (no limit) if limit <= 0
+func newLimiter(limit int) *limiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &limiter{limit: limit}
+}
+
+// acquire waits for a slot, or until ctx is done. A nil limiter never waits.
+func (l *limiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.inUse < l.limit && len(l.waiters) == 0 {
+		l.inUse++
+		l.mu.Unlock()
+		return nil
+	}
+	granted := make(chan struct{})
+	l.waiters = append(l.waiters, granted)
+	l.mu.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, waiter := range l.waiters {
+			if waiter == granted {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// The slot was handed over as ctx finished; pass it on
+		l.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, handing it to the oldest waiter if there is one
+func (l *limiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+func (l *limiter) releaseLocked() {
+	if len(l.waiters) > 0 {
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+		return
+	}
+	l.inUse--
+}
+
+// waiting returns the number of requests waiting for a slot
+func (l *limiter) waiting() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.waiters)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitForWaiters waits until n requests are waiting for a slot
+func waitForWaiters(t *testing.T, l *limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for l.waiting() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d waiter(s), have %d", n, l.waiting())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimiterFairOrder(t *testing.T) {
+	l := newLimiter(1)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	// Queue waiters one at a time so their arrival order is known
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			if err := l.acquire(context.Background()); err == nil {
+				order <- i
+				l.release()
+			}
+		}(i)
+		waitForWaiters(t, l, i+1)
+	}
+
+	l.release()
+	for want := 0; want < 3; want++ {
+		if got := <-order; got != want {
+			t.Errorf("Expected waiter %d to get the slot next, got %d", want, got)
+		}
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := newLimiter(1)
+	l.acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx); err == nil {
+		t.Fatal("Expected acquire to fail once its context is done")
+	}
+	if l.waiting() != 0 {
+		t.Errorf("Expected the cancelled waiter to leave the queue, %d waiting", l.waiting())
+	}
+
+	// The slot is still usable after the cancelled wait
+	l.release()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.acquire(ctx); err != nil {
+		t.Errorf("Expected the released slot to be free, got %v", err)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	var l *limiter = newLimiter(0)
+	for i := 0; i < 100; i++ {
+		if err := l.acquire(context.Background()); err != nil {
+			t.Fatalf("Expected no limit, got %v", err)
+		}
+	}
+	l.release()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestIDHeader carries the request ID; a client-supplied ID is kept so logs can be correlated across services
+const requestIDHeader = "X-Request-ID"
+
+// newLogger creates a logger writing to w in format "text" or "json", at level
+// "debug", "info", "warn" or "error"
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: expected text or json", format)
+	}
+}
+
+// requestInfo is what the request log line reports about a request, filled
+// in by its handler
+type requestInfo struct {
+	id     string
+	model  string
+	inputs int
+}
+
+type requestInfoKey struct{}
+
+// withRequestLogging assigns each request an ID, returned in the X-Request-ID
+// header, and logs the request once it completes with its status, duration,
+// model and input count. Health checks are logged at debug level.
+func withRequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestInfo{id: r.Header.Get(requestIDHeader)}
+		if info.id == "" {
+			info.id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, info.id)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		level := slog.LevelInfo
+		if r.URL.Path == "/health" {
+			level = slog.LevelDebug
+		}
+		slog.Log(r.Context(), level, "request completed",
+			"request_id", info.id,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"model", info.model,
+			"inputs", info.inputs,
+		)
+	})
+}
+
+// annotateRequest records the model and input count for the request log line
+func annotateRequest(ctx context.Context, model string, inputs int) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.model = model
+		info.inputs = inputs
+	}
+}
+
+// requestLogger returns the default logger tagged with the request's ID
+func requestLogger(ctx context.Context) *slog.Logger {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return slog.With("request_id", info.id)
+	}
+	return slog.Default()
+}
+
+// newRequestID returns a random 16-character hex ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logger's JSON output to the returned buffer for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("newLogger failed: %v", err)
+	}
+	prev := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// logEntries parses JSON log lines, keeping those with the given message
+func logEntries(t *testing.T, buf *bytes.Buffer, msg string) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected a JSON log line, got %q", line)
+		}
+		if entry["msg"] == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestRequestLogging(t *testing.T) {
+	logs := captureLogs(t)
+	mockTEI := createMockTEI(t)
+	defer mockTEI.Close()
+
+	server := newTestServer(mockTEI.URL, "test-model")
+	server.aliases["code"] = "test-model"
+	testServer := httptest.NewServer(withRequestLogging(http.HandlerFunc(server.handleEmbeddings)))
+	defer testServer.Close()
+
+	bodyBytes, _ := json.Marshal(EmbeddingRequest{Model: "code", Input: []string{"a", "b", "c"}})
+	req, _ := http.NewRequest(http.MethodPost, testServer.URL, bytes.NewReader(bodyBytes))
+	req.Header.Set(requestIDHeader, "client-id-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get(requestIDHeader) != "client-id-1" {
+		t.Errorf("Expected the client's request ID to be echoed, got %q", resp.Header.Get(requestIDHeader))
+	}
+
+	entries := logEntries(t, logs, "request completed")
+	if len(entries) != 1 {
+		t.Fatalf("Expected one request log entry, got %d: %s", len(entries), logs)
+	}
+	entry := entries[0]
+	if entry["request_id"] != "client-id-1" || entry["model"] != "test-model" || entry["inputs"] != float64(3) || entry["status"] != float64(200) {
+		t.Errorf("Unexpected request log entry: %v", entry)
+	}
+	if _, ok := entry["duration_ms"]; !ok {
+		t.Errorf("Expected a duration in the request log entry: %v", entry)
+	}
+
+	// Requests without an ID get a generated one
+	resp, err = http.Post(testServer.URL, "application/json", bytes.NewReader(bodyBytes))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if id := resp.Header.Get(requestIDHeader); len(id) != 16 {
+		t.Errorf("Expected a generated 16-character request ID, got %q", id)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		if _, err := newLogger(&bytes.Buffer{}, format, "warn"); err != nil {
+			t.Errorf("Expected format %s to be valid, got %v", format, err)
+		}
+	}
+	if _, err := newLogger(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("Expected an error for an unknown log format")
+	}
+	if _, err := newLogger(&bytes.Buffer{}, "json", "loud"); err == nil {
+		t.Error("Expected an error for an unknown log level")
+	}
+}
@@ -4,16 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
 )
 
@@ -25,10 +27,10 @@ type EmbeddingRequest struct {
 
 // OpenAI API response format
 type EmbeddingResponse struct {
-	Object string            `json:"object"`
-	Data   []EmbeddingData   `json:"data"`
-	Model  string            `json:"model"`
-	Usage  EmbeddingUsage    `json:"usage"`
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
 }
 
 type EmbeddingData struct {
@@ -42,6 +44,21 @@ type EmbeddingUsage struct {
 	TotalTokens  int `json:"total_tokens"`
 }
 
+// OpenAI model listing format
+type ModelList struct {
+	Object string      `json:"object"`
+	Data   []ModelInfo `json:"data"`
+}
+
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	// Root is the model an alias resolves to; equal to ID for the loaded model
+	Root string `json:"root"`
+}
+
 // TEI request format (simpler)
 type TEIRequest struct {
 	Inputs []string `json:"inputs"`
@@ -50,17 +67,134 @@ type TEIRequest struct {
 // TEI response format
 type TEIResponse [][]float64
 
+// TEI tokenize request format
+type TEITokenizeRequest struct {
+	Inputs           []string `json:"inputs"`
+	AddSpecialTokens bool     `json:"add_special_tokens"`
+}
+
+// TEI tokenize response format: the tokens of each input
+type TEITokenizeResponse [][]json.RawMessage
+
 // Server manages the TEI wrapper
 type Server struct {
-	teiPort      int
+	teiPort      int // First internal TEI port; each running model gets the next free one
 	teiBinary    string
 	initialModel string
-	currentModel string        // Currently loaded model
-	teiCmd       *exec.Cmd
-	teiBaseURL   string
+	aliases      map[string]string // Friendly model name -> HuggingFace model ID
+	maxModels    int               // Most TEI processes to run at once
+	memoryBudget int64             // Most resident memory for all TEI processes in KB, 0 for no limit
 	client       *http.Client
-	mu           sync.RWMutex  // Protects model switching
-	switching    bool          // True during model switch
+	teiArgs      []string                  // Extra arguments for every TEI process, after --model-id and --port
+	modelArgs    map[string][]string       // HuggingFace model ID -> TEI arguments overriding teiArgs
+	devices      string                    // CUDA_VISIBLE_DEVICES for every TEI process, empty to inherit
+	modelDevices map[string]string         // HuggingFace model ID -> CUDA_VISIBLE_DEVICES overriding devices
+	launch       func(p *teiProcess) error // Starts a TEI process; startTEI if nil
+
+	queueSize    int           // Most requests waiting for a model at once
+	queueTimeout time.Duration // Longest a request waits for a model, or for a concurrency slot
+	concurrency  *limiter      // Bounds requests forwarded to TEI at once; nil for no limit
+
+	restartBackoff    time.Duration // Wait before restarting a crashed TEI process
+	maxRestartBackoff time.Duration // Longest wait between restarts of a crash-looping process
+
+	mu          sync.Mutex             // Protects the fields below and teiProcess bookkeeping
+	processes   map[string]*teiProcess // Model -> TEI process serving it
+	lastModel   string                 // Model of the most recent request, used when a request names none
+	modelMemory map[string]int64       // Last measured memory per model in KB, for planning evictions
+	queued      int                    // Requests waiting for a model
+	crashes     int                    // Unexpected TEI process exits since startup
+	changed     chan struct{}          // Closed and replaced whenever a process becomes ready or idle
+}
+
+// newServer creates a server with an empty process pool
+func newServer(teiPort int, teiBinary, initialModel string) *Server {
+	return &Server{
+		teiPort:      teiPort,
+		teiBinary:    teiBinary,
+		initialModel: initialModel,
+		maxModels:    1,
+		queueSize:    64,
+		queueTimeout: 2 * time.Minute,
+
+		restartBackoff:    time.Second,
+		maxRestartBackoff: time.Minute,
+
+		client: &http.Client{
+			Timeout: 120 * time.Second, // Long timeout for large batches
+		},
+		aliases:      make(map[string]string),
+		modelArgs:    make(map[string][]string),
+		modelDevices: make(map[string]string),
+		processes:    make(map[string]*teiProcess),
+		lastModel:    initialModel,
+		modelMemory:  make(map[string]int64),
+		changed:      make(chan struct{}),
+	}
+}
+
+// aliasFlag collects repeated --alias name=model flags
+type aliasFlag map[string]string
+
+func (a aliasFlag) String() string {
+	pairs := make([]string, 0, len(a))
+	for name, model := range a {
+		pairs = append(pairs, name+"="+model)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (a aliasFlag) Set(value string) error {
+	name, model, ok := strings.Cut(value, "=")
+	if !ok || name == "" || model == "" {
+		return fmt.Errorf("expected name=model, got %q", value)
+	}
+	a[name] = model
+	return nil
+}
+
+// teiOptions are the TEI tuning flags forwarded to text-embeddings-router
+type teiOptions struct {
+	maxBatchTokens        int
+	dtype                 string
+	maxConcurrentRequests int
+	hubCache              string
+	extra                 []string // Arguments after --, passed through verbatim
+}
+
+// args returns the TEI arguments for the options that are set
+func (o teiOptions) args() []string {
+	var args []string
+	if o.maxBatchTokens > 0 {
+		args = append(args, "--max-batch-tokens", strconv.Itoa(o.maxBatchTokens))
+	}
+	if o.dtype != "" {
+		args = append(args, "--dtype", o.dtype)
+	}
+	if o.maxConcurrentRequests > 0 {
+		args = append(args, "--max-concurrent-requests", strconv.Itoa(o.maxConcurrentRequests))
+	}
+	if o.hubCache != "" {
+		args = append(args, "--huggingface-hub-cache", o.hubCache)
+	}
+	return append(args, o.extra...)
+}
+
+// deviceFlag collects repeated --model-devices model=devices flags
+type deviceFlag map[string]string
+
+func (d deviceFlag) String() string {
+	return aliasFlag(d).String()
+}
+
+func (d deviceFlag) Set(value string) error {
+	model, devices, ok := strings.Cut(value, "=")
+	if !ok || model == "" || devices == "" {
+		return fmt.Errorf("expected model=devices, got %q", value)
+	}
+	d[model] = devices
+	return nil
 }
 
 func main() {
@@ -69,164 +203,127 @@ func main() {
 	teiPort := flag.Int("tei-port", 8080, "TEI internal port")
 	teiBinary := flag.String("tei-binary", "text-embeddings-router", "Path to TEI binary")
 	model := flag.String("model", "nomic-ai/nomic-embed-text-v1.5", "Initial model to load")
+	aliases := aliasFlag{}
+	flag.Var(aliases, "alias", "Model alias as name=model (repeatable), e.g. code-scout-code=nomic-ai/nomic-embed-code")
+	maxModels := flag.Int("max-models", 1, "Most models to serve at once, each in its own TEI process (1 switches models by restarting TEI)")
+	queueSize := flag.Int("queue-size", 64, "Most requests to hold while their model loads; more get 503 (0 to never queue)")
+	queueTimeout := flag.Duration("queue-timeout", 2*time.Minute, "Longest a request waits for its model to load or for a concurrency slot")
+	maxConcurrency := flag.Int("max-concurrency", 0, "Most requests forwarded to TEI at once; more wait in arrival order (0 for no limit)")
+	memoryBudget := flag.Int("memory-budget", 0, "Most resident memory for all TEI processes in MB; idle models are stopped to stay within it (0 for no limit)")
+	var tei teiOptions
+	flag.IntVar(&tei.maxBatchTokens, "max-batch-tokens", 16384, "TEI --max-batch-tokens (0 for the TEI default)")
+	flag.StringVar(&tei.dtype, "dtype", "", "TEI --dtype, e.g. float16 or float32 (empty for the TEI default)")
+	flag.IntVar(&tei.maxConcurrentRequests, "max-concurrent-requests", 0, "TEI --max-concurrent-requests (0 for the TEI default)")
+	flag.StringVar(&tei.hubCache, "huggingface-hub-cache", "", "TEI --huggingface-hub-cache directory for downloaded models (empty for the TEI default)")
+	devices := flag.String("devices", "", "CUDA_VISIBLE_DEVICES for every TEI process, e.g. 0 or 0,1 (empty to inherit the environment)")
+	modelDevices := deviceFlag{}
+	flag.Var(modelDevices, "model-devices", "CUDA_VISIBLE_DEVICES for one model as model=devices (repeatable), e.g. code-scout-code=1")
+	configPath := flag.String("config", "", "YAML or JSON file of model aliases and per-model TEI arguments")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Longest to wait for in-flight requests on shutdown before stopping TEI")
+	logFormat := flag.String("log-format", "text", "Log format: text or json")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn or error")
 	flag.Parse()
+	tei.extra = flag.Args()
 
-	// Create server
-	server := &Server{
-		teiPort:      *teiPort,
-		teiBinary:    *teiBinary,
-		initialModel: *model,
-		currentModel: *model,
-		teiBaseURL:   fmt.Sprintf("http://localhost:%d", *teiPort),
-		client: &http.Client{
-			Timeout: 120 * time.Second, // Long timeout for large batches
-		},
+	logger, err := newLogger(os.Stderr, *logFormat, *logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
+	slog.SetDefault(logger)
 
-	// Start TEI process
-	log.Printf("Starting TEI with model: %s", server.initialModel)
-	if err := server.startTEIWithModel(context.Background(), server.initialModel); err != nil {
-		log.Fatalf("Failed to start TEI: %v", err)
+	// Create server
+	server := newServer(*teiPort, *teiBinary, *model)
+	server.aliases = aliases
+	server.teiArgs = tei.args()
+	server.devices = *devices
+	if *configPath != "" {
+		config, err := loadModelConfig(*configPath)
+		if err != nil {
+			slog.Error("Failed to load config", "error", err)
+			os.Exit(1)
+		}
+		config.apply(server)
+	}
+	// Flags override the config file; names may be aliases from either
+	for name, dev := range modelDevices {
+		server.modelDevices[server.resolveModel(name)] = dev
 	}
-	defer server.stopTEI()
+	server.maxModels = *maxModels
+	server.memoryBudget = int64(*memoryBudget) * 1024
+	server.queueSize = *queueSize
+	server.queueTimeout = *queueTimeout
+	server.concurrency = newLimiter(*maxConcurrency)
 
-	// Wait for TEI to be ready
-	log.Printf("Waiting for TEI to be ready...")
-	if err := server.waitForTEI(30 * time.Second); err != nil {
-		log.Fatalf("TEI failed to start: %v", err)
+	// Start TEI process
+	p, err := server.acquire(context.Background(), server.initialModel)
+	if err != nil {
+		slog.Error("Failed to start TEI", "model", server.initialModel, "error", err)
+		os.Exit(1)
 	}
-	log.Printf("TEI is ready!")
+	server.release(p)
+	slog.Info("TEI is ready", "model", server.initialModel)
 
 	// Setup HTTP server
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v1/embeddings", server.handleEmbeddings)
+	mux.HandleFunc("/v1/models", server.handleModels)
+	mux.HandleFunc("/v1/rerank", server.handleRerank)
+	mux.HandleFunc("/api/embed", server.handleOllamaEmbed)
+	mux.HandleFunc("/api/embeddings", server.handleOllamaEmbeddings)
 	mux.HandleFunc("/health", server.handleHealth)
 
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", *port),
-		Handler: mux,
+		Handler: withRequestLogging(mux),
 	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigChan, shutdownSignals...)
 
+	drained := make(chan struct{})
 	go func() {
 		<-sigChan
-		log.Println("Shutting down...")
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		httpServer.Shutdown(ctx)
+		slog.Info("Shutting down")
+		server.drain(httpServer, *shutdownTimeout)
+		close(drained)
 	}()
 
 	// Start server
-	log.Printf("TEI wrapper listening on :%d", *port)
-	log.Printf("OpenAI-compatible endpoint: http://localhost:%d/v1/embeddings", *port)
+	slog.Info("TEI wrapper listening", "port", *port,
+		"openai_endpoint", fmt.Sprintf("http://localhost:%d/v1/embeddings", *port),
+		"ollama_endpoint", fmt.Sprintf("http://localhost:%d/api/embed", *port))
 	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("Server failed: %v", err)
+		server.stopAll()
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
 	}
+	// ListenAndServe returns as soon as shutdown starts; wait for the drain
+	<-drained
 }
 
-// startTEIWithModel starts the TEI process with the specified model
-func (s *Server) startTEIWithModel(ctx context.Context, model string) error {
-	// TEI command: text-embeddings-router --model-id <model> --port <port>
-	s.teiCmd = exec.CommandContext(ctx, s.teiBinary,
-		"--model-id", model,
-		"--port", fmt.Sprintf("%d", s.teiPort),
-		"--max-batch-tokens", "16384", // Reasonable default
-	)
-
-	// Capture output for debugging
-	s.teiCmd.Stdout = os.Stdout
-	s.teiCmd.Stderr = os.Stderr
-
-	if err := s.teiCmd.Start(); err != nil {
-		return fmt.Errorf("failed to start TEI: %w", err)
+// drain stops accepting requests, waits up to timeout for in-flight requests
+// to finish, then stops every TEI process
+func (s *Server) drain(httpServer *http.Server, timeout time.Duration) {
+	slog.Info("Waiting for in-flight requests", "in_flight", s.inFlight(), "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Warn("Stopping with requests still in flight", "in_flight", s.inFlight(), "error", err)
 	}
-
-	log.Printf("TEI process started with model %s (PID: %d)", model, s.teiCmd.Process.Pid)
-	s.currentModel = model
-	return nil
+	s.stopAll()
 }
 
-// stopTEI gracefully stops the TEI process
-func (s *Server) stopTEI() {
-	if s.teiCmd == nil || s.teiCmd.Process == nil {
-		return
-	}
-
-	log.Printf("Stopping TEI process (PID: %d)", s.teiCmd.Process.Pid)
-
-	// Send SIGTERM for graceful shutdown
-	if err := s.teiCmd.Process.Signal(syscall.SIGTERM); err != nil {
-		log.Printf("Failed to send SIGTERM: %v", err)
-		s.teiCmd.Process.Kill()
-		return
-	}
-
-	// Wait for process to exit (with timeout)
-	done := make(chan error, 1)
-	go func() {
-		done <- s.teiCmd.Wait()
-	}()
-
-	select {
-	case <-done:
-		log.Printf("TEI stopped gracefully")
-	case <-time.After(5 * time.Second):
-		log.Printf("TEI didn't stop in time, killing...")
-		s.teiCmd.Process.Kill()
-	}
-}
-
-// waitForTEI waits for TEI to be ready by polling the health endpoint
-func (s *Server) waitForTEI(timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-
-	for time.Now().Before(deadline) {
-		resp, err := s.client.Get(s.teiBaseURL + "/health")
-		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				return nil
-			}
-		}
-		time.Sleep(500 * time.Millisecond)
-	}
-
-	return fmt.Errorf("TEI did not become ready within %v", timeout)
-}
-
-// switchModel switches to a new model by stopping and restarting TEI
-func (s *Server) switchModel(newModel string) error {
+// inFlight returns the number of requests being served or waiting for a model
+func (s *Server) inFlight() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	// Check if already on the requested model
-	if s.currentModel == newModel {
-		return nil
+	count := s.queued
+	for _, p := range s.processes {
+		count += p.active
 	}
-
-	log.Printf("Switching model from %s to %s", s.currentModel, newModel)
-	s.switching = true
-	defer func() { s.switching = false }()
-
-	// Stop current TEI process
-	s.stopTEI()
-
-	// Start new TEI process with new model
-	ctx := context.Background()
-	if err := s.startTEIWithModel(ctx, newModel); err != nil {
-		return fmt.Errorf("failed to start TEI with new model: %w", err)
-	}
-
-	// Wait for new TEI to be ready
-	if err := s.waitForTEI(30 * time.Second); err != nil {
-		return fmt.Errorf("new TEI failed to start: %w", err)
-	}
-
-	log.Printf("Model switched successfully to %s", newModel)
-	return nil
+	return count
 }
 
 // handleEmbeddings handles POST /v1/embeddings requests
@@ -249,44 +346,35 @@ func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if we need to switch models
-	s.mu.RLock()
-	needsSwitch := req.Model != "" && req.Model != s.currentModel
-	isSwitching := s.switching
-	s.mu.RUnlock()
-
-	if isSwitching {
-		// Return 503 with Retry-After header during switch
-		w.Header().Set("Retry-After", "5")
-		http.Error(w, "Model switch in progress, please retry", http.StatusServiceUnavailable)
+	p, ok := s.acquireForRequest(w, r, req.Model, len(req.Input))
+	if !ok {
 		return
 	}
-
-	if needsSwitch {
-		// Switch to the requested model
-		if err := s.switchModel(req.Model); err != nil {
-			log.Printf("Model switch failed: %v", err)
-			http.Error(w, fmt.Sprintf("Model switch failed: %v", err), http.StatusInternalServerError)
-			return
-		}
-	}
+	defer s.finishRequest(p)
 
 	// Forward to TEI
-	embeddings, err := s.getEmbeddings(req.Input)
+	embeddings, err := s.getEmbeddings(p, req.Input)
 	if err != nil {
-		log.Printf("TEI request failed: %v", err)
+		requestLogger(r.Context()).Error("TEI request failed", "model", p.model, "error", err)
 		http.Error(w, fmt.Sprintf("Embedding failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// Report the tokens TEI embedded, falling back to one per input if it can't tokenize
+	tokens, err := s.countTokens(p, req.Input)
+	if err != nil {
+		requestLogger(r.Context()).Warn("TEI tokenize request failed, reporting input count as usage", "model", p.model, "error", err)
+		tokens = len(req.Input)
+	}
+
 	// Build OpenAI-compatible response
 	resp := EmbeddingResponse{
 		Object: "list",
 		Model:  req.Model,
 		Data:   make([]EmbeddingData, len(embeddings)),
 		Usage: EmbeddingUsage{
-			PromptTokens: len(req.Input),
-			TotalTokens:  len(req.Input),
+			PromptTokens: tokens,
+			TotalTokens:  tokens,
 		},
 	}
 
@@ -303,8 +391,97 @@ func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// getEmbeddings sends a request to TEI and returns the embeddings
-func (s *Server) getEmbeddings(inputs []string) ([][]float64, error) {
+// acquireForRequest routes a request to the TEI process for the requested
+// model, starting it if needed; requests wait in a queue while it loads, then
+// for a concurrency slot. On failure it writes the error response and returns
+// false. Callers must call finishRequest when done. inputs is the number of
+// inputs, for the request log.
+func (s *Server) acquireForRequest(w http.ResponseWriter, r *http.Request, model string, inputs int) (*teiProcess, bool) {
+	resolved := s.resolveModel(model)
+	annotateRequest(r.Context(), resolved, inputs)
+	p, err := s.acquire(r.Context(), resolved)
+	if errors.Is(err, errModelLoading) || errors.Is(err, errNoCapacity) || errors.Is(err, errQueueTimeout) {
+		// Return 503 with Retry-After header when the queue is full or timed out
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if err != nil {
+		requestLogger(r.Context()).Error("Model load failed", "model", resolved, "error", err)
+		http.Error(w, fmt.Sprintf("Model load failed: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// Wait for a concurrency slot, in arrival order
+	ctx, cancel := context.WithTimeout(r.Context(), s.queueTimeout)
+	defer cancel()
+	if err := s.concurrency.acquire(ctx); err != nil {
+		s.release(p)
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, fmt.Sprintf("%v after %v: too many concurrent requests", errQueueTimeout, s.queueTimeout), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return p, true
+}
+
+// finishRequest frees the concurrency slot and process taken by acquireForRequest
+func (s *Server) finishRequest(p *teiProcess) {
+	s.concurrency.release()
+	s.release(p)
+}
+
+// resolveModel returns the HuggingFace model ID for a requested model name,
+// which may be an alias
+func (s *Server) resolveModel(name string) string {
+	if model, ok := s.aliases[name]; ok {
+		return model
+	}
+	return name
+}
+
+// handleModels handles GET /v1/models, listing the loaded models and every alias
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	list := ModelList{Object: "list", Data: []ModelInfo{}}
+	s.mu.Lock()
+	for _, p := range s.sortedProcesses() {
+		if !p.isReady() {
+			continue
+		}
+		list.Data = append(list.Data, ModelInfo{
+			ID:      p.model,
+			Object:  "model",
+			Created: p.loadedAt.Unix(),
+			OwnedBy: "tei-wrapper",
+			Root:    p.model,
+		})
+	}
+	s.mu.Unlock()
+
+	names := make([]string, 0, len(s.aliases))
+	for name := range s.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		list.Data = append(list.Data, ModelInfo{
+			ID:      name,
+			Object:  "model",
+			OwnedBy: "tei-wrapper",
+			Root:    s.aliases[name],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// getEmbeddings sends a request to a TEI process and returns the embeddings
+func (s *Server) getEmbeddings(p *teiProcess, inputs []string) ([][]float64, error) {
 	// Build TEI request
 	teiReq := TEIRequest{
 		Inputs: inputs,
@@ -317,7 +494,7 @@ func (s *Server) getEmbeddings(inputs []string) ([][]float64, error) {
 
 	// Send request to TEI
 	resp, err := s.client.Post(
-		s.teiBaseURL+"/embed",
+		p.baseURL+"/embed",
 		"application/json",
 		bytes.NewReader(reqBody),
 	)
@@ -340,34 +517,114 @@ func (s *Server) getEmbeddings(inputs []string) ([][]float64, error) {
 	return teiResp, nil
 }
 
-// handleHealth returns the health status
+// countTokens returns the number of tokens in inputs, including special
+// tokens, as counted by TEI's tokenizer
+func (s *Server) countTokens(p *teiProcess, inputs []string) (int, error) {
+	reqBody, err := json.Marshal(TEITokenizeRequest{Inputs: inputs, AddSpecialTokens: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := s.client.Post(p.baseURL+"/tokenize", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request to TEI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("TEI returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var teiResp TEITokenizeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&teiResp); err != nil {
+		return 0, fmt.Errorf("failed to parse TEI response: %w", err)
+	}
+
+	tokens := 0
+	for _, inputTokens := range teiResp {
+		tokens += len(inputTokens)
+	}
+	return tokens, nil
+}
+
+// handleHealth returns the health status of the most recently used model's
+// TEI process, with the state of every running model
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	s.mu.RLock()
-	currentModel := s.currentModel
-	isSwitching := s.switching
-	s.mu.RUnlock()
+	s.mu.Lock()
+	currentModel := s.lastModel
+	current := s.processes[currentModel]
+	queued := s.queued
+	crashes := s.crashes
+	restarting := current != nil && current.restarting
+	loading := current != nil && !current.isReady()
+	models := make([]map[string]interface{}, 0, len(s.processes))
+	for _, p := range s.sortedProcesses() {
+		status := "ready"
+		if p.restarting {
+			status = "restarting"
+		} else if !p.isReady() {
+			status = "loading"
+		}
+		models = append(models, map[string]interface{}{
+			"model":     p.model,
+			"port":      p.port,
+			"status":    status,
+			"active":    p.active,
+			"memory_mb": p.memoryKB / 1024,
+			"crashes":   p.crashes,
+			"devices":   s.teiDevices(p.model),
+		})
+	}
+	s.mu.Unlock()
+
+	// Check if the current model's TEI process crashed and is being restarted
+	if restarting {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "restarting",
+			"model":   currentModel,
+			"queued":  queued,
+			"crashes": crashes,
+			"models":  models,
+		})
+		return
+	}
 
 	// Check if currently switching models
-	if isSwitching {
+	if loading {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"status":    "switching",
 			"model":     currentModel,
 			"switching": true,
+			"queued":    queued,
+			"crashes":   crashes,
+			"models":    models,
 		})
 		return
 	}
 
 	// Check if TEI is healthy
-	resp, err := s.client.Get(s.teiBaseURL + "/health")
+	var resp *http.Response
+	err := fmt.Errorf("no TEI process is running")
+	if current != nil {
+		resp, err = s.client.Get(current.baseURL + "/health")
+	}
 	if err != nil || resp.StatusCode != http.StatusOK {
+		if err == nil {
+			resp.Body.Close()
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status": "unhealthy",
-			"model":  currentModel,
-			"error":  "TEI is not responding",
+			"status":  "unhealthy",
+			"model":   currentModel,
+			"error":   "TEI is not responding",
+			"crashes": crashes,
+			"models":  models,
 		})
 		return
 	}
@@ -375,7 +632,20 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "ok",
-		"model":  currentModel,
+		"status":  "ok",
+		"model":   currentModel,
+		"queued":  queued,
+		"crashes": crashes,
+		"models":  models,
 	})
 }
+
+// sortedProcesses returns the pool's processes ordered by model. Must be called with s.mu held.
+func (s *Server) sortedProcesses() []*teiProcess {
+	processes := make([]*teiProcess, 0, len(s.processes))
+	for _, p := range s.processes {
+		processes = append(processes, p)
+	}
+	sort.Slice(processes, func(i, j int) bool { return processes[i].model < processes[j].model })
+	return processes
+}
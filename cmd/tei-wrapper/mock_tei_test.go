@@ -4,9 +4,26 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
+// newTestServer creates a wrapper whose pool holds a ready process for model served at baseURL
+func newTestServer(baseURL, model string) *Server {
+	server := newServer(0, "", model)
+	server.client = &http.Client{Timeout: 10 * time.Second}
+	server.processes[model] = readyProcess(model, baseURL)
+	return server
+}
+
+// readyProcess returns a pool entry for a TEI server that is already running at baseURL
+func readyProcess(model, baseURL string) *teiProcess {
+	ready := make(chan struct{})
+	close(ready)
+	return &teiProcess{model: model, baseURL: baseURL, ready: ready, loadedAt: time.Now()}
+}
+
 // Mock TEI server for testing
 func createMockTEI(t *testing.T) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -35,6 +52,54 @@ func createMockTEI(t *testing.T) *httptest.Server {
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(embeddings)
 
+		case "/tokenize":
+			var req TEITokenizeRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("Failed to parse request: %v", err)
+			}
+
+			// One token per word, plus [CLS] and [SEP] when special tokens are added
+			tokens := make([][]map[string]interface{}, len(req.Inputs))
+			for i, input := range req.Inputs {
+				words := strings.Fields(input)
+				if req.AddSpecialTokens {
+					words = append(append([]string{"[CLS]"}, words...), "[SEP]")
+				}
+				for id, word := range words {
+					tokens[i] = append(tokens[i], map[string]interface{}{"id": id, "text": word})
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(tokens)
+
+		case "/rerank":
+			var req TEIRerankRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("Failed to parse request: %v", err)
+			}
+
+			// Texts containing the query rank first, in their original order
+			var ranked TEIRerankResponse
+			for _, matches := range []bool{true, false} {
+				for i, text := range req.Texts {
+					if strings.Contains(text, req.Query) != matches {
+						continue
+					}
+					result := TEIRerankResult{Index: i, Score: 0.1}
+					if matches {
+						result.Score = 0.9
+					}
+					if req.ReturnText {
+						result.Text = text
+					}
+					ranked = append(ranked, result)
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(ranked)
+
 		default:
 			http.NotFound(w, r)
 		}
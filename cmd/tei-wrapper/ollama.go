@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Ollama /api/embed request format
+type OllamaEmbedRequest struct {
+	Model string      `json:"model"`
+	Input ollamaInput `json:"input"`
+}
+
+// ollamaInput is /api/embed's input, which may be a single string or a list
+type ollamaInput []string
+
+func (in *ollamaInput) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*in = ollamaInput{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("input must be a string or a list of strings")
+	}
+	*in = list
+	return nil
+}
+
+// Ollama /api/embed response format; durations are in nanoseconds
+type OllamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	TotalDuration   int64       `json:"total_duration"`
+	LoadDuration    int64       `json:"load_duration"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// Ollama /api/embeddings (legacy) request format
+type OllamaEmbeddingsRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// Ollama /api/embeddings (legacy) response format
+type OllamaEmbeddingsResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// handleOllamaEmbed handles POST /api/embed requests in Ollama's format
+func (s *Server) handleOllamaEmbed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	start := time.Now()
+
+	var req OllamaEmbedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Input) == 0 {
+		http.Error(w, "No input provided", http.StatusBadRequest)
+		return
+	}
+
+	p, ok := s.acquireForRequest(w, r, req.Model, len(req.Input))
+	if !ok {
+		return
+	}
+	defer s.finishRequest(p)
+	loaded := time.Now()
+
+	embeddings, err := s.getEmbeddings(p, req.Input)
+	if err != nil {
+		requestLogger(r.Context()).Error("TEI request failed", "model", p.model, "error", err)
+		http.Error(w, fmt.Sprintf("Embedding failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	tokens, err := s.countTokens(p, req.Input)
+	if err != nil {
+		requestLogger(r.Context()).Warn("TEI tokenize request failed, reporting input count as usage", "model", p.model, "error", err)
+		tokens = len(req.Input)
+	}
+
+	resp := OllamaEmbedResponse{
+		Model:           req.Model,
+		Embeddings:      embeddings,
+		TotalDuration:   time.Since(start).Nanoseconds(),
+		LoadDuration:    loaded.Sub(start).Nanoseconds(),
+		PromptEvalCount: tokens,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleOllamaEmbeddings handles POST /api/embeddings requests, Ollama's
+// legacy single-prompt endpoint
+func (s *Server) handleOllamaEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req OllamaEmbeddingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Prompt == "" {
+		http.Error(w, "No prompt provided", http.StatusBadRequest)
+		return
+	}
+
+	p, ok := s.acquireForRequest(w, r, req.Model, 1)
+	if !ok {
+		return
+	}
+	defer s.finishRequest(p)
+
+	embeddings, err := s.getEmbeddings(p, []string{req.Prompt})
+	if err != nil {
+		requestLogger(r.Context()).Error("TEI request failed", "model", p.model, "error", err)
+		http.Error(w, fmt.Sprintf("Embedding failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(embeddings) != 1 {
+		http.Error(w, fmt.Sprintf("Embedding failed: TEI returned %d embeddings for 1 prompt", len(embeddings)), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OllamaEmbeddingsResponse{Embedding: embeddings[0]})
+}
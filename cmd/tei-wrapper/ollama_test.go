@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOllamaEmbedEndpoint(t *testing.T) {
+	mockTEI := createMockTEI(t)
+	defer mockTEI.Close()
+
+	server := newTestServer(mockTEI.URL, "test-model")
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleOllamaEmbed))
+	defer testServer.Close()
+
+	for _, body := range []string{
+		`{"model": "test-model", "input": "Hello world"}`,
+		`{"model": "test-model", "input": ["Hello world", "Testing embeddings"]}`,
+	} {
+		resp, err := http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(body)))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var embedResp OllamaEmbedResponse
+		err = json.NewDecoder(resp.Body).Decode(&embedResp)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || err != nil {
+			t.Fatalf("%s: expected a 200 JSON response, got %d (%v)", body, resp.StatusCode, err)
+		}
+
+		var req OllamaEmbedRequest
+		json.Unmarshal([]byte(body), &req)
+		if embedResp.Model != "test-model" || len(embedResp.Embeddings) != len(req.Input) {
+			t.Errorf("%s: unexpected response: model %q, %d embeddings", body, embedResp.Model, len(embedResp.Embeddings))
+		}
+		if embedResp.PromptEvalCount != 4*len(req.Input) || embedResp.TotalDuration <= 0 {
+			t.Errorf("%s: unexpected counters: %+v", body, embedResp)
+		}
+	}
+
+	resp, err := http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(`{"model": "test-model", "input": 42}`)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-string input, got %d", resp.StatusCode)
+	}
+}
+
+func TestOllamaEmbeddingsEndpoint(t *testing.T) {
+	mockTEI := createMockTEI(t)
+	defer mockTEI.Close()
+
+	server := newTestServer(mockTEI.URL, "test-model")
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleOllamaEmbeddings))
+	defer testServer.Close()
+
+	resp, err := http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(`{"model": "test-model", "prompt": "Hello world"}`)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var embeddingsResp OllamaEmbeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingsResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(embeddingsResp.Embedding) != 768 {
+		t.Errorf("Expected a 768-dim embedding, got %d", len(embeddingsResp.Embedding))
+	}
+
+	resp, err = http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(`{"model": "test-model"}`)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a prompt, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// errModelLoading is returned for requests to a model whose TEI process is still starting
+	errModelLoading = errors.New("model is loading, please retry")
+	// errNoCapacity is returned when a new model is needed but every TEI process is busy
+	errNoCapacity = errors.New("all TEI processes are busy, please retry")
+	// errQueueTimeout is returned for requests that waited in the queue for longer than --queue-timeout
+	errQueueTimeout = errors.New("timed out waiting for the model")
+)
+
+// teiProcess is a text-embeddings-router process serving a single model
+type teiProcess struct {
+	model   string
+	port    int
+	baseURL string
+	cmd     *exec.Cmd
+	group   processGroup
+	exit    *exitStatus // Set once the process has started
+
+	// Guarded by Server.mu
+	ready      chan struct{} // Closed once the process is serving (or failed to start)
+	err        error         // Why the process failed to start
+	stopping   bool          // Stopped on purpose, so its exit isn't a crash
+	restarting bool          // Being restarted after a crash
+	crashes    int           // Unexpected exits
+	backoff    time.Duration // Wait before the next restart
+	loadedAt   time.Time
+	memoryKB   int64 // Resident memory, measured once the process is ready
+	active     int   // In-flight requests
+	lastUsed   time.Time
+}
+
+// exitStatus reports when a started process exits
+type exitStatus struct {
+	done chan struct{} // Closed when the process exits
+	err  error         // Wait's result; set before done is closed
+}
+
+// watchExit waits for cmd in the background and reports its exit
+func watchExit(cmd *exec.Cmd) *exitStatus {
+	exit := &exitStatus{done: make(chan struct{})}
+	go func() {
+		exit.err = cmd.Wait()
+		close(exit.done)
+	}()
+	return exit
+}
+
+// isReady reports whether the process has finished starting. Must be called with s.mu held.
+func (p *teiProcess) isReady() bool {
+	select {
+	case <-p.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire returns the ready TEI process serving model, starting one if needed.
+// Idle processes are stopped, least recently used first, to stay within
+// --max-models and --memory-budget. An empty model selects the model of the
+// most recent request. While the model loads, or no process can be stopped to
+// make room for it, the request waits in a queue of up to --queue-size requests
+// for at most --queue-timeout. The caller must release the process when done with it.
+func (s *Server) acquire(ctx context.Context, model string) (*teiProcess, error) {
+	var timeout <-chan time.Time
+	var waitingOn *teiProcess
+
+	s.mu.Lock()
+	if model == "" {
+		model = s.lastModel
+	}
+	for {
+		p, ok := s.processes[model]
+		if ok && p.isReady() {
+			p.active++
+			p.lastUsed = time.Now()
+			s.lastModel = model
+			s.mu.Unlock()
+			return p, nil
+		}
+		if !ok && waitingOn != nil && waitingOn.err != nil {
+			// The load this request was queued behind failed
+			s.mu.Unlock()
+			return nil, waitingOn.err
+		}
+
+		reason := errModelLoading
+		if !ok {
+			victims, err := s.makeRoom(model)
+			if err == nil {
+				return s.start(model, victims)
+			}
+			reason = err
+		}
+		waitingOn = p
+
+		// Queue until the pool changes, then look again
+		if timeout == nil {
+			if s.queued >= s.queueSize {
+				s.mu.Unlock()
+				return nil, reason
+			}
+			s.queued++
+			defer s.dequeue()
+			timer := time.NewTimer(s.queueTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return nil, fmt.Errorf("%w after %v: %v", errQueueTimeout, s.queueTimeout, reason)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		s.mu.Lock()
+	}
+}
+
+// start adds a process for model to the pool, stops the victims making room
+// for it, and loads it. Must be called with s.mu held; returns with it released.
+func (s *Server) start(model string, victims []*teiProcess) (*teiProcess, error) {
+	p := &teiProcess{
+		model:    model,
+		port:     s.freePort(),
+		ready:    make(chan struct{}),
+		active:   1,
+		lastUsed: time.Now(),
+	}
+	s.processes[model] = p
+	s.mu.Unlock()
+
+	// Evicted processes must exit before their ports and memory are reused
+	for _, victim := range victims {
+		slog.Info("Stopping idle model to make room", "model", victim.model, "for_model", model)
+		s.stopTEI(victim)
+	}
+
+	memoryKB, err := s.load(p)
+
+	s.mu.Lock()
+	s.notifyLocked()
+	if err != nil {
+		p.err = err
+		close(p.ready)
+		delete(s.processes, model)
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.markReady(p, memoryKB)
+	s.lastModel = model
+	victims = s.overBudget(p)
+	s.mu.Unlock()
+
+	for _, victim := range victims {
+		slog.Info("Stopping idle model to stay within the memory budget", "model", victim.model)
+		s.stopTEI(victim)
+	}
+	return p, nil
+}
+
+// markReady records a loaded process and starts supervising it. Must be called with s.mu held.
+func (s *Server) markReady(p *teiProcess, memoryKB int64) {
+	close(p.ready)
+	p.loadedAt = time.Now()
+	if memoryKB > 0 {
+		p.memoryKB = memoryKB
+		s.modelMemory[p.model] = memoryKB
+	}
+	if p.exit != nil {
+		go s.supervise(p, p.exit)
+	}
+}
+
+// release marks a request to the process as done
+func (s *Server) release(p *teiProcess) {
+	s.mu.Lock()
+	p.active--
+	if p.active == 0 {
+		s.notifyLocked()
+	}
+	s.mu.Unlock()
+}
+
+// dequeue removes a request from the queue count
+func (s *Server) dequeue() {
+	s.mu.Lock()
+	s.queued--
+	s.mu.Unlock()
+}
+
+// notifyLocked wakes queued requests to look at the pool again. Must be called with s.mu held.
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// load starts the TEI process and waits for it to serve requests, returning
+// its resident memory in KB (0 if it can't be measured)
+func (s *Server) load(p *teiProcess) (int64, error) {
+	slog.Info("Starting TEI", "model", p.model, "port", p.port)
+	start := s.startTEI
+	if s.launch != nil {
+		start = s.launch
+	}
+	if err := start(p); err != nil {
+		return 0, err
+	}
+
+	if err := s.waitForTEI(p, 30*time.Second); err != nil {
+		s.terminate(p)
+		return 0, fmt.Errorf("TEI failed to start: %w", err)
+	}
+
+	var memoryKB int64
+	if p.cmd != nil && p.cmd.Process != nil {
+		memoryKB, _ = processMemoryKB(p.cmd.Process.Pid)
+	}
+	slog.Info("Model is ready", "model", p.model, "memory_mb", memoryKB/1024)
+	return memoryKB, nil
+}
+
+// makeRoom picks the idle processes to stop so another model can start within
+// the process and memory limits, and removes them from the pool. The memory a
+// model needs is known once it has been loaded before. Must be called with s.mu held.
+func (s *Server) makeRoom(model string) ([]*teiProcess, error) {
+	maxModels := s.maxModels
+	if maxModels < 1 {
+		maxModels = 1
+	}
+	need := s.modelMemory[model]
+
+	count := len(s.processes)
+	used := s.usedMemoryKB()
+	full := func() bool {
+		if count >= maxModels {
+			return true
+		}
+		return s.memoryBudget > 0 && count > 0 && used+need > s.memoryBudget
+	}
+
+	var victims []*teiProcess
+	for _, p := range s.idleProcesses(nil) {
+		if !full() {
+			break
+		}
+		victims = append(victims, p)
+		count--
+		used -= p.memoryKB
+	}
+	if full() {
+		return nil, errNoCapacity
+	}
+
+	for _, victim := range victims {
+		delete(s.processes, victim.model)
+	}
+	return victims, nil
+}
+
+// overBudget picks idle processes other than keep to stop until the measured
+// memory of all processes fits the budget, and removes them from the pool.
+// Must be called with s.mu held.
+func (s *Server) overBudget(keep *teiProcess) []*teiProcess {
+	if s.memoryBudget <= 0 {
+		return nil
+	}
+
+	used := s.usedMemoryKB()
+	var victims []*teiProcess
+	for _, p := range s.idleProcesses(keep) {
+		if used <= s.memoryBudget {
+			break
+		}
+		victims = append(victims, p)
+		delete(s.processes, p.model)
+		used -= p.memoryKB
+	}
+	if used > s.memoryBudget {
+		slog.Warn("TEI processes are over the memory budget", "used_mb", used/1024, "budget_mb", s.memoryBudget/1024)
+	}
+	return victims
+}
+
+// idleProcesses returns the ready processes with no requests in flight, least
+// recently used first, excluding skip. Must be called with s.mu held.
+func (s *Server) idleProcesses(skip *teiProcess) []*teiProcess {
+	var idle []*teiProcess
+	for _, p := range s.processes {
+		if p != skip && p.active == 0 && p.isReady() {
+			idle = append(idle, p)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].lastUsed.Before(idle[j].lastUsed) })
+	return idle
+}
+
+// usedMemoryKB returns the measured memory of every process. Must be called with s.mu held.
+func (s *Server) usedMemoryKB() int64 {
+	var used int64
+	for _, p := range s.processes {
+		used += p.memoryKB
+	}
+	return used
+}
+
+// freePort returns the lowest TEI port not used by a process in the pool.
+// Must be called with s.mu held.
+func (s *Server) freePort() int {
+	used := make(map[int]bool, len(s.processes))
+	for _, p := range s.processes {
+		used[p.port] = true
+	}
+	port := s.teiPort
+	for used[port] {
+		port++
+	}
+	return port
+}
+
+// stopAll stops every TEI process
+func (s *Server) stopAll() {
+	s.mu.Lock()
+	processes := make([]*teiProcess, 0, len(s.processes))
+	for model, p := range s.processes {
+		processes = append(processes, p)
+		delete(s.processes, model)
+	}
+	s.mu.Unlock()
+
+	for _, p := range processes {
+		s.stopTEI(p)
+	}
+}
+
+// startTEI starts a text-embeddings-router process for the process's model and port
+func (s *Server) startTEI(p *teiProcess) error {
+	cmd := exec.Command(s.teiBinary, s.teiCommandArgs(p)...)
+	if devices := s.teiDevices(p.model); devices != "" {
+		cmd.Env = append(os.Environ(), "CUDA_VISIBLE_DEVICES="+devices)
+	}
+
+	// Capture output for debugging
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	prepareProcess(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start TEI: %w", err)
+	}
+
+	slog.Info("TEI process started", "model", p.model, "pid", cmd.Process.Pid, "devices", s.teiDevices(p.model))
+	group, err := attachProcess(cmd)
+	if err != nil {
+		// The process can still be stopped, just not anything it starts
+		slog.Warn("Failed to track TEI process group", "model", p.model, "error", err)
+	}
+	p.cmd = cmd
+	p.group = group
+	p.exit = watchExit(cmd)
+	go func() {
+		<-p.exit.done
+		group.release()
+	}()
+	p.baseURL = fmt.Sprintf("http://localhost:%d", p.port)
+	return nil
+}
+
+// teiCommandArgs returns the text-embeddings-router arguments for a process:
+// --model-id <model> --port <port>, then the tuning flags, with the model's
+// configured arguments overriding the global ones
+func (s *Server) teiCommandArgs(p *teiProcess) []string {
+	args := []string{"--model-id", p.model, "--port", strconv.Itoa(p.port)}
+	return append(args, mergeArgs(s.teiArgs, s.modelArgs[p.model])...)
+}
+
+// teiDevices returns the CUDA_VISIBLE_DEVICES for a model's TEI process, or
+// "" to inherit the wrapper's environment
+func (s *Server) teiDevices(model string) string {
+	if devices, ok := s.modelDevices[model]; ok {
+		return devices
+	}
+	return s.devices
+}
+
+// stopTEI gracefully stops a TEI process so that it is not restarted
+func (s *Server) stopTEI(p *teiProcess) {
+	s.mu.Lock()
+	p.stopping = true
+	s.mu.Unlock()
+	s.terminate(p)
+}
+
+// terminate sends SIGTERM to a TEI process, killing it if it doesn't exit in time
+func (s *Server) terminate(p *teiProcess) {
+	if p.cmd == nil || p.cmd.Process == nil || p.exit == nil {
+		return
+	}
+
+	slog.Info("Stopping TEI process", "model", p.model, "pid", p.cmd.Process.Pid)
+
+	// Ask for a graceful shutdown: SIGTERM, or Ctrl-Break on Windows
+	if err := p.group.interrupt(p.cmd.Process); err != nil {
+		slog.Warn("Failed to interrupt TEI", "model", p.model, "error", err)
+		p.group.kill(p.cmd.Process)
+		return
+	}
+
+	// Wait for process to exit (with timeout)
+	select {
+	case <-p.exit.done:
+		slog.Info("TEI stopped gracefully", "model", p.model)
+	case <-time.After(5 * time.Second):
+		slog.Warn("TEI didn't stop in time, killing it", "model", p.model)
+		p.group.kill(p.cmd.Process)
+	}
+}
+
+// waitForTEI waits for a TEI process to be ready by polling its health endpoint
+func (s *Server) waitForTEI(p *teiProcess, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	var exited <-chan struct{}
+	if p.exit != nil {
+		exited = p.exit.done
+	}
+
+	for time.Now().Before(deadline) {
+		resp, err := s.client.Get(p.baseURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("TEI exited before becoming ready: %v", p.exit.err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	return fmt.Errorf("TEI did not become ready within %v", timeout)
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// shutdownSignals are the signals that shut the wrapper down gracefully
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// processGroup tracks what's needed to stop a TEI process. On Unix the
+// process itself is enough.
+type processGroup struct{}
+
+// prepareProcess sets up cmd before it's started
+func prepareProcess(cmd *exec.Cmd) {}
+
+// attachProcess returns the processGroup for a started cmd
+func attachProcess(cmd *exec.Cmd) (processGroup, error) {
+	return processGroup{}, nil
+}
+
+// interrupt asks the process to shut down gracefully with SIGTERM
+func (g processGroup) interrupt(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
+
+// kill stops the process immediately
+func (g processGroup) kill(process *os.Process) error {
+	return process.Kill()
+}
+
+// release frees the group once the process has exited
+func (g processGroup) release() {}
+
+// processMemoryKB returns the resident memory of a process in KB
+func processMemoryKB(pid int) (int64, error) {
+	out, err := exec.Command("ps", "-o", "rss=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// shutdownSignals are the signals that shut the wrapper down gracefully. Go
+// delivers Ctrl-C and Ctrl-Break as os.Interrupt, and closing the console
+// window, logging off or shutting down as syscall.SIGTERM.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// processGroup tracks what's needed to stop a TEI process: a job object
+// holding it and any processes it starts, so killing it leaves nothing behind.
+// The job is closed with the wrapper, so TEI doesn't outlive a crashed wrapper either.
+type processGroup struct {
+	job windows.Handle // Zero if the process couldn't be put in a job
+}
+
+// prepareProcess starts cmd in its own process group, so it can be sent
+// Ctrl-Break without interrupting the wrapper too
+func prepareProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+// attachProcess puts a started cmd in a new job object that kills its
+// processes when closed
+func attachProcess(cmd *exec.Cmd) (processGroup, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return processGroup{}, fmt.Errorf("failed to create job object: %w", err)
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return processGroup{}, fmt.Errorf("failed to configure job object: %w", err)
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return processGroup{}, fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(process)
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return processGroup{}, fmt.Errorf("failed to assign process to job object: %w", err)
+	}
+	return processGroup{job: job}, nil
+}
+
+// interrupt asks the process to shut down gracefully by sending Ctrl-Break to
+// its process group. Windows has no SIGTERM to send.
+func (g processGroup) interrupt(process *os.Process) error {
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(process.Pid))
+}
+
+// kill stops the process and everything it started immediately, through its
+// job object, or taskkill when it has none
+func (g processGroup) kill(process *os.Process) error {
+	if g.job != 0 {
+		return windows.TerminateJobObject(g.job, 1)
+	}
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+// release closes the job object once the process has exited, killing any
+// processes it left behind
+func (g processGroup) release() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+	}
+}
+
+// processMemoryKB returns the working set of a process in KB, as reported by tasklist
+func processMemoryKB(pid int) (int64, error) {
+	out, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return 0, err
+	}
+	return parseTasklistMemory(string(out))
+}
+
+// parseTasklistMemory reads the memory usage from a tasklist CSV row such as
+// "text-embeddings-router.exe","1234","Console","1","1,234,567 K". The
+// thousands separator depends on the locale, so every non-digit is dropped.
+func parseTasklistMemory(out string) (int64, error) {
+	line := strings.TrimSpace(out)
+	fields := strings.Split(line, `","`)
+	if len(fields) < 5 {
+		return 0, fmt.Errorf("unexpected tasklist output: %q", line)
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, fields[len(fields)-1])
+	return strconv.ParseInt(digits, 10, 64)
+}
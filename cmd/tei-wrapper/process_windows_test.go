@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestParseTasklistMemory(t *testing.T) {
+	for _, tc := range []struct {
+		out  string
+		want int64
+	}{
+		{"\"text-embeddings-router.exe\",\"1234\",\"Console\",\"1\",\"1,234,567 K\"\r\n", 1234567},
+		{"\"text-embeddings-router.exe\",\"1234\",\"Console\",\"1\",\"1.234.567 K\"\r\n", 1234567},
+	} {
+		got, err := parseTasklistMemory(tc.out)
+		if err != nil || got != tc.want {
+			t.Errorf("parseTasklistMemory(%q) = %d, %v; want %d", tc.out, got, err, tc.want)
+		}
+	}
+
+	// tasklist reports no matching process as an informational line
+	if _, err := parseTasklistMemory("INFO: No tasks are running which match the specified criteria.\r\n"); err == nil {
+		t.Error("expected an error when the process isn't listed")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RerankRequest is the rerank request format (Cohere/Jina style, as used by OpenAI-compatible servers)
+type RerankRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n,omitempty"`            // Most results to return, 0 for all
+	ReturnDocuments bool     `json:"return_documents,omitempty"` // Include each document's text in its result
+}
+
+// RerankResponse lists the documents by relevance, most relevant first
+type RerankResponse struct {
+	Object  string         `json:"object"`
+	Model   string         `json:"model"`
+	Results []RerankResult `json:"results"`
+}
+
+type RerankResult struct {
+	Index          int             `json:"index"`
+	RelevanceScore float64         `json:"relevance_score"`
+	Document       *RerankDocument `json:"document,omitempty"`
+}
+
+type RerankDocument struct {
+	Text string `json:"text"`
+}
+
+// TEI rerank request format
+type TEIRerankRequest struct {
+	Query      string   `json:"query"`
+	Texts      []string `json:"texts"`
+	ReturnText bool     `json:"return_text"`
+}
+
+// TEI rerank response format, sorted by score
+type TEIRerankResponse []TEIRerankResult
+
+type TEIRerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+	Text  string  `json:"text,omitempty"`
+}
+
+// teiStatusError is a non-200 response from TEI
+type teiStatusError struct {
+	status int
+	body   string
+}
+
+func (e *teiStatusError) Error() string {
+	return fmt.Sprintf("TEI returned status %d: %s", e.status, e.body)
+}
+
+// handleRerank handles POST /v1/rerank requests, forwarding them to TEI's
+// /rerank. The model must be a reranker (cross-encoder); TEI rejects
+// rerank requests for embedding models.
+func (s *Server) handleRerank(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RerankRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Query == "" || len(req.Documents) == 0 {
+		http.Error(w, "Query and documents are required", http.StatusBadRequest)
+		return
+	}
+
+	p, ok := s.acquireForRequest(w, r, req.Model, len(req.Documents))
+	if !ok {
+		return
+	}
+	defer s.finishRequest(p)
+
+	teiResp, err := s.rerank(p, req.Query, req.Documents, req.ReturnDocuments)
+	if err != nil {
+		requestLogger(r.Context()).Error("TEI rerank request failed", "model", p.model, "error", err)
+		status := http.StatusInternalServerError
+		var statusErr *teiStatusError
+		if errors.As(err, &statusErr) && statusErr.status < 500 {
+			// TEI answers 4xx (424 for backend errors) when the model can't rerank
+			status = http.StatusBadRequest
+		}
+		http.Error(w, fmt.Sprintf("Rerank failed (is %s a reranker model?): %v", p.model, err), status)
+		return
+	}
+
+	resp := RerankResponse{
+		Object:  "list",
+		Model:   req.Model,
+		Results: make([]RerankResult, 0, len(teiResp)),
+	}
+	for _, ranked := range teiResp {
+		if req.TopN > 0 && len(resp.Results) == req.TopN {
+			break
+		}
+		result := RerankResult{Index: ranked.Index, RelevanceScore: ranked.Score}
+		if req.ReturnDocuments {
+			result.Document = &RerankDocument{Text: ranked.Text}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// rerank scores texts against query with TEI, most relevant first
+func (s *Server) rerank(p *teiProcess, query string, texts []string, returnText bool) (TEIRerankResponse, error) {
+	reqBody, err := json.Marshal(TEIRerankRequest{Query: query, Texts: texts, ReturnText: returnText})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := s.client.Post(p.baseURL+"/rerank", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to TEI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &teiStatusError{status: resp.StatusCode, body: string(body)}
+	}
+
+	var teiResp TEIRerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&teiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse TEI response: %w", err)
+	}
+	return teiResp, nil
+}
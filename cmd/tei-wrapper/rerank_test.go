@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRerankEndpoint(t *testing.T) {
+	mockTEI := createMockTEI(t)
+	defer mockTEI.Close()
+
+	server := newTestServer(mockTEI.URL, "BAAI/bge-reranker-base")
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleRerank))
+	defer testServer.Close()
+
+	reqBody, _ := json.Marshal(RerankRequest{
+		Model:           "BAAI/bge-reranker-base",
+		Query:           "parse",
+		Documents:       []string{"func main() {}", "func parseConfig() {}", "func parseArgs() {}"},
+		TopN:            2,
+		ReturnDocuments: true,
+	})
+	resp, err := http.Post(testServer.URL, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var rerankResp RerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rerankResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if rerankResp.Object != "list" || rerankResp.Model != "BAAI/bge-reranker-base" {
+		t.Errorf("Unexpected response header fields: %+v", rerankResp)
+	}
+	if len(rerankResp.Results) != 2 {
+		t.Fatalf("Expected top_n to limit results to 2, got %d", len(rerankResp.Results))
+	}
+	first := rerankResp.Results[0]
+	if first.Index != 1 || first.RelevanceScore != 0.9 || first.Document == nil || first.Document.Text != "func parseConfig() {}" {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+}
+
+func TestRerankEndpoint_NotReranker(t *testing.T) {
+	// TEI rejects rerank requests for embedding models
+	mockTEI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFailedDependency)
+		w.Write([]byte(`{"error":"model is not a re-ranker model","error_type":"Backend"}`))
+	}))
+	defer mockTEI.Close()
+
+	server := newTestServer(mockTEI.URL, "nomic-ai/nomic-embed-text-v1.5")
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleRerank))
+	defer testServer.Close()
+
+	reqBody, _ := json.Marshal(RerankRequest{Model: "nomic-ai/nomic-embed-text-v1.5", Query: "q", Documents: []string{"d"}})
+	resp, err := http.Post(testServer.URL, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-reranker model, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(testServer.URL, "application/json", bytes.NewReader([]byte(`{"query":"q"}`)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without documents, got %d", resp.StatusCode)
+	}
+}
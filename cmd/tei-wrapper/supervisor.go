@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// maxFailedRestarts is how many restarts in a row may fail before a crashed model is dropped from the pool
+	maxFailedRestarts = 5
+	// stableUptime is how long a process must run before a crash restarts it without the accumulated backoff
+	stableUptime = 5 * time.Minute
+)
+
+// supervise restarts p's TEI process if it exits without being stopped,
+// waiting longer after each crash in a row. While it restarts, requests for
+// the model wait in the queue. If the process can't be restarted, the model is
+// dropped from the pool and the next request for it starts a new process.
+func (s *Server) supervise(p *teiProcess, exit *exitStatus) {
+	<-exit.done
+
+	s.mu.Lock()
+	if p.stopping || s.processes[p.model] != p {
+		s.mu.Unlock()
+		return
+	}
+	p.crashes++
+	s.crashes++
+	p.restarting = true
+	p.ready = make(chan struct{})
+	if time.Since(p.loadedAt) > stableUptime {
+		p.backoff = 0
+	}
+	s.mu.Unlock()
+
+	slog.Error("TEI process exited unexpectedly", "model", p.model, "error", exit.err)
+
+	for failed := 0; failed < maxFailedRestarts; failed++ {
+		s.mu.Lock()
+		p.backoff = s.nextBackoff(p.backoff)
+		wait := p.backoff
+		s.mu.Unlock()
+
+		slog.Info("Restarting TEI", "model", p.model, "backoff", wait)
+		time.Sleep(wait)
+
+		s.mu.Lock()
+		abandoned := p.stopping || s.processes[p.model] != p
+		s.mu.Unlock()
+		if abandoned {
+			return
+		}
+
+		memoryKB, err := s.load(p)
+		if err != nil {
+			slog.Warn("Failed to restart TEI", "model", p.model, "error", err)
+			continue
+		}
+
+		s.mu.Lock()
+		if p.stopping || s.processes[p.model] != p {
+			// Stopped while restarting
+			s.mu.Unlock()
+			s.terminate(p)
+			return
+		}
+		p.restarting = false
+		s.markReady(p, memoryKB)
+		s.notifyLocked()
+		crashes := p.crashes
+		s.mu.Unlock()
+
+		slog.Info("TEI restarted", "model", p.model, "crashes", crashes)
+		return
+	}
+
+	s.mu.Lock()
+	slog.Error("Giving up on TEI after failed restarts", "model", p.model, "restarts", maxFailedRestarts)
+	p.err = fmt.Errorf("TEI for %s crashed and could not be restarted", p.model)
+	p.restarting = false
+	close(p.ready)
+	if s.processes[p.model] == p {
+		delete(s.processes, p.model)
+	}
+	s.notifyLocked()
+	s.mu.Unlock()
+}
+
+// nextBackoff doubles the restart backoff, starting at restartBackoff and
+// capped at maxRestartBackoff. Must be called with s.mu held.
+func (s *Server) nextBackoff(backoff time.Duration) time.Duration {
+	if backoff == 0 {
+		return s.restartBackoff
+	}
+	backoff *= 2
+	if backoff > s.maxRestartBackoff {
+		backoff = s.maxRestartBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// crashableServer creates a wrapper whose launcher starts a mock TEI server and
+// records each launch's exit status, so tests can simulate crashes
+type crashableServer struct {
+	*Server
+	mu       sync.Mutex
+	launches []*exitStatus
+	fail     bool // Launches fail while set
+}
+
+func newCrashableServer(t *testing.T) *crashableServer {
+	c := &crashableServer{Server: newServer(0, "", "model-a")}
+	c.client = &http.Client{Timeout: 10 * time.Second}
+	c.restartBackoff = time.Millisecond
+	c.maxRestartBackoff = 5 * time.Millisecond
+	c.launch = func(p *teiProcess) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.fail {
+			return errors.New("launch failed")
+		}
+		mockTEI := createMockTEI(t)
+		t.Cleanup(mockTEI.Close)
+		p.baseURL = mockTEI.URL
+		p.exit = &exitStatus{done: make(chan struct{})}
+		c.launches = append(c.launches, p.exit)
+		return nil
+	}
+	return c
+}
+
+// crash simulates the most recently launched process exiting
+func (c *crashableServer) crash() {
+	c.mu.Lock()
+	exit := c.launches[len(c.launches)-1]
+	c.mu.Unlock()
+	exit.err = errors.New("signal: killed")
+	close(exit.done)
+}
+
+// waitForCrashes waits until the supervisor has recorded n crashes
+func (c *crashableServer) waitForCrashes(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.Server.mu.Lock()
+		crashes := c.crashes
+		c.Server.mu.Unlock()
+		if crashes >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d crash(es)", n)
+}
+
+func (c *crashableServer) launchCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.launches)
+}
+
+func TestSupervisorRestartsCrashedProcess(t *testing.T) {
+	c := newCrashableServer(t)
+	p, err := c.acquire(context.Background(), "model-a")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	c.release(p)
+
+	c.crash()
+	c.waitForCrashes(t, 1)
+
+	// A request during the restart waits for it instead of failing
+	p, err = c.acquire(context.Background(), "model-a")
+	if err != nil {
+		t.Fatalf("Expected the request to wait for the restart, got %v", err)
+	}
+	c.release(p)
+	if c.launchCount() != 2 {
+		t.Errorf("Expected the process to be launched again, got %d launches", c.launchCount())
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(c.handleHealth))
+	defer testServer.Close()
+	resp, err := http.Get(testServer.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var health map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&health)
+	if health["status"] != "ok" || health["crashes"] != float64(1) {
+		t.Errorf("Expected an ok status reporting 1 crash, got %v", health)
+	}
+}
+
+func TestSupervisorGivesUp(t *testing.T) {
+	c := newCrashableServer(t)
+	p, err := c.acquire(context.Background(), "model-a")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	c.release(p)
+
+	c.mu.Lock()
+	c.fail = true
+	c.mu.Unlock()
+	c.crash()
+	c.waitForCrashes(t, 1)
+
+	if _, err := c.acquire(context.Background(), "model-a"); err == nil {
+		t.Fatal("Expected the request to fail once restarts are exhausted")
+	}
+	c.Server.mu.Lock()
+	_, ok := c.processes["model-a"]
+	c.Server.mu.Unlock()
+	if ok {
+		t.Error("Expected the crashed model to be dropped from the pool")
+	}
+}
+
+func TestSupervisorIgnoresStoppedProcess(t *testing.T) {
+	c := newCrashableServer(t)
+	p, err := c.acquire(context.Background(), "model-a")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	c.release(p)
+
+	c.stopAll()
+	c.crash()
+	time.Sleep(20 * time.Millisecond)
+
+	if c.launchCount() != 1 {
+		t.Errorf("Expected a stopped process not to be restarted, got %d launches", c.launchCount())
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	server := newServer(0, "", "m")
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	var backoff time.Duration
+	for _, want := range expected {
+		backoff = server.nextBackoff(backoff)
+		if backoff != want {
+			t.Errorf("Expected backoff %v, got %v", want, backoff)
+		}
+	}
+	if got := server.nextBackoff(50 * time.Second); got != time.Minute {
+		t.Errorf("Expected backoff capped at 1m, got %v", got)
+	}
+}
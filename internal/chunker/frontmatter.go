@@ -0,0 +1,96 @@
+package chunker
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter is the YAML front matter block at the top of a markdown file
+type frontMatter struct {
+	Title string    `yaml:"title"`
+	Date  string    `yaml:"date"`
+	Tags  yaml.Node `yaml:"tags"` // A list, or a comma- or space-separated string
+}
+
+// splitFrontMatter detects YAML front matter: a first line of "---", up to a
+// closing "---" or "...". It returns the front matter's metadata and the
+// index of the first line after it, or nil and 0 if there is none.
+func splitFrontMatter(lines []string) (map[string]string, int) {
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t") != "---" {
+		return nil, 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if isFrontMatterEnd(lines[i]) {
+			metadata, ok := parseFrontMatter(lines[1:i])
+			if !ok {
+				return nil, 0
+			}
+			return metadata, i + 1
+		}
+	}
+	return nil, 0
+}
+
+// isFrontMatterEnd reports whether line closes a front matter block
+func isFrontMatterEnd(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	return line == "---" || line == "..."
+}
+
+// parseFrontMatter parses the lines between the front matter delimiters into
+// chunk metadata: title, date and tags (comma-separated). It returns false if
+// the lines aren't a YAML mapping, e.g. a thematic break followed by text.
+func parseFrontMatter(lines []string) (map[string]string, bool) {
+	var fm frontMatter
+	var root yaml.Node
+	text := strings.Join(lines, "\n")
+	if err := yaml.Unmarshal([]byte(text), &root); err != nil {
+		return nil, false
+	}
+	if len(root.Content) > 0 && root.Content[0].Kind != yaml.MappingNode {
+		return nil, false
+	}
+	if err := root.Decode(&fm); err != nil {
+		return nil, false
+	}
+
+	metadata := make(map[string]string)
+	if title := strings.TrimSpace(fm.Title); title != "" {
+		metadata["title"] = title
+	}
+	if date := strings.TrimSpace(fm.Date); date != "" {
+		metadata["date"] = date
+	}
+	if tags := frontMatterTags(&fm.Tags); len(tags) > 0 {
+		metadata["tags"] = strings.Join(tags, ",")
+	}
+	return metadata, true
+}
+
+// frontMatterTags returns the tags of a "tags" list or string
+func frontMatterTags(node *yaml.Node) []string {
+	var raw []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				raw = append(raw, item.Value)
+			}
+		}
+	case yaml.ScalarNode:
+		if strings.Contains(node.Value, ",") {
+			raw = strings.Split(node.Value, ",")
+		} else {
+			raw = strings.Fields(node.Value)
+		}
+	}
+
+	var tags []string
+	for _, tag := range raw {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -3,10 +3,13 @@ package chunker
 import (
 	"bufio"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/google/uuid"
 )
@@ -14,17 +17,43 @@ import (
 var (
 	// Matches markdown headers: # Header, ## Header, ### Header
 	headerRegex = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+
+	// Matches opening code fences: ```go, ~~~python, ```
+	fenceRegex = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s{]*)")
 )
 
+// codeFence is an open fenced code block
+type codeFence struct {
+	marker    string // The opening fence; the block closes at a line of at least as many of its character
+	language  string
+	startLine int
+	lines     []string
+}
+
 // MarkdownChunker chunks markdown files by headers
-type MarkdownChunker struct{}
+type MarkdownChunker struct {
+	maxSectionTokens int              // Sections over this are split at paragraphs; 0 disables
+	countTokens      func(string) int // Counts a section's tokens
+}
 
 // NewMarkdownChunker creates a new MarkdownChunker
 func NewMarkdownChunker() *MarkdownChunker {
 	return &MarkdownChunker{}
 }
 
-// ChunkMarkdown splits a markdown file into sections based on headers (H1-H3)
+// SetSectionLimit makes the chunker split sections longer than maxTokens, as
+// counted by countTokens, at paragraph boundaries. 0 disables splitting.
+func (mc *MarkdownChunker) SetSectionLimit(maxTokens int, countTokens func(string) int) {
+	mc.maxSectionTokens = maxTokens
+	mc.countTokens = countTokens
+}
+
+// ChunkMarkdown splits a markdown file into sections based on headers (H1-H3).
+// Sections over the section limit are split into parts at paragraphs.
+// Fenced code blocks are emitted as separate "code_block" chunks, embedded
+// with the code model, and left out of their section's text. YAML front
+// matter is left out of every chunk; its title, date and tags are added to
+// each chunk's metadata.
 func (mc *MarkdownChunker) ChunkMarkdown(filePath string) ([]Chunk, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -32,17 +61,48 @@ func (mc *MarkdownChunker) ChunkMarkdown(filePath string) ([]Chunk, error) {
 	}
 	defer file.Close()
 
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	fileMetadata, bodyStart := splitFrontMatter(lines)
+
 	var chunks []Chunk
 	var currentLines []string
-	var chunkStartLine int = 1
+	var currentLineNums []int // The file line of each of currentLines
+	var chunkStartLine int = bodyStart + 1
 	var currentHeading string
+	var currentAnchor string // The current heading's link anchor, e.g. "#getting-started"
 	var currentLevel int
 	var parentHeadings []string // Stack of parent headings for context
-	lineNum := 1
+	var fence *codeFence        // Set while inside a fenced code block
+	var codeBlocks []Chunk      // Code blocks of the current section, added after it
+	lineNum := bodyStart + 1
+	anchors := make(map[string]int) // Uses of each anchor, to number repeated headings
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	for _, line := range lines[bodyStart:] {
+		// Inside a code block, lines are code until the closing fence
+		if fence != nil {
+			if isClosingFence(line, fence.marker) {
+				if block, ok := mc.createCodeBlockChunk(filePath, fence, currentHeading, currentAnchor, parentHeadings); ok {
+					codeBlocks = append(codeBlocks, block)
+				}
+				fence = nil
+			} else {
+				fence.lines = append(fence.lines, line)
+			}
+			lineNum++
+			continue
+		}
+		if matches := fenceRegex.FindStringSubmatch(line); matches != nil {
+			fence = &codeFence{marker: matches[1], language: strings.ToLower(matches[2]), startLine: lineNum + 1}
+			lineNum++
+			continue
+		}
 
 		// Check if this line is a header
 		if matches := headerRegex.FindStringSubmatch(line); matches != nil {
@@ -51,10 +111,12 @@ func (mc *MarkdownChunker) ChunkMarkdown(filePath string) ([]Chunk, error) {
 
 			// If we have accumulated content, create a chunk for it
 			if len(currentLines) > 0 {
-				chunk := mc.createChunk(filePath, chunkStartLine, lineNum-1, currentLines, currentHeading, currentLevel, parentHeadings)
-				chunks = append(chunks, chunk)
-				currentLines = nil
+				chunk := mc.createChunk(filePath, chunkStartLine, lineNum-1, currentLines, currentHeading, currentAnchor, currentLevel, parentHeadings)
+				chunks = append(chunks, mc.splitSection(chunk, currentLineNums)...)
+				currentLines, currentLineNums = nil, nil
 			}
+			chunks = append(chunks, codeBlocks...)
+			codeBlocks = nil
 
 			// Update parent heading stack based on level
 			// If we're at level 1, clear the stack
@@ -79,41 +141,109 @@ func (mc *MarkdownChunker) ChunkMarkdown(filePath string) ([]Chunk, error) {
 
 			// Start new section
 			currentHeading = headerText
+			currentAnchor = headingAnchor(headerText, anchors)
 			currentLevel = headerLevel
 			chunkStartLine = lineNum
-			currentLines = append(currentLines, line)
-		} else {
-			// Add line to current section
-			currentLines = append(currentLines, line)
 		}
+		// Add line to current section
+		currentLines = append(currentLines, line)
+		currentLineNums = append(currentLineNums, lineNum)
 
 		lineNum++
 	}
 
+	// An unclosed code block runs to the end of the file
+	if fence != nil {
+		if block, ok := mc.createCodeBlockChunk(filePath, fence, currentHeading, currentAnchor, parentHeadings); ok {
+			codeBlocks = append(codeBlocks, block)
+		}
+	}
+
 	// Create chunk for remaining content
 	if len(currentLines) > 0 {
-		chunk := mc.createChunk(filePath, chunkStartLine, lineNum-1, currentLines, currentHeading, currentLevel, parentHeadings)
-		chunks = append(chunks, chunk)
+		chunk := mc.createChunk(filePath, chunkStartLine, lineNum-1, currentLines, currentHeading, currentAnchor, currentLevel, parentHeadings)
+		chunks = append(chunks, mc.splitSection(chunk, currentLineNums)...)
 	}
+	chunks = append(chunks, codeBlocks...)
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+	// If we only have one text chunk with no heading, mark it as a document
+	var textChunks []int
+	for i, chunk := range chunks {
+		if chunk.ChunkType != "code_block" {
+			textChunks = append(textChunks, i)
+		}
+	}
+	if len(textChunks) == 1 && chunks[textChunks[0]].Name == "" {
+		doc := &chunks[textChunks[0]]
+		doc.ChunkType = "document"
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]string)
+		}
+		doc.Metadata["heading"] = filepath.Base(filePath)
+		if title, ok := fileMetadata["title"]; ok {
+			doc.Metadata["heading"] = title
+		}
 	}
 
-	// If we only have one chunk with no heading, mark it as a document
-	if len(chunks) == 1 && chunks[0].Name == "" {
-		chunks[0].ChunkType = "document"
-		if chunks[0].Metadata == nil {
-			chunks[0].Metadata = make(map[string]string)
+	for i := range chunks {
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = make(map[string]string)
+		}
+		for key, value := range fileMetadata {
+			chunks[i].Metadata[key] = value
 		}
-		chunks[0].Metadata["heading"] = filepath.Base(filePath)
 	}
 
 	return chunks, nil
 }
 
+// isClosingFence reports whether line closes a code block opened with marker:
+// a fence of the same character, at least as long, with nothing after it
+func isClosingFence(line, marker string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 || len(trimmed) < len(marker) {
+		return false
+	}
+	return strings.Trim(trimmed, marker[:1]) == ""
+}
+
+// createCodeBlockChunk creates a code chunk for a fenced code block, with the
+// fence language and the heading it appears under as metadata. Blocks with
+// only blank lines are skipped.
+func (mc *MarkdownChunker) createCodeBlockChunk(filePath string, fence *codeFence, heading, anchor string, parents []string) (Chunk, bool) {
+	code := strings.Join(fence.lines, "\n")
+	if strings.TrimSpace(code) == "" {
+		return Chunk{}, false
+	}
+
+	metadata := make(map[string]string)
+	if fence.language != "" {
+		metadata["code_language"] = fence.language
+	}
+	if heading != "" {
+		metadata["heading"] = heading
+	}
+	if len(parents) > 0 {
+		metadata["parent_heading"] = strings.Join(parents, " > ")
+	}
+	addOutline(metadata, heading, anchor, parents)
+
+	return Chunk{
+		ID:            uuid.New().String(),
+		FilePath:      filePath,
+		LineStart:     fence.startLine,
+		LineEnd:       fence.startLine + len(fence.lines) - 1,
+		Language:      "markdown",
+		Code:          code,
+		ChunkType:     "code_block",
+		Name:          heading,
+		Metadata:      metadata,
+		EmbeddingType: "code",
+	}, true
+}
+
 // createChunk creates a chunk with appropriate metadata
-func (mc *MarkdownChunker) createChunk(filePath string, startLine, endLine int, lines []string, heading string, level int, parents []string) Chunk {
+func (mc *MarkdownChunker) createChunk(filePath string, startLine, endLine int, lines []string, heading, anchor string, level int, parents []string) Chunk {
 	metadata := make(map[string]string)
 
 	if heading != "" {
@@ -124,6 +254,7 @@ func (mc *MarkdownChunker) createChunk(filePath string, startLine, endLine int,
 	if len(parents) > 0 {
 		metadata["parent_heading"] = strings.Join(parents, " > ")
 	}
+	addOutline(metadata, heading, anchor, parents)
 
 	chunkType := "section"
 	if heading == "" {
@@ -142,3 +273,104 @@ func (mc *MarkdownChunker) createChunk(filePath string, startLine, endLine int,
 		Metadata:  metadata,
 	}
 }
+
+// splitSection splits a section over the section token limit into parts at
+// paragraph boundaries: blank lines, and code blocks left out of its text.
+// lineNums holds the file line of each line of the section. Every part keeps
+// the section's heading metadata and records its position in "part" (e.g.
+// "2/3"). A paragraph over the limit is a part of its own.
+func (mc *MarkdownChunker) splitSection(section Chunk, lineNums []int) []Chunk {
+	if mc.maxSectionTokens <= 0 || mc.countTokens(section.Code) <= mc.maxSectionTokens {
+		return []Chunk{section}
+	}
+	lines := strings.Split(section.Code, "\n")
+
+	// Find the paragraphs, as ranges of lines. A heading on its own is kept
+	// with the paragraph after it.
+	type lineRange struct{ start, end int }
+	var paragraphs []lineRange
+	for start := 0; start < len(lines); {
+		if strings.TrimSpace(lines[start]) == "" {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" && lineNums[end] == lineNums[end-1]+1 {
+			end++
+		}
+		if len(paragraphs) == 1 && paragraphs[0].end == 1 && headerRegex.MatchString(lines[0]) {
+			paragraphs[0].end = end
+		} else {
+			paragraphs = append(paragraphs, lineRange{start, end})
+		}
+		start = end
+	}
+	if len(paragraphs) < 2 {
+		return []Chunk{section}
+	}
+
+	// Group consecutive paragraphs into parts within the limit
+	var groups []lineRange
+	current := paragraphs[0]
+	currentTokens := 0
+	for i, paragraph := range paragraphs {
+		tokens := mc.countTokens(strings.Join(lines[paragraph.start:paragraph.end], "\n")) + 1 // The paragraph break
+		if i > 0 && currentTokens+tokens > mc.maxSectionTokens {
+			groups = append(groups, current)
+			current, currentTokens = paragraph, 0
+		}
+		current.end = paragraph.end
+		currentTokens += tokens
+	}
+	groups = append(groups, current)
+
+	parts := make([]Chunk, 0, len(groups))
+	for i, group := range groups {
+		part := section
+		if i > 0 {
+			part.ID = uuid.New().String()
+		}
+		part.Code = strings.Join(lines[group.start:group.end], "\n")
+		part.LineStart = lineNums[group.start]
+		part.LineEnd = lineNums[group.end-1]
+		part.Metadata = maps.Clone(section.Metadata)
+		part.Metadata["part"] = fmt.Sprintf("%d/%d", i+1, len(groups))
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// addOutline adds a heading's link anchor and its breadcrumb, the path of
+// headings down to it (e.g. "Guide > Install"), to chunk metadata
+func addOutline(metadata map[string]string, heading, anchor string, parents []string) {
+	if heading == "" {
+		return
+	}
+	metadata["anchor"] = anchor
+	metadata["breadcrumb"] = strings.Join(append(slices.Clone(parents), heading), " > ")
+}
+
+// headingAnchor returns the link anchor of a heading as rendered by GitHub:
+// lowercased, with punctuation dropped and spaces turned into hyphens, e.g.
+// "Architecture Overview" becomes "#architecture-overview". A repeated
+// heading gets a numeric suffix ("#usage-1"); used counts each anchor's uses.
+func headingAnchor(heading string, used map[string]int) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			slug.WriteRune(r)
+		case r == ' ':
+			slug.WriteRune('-')
+		}
+	}
+
+	anchor := slug.String()
+	if n := used[anchor]; n > 0 {
+		used[anchor] = n + 1
+		anchor = fmt.Sprintf("%s-%d", anchor, n)
+	} else {
+		used[anchor] = 1
+	}
+	return "#" + anchor
+}
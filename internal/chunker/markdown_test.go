@@ -3,6 +3,7 @@ package chunker
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -169,3 +170,285 @@ New chapter.
 		}
 	}
 }
+
+func TestMarkdownChunker_CodeBlocks(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdFile := filepath.Join(tmpDir, "guide.md")
+
+	content := "# Guide\n" +
+		"\n" +
+		"## Usage\n" +
+		"\n" +
+		"Call Add:\n" +
+		"\n" +
+		"```Go\n" +
+		"# not a heading\n" +
+		"sum := Add(1, 2)\n" +
+		"```\n" +
+		"\n" +
+		"Then print it.\n" +
+		"\n" +
+		"~~~\n" +
+		"fmt.Println(sum)\n" +
+		"~~~\n" +
+		"\n" +
+		"```\n" +
+		"\n" +
+		"```\n"
+
+	if err := os.WriteFile(mdFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := NewMarkdownChunker().ChunkMarkdown(mdFile)
+	if err != nil {
+		t.Fatalf("ChunkMarkdown failed: %v", err)
+	}
+
+	var sections, blocks []Chunk
+	for _, chunk := range chunks {
+		if chunk.ChunkType == "code_block" {
+			blocks = append(blocks, chunk)
+		} else {
+			sections = append(sections, chunk)
+		}
+	}
+
+	// The heading inside the fence doesn't start a section
+	if len(sections) != 2 || sections[1].Name != "Usage" {
+		t.Fatalf("Expected sections Guide and Usage, got %+v", sections)
+	}
+	if strings.Contains(sections[1].Code, "Add(1, 2)") || !strings.Contains(sections[1].Code, "Then print it.") {
+		t.Errorf("Expected the section text without its code blocks, got %q", sections[1].Code)
+	}
+
+	// The empty block is skipped
+	if len(blocks) != 2 {
+		t.Fatalf("Expected 2 code blocks, got %d", len(blocks))
+	}
+	first := blocks[0]
+	if first.Code != "# not a heading\nsum := Add(1, 2)" || first.LineStart != 8 || first.LineEnd != 9 {
+		t.Errorf("Unexpected first code block: %q (lines %d-%d)", first.Code, first.LineStart, first.LineEnd)
+	}
+	if first.EmbeddingType != "code" || first.Metadata["code_language"] != "go" || first.Metadata["heading"] != "Usage" || first.Metadata["parent_heading"] != "Guide" {
+		t.Errorf("Unexpected first code block metadata: %q %v", first.EmbeddingType, first.Metadata)
+	}
+	if _, ok := blocks[1].Metadata["code_language"]; ok || blocks[1].Code != "fmt.Println(sum)" {
+		t.Errorf("Unexpected second code block: %q %v", blocks[1].Code, blocks[1].Metadata)
+	}
+
+	// Code blocks come after their section
+	if chunks[len(chunks)-1].ChunkType != "code_block" || chunks[1].Name != "Usage" {
+		t.Errorf("Expected code blocks to follow their section, got order %v", chunkTypes(chunks))
+	}
+}
+
+func chunkTypes(chunks []Chunk) []string {
+	types := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		types[i] = chunk.ChunkType
+	}
+	return types
+}
+
+func TestMarkdownChunker_FrontMatter(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdFile := filepath.Join(tmpDir, "post.md")
+
+	content := `---
+title: Indexing Guide
+date: 2024-05-01
+tags: [search, indexing]
+draft: true
+---
+# Overview
+
+How indexing works.
+`
+
+	if err := os.WriteFile(mdFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := NewMarkdownChunker().ChunkMarkdown(mdFile)
+	if err != nil {
+		t.Fatalf("ChunkMarkdown failed: %v", err)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+
+	chunk := chunks[0]
+	if strings.Contains(chunk.Code, "title:") || chunk.LineStart != 7 {
+		t.Errorf("Expected the front matter to be left out, got %q from line %d", chunk.Code, chunk.LineStart)
+	}
+	if chunk.Metadata["title"] != "Indexing Guide" || chunk.Metadata["date"] != "2024-05-01" || chunk.Metadata["tags"] != "search,indexing" {
+		t.Errorf("Unexpected front matter metadata: %v", chunk.Metadata)
+	}
+	if _, ok := chunk.Metadata["draft"]; ok {
+		t.Errorf("Expected only title, date and tags to be kept: %v", chunk.Metadata)
+	}
+}
+
+func TestMarkdownChunker_FrontMatterFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		heading string
+		tags    string
+	}{
+		// A thematic break followed by prose is not front matter
+		{"rule", "---\nJust some text.\n---\nMore text.\n", "rule.md", ""},
+		{"unclosed", "---\ntitle: Draft\n\nNo closing delimiter.\n", "unclosed.md", ""},
+		{"tags-string", "---\ntitle: Notes\ntags: go, cli\n...\nPlain notes.\n", "Notes", "go,cli"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdFile := filepath.Join(tmpDir, tt.name+".md")
+			if err := os.WriteFile(mdFile, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			chunks, err := NewMarkdownChunker().ChunkMarkdown(mdFile)
+			if err != nil {
+				t.Fatalf("ChunkMarkdown failed: %v", err)
+			}
+			if len(chunks) != 1 || chunks[0].ChunkType != "document" {
+				t.Fatalf("Expected one document chunk, got %+v", chunks)
+			}
+			if chunks[0].Metadata["heading"] != tt.heading || chunks[0].Metadata["tags"] != tt.tags {
+				t.Errorf("Unexpected metadata: %v", chunks[0].Metadata)
+			}
+		})
+	}
+}
+
+func TestMarkdownChunker_SplitsOversizedSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdFile := filepath.Join(tmpDir, "guide.md")
+	content := "# Guide\n" +
+		"## Install\n" +
+		"\n" +
+		"alpha beta gamma delta\n" +
+		"epsilon zeta\n" +
+		"\n" +
+		"```sh\n" +
+		"make install\n" +
+		"```\n" +
+		"eta theta iota kappa\n" +
+		"\n" +
+		"lambda mu nu xi\n" +
+		"## Next\n" +
+		"Short.\n"
+	if err := os.WriteFile(mdFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mc := NewMarkdownChunker()
+	mc.SetSectionLimit(10, func(s string) int { return len(strings.Fields(s)) })
+	chunks, err := mc.ChunkMarkdown(mdFile)
+	if err != nil {
+		t.Fatalf("ChunkMarkdown failed: %v", err)
+	}
+
+	got := chunkTypes(chunks)
+	want := []string{"section", "section", "section", "code_block", "section"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected chunk types %v, got %v", want, got)
+	}
+
+	// The heading stays with the first paragraph; the code block separates paragraphs
+	parts := []struct {
+		code               string
+		lineStart, lineEnd int
+		part               string
+	}{
+		{"## Install\n\nalpha beta gamma delta\nepsilon zeta", 2, 5, "1/2"},
+		{"eta theta iota kappa\n\nlambda mu nu xi", 10, 12, "2/2"},
+	}
+	for i, want := range parts {
+		chunk := chunks[i+1]
+		if chunk.Code != want.code || chunk.LineStart != want.lineStart || chunk.LineEnd != want.lineEnd {
+			t.Errorf("Part %d: expected lines %d-%d %q, got lines %d-%d %q",
+				i+1, want.lineStart, want.lineEnd, want.code, chunk.LineStart, chunk.LineEnd, chunk.Code)
+		}
+		if chunk.Name != "Install" || chunk.Metadata["heading"] != "Install" ||
+			chunk.Metadata["heading_level"] != "2" || chunk.Metadata["parent_heading"] != "Guide" {
+			t.Errorf("Part %d lost its heading metadata: name %q, %v", i+1, chunk.Name, chunk.Metadata)
+		}
+		if chunk.Metadata["part"] != want.part {
+			t.Errorf("Part %d: expected part %q, got %q", i+1, want.part, chunk.Metadata["part"])
+		}
+	}
+	if chunks[1].ID == chunks[2].ID {
+		t.Error("Expected parts to have distinct IDs")
+	}
+	if _, ok := chunks[4].Metadata["part"]; ok {
+		t.Errorf("Expected a section within the limit not to be split, got %v", chunks[4].Metadata)
+	}
+}
+
+func TestMarkdownChunker_Outline(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdFile := filepath.Join(tmpDir, "outline.md")
+	content := "# Architecture Overview\n" +
+		"Intro.\n" +
+		"## Usage\n" +
+		"```go\n" +
+		"run()\n" +
+		"```\n" +
+		"# CLI\n" +
+		"## Usage\n" +
+		"Flags.\n"
+	if err := os.WriteFile(mdFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := NewMarkdownChunker().ChunkMarkdown(mdFile)
+	if err != nil {
+		t.Fatalf("ChunkMarkdown failed: %v", err)
+	}
+
+	want := []struct{ anchor, breadcrumb string }{
+		{"#architecture-overview", "Architecture Overview"},
+		{"#usage", "Architecture Overview > Usage"},
+		{"#usage", "Architecture Overview > Usage"}, // The code block
+		{"#cli", "CLI"},
+		{"#usage-1", "CLI > Usage"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %v", len(want), len(chunks), chunkTypes(chunks))
+	}
+	for i, w := range want {
+		if chunks[i].Metadata["anchor"] != w.anchor || chunks[i].Metadata["breadcrumb"] != w.breadcrumb {
+			t.Errorf("Chunk %d: expected %s (%s), got %s (%s)", i, w.anchor, w.breadcrumb,
+				chunks[i].Metadata["anchor"], chunks[i].Metadata["breadcrumb"])
+		}
+	}
+}
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{"Getting Started", "#getting-started"},
+		{"What's new in v2.0?", "#whats-new-in-v20"},
+		{"`--max-tokens` flag", "#--max-tokens-flag"},
+		{"snake_case & Ünïcode", "#snake_case--ünïcode"},
+	}
+	for _, tt := range tests {
+		if got := headingAnchor(tt.heading, make(map[string]int)); got != tt.want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", tt.heading, got, tt.want)
+		}
+	}
+
+	used := make(map[string]int)
+	for _, want := range []string{"#faq", "#faq-1", "#faq-2"} {
+		if got := headingAnchor("FAQ", used); got != want {
+			t.Errorf("Repeated heading: got %q, want %q", got, want)
+		}
+	}
+}
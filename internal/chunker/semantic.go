@@ -4,11 +4,28 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jlanders/code-scout/internal/parser"
 )
 
+// ParseError is a code file that couldn't be parsed into chunks
+type ParseError struct {
+	Path     string
+	Language string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s as %s: %v", e.Path, e.Language, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // SemanticChunker uses Tree-sitter for code and header-based chunking for docs
 type SemanticChunker struct {
 	markdownChunker *MarkdownChunker
@@ -21,6 +38,12 @@ func NewSemantic() (*SemanticChunker, error) {
 	}, nil
 }
 
+// SetSectionLimit splits markdown sections longer than maxTokens, as counted
+// by countTokens, at paragraph boundaries. 0 disables splitting.
+func (s *SemanticChunker) SetSectionLimit(maxTokens int, countTokens func(string) int) {
+	s.markdownChunker.SetSectionLimit(maxTokens, countTokens)
+}
+
 // ChunkFile splits a file into semantic chunks based on language type
 func (s *SemanticChunker) ChunkFile(filePath, language string) ([]Chunk, error) {
 	// Route to appropriate chunker based on language
@@ -31,11 +54,15 @@ func (s *SemanticChunker) ChunkFile(filePath, language string) ([]Chunk, error)
 	case "markdown", "text", "rst":
 		// Documentation files - use markdown chunker
 		chunks, err = s.chunkDocumentation(filePath, language)
-	case "go", "python", "javascript", "typescript", "java", "rust", "c", "cpp", "ruby", "php", "scala":
-		// Code files - use tree-sitter
-		chunks, err = s.chunkCode(filePath, language)
+	case "shell":
+		// Shell scripts have no parser - the whole script is one chunk
+		chunks, err = s.chunkScript(filePath, language)
 	default:
-		return nil, fmt.Errorf("unsupported language: %s", language)
+		// Code files, including registered languages - use tree-sitter
+		if !parser.LanguageByName(language).IsSupported() {
+			return nil, fmt.Errorf("unsupported language: %s", language)
+		}
+		chunks, err = s.chunkCode(filePath, language)
 	}
 
 	if err != nil {
@@ -77,14 +104,42 @@ func (s *SemanticChunker) chunkDocumentation(filePath, language string) ([]Chunk
 		return nil, err
 	}
 
-	// Set embedding_type to "docs" for all documentation chunks
+	// Set embedding_type to "docs" for documentation chunks; code blocks
+	// extracted from markdown already use the code model
 	for i := range chunks {
-		chunks[i].EmbeddingType = "docs"
+		if chunks[i].EmbeddingType == "" {
+			chunks[i].EmbeddingType = "docs"
+		}
 	}
 
 	return chunks, nil
 }
 
+// chunkScript makes a whole script one code chunk, named after the file
+func (s *SemanticChunker) chunkScript(filePath, language string) ([]Chunk, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	code := strings.TrimRight(string(content), "\n")
+	if strings.TrimSpace(code) == "" {
+		return nil, nil
+	}
+
+	chunks := []Chunk{{
+		ID:            uuid.New().String(),
+		FilePath:      filePath,
+		LineStart:     1,
+		LineEnd:       strings.Count(code, "\n") + 1,
+		Language:      language,
+		Code:          code,
+		ChunkType:     "script",
+		Name:          filepath.Base(filePath),
+		EmbeddingType: "code",
+	}}
+	return append(chunks, todoChunks(filePath, language, content, nil)...), nil
+}
+
 // chunkCode handles code files with tree-sitter for all supported languages
 func (s *SemanticChunker) chunkCode(filePath, language string) ([]Chunk, error) {
 	// Read the source file
@@ -96,20 +151,20 @@ func (s *SemanticChunker) chunkCode(filePath, language string) ([]Chunk, error)
 	// Detect language from file path and content
 	lang := parser.DetectLanguage(filePath, sourceCode)
 	if lang == parser.LanguageUnknown {
-		return nil, fmt.Errorf("could not detect language for file: %s", filePath)
+		return nil, &ParseError{Path: filePath, Language: language, Err: fmt.Errorf("could not detect language")}
 	}
 
 	// Create parser for the detected language
 	p, err := parser.NewParser(lang)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create parser for %s: %w", lang.String(), err)
+		return nil, &ParseError{Path: filePath, Language: lang.String(), Err: fmt.Errorf("failed to create parser: %w", err)}
 	}
 
 	// Extract semantic chunks using Tree-sitter
 	extractor := parser.NewExtractor(p, sourceCode)
 	parserChunks, err := extractor.ExtractFunctions(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract chunks: %w", err)
+		return nil, &ParseError{Path: filePath, Language: lang.String(), Err: fmt.Errorf("failed to extract chunks: %w", err)}
 	}
 
 	// Convert parser chunks to chunker chunks
@@ -152,8 +207,19 @@ func (s *SemanticChunker) chunkCode(filePath, language string) ([]Chunk, error)
 			chunk.Metadata["doc_comment"] = pc.DocComment
 		}
 
+		// Add the code a test exercises, so tests can be found by their target
+		if target := testTarget(filePath, language, pc.Name); target != "" {
+			if chunk.Metadata == nil {
+				chunk.Metadata = make(map[string]string)
+			}
+			chunk.Metadata["test_target"] = target
+		}
+
 		chunks = append(chunks, chunk)
 	}
 
+	// Work item comments become their own chunks
+	chunks = append(chunks, todoChunks(filePath, language, sourceCode, chunks)...)
+
 	return chunks, nil
 }
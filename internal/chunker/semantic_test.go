@@ -211,6 +211,52 @@ class Person:
 	}
 }
 
+func TestSemanticChunkerShebangScripts(t *testing.T) {
+	tmpDir := t.TempDir()
+	deploy := filepath.Join(tmpDir, "deploy")
+	setup := filepath.Join(tmpDir, "setup")
+	if err := os.WriteFile(deploy, []byte("#!/usr/bin/env python3\ndef main():\n    pass\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(setup, []byte("#!/bin/sh\nset -e\nmake install\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	chunker, err := NewSemantic()
+	if err != nil {
+		t.Fatalf("Failed to create semantic chunker: %v", err)
+	}
+
+	// An extensionless Python script is parsed using its shebang
+	chunks, err := chunker.ChunkFile(deploy, "python")
+	if err != nil {
+		t.Fatalf("Failed to chunk Python script: %v", err)
+	}
+	foundMain := false
+	for _, chunk := range chunks {
+		if chunk.ChunkType == "function" && chunk.Name == "main" {
+			foundMain = true
+		}
+	}
+	if !foundMain {
+		t.Errorf("Expected the main function, got %+v", chunks)
+	}
+
+	// A shell script is one chunk
+	chunks, err = chunker.ChunkFile(setup, "shell")
+	if err != nil {
+		t.Fatalf("Failed to chunk shell script: %v", err)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+	chunk := chunks[0]
+	if chunk.ChunkType != "script" || chunk.Name != "setup" || chunk.EmbeddingType != "code" ||
+		chunk.LineStart != 1 || chunk.LineEnd != 3 {
+		t.Errorf("Unexpected script chunk: %+v", chunk)
+	}
+}
+
 func contains(s, substr string) bool {
 	if s == "" || substr == "" {
 		return false
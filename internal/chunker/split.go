@@ -0,0 +1,73 @@
+package chunker
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SplitOversized splits a chunk whose code exceeds maxTokens, as counted by
+// countTokens, into consecutive parts on line boundaries. A single line over
+// the limit is truncated, in which case truncated is true. A chunk within
+// the limit is returned unchanged. The first part keeps the chunk's ID; each
+// part records its position in the "part" metadata field (e.g. "2/3").
+func SplitOversized(chunk Chunk, maxTokens int, countTokens func(string) int) (parts []Chunk, truncated bool) {
+	if maxTokens <= 0 || countTokens(chunk.Code) <= maxTokens {
+		return []Chunk{chunk}, false
+	}
+
+	var groups [][]string
+	var current []string
+	currentTokens := 0
+	for _, line := range strings.Split(chunk.Code, "\n") {
+		lineTokens := countTokens(line) + 1 // The newline
+		if lineTokens > maxTokens {
+			line = truncateToTokens(line, maxTokens-1, countTokens)
+			lineTokens = maxTokens
+			truncated = true
+		}
+		if len(current) > 0 && currentTokens+lineTokens > maxTokens {
+			groups = append(groups, current)
+			current, currentTokens = nil, 0
+		}
+		current = append(current, line)
+		currentTokens += lineTokens
+	}
+	groups = append(groups, current)
+
+	lineStart := chunk.LineStart
+	for i, lines := range groups {
+		part := chunk
+		if i > 0 {
+			part.ID = uuid.New().String()
+		}
+		part.Code = strings.Join(lines, "\n")
+		part.LineStart = lineStart
+		part.LineEnd = lineStart + len(lines) - 1
+		part.Metadata = maps.Clone(chunk.Metadata)
+		if part.Metadata == nil {
+			part.Metadata = make(map[string]string)
+		}
+		part.Metadata["part"] = fmt.Sprintf("%d/%d", i+1, len(groups))
+		parts = append(parts, part)
+		lineStart = part.LineEnd + 1
+	}
+	return parts, truncated
+}
+
+// truncateToTokens returns the longest prefix of line within maxTokens
+func truncateToTokens(line string, maxTokens int, countTokens func(string) int) string {
+	runes := []rune(line)
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if countTokens(string(runes[:mid])) <= maxTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return string(runes[:low])
+}
@@ -0,0 +1,58 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+// countWords counts whitespace-separated words, standing in for a tokenizer
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestSplitOversized(t *testing.T) {
+	chunk := Chunk{
+		ID:        "orig",
+		FilePath:  "/repo/big.go",
+		LineStart: 10,
+		LineEnd:   13,
+		Code:      "a b\nc d\ne f\ng h",
+		Name:      "Big",
+		Metadata:  map[string]string{"package": "main"},
+	}
+
+	parts, truncated := SplitOversized(chunk, 6, countWords)
+	if truncated {
+		t.Error("expected no truncation")
+	}
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d: %+v", len(parts), parts)
+	}
+	if parts[0].ID != "orig" || parts[1].ID == "orig" {
+		t.Errorf("expected the first part to keep the chunk ID, got %q and %q", parts[0].ID, parts[1].ID)
+	}
+	if parts[0].Code != "a b\nc d" || parts[0].LineStart != 10 || parts[0].LineEnd != 11 {
+		t.Errorf("unexpected first part: %+v", parts[0])
+	}
+	if parts[1].LineStart != 12 || parts[1].LineEnd != 13 || parts[1].Metadata["part"] != "2/2" || parts[1].Metadata["package"] != "main" {
+		t.Errorf("unexpected second part: %+v", parts[1])
+	}
+	if _, ok := chunk.Metadata["part"]; ok {
+		t.Error("splitting modified the original chunk's metadata")
+	}
+}
+
+func TestSplitOversized_WithinLimit(t *testing.T) {
+	chunk := Chunk{ID: "c", Code: "a b c"}
+	if parts, _ := SplitOversized(chunk, 3, countWords); len(parts) != 1 || parts[0].Metadata != nil {
+		t.Errorf("expected the chunk unchanged, got %+v", parts)
+	}
+}
+
+func TestSplitOversized_TruncatesLongLine(t *testing.T) {
+	chunk := Chunk{ID: "c", LineStart: 1, LineEnd: 1, Code: "a b c d e f g h"}
+	parts, truncated := SplitOversized(chunk, 4, countWords)
+	if !truncated || len(parts) != 1 || parts[0].Code != "a b c " {
+		t.Errorf("expected the line truncated to 3 words, got %v %+v", truncated, parts)
+	}
+}
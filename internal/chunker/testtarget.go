@@ -0,0 +1,51 @@
+package chunker
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// goTestPrefixes are the name prefixes of Go test, benchmark and fuzz functions
+var goTestPrefixes = []string{"Test", "Benchmark", "Fuzz"}
+
+// jsTestInfixes mark JavaScript and TypeScript test files: foo.test.js, foo.spec.ts
+var jsTestInfixes = []string{".test.", ".spec."}
+
+// testTarget returns the name of the code a test chunk exercises, going by
+// each language's test naming conventions, or "" if the chunk isn't a test:
+//   - Go: TestFoo, TestFoo_bar, BenchmarkFoo and FuzzFoo in _test.go files test Foo
+//   - Python: test_foo tests foo, and a TestFoo class tests Foo
+//   - JavaScript/TypeScript: every chunk of foo.test.js or foo.spec.ts tests foo
+func testTarget(filePath, language, name string) string {
+	switch language {
+	case "go":
+		if !strings.HasSuffix(filePath, "_test.go") {
+			return ""
+		}
+		for _, prefix := range goTestPrefixes {
+			rest, ok := strings.CutPrefix(name, prefix)
+			if !ok {
+				continue
+			}
+			// Test_foo tests the unexported foo; TestFoo_case tests Foo
+			rest = strings.TrimPrefix(rest, "_")
+			target, _, _ := strings.Cut(rest, "_")
+			return target
+		}
+	case "python":
+		if rest, ok := strings.CutPrefix(name, "test_"); ok {
+			return rest
+		}
+		if rest, ok := strings.CutPrefix(name, "Test"); ok {
+			return rest
+		}
+	case "javascript", "typescript":
+		base := filepath.Base(filePath)
+		for _, infix := range jsTestInfixes {
+			if i := strings.Index(base, infix); i > 0 {
+				return base[:i]
+			}
+		}
+	}
+	return ""
+}
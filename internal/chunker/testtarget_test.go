@@ -0,0 +1,30 @@
+package chunker
+
+import "testing"
+
+func TestTestTarget(t *testing.T) {
+	tests := []struct {
+		filePath, language, name string
+		want                     string
+	}{
+		{"scanner_test.go", "go", "TestScanCodeFiles", "ScanCodeFiles"},
+		{"scanner_test.go", "go", "TestScanCodeFiles_SkipRules", "ScanCodeFiles"},
+		{"scanner_test.go", "go", "Test_shebangLanguage", "shebangLanguage"},
+		{"scanner_test.go", "go", "BenchmarkScan", "Scan"},
+		{"scanner_test.go", "go", "writeTree", ""},
+		{"scanner.go", "go", "TestMode", ""},
+		{"tests/test_config.py", "python", "test_load_config", "load_config"},
+		{"tests/test_config.py", "python", "TestConfigLoader", "ConfigLoader"},
+		{"tests/test_config.py", "python", "helper", ""},
+		{"src/parser.test.ts", "typescript", "", "parser"},
+		{"src/parser.spec.js", "javascript", "parse", "parser"},
+		{"src/parser.js", "javascript", "parse", ""},
+		{"Main.java", "java", "testMain", ""},
+	}
+
+	for _, tt := range tests {
+		if got := testTarget(tt.filePath, tt.language, tt.name); got != tt.want {
+			t.Errorf("testTarget(%q, %q, %q) = %q, want %q", tt.filePath, tt.language, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// todoComment matches a comment starting with a work item marker, with an
+// optional owner: "// TODO(alice): handle retries", "# FIXME flaky", "/* HACK */"
+var todoComment = regexp.MustCompile(`(?://|#|/\*|\*|--)\s*(TODO|FIXME|HACK)\b(?:\(([^)]*)\))?:?\s*(.*)$`)
+
+// commentContinuation matches a comment line without a marker, which continues
+// the work item above it
+var commentContinuation = regexp.MustCompile(`^\s*(?://|#|\*|--)\s*(.*)$`)
+
+// todoChunks extracts TODO, FIXME and HACK comments from source as "todo"
+// chunks, each with the comment lines that follow it. They are embedded with
+// the docs model, and carry the enclosing chunk's name and type, if any, as
+// "function" and "enclosing_type" metadata.
+func todoChunks(filePath, language string, source []byte, enclosing []Chunk) []Chunk {
+	lines := strings.Split(string(source), "\n")
+
+	var chunks []Chunk
+	for i := 0; i < len(lines); i++ {
+		match := todoComment.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+
+		text := []string{strings.TrimSpace(strings.TrimSuffix(match[3], "*/"))}
+		end := i
+		for end+1 < len(lines) && !todoComment.MatchString(lines[end+1]) {
+			continuation := commentContinuation.FindStringSubmatch(lines[end+1])
+			if continuation == nil || strings.TrimSpace(continuation[1]) == "" {
+				break
+			}
+			text = append(text, strings.TrimSpace(strings.TrimSuffix(continuation[1], "*/")))
+			end++
+		}
+
+		metadata := map[string]string{"marker": match[1]}
+		if match[2] != "" {
+			metadata["owner"] = match[2]
+		}
+		if chunk := innermostChunk(enclosing, i+1); chunk != nil {
+			metadata["function"] = chunk.Name
+			metadata["enclosing_type"] = chunk.ChunkType
+		}
+
+		chunks = append(chunks, Chunk{
+			ID:            uuid.New().String(),
+			FilePath:      filePath,
+			LineStart:     i + 1,
+			LineEnd:       end + 1,
+			Language:      language,
+			Code:          match[1] + ": " + strings.Join(text, " "),
+			ChunkType:     "todo",
+			Name:          match[1],
+			Metadata:      metadata,
+			EmbeddingType: "docs",
+		})
+		i = end
+	}
+	return chunks
+}
+
+// innermostChunk returns the smallest named chunk spanning line, or nil
+func innermostChunk(chunks []Chunk, line int) *Chunk {
+	var innermost *Chunk
+	for i := range chunks {
+		c := &chunks[i]
+		if c.Name == "" || line < c.LineStart || line > c.LineEnd {
+			continue
+		}
+		if innermost == nil || c.LineEnd-c.LineStart < innermost.LineEnd-innermost.LineStart {
+			innermost = c
+		}
+	}
+	return innermost
+}
@@ -0,0 +1,44 @@
+package chunker
+
+import "testing"
+
+func TestTodoChunks(t *testing.T) {
+	source := `package main
+
+// TODO(alice): retry failed requests
+// with exponential backoff
+func fetch() {
+	x := 1 // FIXME off by one
+
+	/* HACK: skip validation */
+}
+
+// A TODO in prose isn't a marker
+`
+	enclosing := []Chunk{{Name: "fetch", ChunkType: "function", LineStart: 5, LineEnd: 9}}
+	chunks := todoChunks("main.go", "go", []byte(source), enclosing)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 todo chunks, got %d: %+v", len(chunks), chunks)
+	}
+
+	first := chunks[0]
+	if first.Code != "TODO: retry failed requests with exponential backoff" {
+		t.Errorf("Unexpected code: %q", first.Code)
+	}
+	if first.LineStart != 3 || first.LineEnd != 4 {
+		t.Errorf("Expected lines 3-4, got %d-%d", first.LineStart, first.LineEnd)
+	}
+	if first.Metadata["owner"] != "alice" || first.Metadata["function"] != "" {
+		t.Errorf("Unexpected metadata: %v", first.Metadata)
+	}
+	if first.ChunkType != "todo" || first.EmbeddingType != "docs" {
+		t.Errorf("Expected a docs todo chunk, got %s %s", first.ChunkType, first.EmbeddingType)
+	}
+
+	if chunks[1].Metadata["marker"] != "FIXME" || chunks[1].Metadata["function"] != "fetch" {
+		t.Errorf("Expected a FIXME in fetch, got %v", chunks[1].Metadata)
+	}
+	if chunks[2].Code != "HACK: skip validation" {
+		t.Errorf("Unexpected code: %q", chunks[2].Code)
+	}
+}
@@ -6,15 +6,156 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/pathglob"
 )
 
 // Config holds the application configuration
 type Config struct {
 	Endpoint  string `json:"endpoint"`
-	APIKey    string `json:"api_key,omitempty"`    // Optional API key for authentication
+	APIKey    string `json:"api_key,omitempty"` // Optional API key for authentication
 	CodeModel string `json:"code_model"`
 	TextModel string `json:"text_model"`
+	// CodeMaxTokens and TextMaxTokens are the models' input limits; longer
+	// chunks are split before embedding (default: the model's known limit, or 8192)
+	CodeMaxTokens int `json:"code_max_tokens,omitempty"`
+	TextMaxTokens int `json:"text_max_tokens,omitempty"`
+	// CodeDimension and TextDimension are the models' expected vector
+	// dimensions; embeddings of any other size are rejected before they are
+	// stored (default: whatever the model returns, recorded in the index)
+	CodeDimension int `json:"code_dimension,omitempty"`
+	TextDimension int `json:"text_dimension,omitempty"`
+	// ModelPrefixes sets the instructions prepended to the documents and
+	// search queries embedded with a model, keyed by model name, e.g.
+	// {"nomic-embed-text": {"document": "search_document: ", "query": "search_query: "}}.
+	// An entry replaces the prefixes built in for well-known models; {} turns them off.
+	ModelPrefixes map[string]ModelPrefixes `json:"model_prefixes,omitempty"`
+	// ShadowCodeModel and ShadowTextModel build a second, shadow index beside
+	// the main one on every index run, for comparing models with search
+	// --compare. An unset shadow model falls back to the main model.
+	ShadowCodeModel string `json:"shadow_code_model,omitempty"`
+	ShadowTextModel string `json:"shadow_text_model,omitempty"`
+	// MaxSectionTokens splits markdown sections longer than this at paragraph
+	// boundaries, so one huge section doesn't become one huge chunk (default: 1024)
+	MaxSectionTokens int `json:"max_section_tokens,omitempty"`
+	// ChunkTemplate is the Go text/template rendering the text embedded for
+	// each code chunk, with fields .Path, .Package, .Language, .Symbol,
+	// .Signature, .DocComment and .Code (default: the context fields ahead
+	// of the code; "{{.Code}}" embeds the code alone)
+	ChunkTemplate string `json:"chunk_template,omitempty"`
+	// Provider selects the embedding API: "openai" (default; any
+	// OpenAI-compatible /v1/embeddings API, including Ollama), "cohere", "voyage",
+	// "llamacpp" (llama-server), or "onnx" (in-process; code_model and
+	// text_model are model directories)
+	Provider string `json:"provider,omitempty"`
+	// RateLimit caps embedding requests across all workers, so cloud
+	// providers' limits aren't tripped by concurrent indexing
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// EmbeddingTimeout limits each embedding request attempt, e.g. "30s" (default: 2m)
+	EmbeddingTimeout string `json:"embedding_timeout,omitempty"`
+	// MaxRetries is how many times a failed embedding request is retried (default: 2)
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// Backoff sets the wait between embedding request retries
+	Backoff *Backoff `json:"backoff,omitempty"`
+	// QueryCache keeps search query embeddings on disk in
+	// ~/.code-scout/query-cache/, so repeated and paginated searches don't
+	// embed their query again (default: true). They're always cached in memory.
+	QueryCache *bool `json:"query_cache,omitempty"`
+	// Tracing exports OpenTelemetry spans for each command (scanning, chunking,
+	// embedding batches, storage operations) to an OTLP collector. Setting it,
+	// even to {}, enables tracing.
+	Tracing *Tracing `json:"tracing,omitempty"`
+	// VectorPrecision is the element type used to store vectors. Only "float32"
+	// is supported: reduced-precision vectors can't be read back to compact or
+	// migrate a table (see docs/design/vector-storage.md)
+	VectorPrecision string `json:"vector_precision,omitempty"`
+	// Backend selects the vector store: "lancedb" (default, local) or "qdrant"
+	Backend          string `json:"backend,omitempty"`
+	QdrantURL        string `json:"qdrant_url,omitempty"`        // Qdrant HTTP API base URL
+	QdrantAPIKey     string `json:"qdrant_api_key,omitempty"`    // Optional Qdrant API key
+	QdrantCollection string `json:"qdrant_collection,omitempty"` // Collection name prefix (default: derived from project directory)
+	// LanceDBURI stores the LanceDB index in an object store (s3://, gs://, az://)
+	// instead of the project's .code-scout/ directory, so it can be shared
+	LanceDBURI string `json:"lancedb_uri,omitempty"`
+	// StorageOptions holds object store credentials and settings for lancedb_uri,
+	// e.g. access_key_id, region, endpoint. Unset keys fall back to the
+	// provider's standard environment variables.
+	StorageOptions map[string]string `json:"storage_options,omitempty"`
+	// GlobalIndex stores this project's index in the shared multi-project index
+	// at ~/.code-scout/global/ instead of the project's .code-scout/ directory
+	GlobalIndex bool `json:"global_index,omitempty"`
+	// Project names the project in the global index (default: the directory name)
+	Project string `json:"project,omitempty"`
+	// ChatModel enables LLM features (query expansion, ask, explain) using an
+	// OpenAI-compatible chat completions API
+	ChatModel    string `json:"chat_model,omitempty"`
+	ChatEndpoint string `json:"chat_endpoint,omitempty"` // Chat API base URL (default: endpoint)
+	ChatAPIKey   string `json:"chat_api_key,omitempty"`  // Chat API key (default: api_key)
+	// SummarizeChunksOver has the chat model write a one-paragraph summary of
+	// each code chunk longer than this many tokens at index time, embedded with
+	// the text model and linked to the chunk (0, the default, disables it)
+	SummarizeChunksOver int `json:"summarize_chunks_over,omitempty"`
+	// HybridWeights weights each ranking when search fuses code, docs, and
+	// keyword results (default: 1 each)
+	HybridWeights *HybridWeights `json:"hybrid_weights,omitempty"`
+	// RecencyWeight boosts search results from recently modified files (0 disables;
+	// 0.5 ranks a file modified just now 1.5x higher)
+	RecencyWeight float64 `json:"recency_weight,omitempty"`
+	// Boost multiplies the ranking of search results whose path (relative to
+	// the project root) matches a glob, e.g. {"**/testdata/**": 0.5}
+	Boost map[string]float64 `json:"boost,omitempty"`
+	// Skip lists globs (relative to the project root) of files and directories
+	// not to index, in addition to the built-in rules for vendored, generated
+	// and minified code (vendor/, node_modules/, dist/, target/, *.min.js,
+	// *.pb.go, "Code generated" headers)
+	Skip []string `json:"skip,omitempty"`
+	// Include lists globs of files indexed even if a skip rule matches them,
+	// e.g. ["vendor/github.com/acme/**"]
+	Include []string `json:"include,omitempty"`
+	// Languages enables or disables languages by name, e.g. {"php": false},
+	// leaving disabled ones out of indexing and search. Unlisted languages are enabled.
+	Languages map[string]bool `json:"languages,omitempty"`
+}
+
+// HybridWeights are reciprocal rank fusion weights for search rankings. Unset
+// (zero) weights default to 1.
+type HybridWeights struct {
+	Code    float64 `json:"code,omitempty"`    // Code embedding matches
+	Docs    float64 `json:"docs,omitempty"`    // Documentation embedding matches
+	Lexical float64 `json:"lexical,omitempty"` // Full-text keyword matches (--lexical)
+	// Identifier weights exact symbol name matches from the identifier index
+	Identifier float64 `json:"identifier,omitempty"`
+}
+
+// ModelPrefixes are a model's instruction prefixes
+type ModelPrefixes struct {
+	Document string `json:"document,omitempty"` // Prepended to indexed chunks
+	Query    string `json:"query,omitempty"`    // Prepended to search queries
+}
+
+// RateLimit is a client-side embedding request budget. Zero fields are unlimited.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+	TokensPerMinute   int     `json:"tokens_per_minute,omitempty"` // Estimated input tokens
+}
+
+// Tracing configures the OTLP/HTTP trace exporter. The standard
+// OTEL_EXPORTER_OTLP_* environment variables also apply.
+type Tracing struct {
+	// Endpoint is the collector's OTLP/HTTP URL (default: http://localhost:4318)
+	Endpoint string            `json:"endpoint,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"` // Sent with every export, e.g. for authentication
+}
+
+// Backoff is an exponential backoff policy. Unset fields keep their defaults.
+type Backoff struct {
+	Initial    string  `json:"initial,omitempty"`    // Wait before the first retry, e.g. "500ms" (default: 1s)
+	Max        string  `json:"max,omitempty"`        // Cap on the wait (default: 30s)
+	Multiplier float64 `json:"multiplier,omitempty"` // Growth after each retry (default: 2)
 }
 
 // Default returns the default configuration
@@ -48,15 +189,45 @@ func Load() (*Config, error) {
 
 // loadUserConfig loads ~/.code-scout/config.json
 func loadUserConfig() (*Config, error) {
-	homeDir, err := os.UserHomeDir()
+	configPath, err := userConfigPath()
 	if err != nil {
 		return nil, err
 	}
-
-	configPath := filepath.Join(homeDir, ".code-scout", "config.json")
 	return loadFromFile(configPath)
 }
 
+// userConfigPath returns the path of the user-level config file
+func userConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".code-scout", "config.json"), nil
+}
+
+// Sources returns the config files Load reads that exist, in the order they
+// are merged, or an error naming the first one that can't be read or parsed
+// (Load skips those)
+func Sources() ([]string, error) {
+	var paths []string
+	if userPath, err := userConfigPath(); err == nil {
+		paths = append(paths, userPath)
+	}
+	paths = append(paths, ".code-scout.json")
+
+	var sources []string
+	for _, path := range paths {
+		cfg, err := loadFromFile(path)
+		if err != nil {
+			return sources, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		if cfg != nil {
+			sources = append(sources, path)
+		}
+	}
+	return sources, nil
+}
+
 // loadProjectConfig loads .code-scout.json from current directory
 func loadProjectConfig() (*Config, error) {
 	return loadFromFile(".code-scout.json")
@@ -94,10 +265,137 @@ func mergeConfig(dst, src *Config) {
 	if src.TextModel != "" {
 		dst.TextModel = src.TextModel
 	}
+	if src.CodeMaxTokens != 0 {
+		dst.CodeMaxTokens = src.CodeMaxTokens
+	}
+	if src.TextMaxTokens != 0 {
+		dst.TextMaxTokens = src.TextMaxTokens
+	}
+	if src.CodeDimension != 0 {
+		dst.CodeDimension = src.CodeDimension
+	}
+	if src.TextDimension != 0 {
+		dst.TextDimension = src.TextDimension
+	}
+	if src.ShadowCodeModel != "" {
+		dst.ShadowCodeModel = src.ShadowCodeModel
+	}
+	if src.ShadowTextModel != "" {
+		dst.ShadowTextModel = src.ShadowTextModel
+	}
+	if src.MaxSectionTokens != 0 {
+		dst.MaxSectionTokens = src.MaxSectionTokens
+	}
+	if src.ChunkTemplate != "" {
+		dst.ChunkTemplate = src.ChunkTemplate
+	}
+	if src.Provider != "" {
+		dst.Provider = src.Provider
+	}
+	if src.RateLimit != nil {
+		dst.RateLimit = src.RateLimit
+	}
+	if src.EmbeddingTimeout != "" {
+		dst.EmbeddingTimeout = src.EmbeddingTimeout
+	}
+	if src.MaxRetries != nil {
+		dst.MaxRetries = src.MaxRetries
+	}
+	if src.QueryCache != nil {
+		dst.QueryCache = src.QueryCache
+	}
+	if src.Tracing != nil {
+		dst.Tracing = src.Tracing
+	}
+	if src.Backoff != nil {
+		dst.Backoff = src.Backoff
+	}
+	if src.VectorPrecision != "" {
+		dst.VectorPrecision = src.VectorPrecision
+	}
+	if src.Backend != "" {
+		dst.Backend = src.Backend
+	}
+	if src.QdrantURL != "" {
+		dst.QdrantURL = src.QdrantURL
+	}
+	if src.QdrantAPIKey != "" {
+		dst.QdrantAPIKey = src.QdrantAPIKey
+	}
+	if src.QdrantCollection != "" {
+		dst.QdrantCollection = src.QdrantCollection
+	}
+	if src.LanceDBURI != "" {
+		dst.LanceDBURI = src.LanceDBURI
+	}
+	if len(src.StorageOptions) > 0 {
+		dst.StorageOptions = src.StorageOptions
+	}
+	if src.GlobalIndex {
+		dst.GlobalIndex = true
+	}
+	if src.Project != "" {
+		dst.Project = src.Project
+	}
+	if src.ChatModel != "" {
+		dst.ChatModel = src.ChatModel
+	}
+	if src.ChatEndpoint != "" {
+		dst.ChatEndpoint = src.ChatEndpoint
+	}
+	if src.SummarizeChunksOver != 0 {
+		dst.SummarizeChunksOver = src.SummarizeChunksOver
+	}
+	if src.ChatAPIKey != "" {
+		dst.ChatAPIKey = src.ChatAPIKey
+	}
+	if src.HybridWeights != nil {
+		dst.HybridWeights = src.HybridWeights
+	}
+	if src.RecencyWeight != 0 {
+		dst.RecencyWeight = src.RecencyWeight
+	}
+	if len(src.Boost) > 0 {
+		dst.Boost = src.Boost
+	}
+	if len(src.Skip) > 0 {
+		dst.Skip = src.Skip
+	}
+	if len(src.Include) > 0 {
+		dst.Include = src.Include
+	}
+	if len(src.Languages) > 0 {
+		dst.Languages = src.Languages
+	}
+	if len(src.ModelPrefixes) > 0 {
+		dst.ModelPrefixes = src.ModelPrefixes
+	}
+}
+
+// Error is an invalid configuration
+type Error struct {
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
 }
 
-// Validate validates the configuration
+// Validate validates the configuration, returning an *Error describing the
+// first problem found
 func (c *Config) Validate() error {
+	if err := c.validate(); err != nil {
+		return &Error{Err: err}
+	}
+	return nil
+}
+
+// validate checks the configuration, normalizing endpoint URLs
+func (c *Config) validate() error {
 	// Validate endpoint is a valid URL
 	if c.Endpoint == "" {
 		return fmt.Errorf("endpoint cannot be empty")
@@ -123,9 +421,214 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("text_model cannot be empty")
 	}
 
+	if c.CodeMaxTokens < 0 || c.TextMaxTokens < 0 {
+		return fmt.Errorf("code_max_tokens and text_max_tokens must not be negative")
+	}
+	if c.CodeDimension < 0 || c.TextDimension < 0 {
+		return fmt.Errorf("code_dimension and text_dimension must not be negative")
+	}
+	if c.MaxSectionTokens < 0 {
+		return fmt.Errorf("max_section_tokens must not be negative")
+	}
+
+	switch c.Provider {
+	case "", "openai", "cohere", "voyage", "llamacpp", "onnx":
+	default:
+		return fmt.Errorf("provider must be openai, cohere, voyage, llamacpp, or onnx, got: %s", c.Provider)
+	}
+
+	if c.RateLimit != nil && (c.RateLimit.RequestsPerSecond < 0 || c.RateLimit.TokensPerMinute < 0) {
+		return fmt.Errorf("rate_limit must not be negative")
+	}
+
+	if err := validateDuration("embedding_timeout", c.EmbeddingTimeout); err != nil {
+		return err
+	}
+	if c.MaxRetries != nil && *c.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative, got: %d", *c.MaxRetries)
+	}
+	if c.Backoff != nil {
+		if err := validateDuration("backoff.initial", c.Backoff.Initial); err != nil {
+			return err
+		}
+		if err := validateDuration("backoff.max", c.Backoff.Max); err != nil {
+			return err
+		}
+		if c.Backoff.Multiplier != 0 && c.Backoff.Multiplier < 1 {
+			return fmt.Errorf("backoff.multiplier must be at least 1, got: %g", c.Backoff.Multiplier)
+		}
+	}
+
+	if c.ChunkTemplate != "" {
+		if _, err := template.New("chunk_template").Parse(c.ChunkTemplate); err != nil {
+			return fmt.Errorf("invalid chunk_template: %w", err)
+		}
+	}
+
+	if c.Tracing != nil && c.Tracing.Endpoint != "" {
+		parsedTracing, err := url.Parse(c.Tracing.Endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid tracing.endpoint URL: %w", err)
+		}
+		if parsedTracing.Scheme != "http" && parsedTracing.Scheme != "https" {
+			return fmt.Errorf("tracing.endpoint must use http or https scheme, got: %s", parsedTracing.Scheme)
+		}
+	}
+
+	switch c.VectorPrecision {
+	case "", "float32":
+	case "float16", "int8":
+		return fmt.Errorf("vector_precision %s is not supported: optimize and schema migrations can't read reduced-precision vectors back, so they would have to be re-embedded; remove the setting", c.VectorPrecision)
+	default:
+		return fmt.Errorf("vector_precision must be float32, got: %s", c.VectorPrecision)
+	}
+
+	switch c.Backend {
+	case "", "lancedb":
+	case "qdrant":
+		if c.QdrantURL == "" {
+			return fmt.Errorf("qdrant_url is required when backend is qdrant")
+		}
+		if _, err := url.Parse(c.QdrantURL); err != nil {
+			return fmt.Errorf("invalid qdrant_url: %w", err)
+		}
+	default:
+		return fmt.Errorf("backend must be lancedb or qdrant, got: %s", c.Backend)
+	}
+
+	if c.LanceDBURI != "" {
+		if c.Backend == "qdrant" {
+			return fmt.Errorf("lancedb_uri cannot be used with the qdrant backend")
+		}
+		parsedURI, err := url.Parse(c.LanceDBURI)
+		if err != nil {
+			return fmt.Errorf("invalid lancedb_uri: %w", err)
+		}
+		switch parsedURI.Scheme {
+		case "s3", "gs", "az":
+		default:
+			return fmt.Errorf("lancedb_uri must use s3, gs, or az scheme, got: %s", parsedURI.Scheme)
+		}
+		if parsedURI.Host == "" {
+			return fmt.Errorf("lancedb_uri must include a bucket or container name")
+		}
+	}
+
+	if c.GlobalIndex && (c.Backend == "qdrant" || c.LanceDBURI != "") {
+		return fmt.Errorf("global_index is only supported by the local lancedb backend")
+	}
+
+	if c.ChatEndpoint != "" {
+		parsedChat, err := url.Parse(c.ChatEndpoint)
+		if err != nil {
+			return fmt.Errorf("invalid chat_endpoint URL: %w", err)
+		}
+		if parsedChat.Scheme != "http" && parsedChat.Scheme != "https" {
+			return fmt.Errorf("chat_endpoint must use http or https scheme, got: %s", parsedChat.Scheme)
+		}
+		c.ChatEndpoint = strings.TrimSuffix(c.ChatEndpoint, "/")
+	}
+
+	if c.SummarizeChunksOver < 0 {
+		return fmt.Errorf("summarize_chunks_over must not be negative")
+	}
+	if c.SummarizeChunksOver > 0 && c.ChatModel == "" {
+		return fmt.Errorf("summarize_chunks_over requires chat_model")
+	}
+
+	if w := c.HybridWeights; w != nil && (w.Code < 0 || w.Docs < 0 || w.Lexical < 0 || w.Identifier < 0) {
+		return fmt.Errorf("hybrid_weights must not be negative")
+	}
+	if c.RecencyWeight < 0 {
+		return fmt.Errorf("recency_weight must not be negative, got: %g", c.RecencyWeight)
+	}
+	for pattern, factor := range c.Boost {
+		if err := pathglob.Validate(pattern); err != nil {
+			return fmt.Errorf("invalid boost pattern %q: %w", pattern, err)
+		}
+		if factor <= 0 {
+			return fmt.Errorf("boost for %q must be positive, got: %g", pattern, factor)
+		}
+	}
+	for _, pattern := range c.Skip {
+		if err := pathglob.Validate(pattern); err != nil {
+			return fmt.Errorf("invalid skip pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range c.Include {
+		if err := pathglob.Validate(pattern); err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+	}
+	for name := range c.Languages {
+		if name == "" || name != strings.ToLower(name) {
+			return fmt.Errorf("languages keys must be lowercase language names, got: %q", name)
+		}
+	}
+
 	return nil
 }
 
+// validateDuration checks that an optional duration field parses and isn't negative
+func validateDuration(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", field, err)
+	}
+	if d < 0 {
+		return fmt.Errorf("%s must not be negative, got: %s", field, value)
+	}
+	return nil
+}
+
+// FusionWeights returns the configured hybrid_weights with unset weights defaulted to 1
+func (c *Config) FusionWeights() HybridWeights {
+	weights := HybridWeights{Code: 1, Docs: 1, Lexical: 1, Identifier: 1}
+	if w := c.HybridWeights; w != nil {
+		if w.Code > 0 {
+			weights.Code = w.Code
+		}
+		if w.Docs > 0 {
+			weights.Docs = w.Docs
+		}
+		if w.Lexical > 0 {
+			weights.Lexical = w.Lexical
+		}
+		if w.Identifier > 0 {
+			weights.Identifier = w.Identifier
+		}
+	}
+	return weights
+}
+
+// DisabledLanguages returns the languages turned off in languages, sorted
+func (c *Config) DisabledLanguages() []string {
+	var disabled []string
+	for name, enabled := range c.Languages {
+		if !enabled {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(disabled)
+	return disabled
+}
+
+// ChatSettings returns the endpoint and API key for the chat API, falling back
+// to the embedding endpoint and key
+func (c *Config) ChatSettings() (endpoint, apiKey string) {
+	endpoint, apiKey = c.ChatEndpoint, c.ChatAPIKey
+	if endpoint == "" {
+		endpoint = c.Endpoint
+	}
+	if apiKey == "" {
+		apiKey = c.APIKey
+	}
+	return endpoint, apiKey
+}
+
 // Save saves the configuration to a file
 func (c *Config) Save(path string) error {
 	data, err := json.MarshalIndent(c, "", "  ")
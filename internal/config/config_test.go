@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -62,6 +64,30 @@ func TestLoadFromFile_NotExists(t *testing.T) {
 	}
 }
 
+func TestSources(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Chdir(tempDir)
+
+	if sources, err := Sources(); err != nil || len(sources) != 0 {
+		t.Fatalf("expected no config files, got %v, %v", sources, err)
+	}
+
+	if err := os.WriteFile(".code-scout.json", []byte(`{"endpoint": "http://custom:8080"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if sources, err := Sources(); err != nil || len(sources) != 1 || sources[0] != ".code-scout.json" {
+		t.Errorf("expected the project config, got %v, %v", sources, err)
+	}
+
+	if err := os.WriteFile(".code-scout.json", []byte(`{"endpoint": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sources(); err == nil || !strings.Contains(err.Error(), ".code-scout.json") {
+		t.Errorf("expected an error naming the malformed file, got %v", err)
+	}
+}
+
 func TestMergeConfig(t *testing.T) {
 	dst := Default()
 	src := &Config{
@@ -184,6 +210,295 @@ func TestValidate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "float16 vector precision",
+			config: &Config{
+				Endpoint:        "http://localhost:11434",
+				CodeModel:       "model1",
+				TextModel:       "model2",
+				VectorPrecision: "float16",
+			},
+			expectErr: true,
+		},
+		{
+			name: "unsupported vector precision",
+			config: &Config{
+				Endpoint:        "http://localhost:11434",
+				CodeModel:       "model1",
+				TextModel:       "model2",
+				VectorPrecision: "int4",
+			},
+			expectErr: true,
+		},
+		{
+			name: "qdrant backend",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Backend:   "qdrant",
+				QdrantURL: "http://localhost:6333",
+			},
+			expectErr: false,
+		},
+		{
+			name: "qdrant backend without url",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Backend:   "qdrant",
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown backend",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Backend:   "pinecone",
+			},
+			expectErr: true,
+		},
+		{
+			name: "s3 lancedb uri",
+			config: &Config{
+				Endpoint:   "http://localhost:11434",
+				CodeModel:  "model1",
+				TextModel:  "model2",
+				LanceDBURI: "s3://team-bucket/code-scout/repo",
+			},
+			expectErr: false,
+		},
+		{
+			name: "local path as lancedb uri",
+			config: &Config{
+				Endpoint:   "http://localhost:11434",
+				CodeModel:  "model1",
+				TextModel:  "model2",
+				LanceDBURI: "/var/lib/code-scout",
+			},
+			expectErr: true,
+		},
+		{
+			name: "lancedb uri with qdrant backend",
+			config: &Config{
+				Endpoint:   "http://localhost:11434",
+				CodeModel:  "model1",
+				TextModel:  "model2",
+				Backend:    "qdrant",
+				QdrantURL:  "http://localhost:6333",
+				LanceDBURI: "gs://team-bucket/index",
+			},
+			expectErr: true,
+		},
+		{
+			name: "global index",
+			config: &Config{
+				Endpoint:    "http://localhost:11434",
+				CodeModel:   "model1",
+				TextModel:   "model2",
+				GlobalIndex: true,
+				Project:     "api",
+			},
+			expectErr: false,
+		},
+		{
+			name: "global index with remote uri",
+			config: &Config{
+				Endpoint:    "http://localhost:11434",
+				CodeModel:   "model1",
+				TextModel:   "model2",
+				GlobalIndex: true,
+				LanceDBURI:  "s3://team-bucket/index",
+			},
+			expectErr: true,
+		},
+		{
+			name: "chat endpoint",
+			config: &Config{
+				Endpoint:     "http://localhost:11434",
+				CodeModel:    "model1",
+				TextModel:    "model2",
+				ChatModel:    "qwen2.5-coder:7b",
+				ChatEndpoint: "https://openrouter.ai/api",
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid chat endpoint scheme",
+			config: &Config{
+				Endpoint:     "http://localhost:11434",
+				CodeModel:    "model1",
+				TextModel:    "model2",
+				ChatEndpoint: "ftp://chat.example.com",
+			},
+			expectErr: true,
+		},
+		{
+			name: "tracing with default endpoint",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Tracing:   &Tracing{},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid tracing endpoint scheme",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Tracing:   &Tracing{Endpoint: "grpc://localhost:4317"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid chunk template",
+			config: &Config{
+				Endpoint:      "http://localhost:11434",
+				CodeModel:     "model1",
+				TextModel:     "model2",
+				ChunkTemplate: "{{.Path}",
+			},
+			expectErr: true,
+		},
+		{
+			name: "chunk summaries without a chat model",
+			config: &Config{
+				Endpoint:            "http://localhost:11434",
+				CodeModel:           "model1",
+				TextModel:           "model2",
+				SummarizeChunksOver: 2000,
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative hybrid weight",
+			config: &Config{
+				Endpoint:      "http://localhost:11434",
+				CodeModel:     "model1",
+				TextModel:     "model2",
+				HybridWeights: &HybridWeights{Code: 1, Docs: -0.5},
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative max section tokens",
+			config: &Config{
+				Endpoint:         "http://localhost:11434",
+				CodeModel:        "model1",
+				TextModel:        "model2",
+				MaxSectionTokens: -1,
+			},
+			expectErr: true,
+		},
+		{
+			name: "cohere provider",
+			config: &Config{
+				Endpoint:  "https://api.cohere.com",
+				APIKey:    "key",
+				CodeModel: "embed-english-v3.0",
+				TextModel: "embed-english-v3.0",
+				Provider:  "cohere",
+			},
+			expectErr: false,
+		},
+		{
+			name: "unknown provider",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Provider:  "jina",
+			},
+			expectErr: true,
+		},
+		{
+			name: "rate limit",
+			config: &Config{
+				Endpoint:  "https://api.openai.com",
+				CodeModel: "model1",
+				TextModel: "model2",
+				RateLimit: &RateLimit{RequestsPerSecond: 5, TokensPerMinute: 1000000},
+			},
+			expectErr: false,
+		},
+		{
+			name: "negative rate limit",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				RateLimit: &RateLimit{RequestsPerSecond: -1},
+			},
+			expectErr: true,
+		},
+		{
+			name: "retry settings",
+			config: &Config{
+				Endpoint:         "http://localhost:11434",
+				CodeModel:        "model1",
+				TextModel:        "model2",
+				EmbeddingTimeout: "45s",
+				MaxRetries:       new(int),
+				Backoff:          &Backoff{Initial: "250ms", Max: "10s", Multiplier: 1.5},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid embedding timeout",
+			config: &Config{
+				Endpoint:         "http://localhost:11434",
+				CodeModel:        "model1",
+				TextModel:        "model2",
+				EmbeddingTimeout: "soon",
+			},
+			expectErr: true,
+		},
+		{
+			name: "backoff multiplier below 1",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Backoff:   &Backoff{Multiplier: 0.5},
+			},
+			expectErr: true,
+		},
+		{
+			name: "path boosts",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Boost:     map[string]float64{"internal/core/**": 1.3, "**/testdata/**": 0.5},
+			},
+			expectErr: false,
+		},
+		{
+			name: "zero path boost",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Boost:     map[string]float64{"gen/**": 0},
+			},
+			expectErr: true,
+		},
+		{
+			name: "capitalized language",
+			config: &Config{
+				Endpoint:  "http://localhost:11434",
+				CodeModel: "model1",
+				TextModel: "model2",
+				Languages: map[string]bool{"PHP": false},
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -192,6 +507,10 @@ func TestValidate(t *testing.T) {
 			if tt.expectErr && err == nil {
 				t.Errorf("expected error, got nil")
 			}
+			var configErr *Error
+			if tt.expectErr && err != nil && !errors.As(err, &configErr) {
+				t.Errorf("expected a *config.Error, got %T", err)
+			}
 			if !tt.expectErr && err != nil {
 				t.Errorf("expected no error, got: %v", err)
 			}
@@ -214,3 +533,35 @@ func TestValidate_TrimsTrailingSlash(t *testing.T) {
 		t.Errorf("expected endpoint without trailing slash, got: %s", cfg.Endpoint)
 	}
 }
+
+func TestChatSettings(t *testing.T) {
+	cfg := &Config{Endpoint: "http://localhost:11434", APIKey: "embed-key"}
+	if endpoint, apiKey := cfg.ChatSettings(); endpoint != "http://localhost:11434" || apiKey != "embed-key" {
+		t.Errorf("expected fallback to embedding settings, got %s %s", endpoint, apiKey)
+	}
+
+	cfg.ChatEndpoint = "https://chat.example.com"
+	cfg.ChatAPIKey = "chat-key"
+	if endpoint, apiKey := cfg.ChatSettings(); endpoint != "https://chat.example.com" || apiKey != "chat-key" {
+		t.Errorf("expected chat settings, got %s %s", endpoint, apiKey)
+	}
+}
+
+func TestFusionWeights(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.FusionWeights(); got != (HybridWeights{Code: 1, Docs: 1, Lexical: 1, Identifier: 1}) {
+		t.Errorf("expected default weights, got %+v", got)
+	}
+
+	cfg.HybridWeights = &HybridWeights{Docs: 0.5}
+	if got := cfg.FusionWeights(); got != (HybridWeights{Code: 1, Docs: 0.5, Lexical: 1, Identifier: 1}) {
+		t.Errorf("expected unset weights to default to 1, got %+v", got)
+	}
+}
+
+func TestDisabledLanguages(t *testing.T) {
+	cfg := &Config{Languages: map[string]bool{"php": false, "go": true, "cpp": false}}
+	if got := cfg.DisabledLanguages(); strings.Join(got, ",") != "cpp,php" {
+		t.Errorf("expected [cpp php], got %v", got)
+	}
+}
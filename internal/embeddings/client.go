@@ -2,11 +2,11 @@ package embeddings
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 )
 
 const (
@@ -18,19 +18,36 @@ const (
 	DefaultTextModel = "code-scout-text"
 )
 
-// Client is the interface for embedding clients
+// Client is the interface for embedding clients. Cancelling ctx aborts
+// in-flight requests.
 type Client interface {
-	Embed(text string) ([]float64, error)
-	EmbedMany(texts []string) ([][]float64, error)
+	Embed(ctx context.Context, text string) ([]float64, error)
+	EmbedMany(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// QueryEmbedder is implemented by clients whose provider embeds search
+// queries differently from the documents being indexed
+type QueryEmbedder interface {
+	EmbedQuery(ctx context.Context, text string) ([]float64, error)
+}
+
+// EmbedQuery embeds a search query, using the client's query embedding when
+// it has one
+func EmbedQuery(ctx context.Context, client Client, text string) ([]float64, error) {
+	if queryClient, ok := client.(QueryEmbedder); ok {
+		return queryClient.EmbedQuery(ctx, text)
+	}
+	return client.Embed(ctx, text)
 }
 
 // OpenAIClient handles communication with OpenAI-compatible embedding APIs
 // (supports Ollama, OpenRouter, and other compatible services)
 type OpenAIClient struct {
 	endpoint string
-	apiKey   string        // Optional API key for authentication
+	apiKey   string // Optional API key for authentication
 	model    string
 	client   *http.Client
+	retrier
 }
 
 // openAIEmbedRequest represents the OpenAI-compatible embedding request
@@ -99,8 +116,8 @@ func NewOllamaClientWithEndpoint(endpoint, model string) *OpenAIClient {
 }
 
 // Embed generates an embedding for the given text using OpenAI-compatible API with retry logic
-func (c *OpenAIClient) Embed(text string) ([]float64, error) {
-	embeddings, err := c.EmbedMany([]string{text})
+func (c *OpenAIClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.EmbedMany(ctx, []string{text})
 	if err != nil {
 		return nil, err
 	}
@@ -111,45 +128,26 @@ func (c *OpenAIClient) Embed(text string) ([]float64, error) {
 }
 
 // EmbedMany generates embeddings for multiple texts in a single API request when possible
-func (c *OpenAIClient) EmbedMany(texts []string) ([][]float64, error) {
+func (c *OpenAIClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
-	return c.embedWithRetry(texts, len(texts))
+	return c.embedWithRetry(ctx, texts, len(texts))
 }
 
 // EmbedBatch generates embeddings for multiple texts (alias for EmbedMany)
-func (c *OpenAIClient) EmbedBatch(texts []string) ([][]float64, error) {
-	return c.EmbedMany(texts)
+func (c *OpenAIClient) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	return c.EmbedMany(ctx, texts)
 }
 
-func (c *OpenAIClient) embedWithRetry(texts []string, expected int) ([][]float64, error) {
-	const maxRetries = 3
-	const initialBackoff = 1 * time.Second
-
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			backoff := initialBackoff * time.Duration(1<<uint(attempt-1))
-			time.Sleep(backoff)
-		}
-
-		embeddings, err := c.embedOnce(texts)
-		if err == nil {
-			if len(embeddings) != expected {
-				return nil, fmt.Errorf("expected %d embeddings, got %d", expected, len(embeddings))
-			}
-			return embeddings, nil
-		}
-
-		lastErr = err
-	}
-
-	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+func (c *OpenAIClient) embedWithRetry(ctx context.Context, texts []string, expected int) ([][]float64, error) {
+	return c.withRetry(ctx, expected, func(ctx context.Context) ([][]float64, error) {
+		return c.embedOnce(ctx, texts)
+	})
 }
 
 // embedOnce makes a single embedding request without retries
-func (c *OpenAIClient) embedOnce(texts []string) ([][]float64, error) {
+func (c *OpenAIClient) embedOnce(ctx context.Context, texts []string) ([][]float64, error) {
 	reqBody := openAIEmbedRequest{
 		Model: c.model,
 		Input: texts,
@@ -161,7 +159,7 @@ func (c *OpenAIClient) embedOnce(texts []string) ([][]float64, error) {
 	}
 
 	url := c.endpoint + "/v1/embeddings"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -174,13 +172,16 @@ func (c *OpenAIClient) embedOnce(texts []string) ([][]float64, error) {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request to embedding API: %w", err)
+		return nil, requestError("embedding API", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("embedding API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, responseError("embedding API", resp, body)
+	}
+	if wait := exhaustedWait(resp.Header); wait > 0 {
+		c.throttle.pause(wait)
 	}
 
 	var embedResp openAIEmbedResponse
@@ -0,0 +1,141 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// DefaultCohereEndpoint is the Cohere API base URL
+	DefaultCohereEndpoint = "https://api.cohere.com"
+	// cohereMaxTexts is the most texts Cohere accepts in one embed request
+	cohereMaxTexts = 96
+)
+
+// CohereClient handles communication with Cohere's Embed API (v2), which
+// embeds indexed documents and search queries with different input types
+type CohereClient struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+	retrier
+}
+
+// cohereEmbedRequest represents a Cohere v2 embed request
+type cohereEmbedRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"` // search_document or search_query
+	EmbeddingTypes []string `json:"embedding_types"`
+	Truncate       string   `json:"truncate,omitempty"`
+}
+
+// cohereEmbedResponse represents a Cohere v2 embed response
+type cohereEmbedResponse struct {
+	Embeddings struct {
+		Float [][]float64 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// NewCohereClient creates a Cohere embedding client. An empty endpoint uses DefaultCohereEndpoint.
+func NewCohereClient(endpoint, apiKey, model string) *CohereClient {
+	if endpoint == "" {
+		endpoint = DefaultCohereEndpoint
+	}
+	return &CohereClient{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    model,
+		client:   &http.Client{},
+	}
+}
+
+// Embed generates a document embedding for the given text
+func (c *CohereClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.EmbedMany(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedMany generates document embeddings, split into requests of at most
+// cohereMaxTexts texts
+func (c *CohereClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	var embeddings [][]float64
+	for start := 0; start < len(texts); start += cohereMaxTexts {
+		batch := texts[start:min(start+cohereMaxTexts, len(texts))]
+		batchEmbeddings, err := c.embed(ctx, batch, "search_document")
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batchEmbeddings...)
+	}
+	return embeddings, nil
+}
+
+// EmbedQuery generates a search query embedding for the given text
+func (c *CohereClient) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.embed(ctx, []string{text}, "search_query")
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (c *CohereClient) embed(ctx context.Context, texts []string, inputType string) ([][]float64, error) {
+	return c.withRetry(ctx, len(texts), func(ctx context.Context) ([][]float64, error) {
+		return c.embedOnce(ctx, texts, inputType)
+	})
+}
+
+// embedOnce makes a single embed request without retries
+func (c *CohereClient) embedOnce(ctx context.Context, texts []string, inputType string) ([][]float64, error) {
+	reqBody := cohereEmbedRequest{
+		Model:          c.model,
+		Texts:          texts,
+		InputType:      inputType,
+		EmbeddingTypes: []string{"float"},
+		Truncate:       "END",
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/v2/embed", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, requestError("Cohere API", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError("Cohere API", resp, body)
+	}
+
+	var embedResp cohereEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Embeddings.Float) == 0 {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+	return embedResp.Embeddings.Float, nil
+}
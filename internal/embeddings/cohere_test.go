@@ -0,0 +1,59 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCohereClient(t *testing.T) {
+	var mu sync.Mutex
+	var requests []cohereEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/embed" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req cohereEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		var resp cohereEmbedResponse
+		for i := range req.Texts {
+			resp.Embeddings.Float = append(resp.Embeddings.Float, []float64{float64(i), 1})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewCohereClient(server.URL, "key", "embed-english-v3.0")
+
+	texts := make([]string, cohereMaxTexts+4)
+	for i := range texts {
+		texts[i] = "doc"
+	}
+	embeddings, err := client.EmbedMany(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedMany failed: %v", err)
+	}
+	if len(embeddings) != len(texts) {
+		t.Fatalf("expected %d embeddings, got %d", len(texts), len(embeddings))
+	}
+	if len(requests) != 2 || len(requests[0].Texts) != cohereMaxTexts || requests[0].InputType != "search_document" {
+		t.Errorf("expected documents split into two search_document requests, got %+v", requests)
+	}
+
+	if _, err := EmbedQuery(context.Background(), client, "how are users authenticated"); err != nil {
+		t.Fatalf("EmbedQuery failed: %v", err)
+	}
+	last := requests[len(requests)-1]
+	if last.InputType != "search_query" || last.Model != "embed-english-v3.0" {
+		t.Errorf("expected a search_query request, got %+v", last)
+	}
+}
@@ -0,0 +1,179 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// LlamaCppClient handles communication with llama.cpp's llama-server. Its
+// native /embedding endpoint takes one text as "content" and, depending on
+// the server version, returns the vector as {"embedding": [...]} or
+// [{"index": 0, "embedding": [[...]]}]. Servers without it are called through
+// /v1/embeddings, still one text per request, since older builds reject
+// batched input.
+type LlamaCppClient struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+	retrier
+	// openAIOnly is set once the server answers 404 for /embedding
+	openAIOnly atomic.Bool
+}
+
+// NewLlamaCppClient creates a llama-server embedding client
+func NewLlamaCppClient(endpoint, apiKey, model string) *LlamaCppClient {
+	return &LlamaCppClient{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    model,
+		client:   &http.Client{},
+	}
+}
+
+// Embed generates an embedding for the given text
+func (c *LlamaCppClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.withRetry(ctx, 1, func(ctx context.Context) ([][]float64, error) {
+		embedding, err := c.embedOnce(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		return [][]float64{embedding}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedMany generates embeddings with one request per text
+func (c *LlamaCppClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embedding, err := c.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// errNotFound marks a 404 from /embedding, which switches the client to /v1/embeddings
+var errNotFound = errors.New("endpoint not found")
+
+// embedOnce makes a single embedding request without retries
+func (c *LlamaCppClient) embedOnce(ctx context.Context, text string) ([]float64, error) {
+	if !c.openAIOnly.Load() {
+		body, err := c.post(ctx, "/embedding", map[string]interface{}{"content": text})
+		if err != errNotFound {
+			if err != nil {
+				return nil, err
+			}
+			return parseLlamaCppEmbedding(body)
+		}
+		c.openAIOnly.Store(true)
+	}
+
+	body, err := c.post(ctx, "/v1/embeddings", map[string]interface{}{"model": c.model, "input": text})
+	if err != nil {
+		return nil, err
+	}
+	var embedResp struct {
+		Data []struct {
+			Embedding json.RawMessage `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+	return parseLlamaCppVector(embedResp.Data[0].Embedding)
+}
+
+// post sends a JSON request and returns the response body
+func (c *LlamaCppClient) post(ctx context.Context, path string, payload interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, requestError("llama-server", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && path == "/embedding":
+		return nil, errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, responseError("llama-server", resp, body)
+	}
+	return body, nil
+}
+
+// parseLlamaCppEmbedding decodes a /embedding response in either the object
+// or the array form
+func parseLlamaCppEmbedding(body []byte) ([]float64, error) {
+	var items []struct {
+		Embedding json.RawMessage `json:"embedding"`
+	}
+	if err := json.Unmarshal(body, &items); err == nil {
+		if len(items) == 0 {
+			return nil, fmt.Errorf("no embedding data in response")
+		}
+		return parseLlamaCppVector(items[0].Embedding)
+	}
+
+	var single struct {
+		Embedding json.RawMessage `json:"embedding"`
+	}
+	if err := json.Unmarshal(body, &single); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return parseLlamaCppVector(single.Embedding)
+}
+
+// parseLlamaCppVector decodes an embedding given as a vector or as a single
+// pooled row [[...]]. Several rows mean the server returns one vector per
+// token, which needs llama-server's --pooling option.
+func parseLlamaCppVector(raw json.RawMessage) ([]float64, error) {
+	var vector []float64
+	if err := json.Unmarshal(raw, &vector); err == nil {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("no embedding data in response")
+		}
+		return vector, nil
+	}
+
+	var rows [][]float64
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+	if len(rows) != 1 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("llama-server returned %d token embeddings instead of one pooled embedding; start it with --pooling mean", len(rows))
+	}
+	return rows[0], nil
+}
@@ -0,0 +1,64 @@
+package embeddings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseLlamaCppEmbedding(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		expected  []float64
+		expectErr bool
+	}{
+		{"object", `{"embedding":[0.1,0.2]}`, []float64{0.1, 0.2}, false},
+		{"array of pooled rows", `[{"index":0,"embedding":[[0.3,0.4]]}]`, []float64{0.3, 0.4}, false},
+		{"array of vectors", `[{"index":0,"embedding":[0.5]}]`, []float64{0.5}, false},
+		{"token embeddings", `[{"index":0,"embedding":[[0.1],[0.2]]}]`, nil, true},
+		{"empty", `[]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLlamaCppEmbedding([]byte(tt.body))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v (%v), expected %v", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLlamaCppClient_FallsBackToOpenAIEndpoint(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/embedding" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":[{"embedding":[[1,2]]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewLlamaCppClient(server.URL, "", "nomic-embed-code.gguf")
+	embeddings, err := client.EmbedMany(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("EmbedMany failed: %v", err)
+	}
+	if len(embeddings) != 2 || !reflect.DeepEqual(embeddings[1], []float64{1, 2}) {
+		t.Errorf("unexpected embeddings: %v", embeddings)
+	}
+	expected := []string{"/embedding", "/v1/embeddings", "/v1/embeddings"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("requests = %v, expected %v", paths, expected)
+	}
+}
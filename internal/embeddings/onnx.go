@@ -0,0 +1,166 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// onnxModelFile and onnxVocabFile are the files an ONNX model directory must contain
+	onnxModelFile = "model.onnx"
+	onnxVocabFile = "vocab.txt"
+	// onnxMaxTokens is the longest input the BERT-style models accept; longer chunks are truncated
+	onnxMaxTokens = 512
+)
+
+// onnxSession runs a transformer model over a batch of token ids, returning
+// the first output's data and shape. Inputs are row-major [batch, seqLen].
+type onnxSession interface {
+	run(inputIDs, attentionMask, tokenTypeIDs []int64, batch, seqLen int) ([]float32, []int64, error)
+	close()
+}
+
+// ONNXClient generates embeddings in-process with onnxruntime, so indexing
+// works without an embedding server. The model directory holds model.onnx
+// and the WordPiece vocab.txt of a BERT-style sentence-embedding model such
+// as all-MiniLM-L6-v2.
+type ONNXClient struct {
+	tokenizer *wordPieceTokenizer
+	session   onnxSession
+}
+
+// NewONNXClient loads the model in modelDir. It fails if code-scout was built
+// without ONNX support (-tags onnx).
+func NewONNXClient(modelDir string) (*ONNXClient, error) {
+	tokenizer, err := loadWordPieceTokenizer(filepath.Join(modelDir, onnxVocabFile))
+	if err != nil {
+		return nil, err
+	}
+
+	modelPath := filepath.Join(modelDir, onnxModelFile)
+	if _, err := os.Stat(modelPath); err != nil {
+		return nil, fmt.Errorf("failed to find ONNX model: %w", err)
+	}
+	session, err := newONNXSession(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	return &ONNXClient{tokenizer: tokenizer, session: session}, nil
+}
+
+// Close releases the onnxruntime session
+func (c *ONNXClient) Close() {
+	c.session.close()
+}
+
+// Embed generates an embedding for the given text
+func (c *ONNXClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.EmbedMany(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedMany generates embeddings for texts in one model run, padding each to
+// the longest. A model run can't be interrupted, so ctx is only checked before it starts.
+func (c *ONNXClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	encoded := make([][]int64, len(texts))
+	seqLen := 0
+	for i, text := range texts {
+		encoded[i] = c.tokenizer.encode(text, onnxMaxTokens)
+		seqLen = max(seqLen, len(encoded[i]))
+	}
+
+	inputIDs := make([]int64, len(texts)*seqLen)
+	attentionMask := make([]int64, len(texts)*seqLen)
+	tokenTypeIDs := make([]int64, len(texts)*seqLen)
+	for i, ids := range encoded {
+		row := i * seqLen
+		for j := 0; j < seqLen; j++ {
+			if j < len(ids) {
+				inputIDs[row+j] = ids[j]
+				attentionMask[row+j] = 1
+			} else {
+				inputIDs[row+j] = c.tokenizer.pad
+			}
+		}
+	}
+
+	output, shape, err := c.session.run(inputIDs, attentionMask, tokenTypeIDs, len(texts), seqLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ONNX model: %w", err)
+	}
+	return poolEmbeddings(output, shape, attentionMask, len(texts))
+}
+
+// poolEmbeddings turns model output into one normalized vector per text.
+// Token-level output [batch, seq, dim] is mean-pooled over unmasked tokens;
+// sentence-level output [batch, dim] is used as is.
+func poolEmbeddings(output []float32, shape []int64, attentionMask []int64, batch int) ([][]float64, error) {
+	embeddings := make([][]float64, batch)
+	switch {
+	case len(shape) == 2 && shape[0] == int64(batch):
+		dim := int(shape[1])
+		for i := range embeddings {
+			embeddings[i] = make([]float64, dim)
+			for d := 0; d < dim; d++ {
+				embeddings[i][d] = float64(output[i*dim+d])
+			}
+		}
+	case len(shape) == 3 && shape[0] == int64(batch):
+		seqLen, dim := int(shape[1]), int(shape[2])
+		if len(attentionMask) != batch*seqLen {
+			return nil, fmt.Errorf("model output has %d tokens per text, expected %d", seqLen, len(attentionMask)/batch)
+		}
+		for i := range embeddings {
+			embeddings[i] = make([]float64, dim)
+			tokens := 0
+			for j := 0; j < seqLen; j++ {
+				if attentionMask[i*seqLen+j] == 0 {
+					continue
+				}
+				tokens++
+				offset := (i*seqLen + j) * dim
+				for d := 0; d < dim; d++ {
+					embeddings[i][d] += float64(output[offset+d])
+				}
+			}
+			for d := range embeddings[i] {
+				embeddings[i][d] /= float64(max(tokens, 1))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected model output shape %v", shape)
+	}
+
+	for _, embedding := range embeddings {
+		normalize(embedding)
+	}
+	return embeddings, nil
+}
+
+// normalize scales v to unit length in place
+func normalize(v []float64) {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+}
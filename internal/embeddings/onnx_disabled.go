@@ -0,0 +1,10 @@
+//go:build !onnx || !cgo
+
+package embeddings
+
+import "fmt"
+
+// newONNXSession reports that this build has no onnxruntime support
+func newONNXSession(modelPath string) (onnxSession, error) {
+	return nil, fmt.Errorf("code-scout was built without ONNX support; rebuild with 'go build -tags onnx' and onnxruntime installed")
+}
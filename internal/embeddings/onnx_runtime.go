@@ -0,0 +1,259 @@
+//go:build onnx && cgo
+
+package embeddings
+
+/*
+#cgo LDFLAGS: -lonnxruntime
+#include <stdlib.h>
+#include <string.h>
+#include <onnxruntime_c_api.h>
+
+static const OrtApi *ort(void) {
+	return OrtGetApiBase()->GetApi(ORT_API_VERSION);
+}
+
+// ort_error converts a status into a malloc'd message, or NULL on success
+static char *ort_error(OrtStatus *status) {
+	if (status == NULL) {
+		return NULL;
+	}
+	char *message = strdup(ort()->GetErrorMessage(status));
+	ort()->ReleaseStatus(status);
+	return message;
+}
+
+static char *ort_create_session(const char *path, OrtEnv **env, OrtSession **session) {
+	char *err = ort_error(ort()->CreateEnv(ORT_LOGGING_LEVEL_WARNING, "code-scout", env));
+	if (err != NULL) {
+		return err;
+	}
+	OrtSessionOptions *options;
+	err = ort_error(ort()->CreateSessionOptions(&options));
+	if (err != NULL) {
+		return err;
+	}
+	err = ort_error(ort()->CreateSession(*env, path, options, session));
+	ort()->ReleaseSessionOptions(options);
+	return err;
+}
+
+// ort_io_name returns a malloc'd copy of an input (output == 0) or output name
+static char *ort_io_name(OrtSession *session, size_t index, int output, char **name) {
+	OrtAllocator *allocator;
+	char *err = ort_error(ort()->GetAllocatorWithDefaultOptions(&allocator));
+	if (err != NULL) {
+		return err;
+	}
+	char *value;
+	if (output) {
+		err = ort_error(ort()->SessionGetOutputName(session, index, allocator, &value));
+	} else {
+		err = ort_error(ort()->SessionGetInputName(session, index, allocator, &value));
+	}
+	if (err != NULL) {
+		return err;
+	}
+	*name = strdup(value);
+	free(ort_error(ort()->AllocatorFree(allocator, value)));
+	return NULL;
+}
+
+static char *ort_input_count(OrtSession *session, size_t *count) {
+	return ort_error(ort()->SessionGetInputCount(session, count));
+}
+
+// ort_run runs the model on n_inputs int64 tensors of shape [batch, seq_len],
+// stored consecutively in data, and returns a malloc'd copy of the first
+// output tensor with its shape (rank at most 4)
+static char *ort_run(OrtSession *session, const char **input_names, int64_t *data, size_t n_inputs,
+		int64_t batch, int64_t seq_len, const char *output_name,
+		float **out, int64_t *out_shape, size_t *out_rank) {
+	OrtMemoryInfo *memory;
+	char *err = ort_error(ort()->CreateCpuMemoryInfo(OrtArenaAllocator, OrtMemTypeDefault, &memory));
+	if (err != NULL) {
+		return err;
+	}
+
+	int64_t shape[2] = {batch, seq_len};
+	size_t elements = (size_t)(batch * seq_len);
+	OrtValue *inputs[3] = {NULL, NULL, NULL};
+	OrtValue *output = NULL;
+	OrtTensorTypeAndShapeInfo *info = NULL;
+	for (size_t i = 0; i < n_inputs && err == NULL; i++) {
+		err = ort_error(ort()->CreateTensorWithDataAsOrtValue(memory, data + i * elements,
+			elements * sizeof(int64_t), shape, 2, ONNX_TENSOR_ELEMENT_DATA_TYPE_INT64, &inputs[i]));
+	}
+	if (err == NULL) {
+		err = ort_error(ort()->Run(session, NULL, input_names, (const OrtValue *const *)inputs, n_inputs,
+			&output_name, 1, &output));
+	}
+	if (err == NULL) {
+		err = ort_error(ort()->GetTensorTypeAndShape(output, &info));
+	}
+	if (err == NULL) {
+		err = ort_error(ort()->GetDimensionsCount(info, out_rank));
+	}
+	if (err == NULL && *out_rank > 4) {
+		err = strdup("model output has more than 4 dimensions");
+	}
+	if (err == NULL) {
+		err = ort_error(ort()->GetDimensions(info, out_shape, *out_rank));
+	}
+	size_t count = 0;
+	if (err == NULL) {
+		err = ort_error(ort()->GetTensorShapeElementCount(info, &count));
+	}
+	float *values = NULL;
+	if (err == NULL) {
+		err = ort_error(ort()->GetTensorMutableData(output, (void **)&values));
+	}
+	if (err == NULL) {
+		*out = malloc(count * sizeof(float));
+		memcpy(*out, values, count * sizeof(float));
+	}
+
+	if (info != NULL) {
+		ort()->ReleaseTensorTypeAndShapeInfo(info);
+	}
+	if (output != NULL) {
+		ort()->ReleaseValue(output);
+	}
+	for (size_t i = 0; i < n_inputs; i++) {
+		if (inputs[i] != NULL) {
+			ort()->ReleaseValue(inputs[i]);
+		}
+	}
+	ort()->ReleaseMemoryInfo(memory);
+	return err;
+}
+
+static void ort_release(OrtEnv *env, OrtSession *session) {
+	if (session != NULL) {
+		ort()->ReleaseSession(session);
+	}
+	if (env != NULL) {
+		ort()->ReleaseEnv(env);
+	}
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ortSession is an onnxruntime inference session
+type ortSession struct {
+	env        *C.OrtEnv
+	session    *C.OrtSession
+	inputs     []string // Model inputs, a subset of input_ids, attention_mask, token_type_ids
+	outputName string
+}
+
+// ortError converts a message returned by the C helpers into an error, freeing it
+func ortError(message *C.char) error {
+	if message == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(message))
+	return errors.New(C.GoString(message))
+}
+
+// newONNXSession loads the model at modelPath
+func newONNXSession(modelPath string) (onnxSession, error) {
+	path := C.CString(modelPath)
+	defer C.free(unsafe.Pointer(path))
+
+	s := &ortSession{}
+	if err := ortError(C.ort_create_session(path, &s.env, &s.session)); err != nil {
+		s.close()
+		return nil, fmt.Errorf("failed to load ONNX model: %w", err)
+	}
+
+	var count C.size_t
+	if err := ortError(C.ort_input_count(s.session, &count)); err != nil {
+		s.close()
+		return nil, fmt.Errorf("failed to read ONNX model inputs: %w", err)
+	}
+	for i := C.size_t(0); i < count; i++ {
+		name, err := s.ioName(i, 0)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		switch name {
+		case "input_ids", "attention_mask", "token_type_ids":
+			s.inputs = append(s.inputs, name)
+		default:
+			s.close()
+			return nil, fmt.Errorf("unsupported ONNX model input %q", name)
+		}
+	}
+
+	outputName, err := s.ioName(0, 1)
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	s.outputName = outputName
+	return s, nil
+}
+
+// ioName returns the name of an input or output of the model
+func (s *ortSession) ioName(index C.size_t, output C.int) (string, error) {
+	var name *C.char
+	if err := ortError(C.ort_io_name(s.session, index, output, &name)); err != nil {
+		return "", fmt.Errorf("failed to read ONNX model input and output names: %w", err)
+	}
+	defer C.free(unsafe.Pointer(name))
+	return C.GoString(name), nil
+}
+
+func (s *ortSession) run(inputIDs, attentionMask, tokenTypeIDs []int64, batch, seqLen int) ([]float32, []int64, error) {
+	values := map[string][]int64{"input_ids": inputIDs, "attention_mask": attentionMask, "token_type_ids": tokenTypeIDs}
+	elements := batch * seqLen
+
+	// Inputs are copied to C memory, since C may not hold pointers into Go memory
+	data := (*C.int64_t)(C.malloc(C.size_t(len(s.inputs)*elements) * C.size_t(unsafe.Sizeof(C.int64_t(0)))))
+	defer C.free(unsafe.Pointer(data))
+	dataSlice := unsafe.Slice((*int64)(unsafe.Pointer(data)), len(s.inputs)*elements)
+
+	names := (**C.char)(C.malloc(C.size_t(len(s.inputs)) * C.size_t(unsafe.Sizeof((*C.char)(nil)))))
+	defer C.free(unsafe.Pointer(names))
+	nameSlice := unsafe.Slice(names, len(s.inputs))
+	for i, input := range s.inputs {
+		copy(dataSlice[i*elements:], values[input])
+		nameSlice[i] = C.CString(input)
+		defer C.free(unsafe.Pointer(nameSlice[i]))
+	}
+
+	outputName := C.CString(s.outputName)
+	defer C.free(unsafe.Pointer(outputName))
+
+	var out *C.float
+	var shape [4]C.int64_t
+	var rank C.size_t
+	err := ortError(C.ort_run(s.session, names, data, C.size_t(len(s.inputs)), C.int64_t(batch), C.int64_t(seqLen),
+		outputName, &out, &shape[0], &rank))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer C.free(unsafe.Pointer(out))
+
+	outputShape := make([]int64, rank)
+	count := 1
+	for i := range outputShape {
+		outputShape[i] = int64(shape[i])
+		count *= int(shape[i])
+	}
+	output := make([]float32, count)
+	copy(output, unsafe.Slice((*float32)(unsafe.Pointer(out)), count))
+	return output, outputShape, nil
+}
+
+func (s *ortSession) close() {
+	C.ort_release(s.env, s.session)
+	s.env, s.session = nil, nil
+}
@@ -0,0 +1,55 @@
+package embeddings
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// fakeSession returns token-level output where each token's vector is [id, 1]
+type fakeSession struct{}
+
+func (fakeSession) run(inputIDs, attentionMask, tokenTypeIDs []int64, batch, seqLen int) ([]float32, []int64, error) {
+	output := make([]float32, 0, len(inputIDs)*2)
+	for _, id := range inputIDs {
+		output = append(output, float32(id), 1)
+	}
+	return output, []int64{int64(batch), int64(seqLen), 2}, nil
+}
+
+func (fakeSession) close() {}
+
+func TestONNXClient_MeanPoolsUnpaddedTokens(t *testing.T) {
+	client := &ONNXClient{tokenizer: newTestTokenizer(t), session: fakeSession{}}
+
+	// "x" encodes as [CLS]=2 x=10 [SEP]=3; padding to the longer text must not affect it
+	embeddings, err := client.EmbedMany(context.Background(), []string{"x", "parse parse parse"})
+	if err != nil {
+		t.Fatalf("EmbedMany failed: %v", err)
+	}
+	mean := (2.0 + 10 + 3) / 3
+	norm := math.Sqrt(mean*mean + 1)
+	if math.Abs(embeddings[0][0]-mean/norm) > 1e-9 || math.Abs(embeddings[0][1]-1/norm) > 1e-9 {
+		t.Errorf("unexpected pooled embedding %v", embeddings[0])
+	}
+}
+
+func TestPoolEmbeddings_SentenceOutput(t *testing.T) {
+	embeddings, err := poolEmbeddings([]float32{3, 4, 0, 2}, []int64{2, 2}, nil, 2)
+	if err != nil {
+		t.Fatalf("poolEmbeddings failed: %v", err)
+	}
+	if embeddings[0][0] != 0.6 || embeddings[0][1] != 0.8 || embeddings[1][1] != 1 {
+		t.Errorf("expected normalized sentence embeddings, got %v", embeddings)
+	}
+
+	if _, err := poolEmbeddings([]float32{1}, []int64{1}, nil, 1); err == nil {
+		t.Error("expected an error for a 1-dimensional output")
+	}
+}
+
+func TestNewONNXClient_MissingModel(t *testing.T) {
+	if _, err := NewONNXClient(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without a model")
+	}
+}
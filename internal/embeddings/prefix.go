@@ -0,0 +1,66 @@
+package embeddings
+
+import "context"
+
+// Prefixes are the instructions a model was trained to expect ahead of the
+// documents it embeds and the search queries it embeds
+type Prefixes struct {
+	Document string
+	Query    string
+}
+
+// knownPrefixes are the instruction prefixes of common models trained with them
+var knownPrefixes = map[string]Prefixes{
+	"nomic-embed-text":      {Document: "search_document: ", Query: "search_query: "},
+	"nomic-embed-code":      {Query: "Represent this query for searching relevant code: "},
+	"mxbai-embed-large":     {Query: "Represent this sentence for searching relevant passages: "},
+	"e5-small-v2":           {Document: "passage: ", Query: "query: "},
+	"e5-base-v2":            {Document: "passage: ", Query: "query: "},
+	"e5-large-v2":           {Document: "passage: ", Query: "query: "},
+	"multilingual-e5-small": {Document: "passage: ", Query: "query: "},
+	"multilingual-e5-base":  {Document: "passage: ", Query: "query: "},
+	"multilingual-e5-large": {Document: "passage: ", Query: "query: "},
+}
+
+// KnownPrefixes returns the instruction prefixes a model expects, by its name
+// with any provider prefix or tag removed (see MaxTokens), and false for
+// models that don't use any
+func KnownPrefixes(model string) (Prefixes, bool) {
+	prefixes, ok := knownPrefixes[baseModelName(model)]
+	return prefixes, ok
+}
+
+// prefixedClient prepends instruction prefixes to the texts its client embeds
+type prefixedClient struct {
+	client   Client
+	prefixes Prefixes
+}
+
+// WithPrefixes returns a client that embeds documents (Embed, EmbedMany) with
+// prefixes.Document ahead of them and search queries (EmbedQuery) with
+// prefixes.Query ahead of them
+func WithPrefixes(client Client, prefixes Prefixes) Client {
+	if prefixes == (Prefixes{}) {
+		return client
+	}
+	return &prefixedClient{client: client, prefixes: prefixes}
+}
+
+func (c *prefixedClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	return c.client.Embed(ctx, c.prefixes.Document+text)
+}
+
+func (c *prefixedClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	if c.prefixes.Document == "" {
+		return c.client.EmbedMany(ctx, texts)
+	}
+	prefixed := make([]string, len(texts))
+	for i, text := range texts {
+		prefixed[i] = c.prefixes.Document + text
+	}
+	return c.client.EmbedMany(ctx, prefixed)
+}
+
+func (c *prefixedClient) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	return EmbedQuery(ctx, c.client, c.prefixes.Query+text)
+}
@@ -0,0 +1,65 @@
+package embeddings
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// recordingClient records the texts it's asked to embed
+type recordingClient struct {
+	texts []string
+}
+
+func (c *recordingClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	c.texts = append(c.texts, text)
+	return []float64{1}, nil
+}
+
+func (c *recordingClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	c.texts = append(c.texts, texts...)
+	return make([][]float64, len(texts)), nil
+}
+
+func TestWithPrefixes(t *testing.T) {
+	inner := &recordingClient{}
+	client := WithPrefixes(inner, Prefixes{Document: "search_document: ", Query: "search_query: "})
+	ctx := context.Background()
+
+	if _, err := client.EmbedMany(ctx, []string{"func a() {}", "func b() {}"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Embed(ctx, "func c() {}"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EmbedQuery(ctx, client, "where is c"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"search_document: func a() {}",
+		"search_document: func b() {}",
+		"search_document: func c() {}",
+		"search_query: where is c",
+	}
+	if !reflect.DeepEqual(inner.texts, want) {
+		t.Errorf("got %q, want %q", inner.texts, want)
+	}
+}
+
+func TestWithPrefixes_NoneKeepsClient(t *testing.T) {
+	inner := &recordingClient{}
+	if client := WithPrefixes(inner, Prefixes{}); client != Client(inner) {
+		t.Error("expected the client itself when there are no prefixes")
+	}
+}
+
+func TestKnownPrefixes(t *testing.T) {
+	prefixes, ok := KnownPrefixes("ollama/nomic-embed-text:latest")
+	if !ok || prefixes.Document != "search_document: " || prefixes.Query != "search_query: " {
+		t.Errorf("expected nomic-embed-text's prefixes, got %+v", prefixes)
+	}
+	if _, ok := KnownPrefixes("text-embedding-3-small"); ok {
+		t.Error("expected no prefixes for a model trained without them")
+	}
+}
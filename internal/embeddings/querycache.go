@@ -0,0 +1,158 @@
+package embeddings
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QueryCache caches search query embeddings by model and query text, in
+// memory and optionally on disk, so a repeated or paginated search doesn't
+// embed its query again. It's safe for concurrent use, and the disk cache can
+// be shared by several processes.
+type QueryCache struct {
+	dir        string // Disk cache directory; "" for memory only
+	maxEntries int    // Entries kept in memory, and files kept on disk
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // By key; values are *queryCacheEntry
+	recent  *list.List               // Most recently used first
+}
+
+// queryCacheEntry is a cached embedding, also the format of the disk cache's files
+type queryCacheEntry struct {
+	Model     string    `json:"model"`
+	Query     string    `json:"query"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// NewQueryCache creates a cache holding up to maxEntries embeddings in memory,
+// and as many files in dir. An empty dir keeps the cache in memory only.
+func NewQueryCache(dir string, maxEntries int) *QueryCache {
+	return &QueryCache{
+		dir:        dir,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// queryCacheKey identifies a model's embedding of a query
+func queryCacheKey(model, query string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached embedding of query by model
+func (c *QueryCache) Get(model, query string) ([]float64, bool) {
+	key := queryCacheKey(model, query)
+
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.recent.MoveToFront(element)
+		embedding := element.Value.(*queryCacheEntry).Embedding
+		c.mu.Unlock()
+		return embedding, true
+	}
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return nil, false
+	}
+	path := filepath.Join(c.dir, key+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry queryCacheEntry
+	// A file that's corrupt, or for another model and query with the same hash, is a miss
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Model != model || entry.Query != query || len(entry.Embedding) == 0 {
+		return nil, false
+	}
+	// Keep it from being pruned as one of the oldest
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	c.remember(key, &entry)
+	return entry.Embedding, true
+}
+
+// Put caches the embedding of query by model. Failing to write the disk
+// cache only loses the entry, so errors are ignored.
+func (c *QueryCache) Put(model, query string, embedding []float64) {
+	key := queryCacheKey(model, query)
+	entry := &queryCacheEntry{Model: model, Query: query, Embedding: embedding}
+	c.remember(key, entry)
+
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// Queries may be sensitive, so only the user can read the cache
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), filepath.Join(c.dir, key+".json")) != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	c.pruneDisk()
+}
+
+// remember adds an entry to the memory cache, evicting the least recently used
+func (c *QueryCache) remember(key string, entry *queryCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.recent.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(entry)
+	for c.recent.Len() > c.maxEntries {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		old := oldest.Value.(*queryCacheEntry)
+		delete(c.entries, queryCacheKey(old.Model, old.Query))
+	}
+}
+
+// pruneDisk removes the oldest files beyond maxEntries from the disk cache
+func (c *QueryCache) pruneDisk() {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil || len(files) <= c.maxEntries {
+		return
+	}
+
+	type cached struct {
+		path    string
+		modTime int64
+	}
+	entries := make([]cached, 0, len(files))
+	for _, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			entries = append(entries, cached{path: path, modTime: info.ModTime().UnixNano()})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime < entries[j].modTime })
+	for i := 0; i < len(entries)-c.maxEntries; i++ {
+		os.Remove(entries[i].path)
+	}
+}
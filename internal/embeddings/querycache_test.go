@@ -0,0 +1,58 @@
+package embeddings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestQueryCache_MemoryAndDisk(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "query-cache")
+	cache := NewQueryCache(dir, 10)
+
+	if _, ok := cache.Get("model-a", "parse config"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	cache.Put("model-a", "parse config", []float64{0.1, 0.2})
+	if got, ok := cache.Get("model-a", "parse config"); !ok || !reflect.DeepEqual(got, []float64{0.1, 0.2}) {
+		t.Errorf("expected a memory hit, got %v, %v", got, ok)
+	}
+	if _, ok := cache.Get("model-b", "parse config"); ok {
+		t.Error("expected another model's embedding of the query to miss")
+	}
+
+	// A new process finds the embedding on disk
+	if got, ok := NewQueryCache(dir, 10).Get("model-a", "parse config"); !ok || !reflect.DeepEqual(got, []float64{0.1, 0.2}) {
+		t.Errorf("expected a disk hit, got %v, %v", got, ok)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("expected a private cache directory, got %v, %v", info, err)
+	}
+}
+
+func TestQueryCache_Evicts(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewQueryCache(dir, 2)
+	cache.Put("m", "one", []float64{1})
+	cache.Put("m", "two", []float64{2})
+	cache.Get("m", "one")
+	cache.Put("m", "three", []float64{3})
+
+	memory := NewQueryCache("", 2)
+	memory.Put("m", "one", []float64{1})
+	memory.Put("m", "two", []float64{2})
+	memory.Get("m", "one")
+	memory.Put("m", "three", []float64{3})
+	if _, ok := memory.Get("m", "two"); ok {
+		t.Error("expected the least recently used entry to be evicted from memory")
+	}
+	if _, ok := memory.Get("m", "one"); !ok {
+		t.Error("expected a recently used entry to be kept")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Errorf("expected the disk cache pruned to 2 files, got %d", len(files))
+	}
+}
@@ -0,0 +1,208 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRateLimitWait is how long to pause after a 429 with no Retry-After hint
+	defaultRateLimitWait = 2 * time.Second
+	// maxRateLimitWaits bounds how many 429 responses a request waits out before failing
+	maxRateLimitWaits = 10
+)
+
+// rateLimitError is returned for 429 responses. RetryAfter is the server's
+// requested wait, or 0 if it didn't say.
+type rateLimitError struct {
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("embedding API rate limit exceeded (status 429): %s", e.Body)
+}
+
+// throttle pauses every request made through a client. Workers share one
+// client, so when one request learns the provider's limit is exhausted, all
+// workers wait instead of each hammering the API with retries.
+type throttle struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until any pause has elapsed or ctx is cancelled
+func (t *throttle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	delay := time.Until(t.until)
+	t.mu.Unlock()
+	return sleepContext(ctx, delay)
+}
+
+// sleepContext sleeps for d, returning early with ctx's error if it's cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause delays requests for d, extending any pause already in effect
+func (t *throttle) pause(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+}
+
+// retryAfter parses the wait requested by a rate-limited response: the
+// retry-after-ms or Retry-After header (seconds or an HTTP date), falling back
+// to OpenAI's x-ratelimit-reset-* headers. Returns 0 if none is present.
+func retryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(time.Until(date), 0)
+		}
+	}
+	return max(resetDuration(header, "x-ratelimit-reset-requests"), resetDuration(header, "x-ratelimit-reset-tokens"))
+}
+
+// exhaustedWait returns how long to pause before the next request when a
+// successful response reports that the request or token budget is used up
+// (OpenAI's x-ratelimit-remaining-* headers), or 0 if budget remains
+func exhaustedWait(header http.Header) time.Duration {
+	var wait time.Duration
+	if header.Get("x-ratelimit-remaining-requests") == "0" {
+		wait = max(wait, resetDuration(header, "x-ratelimit-reset-requests"))
+	}
+	if header.Get("x-ratelimit-remaining-tokens") == "0" {
+		wait = max(wait, resetDuration(header, "x-ratelimit-reset-tokens"))
+	}
+	return wait
+}
+
+// resetDuration parses an OpenAI reset header such as "1s", "6m0s", or "20ms"
+func resetDuration(header http.Header, key string) time.Duration {
+	d, err := time.ParseDuration(header.Get(key))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// RateLimiter caps the request and token rate of every client sharing it.
+// Requests that exceed the budget wait rather than fail.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests *bucket // nil when requests are unlimited
+	tokens   *bucket // nil when tokens are unlimited
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond requests and
+// tokensPerMinute estimated input tokens; zero leaves that dimension unlimited
+func NewRateLimiter(requestsPerSecond float64, tokensPerMinute int) *RateLimiter {
+	limiter := &RateLimiter{}
+	now := time.Now()
+	if requestsPerSecond > 0 {
+		limiter.requests = newBucket(max(requestsPerSecond, 1), requestsPerSecond, now)
+	}
+	if tokensPerMinute > 0 {
+		limiter.tokens = newBucket(float64(tokensPerMinute), float64(tokensPerMinute)/60, now)
+	}
+	return limiter
+}
+
+// wait blocks until a request for texts fits in the budget or ctx is cancelled
+func (l *RateLimiter) wait(ctx context.Context, texts []string) error {
+	tokens := 0
+	for _, text := range texts {
+		tokens += CountTokens(text)
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	var delay time.Duration
+	if l.requests != nil {
+		delay = l.requests.reserve(1, now)
+	}
+	if l.tokens != nil {
+		delay = max(delay, l.tokens.reserve(float64(tokens), now))
+	}
+	l.mu.Unlock()
+
+	return sleepContext(ctx, delay)
+}
+
+// bucket is a token bucket refilled continuously at rate per second up to capacity
+type bucket struct {
+	capacity  float64
+	rate      float64
+	available float64
+	last      time.Time
+}
+
+func newBucket(capacity, rate float64, now time.Time) *bucket {
+	return &bucket{capacity: capacity, rate: rate, available: capacity, last: now}
+}
+
+// reserve takes n from the bucket, going into debt if needed, and returns how
+// long to wait until the debt is repaid
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	b.available = min(b.capacity, b.available+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.available -= n
+	if b.available >= 0 {
+		return 0
+	}
+	return time.Duration(-b.available / b.rate * float64(time.Second))
+}
+
+// rateLimitedClient waits on a shared RateLimiter before each call to its client
+type rateLimitedClient struct {
+	client  Client
+	limiter *RateLimiter
+}
+
+// WithRateLimit returns a client that waits on limiter before each request to client
+func WithRateLimit(client Client, limiter *RateLimiter) Client {
+	return &rateLimitedClient{client: client, limiter: limiter}
+}
+
+func (c *rateLimitedClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	if err := c.limiter.wait(ctx, []string{text}); err != nil {
+		return nil, err
+	}
+	return c.client.Embed(ctx, text)
+}
+
+func (c *rateLimitedClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	if err := c.limiter.wait(ctx, texts); err != nil {
+		return nil, err
+	}
+	return c.client.EmbedMany(ctx, texts)
+}
+
+func (c *rateLimitedClient) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	if err := c.limiter.wait(ctx, []string{text}); err != nil {
+		return nil, err
+	}
+	return EmbedQuery(ctx, c.client, text)
+}
@@ -0,0 +1,103 @@
+package embeddings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, 250 * time.Millisecond},
+		{"reset headers", http.Header{"X-Ratelimit-Reset-Requests": {"1s"}, "X-Ratelimit-Reset-Tokens": {"6m0s"}}, 6 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header); got != tt.expected {
+				t.Errorf("retryAfter = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExhaustedWait(t *testing.T) {
+	header := http.Header{
+		"X-Ratelimit-Remaining-Requests": {"12"},
+		"X-Ratelimit-Reset-Requests":     {"1s"},
+		"X-Ratelimit-Remaining-Tokens":   {"0"},
+		"X-Ratelimit-Reset-Tokens":       {"20ms"},
+	}
+	if got := exhaustedWait(header); got != 20*time.Millisecond {
+		t.Errorf("exhaustedWait = %v, expected 20ms", got)
+	}
+
+	header.Set("X-Ratelimit-Remaining-Tokens", "500")
+	if got := exhaustedWait(header); got != 0 {
+		t.Errorf("exhaustedWait with budget left = %v, expected 0", got)
+	}
+}
+
+func TestEmbed_WaitsOutRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// More 429s than the three ordinary attempts, to check they don't consume them
+		if calls.Add(1) <= 4 {
+			w.Header().Set("retry-after-ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":[{"embedding":[0.1,0.2],"index":0}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithConfig(server.URL, "", "text-embedding-3-small")
+	embedding, err := client.Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(embedding) != 2 {
+		t.Errorf("unexpected embedding: %v", embedding)
+	}
+	if got := calls.Load(); got != 5 {
+		t.Errorf("expected 5 requests, got %d", got)
+	}
+}
+
+func TestBucketReserve(t *testing.T) {
+	start := time.Now()
+	b := newBucket(2, 2, start) // 2 requests per second, bursts of 2
+
+	if wait := b.reserve(1, start); wait != 0 {
+		t.Errorf("first request waited %v", wait)
+	}
+	if wait := b.reserve(1, start); wait != 0 {
+		t.Errorf("second request within the burst waited %v", wait)
+	}
+	if wait := b.reserve(1, start); wait != 500*time.Millisecond {
+		t.Errorf("third request waited %v, expected 500ms", wait)
+	}
+
+	// After the debt is repaid and the bucket refills, requests go through again
+	if wait := b.reserve(1, start.Add(2*time.Second)); wait != 0 {
+		t.Errorf("request after refill waited %v", wait)
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	limiter := NewRateLimiter(0, 0)
+	if limiter.requests != nil || limiter.tokens != nil {
+		t.Errorf("expected no buckets for zero limits, got %+v", limiter)
+	}
+	if err := limiter.wait(context.Background(), []string{"no limit"}); err != nil { // Must not block
+		t.Errorf("wait failed: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how embedding requests are timed out and retried
+type RetryPolicy struct {
+	Timeout        time.Duration // Limit for each request attempt; 0 for none
+	MaxRetries     int           // Retries after the first attempt fails
+	InitialBackoff time.Duration // Wait before the first retry
+	MaxBackoff     time.Duration // Cap on the wait between retries; 0 for none
+	Multiplier     float64       // Growth of the wait after each retry
+}
+
+// DefaultRetryPolicy returns the policy clients use unless configured otherwise
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Timeout:        2 * time.Minute,
+		MaxRetries:     2,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+	}
+}
+
+// statusError is an unsuccessful HTTP response from an embedding provider
+type statusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, e.g. on 503; 0 if absent
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// responseError converts an unsuccessful response into a rateLimitError for
+// 429 and a statusError otherwise
+func responseError(provider string, resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &rateLimitError{RetryAfter: retryAfter(resp.Header), Body: string(body)}
+	}
+	return &statusError{Provider: provider, StatusCode: resp.StatusCode, Body: string(body), RetryAfter: retryAfter(resp.Header)}
+}
+
+// UnreachableError is a request that failed because the endpoint couldn't be
+// connected to, e.g. because the server isn't running or its host doesn't resolve
+type UnreachableError struct {
+	Provider string
+	Err      error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("failed to make request to %s: %v", e.Provider, e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// requestError wraps an error sending a request, as an UnreachableError if no
+// connection could be made
+func requestError(provider string, err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return &UnreachableError{Provider: provider, Err: err}
+	}
+	return fmt.Errorf("failed to make request to %s: %w", provider, err)
+}
+
+// isRetryable reports whether a failed request may succeed if repeated:
+// server errors, timeouts, and network failures are; client errors such as
+// 400 (bad input) or 401 (bad API key) are not
+func isRetryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= 500 || status.StatusCode == http.StatusRequestTimeout
+	}
+	return true
+}
+
+// backoff returns the wait before the given retry (1 for the first)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		wait *= max(p.Multiplier, 1)
+	}
+	if p.MaxBackoff > 0 {
+		wait = min(wait, float64(p.MaxBackoff))
+	}
+	return time.Duration(wait)
+}
+
+// jitter randomizes a wait to between half and all of it, so workers that
+// failed together don't retry in lockstep
+func jitter(wait time.Duration) time.Duration {
+	if wait <= 1 {
+		return wait
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retrier retries a client's requests under its RetryPolicy. Its throttle is
+// shared by all of the client's requests.
+type retrier struct {
+	policy   *RetryPolicy // nil until SetRetryPolicy; DefaultRetryPolicy applies
+	throttle throttle     // Shared pause after the provider reports a rate limit
+}
+
+// SetRetryPolicy replaces the client's timeout and retry settings
+func (r *retrier) SetRetryPolicy(policy RetryPolicy) {
+	r.policy = &policy
+}
+
+// withRetry calls embed until it returns the expected number of embeddings,
+// backing off with jitter between failed attempts and waiting out rate
+// limits. Errors that can't succeed on retry are returned at once. Each
+// attempt gets its own timeout; cancelling ctx stops retrying at once.
+func (r *retrier) withRetry(ctx context.Context, expected int, embed func(ctx context.Context) ([][]float64, error)) ([][]float64, error) {
+	policy := DefaultRetryPolicy()
+	if r.policy != nil {
+		policy = *r.policy
+	}
+	attempts := policy.MaxRetries + 1
+
+	var lastErr error
+	var wait time.Duration
+	rateLimitWaits := 0
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		if err := r.throttle.wait(ctx); err != nil {
+			return nil, err
+		}
+		embeddings, err := r.try(ctx, policy.Timeout, embed)
+		if err == nil {
+			if len(embeddings) != expected {
+				return nil, fmt.Errorf("expected %d embeddings, got %d", expected, len(embeddings))
+			}
+			return embeddings, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// Rate limits pause every worker for as long as the provider asks,
+		// and don't use up the attempts meant for real failures
+		var limited *rateLimitError
+		if errors.As(err, &limited) && rateLimitWaits < maxRateLimitWaits {
+			rateLimitWaits++
+			pause := limited.RetryAfter
+			if pause <= 0 {
+				pause = defaultRateLimitWait
+			}
+			r.throttle.pause(pause)
+			wait = 0
+			attempt--
+			continue
+		}
+
+		if !isRetryable(err) {
+			return nil, err
+		}
+		wait = jitter(policy.backoff(attempt + 1))
+		var status *statusError
+		if errors.As(err, &status) {
+			wait = max(wait, status.RetryAfter)
+		}
+	}
+
+	return nil, fmt.Errorf("failed after %d attempts: %w", attempts, lastErr)
+}
+
+// try runs embed once, limited to timeout if set
+func (r *retrier) try(ctx context.Context, timeout time.Duration, embed func(ctx context.Context) ([][]float64, error)) ([][]float64, error) {
+	if timeout <= 0 {
+		return embed(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	embeddings, err := embed(attemptCtx)
+	if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("embedding request timed out after %v: %w", timeout, err)
+	}
+	return embeddings, err
+}
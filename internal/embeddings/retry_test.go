@@ -0,0 +1,135 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %v, expected %v", i+1, got, want)
+		}
+	}
+}
+
+func TestRetrier_MaxRetries(t *testing.T) {
+	r := &retrier{}
+	r.SetRetryPolicy(RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond})
+
+	calls := 0
+	_, err := r.withRetry(context.Background(), 1, func(ctx context.Context) ([][]float64, error) {
+		calls++
+		return nil, errors.New("server error")
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected 3 attempts and an error, got %d attempts (%v)", calls, err)
+	}
+}
+
+func TestRetrier_TimeoutRetries(t *testing.T) {
+	r := &retrier{}
+	r.SetRetryPolicy(RetryPolicy{Timeout: 20 * time.Millisecond, MaxRetries: 1, InitialBackoff: time.Millisecond})
+
+	calls := 0
+	embeddings, err := r.withRetry(context.Background(), 1, func(ctx context.Context) ([][]float64, error) {
+		calls++
+		if calls == 1 {
+			<-ctx.Done() // The first attempt hangs until its timeout
+			return nil, ctx.Err()
+		}
+		return [][]float64{{1}}, nil
+	})
+	if err != nil || len(embeddings) != 1 || calls != 2 {
+		t.Errorf("expected the timed-out attempt to be retried, got %d attempts (%v)", calls, err)
+	}
+
+	r.SetRetryPolicy(RetryPolicy{Timeout: 10 * time.Millisecond})
+	_, err = r.withRetry(context.Background(), 1, func(ctx context.Context) ([][]float64, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+}
+
+func TestRetrier_ClientErrorsNotRetried(t *testing.T) {
+	r := &retrier{}
+	r.SetRetryPolicy(RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond})
+
+	for _, tt := range []struct {
+		status        int
+		expectedCalls int
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusUnauthorized, 1},
+		{http.StatusServiceUnavailable, 4},
+	} {
+		calls := 0
+		_, err := r.withRetry(context.Background(), 1, func(ctx context.Context) ([][]float64, error) {
+			calls++
+			return nil, &statusError{Provider: "test API", StatusCode: tt.status}
+		})
+		if err == nil || calls != tt.expectedCalls {
+			t.Errorf("status %d: expected %d attempts and an error, got %d (%v)", tt.status, tt.expectedCalls, calls, err)
+		}
+	}
+}
+
+func TestRetrier_HonorsRetryAfterOnServerError(t *testing.T) {
+	r := &retrier{}
+	r.SetRetryPolicy(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond})
+
+	start := time.Now()
+	calls := 0
+	_, err := r.withRetry(context.Background(), 1, func(ctx context.Context) ([][]float64, error) {
+		calls++
+		if calls == 1 {
+			return nil, &statusError{Provider: "test API", StatusCode: http.StatusServiceUnavailable, RetryAfter: 50 * time.Millisecond}
+		}
+		return [][]float64{{1}}, nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("retried after %v, before the 50ms Retry-After", elapsed)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if got := jitter(time.Second); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("jitter(1s) = %v, expected between 500ms and 1s", got)
+		}
+	}
+}
+
+func TestClient_UnreachableEndpoint(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "http://" + listener.Addr().String()
+	listener.Close()
+
+	client := NewClientWithConfig(endpoint, "", "model")
+	client.SetRetryPolicy(RetryPolicy{})
+	_, err = client.Embed(context.Background(), "text")
+	var unreachable *UnreachableError
+	if !errors.As(err, &unreachable) {
+		t.Errorf("expected an UnreachableError for a closed port, got %v", err)
+	}
+
+	if err := requestError("embedding API", context.DeadlineExceeded); errors.As(err, &unreachable) {
+		t.Error("expected a timeout not to be reported as unreachable")
+	}
+}
@@ -0,0 +1,80 @@
+package embeddings
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultMaxTokens is the input limit assumed for models not in knownMaxTokens
+const DefaultMaxTokens = 8192
+
+// knownMaxTokens is the maximum input length of common embedding models
+var knownMaxTokens = map[string]int{
+	DefaultCodeModel:          32768,
+	DefaultTextModel:          8192,
+	"nomic-embed-text":        8192,
+	"nomic-embed-code":        32768,
+	"text-embedding-3-small":  8191,
+	"text-embedding-3-large":  8191,
+	"text-embedding-ada-002":  8191,
+	"voyage-code-3":           32000,
+	"voyage-code-2":           16000,
+	"voyage-3":                32000,
+	"embed-english-v3.0":      512,
+	"embed-multilingual-v3.0": 512,
+}
+
+// MaxTokens returns the input limit of a model, by its name with any
+// provider prefix (e.g. "manutic/") or tag (e.g. ":latest") removed
+func MaxTokens(model string) int {
+	if limit, ok := knownMaxTokens[baseModelName(model)]; ok {
+		return limit
+	}
+	return DefaultMaxTokens
+}
+
+// baseModelName returns a model's name without any provider prefix or tag
+func baseModelName(model string) string {
+	name := model[strings.LastIndex(model, "/")+1:]
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// CountTokens estimates how many tokens a BPE or WordPiece tokenizer splits
+// text into. Words count about one token per 4 characters, each symbol is a
+// token, and a run of whitespace is one token. It errs high for code, so
+// inputs kept under a model's limit by this count fit.
+func CountTokens(text string) int {
+	tokens := 0
+	wordLen := 0
+	inSpace := false
+	flushWord := func() {
+		if wordLen > 0 {
+			tokens += (wordLen + 3) / 4
+			wordLen = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'):
+			wordLen++
+			inSpace = false
+		case unicode.IsSpace(r):
+			flushWord()
+			if !inSpace {
+				tokens++
+				inSpace = true
+			}
+		default:
+			// Symbols and non-ASCII characters are about a token each
+			flushWord()
+			tokens++
+			inSpace = false
+		}
+	}
+	flushWord()
+	return tokens
+}
@@ -0,0 +1,35 @@
+package embeddings
+
+import "testing"
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"func", 1},
+		{"generateEmbeddings", 5}, // 18 characters
+		{"a := b + 1", 10},        // Each symbol, word, and space run
+		{"x\n\n\ty", 3},
+	}
+	for _, tt := range tests {
+		if got := CountTokens(tt.text); got != tt.expected {
+			t.Errorf("CountTokens(%q) = %d, expected %d", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestMaxTokens(t *testing.T) {
+	tests := map[string]int{
+		"code-scout-code":                 32768,
+		"manutic/nomic-embed-code:latest": 32768,
+		"embed-english-v3.0":              512,
+		"some-new-model":                  DefaultMaxTokens,
+	}
+	for model, expected := range tests {
+		if got := MaxTokens(model); got != expected {
+			t.Errorf("MaxTokens(%q) = %d, expected %d", model, got, expected)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// DefaultVoyageEndpoint is the Voyage AI API base URL
+	DefaultVoyageEndpoint = "https://api.voyageai.com"
+	// voyageMaxTexts is the most texts Voyage accepts in one request
+	voyageMaxTexts = 1000
+	// voyageMaxTokens keeps each request under Voyage's 120K token limit for
+	// voyage-code models, with headroom for the token estimate being low
+	voyageMaxTokens = 100000
+)
+
+// VoyageClient handles communication with Voyage AI's embeddings API, which
+// embeds indexed documents and search queries with different input types
+type VoyageClient struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+	retrier
+}
+
+// voyageEmbedRequest represents a Voyage embeddings request
+type voyageEmbedRequest struct {
+	Input      []string `json:"input"`
+	Model      string   `json:"model"`
+	InputType  string   `json:"input_type"` // document or query
+	Truncation bool     `json:"truncation"`
+}
+
+// voyageEmbedResponse represents a Voyage embeddings response
+type voyageEmbedResponse struct {
+	Data []struct {
+		Embedding []float64 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// NewVoyageClient creates a Voyage AI embedding client. An empty endpoint uses DefaultVoyageEndpoint.
+func NewVoyageClient(endpoint, apiKey, model string) *VoyageClient {
+	if endpoint == "" {
+		endpoint = DefaultVoyageEndpoint
+	}
+	return &VoyageClient{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    model,
+		client:   &http.Client{},
+	}
+}
+
+// Embed generates a document embedding for the given text
+func (c *VoyageClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.EmbedMany(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedMany generates document embeddings, split into requests within
+// Voyage's per-request text and token limits
+func (c *VoyageClient) EmbedMany(ctx context.Context, texts []string) ([][]float64, error) {
+	var embeddings [][]float64
+	for _, batch := range voyageBatches(texts) {
+		batchEmbeddings, err := c.embed(ctx, batch, "document")
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batchEmbeddings...)
+	}
+	return embeddings, nil
+}
+
+// EmbedQuery generates a search query embedding for the given text
+func (c *VoyageClient) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.embed(ctx, []string{text}, "query")
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// voyageBatches splits texts into batches of at most voyageMaxTexts texts and
+// voyageMaxTokens estimated tokens. A single text over the token limit gets a
+// batch of its own and is truncated by the API.
+func voyageBatches(texts []string) [][]string {
+	var batches [][]string
+	start, tokens := 0, 0
+	for i, text := range texts {
+		textTokens := CountTokens(text)
+		if i > start && (i-start == voyageMaxTexts || tokens+textTokens > voyageMaxTokens) {
+			batches = append(batches, texts[start:i])
+			start, tokens = i, 0
+		}
+		tokens += textTokens
+	}
+	if start < len(texts) {
+		batches = append(batches, texts[start:])
+	}
+	return batches
+}
+
+func (c *VoyageClient) embed(ctx context.Context, texts []string, inputType string) ([][]float64, error) {
+	return c.withRetry(ctx, len(texts), func(ctx context.Context) ([][]float64, error) {
+		return c.embedOnce(ctx, texts, inputType)
+	})
+}
+
+// embedOnce makes a single embeddings request without retries
+func (c *VoyageClient) embedOnce(ctx context.Context, texts []string, inputType string) ([][]float64, error) {
+	reqBody := voyageEmbedRequest{
+		Input:      texts,
+		Model:      c.model,
+		InputType:  inputType,
+		Truncation: true,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/v1/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, requestError("Voyage API", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, responseError("Voyage API", resp, body)
+	}
+
+	var embedResp voyageEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+
+	embeddings := make([][]float64, len(embedResp.Data))
+	for _, data := range embedResp.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	return embeddings, nil
+}
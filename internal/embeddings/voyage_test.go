@@ -0,0 +1,54 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVoyageBatches(t *testing.T) {
+	texts := make([]string, voyageMaxTexts+1)
+	if batches := voyageBatches(texts); len(batches) != 2 || len(batches[0]) != voyageMaxTexts {
+		t.Errorf("expected a batch of %d and a batch of 1, got %d batches", voyageMaxTexts, len(batches))
+	}
+
+	large := strings.Repeat("x", voyageMaxTokens*4)
+	batches := voyageBatches([]string{"a", large, "b"})
+	if len(batches) != 3 {
+		t.Errorf("expected an oversized text in its own batch, got %d batches", len(batches))
+	}
+
+	if batches := voyageBatches(nil); len(batches) != 0 {
+		t.Errorf("expected no batches for no texts, got %d", len(batches))
+	}
+}
+
+func TestVoyageClient(t *testing.T) {
+	var requests []voyageEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req voyageEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		// Return embeddings out of order to check they're placed by index
+		w.Write([]byte(`{"data":[{"embedding":[2],"index":1},{"embedding":[1],"index":0}]}`))
+	}))
+	defer server.Close()
+
+	client := NewVoyageClient(server.URL, "key", "voyage-code-3")
+	embeddings, err := client.EmbedMany(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("EmbedMany failed: %v", err)
+	}
+	if embeddings[0][0] != 1 || embeddings[1][0] != 2 {
+		t.Errorf("embeddings not ordered by index: %v", embeddings)
+	}
+	if requests[0].InputType != "document" || !requests[0].Truncation {
+		t.Errorf("unexpected document request: %+v", requests[0])
+	}
+}
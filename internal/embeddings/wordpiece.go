@@ -0,0 +1,138 @@
+package embeddings
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxWordChars is the longest word WordPiece splits; longer words become [UNK]
+const maxWordChars = 200
+
+// wordPieceTokenizer is a BERT-style uncased WordPiece tokenizer, as used by
+// the small sentence-embedding models run through the ONNX backend
+type wordPieceTokenizer struct {
+	vocab map[string]int64
+	cls   int64
+	sep   int64
+	pad   int64
+	unk   int64
+}
+
+// loadWordPieceTokenizer reads a vocab.txt file with one token per line, the id being the line number
+func loadWordPieceTokenizer(path string) (*wordPieceTokenizer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer file.Close()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	for id := int64(0); scanner.Scan(); id++ {
+		vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	return newWordPieceTokenizer(vocab)
+}
+
+// newWordPieceTokenizer creates a tokenizer over vocab, which must contain BERT's special tokens
+func newWordPieceTokenizer(vocab map[string]int64) (*wordPieceTokenizer, error) {
+	t := &wordPieceTokenizer{vocab: vocab}
+	for token, id := range map[string]*int64{"[CLS]": &t.cls, "[SEP]": &t.sep, "[PAD]": &t.pad, "[UNK]": &t.unk} {
+		value, ok := vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("vocabulary is missing %s", token)
+		}
+		*id = value
+	}
+	return t, nil
+}
+
+// encode returns the token ids for text wrapped in [CLS] and [SEP], truncated to maxTokens
+func (t *wordPieceTokenizer) encode(text string, maxTokens int) []int64 {
+	ids := []int64{t.cls}
+	for _, word := range basicTokenize(text) {
+		ids = append(ids, t.wordPieces(word)...)
+		if len(ids) >= maxTokens-1 {
+			ids = ids[:maxTokens-1]
+			break
+		}
+	}
+	return append(ids, t.sep)
+}
+
+// wordPieces splits a word into the longest vocabulary pieces, continuation
+// pieces being prefixed with ##
+func (t *wordPieceTokenizer) wordPieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > maxWordChars {
+		return []int64{t.unk}
+	}
+
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := t.vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{t.unk}
+		}
+		start = end
+	}
+	return ids
+}
+
+// basicTokenize lowercases text, strips accents, and splits it into words on
+// whitespace, punctuation, and CJK characters
+func basicTokenize(text string) []string {
+	var words []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == 0, r == unicode.ReplacementChar, unicode.IsControl(r) && !unicode.IsSpace(r):
+			// Accents and control characters are dropped
+		case unicode.IsSpace(r):
+			flush()
+		case isPunctuation(r) || unicode.Is(unicode.Han, r):
+			flush()
+			words = append(words, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// isPunctuation matches BERT's definition: Unicode punctuation plus all
+// non-alphanumeric ASCII symbols such as $ and `
+func isPunctuation(r rune) bool {
+	if r < 128 && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) && unicode.IsPrint(r) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
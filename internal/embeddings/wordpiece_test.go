@@ -0,0 +1,51 @@
+package embeddings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestTokenizer(t *testing.T) *wordPieceTokenizer {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	vocab := "[PAD]\n[UNK]\n[CLS]\n[SEP]\nparse\n##r\nhttp\n(\n)\ncafe\nx\n"
+	if err := os.WriteFile(path, []byte(vocab), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tokenizer, err := loadWordPieceTokenizer(path)
+	if err != nil {
+		t.Fatalf("failed to load tokenizer: %v", err)
+	}
+	return tokenizer
+}
+
+func TestBasicTokenize(t *testing.T) {
+	got := basicTokenize("Parse(HTTP)  Café\tx_y")
+	expected := []string{"parse", "(", "http", ")", "cafe", "x", "_", "y"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("basicTokenize = %q, expected %q", got, expected)
+	}
+}
+
+func TestWordPieceEncode(t *testing.T) {
+	tokenizer := newTestTokenizer(t)
+
+	// [CLS] parse ##r ( http ) [UNK] [SEP]
+	got := tokenizer.encode("Parser(HTTP) unknown", 512)
+	expected := []int64{2, 4, 5, 7, 6, 8, 1, 3}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("encode = %v, expected %v", got, expected)
+	}
+
+	if got := tokenizer.encode("parse parse parse parse", 4); !reflect.DeepEqual(got, []int64{2, 4, 4, 3}) {
+		t.Errorf("expected truncation to 4 tokens, got %v", got)
+	}
+}
+
+func TestNewWordPieceTokenizer_MissingSpecialTokens(t *testing.T) {
+	if _, err := newWordPieceTokenizer(map[string]int64{"[CLS]": 0}); err == nil {
+		t.Error("expected an error for a vocabulary without [SEP], [PAD], and [UNK]")
+	}
+}
@@ -0,0 +1,112 @@
+package gitinfo
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Info describes the state of a git working tree
+type Info struct {
+	Commit string // Full HEAD commit hash
+	Dirty  bool   // True if the working tree has uncommitted changes
+}
+
+// ShortCommit returns the abbreviated (7 character) commit hash
+func (i *Info) ShortCommit() string {
+	if len(i.Commit) > 7 {
+		return i.Commit[:7]
+	}
+	return i.Commit
+}
+
+// Inspect returns the HEAD commit and dirty state of the repository containing dir.
+// Returns nil (and no error) if dir is not inside a git repository or git is not installed.
+func Inspect(dir string) (*Info, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, nil
+	}
+
+	inside, err := runGit(dir, "rev-parse", "--is-inside-work-tree")
+	if err != nil || inside != "true" {
+		return nil, nil
+	}
+
+	commit, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		// Repository without commits yet
+		return nil, nil
+	}
+
+	status, err := runGit(dir, "status", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	return &Info{
+		Commit: commit,
+		Dirty:  status != "",
+	}, nil
+}
+
+// ChangedFiles returns the files under dir that differ between ref and the
+// working tree, including deleted and untracked files, as paths relative to dir
+func ChangedFiles(dir, ref string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	diff, err := runGit(dir, "diff", "--name-only", "--no-renames", "--relative", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := runGit(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		if line != "" && !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// HooksDir returns the hooks directory of the repository containing dir (which
+// honors core.hooksPath) and dir's path from the repository root, such as
+// "tools/" or "" at the root
+func HooksDir(dir string) (hooks, prefix string, err error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", "", fmt.Errorf("git is not installed")
+	}
+	out, err := runGit(dir, "rev-parse", "--git-path", "hooks", "--show-prefix")
+	if err != nil {
+		return "", "", err
+	}
+	hooks, prefix, _ = strings.Cut(out, "\n")
+	if !filepath.IsAbs(hooks) {
+		hooks = filepath.Join(dir, hooks)
+	}
+	return hooks, prefix, nil
+}
+
+// runGit runs a git command in dir and returns its trimmed stdout
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,131 @@
+package gitinfo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+	} {
+		if _, err := runGit(dir, args...); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	return dir
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGit(dir, "add", name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runGit(dir, "commit", "-q", "-m", "add "+name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspect_NotARepository(t *testing.T) {
+	info, err := Inspect(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info != nil {
+		t.Errorf("expected nil info outside a repository, got %+v", info)
+	}
+}
+
+func TestInspect_CleanAndDirty(t *testing.T) {
+	dir := initRepo(t)
+	commitFile(t, dir, "main.go", "package main\n")
+
+	info, err := Inspect(dir)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if info == nil {
+		t.Fatal("expected info for repository")
+	}
+	if len(info.Commit) != 40 {
+		t.Errorf("expected full commit hash, got %q", info.Commit)
+	}
+	if info.Dirty {
+		t.Error("expected clean working tree")
+	}
+	if len(info.ShortCommit()) != 7 {
+		t.Errorf("expected 7 character short commit, got %q", info.ShortCommit())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err = Inspect(dir)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if !info.Dirty {
+		t.Error("expected dirty working tree after modification")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := initRepo(t)
+	commitFile(t, dir, "kept.go", "package main\n")
+	commitFile(t, dir, "changed.go", "package main\n")
+	commitFile(t, dir, "deleted.go", "package main\n")
+
+	if err := os.WriteFile(filepath.Join(dir, "changed.go"), []byte("package main\n\nfunc f() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "deleted.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ChangedFiles(dir, "HEAD")
+	if err != nil {
+		t.Fatalf("ChangedFiles failed: %v", err)
+	}
+	got := strings.Join(files, ",")
+	if got != "changed.go,deleted.go,new.go" {
+		t.Errorf("expected changed.go,deleted.go,new.go, got %q", got)
+	}
+
+	if _, err := ChangedFiles(dir, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
+func TestHooksDir(t *testing.T) {
+	dir := initRepo(t)
+	sub := filepath.Join(dir, "tools")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, prefix, err := HooksDir(sub)
+	if err != nil {
+		t.Fatalf("HooksDir failed: %v", err)
+	}
+	if hooks != filepath.Join(dir, ".git", "hooks") || prefix != "tools/" {
+		t.Errorf("expected %s and tools/, got %s and %q", filepath.Join(dir, ".git", "hooks"), hooks, prefix)
+	}
+}
@@ -0,0 +1,104 @@
+// Package llm provides a client for OpenAI-compatible chat completions APIs,
+// used by code-scout's optional LLM features.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultTimeout bounds a single completion; local models can be slow to answer
+const defaultTimeout = 2 * time.Minute
+
+// Message is a single chat message
+type Message struct {
+	Role    string `json:"role"` // "system", "user", or "assistant"
+	Content string `json:"content"`
+}
+
+// Client is the interface for chat completion clients. Cancelling ctx aborts
+// the in-flight request.
+type Client interface {
+	CompleteContext(ctx context.Context, messages []Message) (string, error)
+}
+
+// ChatClient calls an OpenAI-compatible /v1/chat/completions endpoint
+// (Ollama, OpenRouter, vLLM, and similar services)
+type ChatClient struct {
+	endpoint string
+	apiKey   string // Optional API key for authentication
+	model    string
+	client   *http.Client
+}
+
+// chatRequest represents the OpenAI-compatible chat completions request
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+// chatResponse represents the OpenAI-compatible chat completions response
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+// NewClient creates a chat client for model at endpoint
+func NewClient(endpoint, apiKey, model string) *ChatClient {
+	return &ChatClient{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    model,
+		client:   &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// Complete sends messages to the model and returns its reply
+func (c *ChatClient) Complete(messages []Message) (string, error) {
+	return c.CompleteContext(context.Background(), messages)
+}
+
+// CompleteContext sends messages to the model and returns its reply, aborting
+// the request if ctx is cancelled
+func (c *ChatClient) CompleteContext(ctx context.Context, messages []Message) (string, error) {
+	jsonData, err := json.Marshal(chatRequest{Model: c.model, Messages: messages, Temperature: 0.2})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request to chat API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("chat API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in chat response")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
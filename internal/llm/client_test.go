@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChatClient_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing API key header")
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Model != "chat-model" || len(req.Messages) != 2 || req.Messages[1].Content != "hello" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi there"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "secret", "chat-model")
+	reply, err := client.Complete([]Message{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hello"}})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if reply != "hi there" {
+		t.Errorf("expected reply 'hi there', got %q", reply)
+	}
+}
+
+func TestChatClient_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "", "missing").Complete([]Message{{Role: "user", Content: "x"}}); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+}
+
+func TestChatClient_CompleteContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := NewClient(server.URL, "", "slow").CompleteContext(ctx, []Message{{Role: "user", Content: "x"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request aborted with ctx, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package lsp implements the subset of the Language Server Protocol used by
+// `code-scout lsp`: JSON-RPC message framing and the types needed for
+// workspace/symbol requests. `code-scout rpc` shares its JSON-RPC types.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeServerNotInitialized is returned for requests sent before initialize
+	CodeServerNotInitialized = -32002
+	// CodeRequestCancelled is returned for requests cancelled by the client
+	CodeRequestCancelled = -32800
+)
+
+// Message is an incoming JSON-RPC request or notification. Notifications have no ID.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the message expects no response
+func (m *Message) IsNotification() bool {
+	return len(m.ID) == 0
+}
+
+// Response is an outgoing JSON-RPC response. Exactly one of Result and Error
+// is set; use NullResult for requests that succeed without a value.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *ResponseError  `json:"error,omitempty"`
+}
+
+// NullResult is the result of a successful request with no value, such as shutdown
+var NullResult = json.RawMessage("null")
+
+// ResponseError is the error member of a failed response
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// InitializeParams holds the fields of an initialize request used to locate the workspace
+type InitializeParams struct {
+	RootURI          string            `json:"rootUri"`
+	RootPath         string            `json:"rootPath"`
+	WorkspaceFolders []WorkspaceFolder `json:"workspaceFolders"`
+}
+
+// WorkspaceFolder is a workspace root reported by the client
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// RootDir returns the workspace directory named by the client, or "" if none was given
+func (p *InitializeParams) RootDir() string {
+	if len(p.WorkspaceFolders) > 0 {
+		if dir, err := URIToPath(p.WorkspaceFolders[0].URI); err == nil {
+			return dir
+		}
+	}
+	if p.RootURI != "" {
+		if dir, err := URIToPath(p.RootURI); err == nil {
+			return dir
+		}
+	}
+	return p.RootPath
+}
+
+// WorkspaceSymbolParams holds the parameters of a workspace/symbol request
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+// SymbolKind is the LSP symbol kind enumeration
+type SymbolKind int
+
+// Symbol kinds used for code-scout chunk types
+const (
+	SymbolKindFile      SymbolKind = 1
+	SymbolKindModule    SymbolKind = 2
+	SymbolKindClass     SymbolKind = 5
+	SymbolKindMethod    SymbolKind = 6
+	SymbolKindEnum      SymbolKind = 10
+	SymbolKindInterface SymbolKind = 11
+	SymbolKindFunction  SymbolKind = 12
+	SymbolKindVariable  SymbolKind = 13
+	SymbolKindConstant  SymbolKind = 14
+	SymbolKindString    SymbolKind = 15
+	SymbolKindStruct    SymbolKind = 23
+)
+
+// SymbolInformation is a single workspace/symbol result
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+// Location is a range inside a document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Range is a span between two positions, end exclusive
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Position is a zero-based line and character offset
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// PathToURI converts an absolute file path to a file:// URI
+func PathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// URIToPath converts a file:// URI to a file path
+func URIToPath(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid URI %q: %w", uri, err)
+	}
+	if parsed.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", parsed.Scheme)
+	}
+	return filepath.FromSlash(parsed.Path), nil
+}
+
+// ReadMessage reads one Content-Length framed message body
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	contentLength := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header: %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || contentLength < 0 {
+				return nil, fmt.Errorf("invalid Content-Length: %q", value)
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
+
+// WriteMessage writes value as a Content-Length framed JSON message
+func WriteMessage(w io.Writer, value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
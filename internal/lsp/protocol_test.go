@@ -0,0 +1,62 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMessageFraming(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, map[string]string{"method": "initialize"}); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+	if err := WriteMessage(&buf, map[string]string{"method": "shutdown"}); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+
+	reader := bufio.NewReader(&buf)
+	for _, expected := range []string{`{"method":"initialize"}`, `{"method":"shutdown"}`} {
+		body, err := ReadMessage(reader)
+		if err != nil {
+			t.Fatalf("ReadMessage failed: %v", err)
+		}
+		if string(body) != expected {
+			t.Errorf("expected %s, got %s", expected, body)
+		}
+	}
+}
+
+func TestReadMessage_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing length": "Content-Type: application/json\r\n\r\n{}",
+		"bad length":     "Content-Length: abc\r\n\r\n{}",
+		"short body":     "Content-Length: 10\r\n\r\n{}",
+		"bad header":     "garbage\r\n\r\n{}",
+	}
+	for name, input := range tests {
+		if _, err := ReadMessage(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestInitializeParams_RootDir(t *testing.T) {
+	params := InitializeParams{
+		RootURI:          "file:///home/user/other",
+		WorkspaceFolders: []WorkspaceFolder{{URI: "file:///home/user/my%20project"}},
+	}
+	if got := params.RootDir(); got != "/home/user/my project" {
+		t.Errorf("expected workspace folder, got %q", got)
+	}
+
+	params.WorkspaceFolders = nil
+	if got := params.RootDir(); got != "/home/user/other" {
+		t.Errorf("expected rootUri, got %q", got)
+	}
+
+	if got := PathToURI("/home/user/my project/a.go"); got != "file:///home/user/my%20project/a.go" {
+		t.Errorf("unexpected URI: %s", got)
+	}
+}
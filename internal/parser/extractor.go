@@ -61,6 +61,12 @@ func (e *Extractor) walkNode(node *sitter.Node, chunks *[]*Chunk) {
 		return
 	}
 
+	// Registered extraction rules replace the built-in node kinds below
+	if rules, ok := extractionRules(e.parser.Language()); ok {
+		e.walkNodeWithRules(node, rules, chunks)
+		return
+	}
+
 	nodeKind := node.Kind()
 
 	// Go-specific nodes
@@ -173,6 +179,26 @@ func (e *Extractor) walkNode(node *sitter.Node, chunks *[]*Chunk) {
 	}
 }
 
+// walkNodeWithRules recursively walks the AST and extracts the node kinds in rules
+func (e *Extractor) walkNodeWithRules(node *sitter.Node, rules ExtractionRules, chunks *[]*Chunk) {
+	if node == nil {
+		return
+	}
+
+	if chunkType, ok := rules[node.Kind()]; ok {
+		chunk := e.extractGenericNode(node, node.Kind())
+		if chunk != nil {
+			chunk.Type = chunkType
+			*chunks = append(*chunks, chunk)
+		}
+	}
+
+	childCount := node.ChildCount()
+	for i := uint(0); i < childCount; i++ {
+		e.walkNodeWithRules(node.Child(i), rules, chunks)
+	}
+}
+
 // extractFunction extracts a function declaration chunk
 func (e *Extractor) extractFunction(node *sitter.Node) *Chunk {
 	if node == nil {
@@ -206,7 +232,7 @@ func (e *Extractor) extractFunction(node *sitter.Node) *Chunk {
 	startLine := int(node.StartPosition().Row) + 1
 	endLine := int(node.EndPosition().Row) + 1
 
-	return &Chunk{
+	chunk := &Chunk{
 		Type:       ChunkTypeFunction,
 		Name:       name,
 		Content:    content,
@@ -218,6 +244,13 @@ func (e *Extractor) extractFunction(node *sitter.Node) *Chunk {
 		EndByte:    int(endByte),
 		Metadata:   make(map[string]string),
 	}
+
+	// Store type parameters of generic functions in metadata
+	if typeParams := e.extractTypeParameters(node); typeParams != "" {
+		chunk.Metadata["type_params"] = typeParams
+	}
+
+	return chunk
 }
 
 // extractMethod extracts a method declaration chunk
@@ -277,12 +310,15 @@ func (e *Extractor) extractFunctionSignature(node *sitter.Node) string {
 		return ""
 	}
 
-	// Get parameters
+	// Get parameters, after the type parameters of a generic function
 	paramsNode := node.ChildByFieldName("parameters")
 	params := ""
 	if paramsNode != nil {
 		params = paramsNode.Utf8Text(e.sourceCode)
 	}
+	if typeParams := e.extractTypeParameters(node); typeParams != "" {
+		params = "[" + typeParams + "]" + params
+	}
 
 	// Get result (return type)
 	resultNode := node.ChildByFieldName("result")
@@ -294,6 +330,20 @@ func (e *Extractor) extractFunctionSignature(node *sitter.Node) string {
 	return params + result
 }
 
+// extractTypeParameters extracts the type parameter list of a generic function
+// or type without its brackets, e.g. "K comparable, V any"
+func (e *Extractor) extractTypeParameters(node *sitter.Node) string {
+	typeParamsNode := node.ChildByFieldName("type_parameters")
+	if typeParamsNode == nil {
+		return ""
+	}
+
+	text := typeParamsNode.Utf8Text(e.sourceCode)
+	text = strings.TrimPrefix(text, "[")
+	text = strings.TrimSuffix(text, "]")
+	return strings.TrimSpace(text)
+}
+
 // extractReceiver extracts the receiver type from a method
 func (e *Extractor) extractReceiver(receiverNode *sitter.Node) string {
 	if receiverNode == nil {
@@ -420,6 +470,11 @@ func (e *Extractor) extractTypeSpec(typeSpecNode, typeDeclarationNode *sitter.No
 		chunk.Metadata["fields"] = strings.Join(fields, ", ")
 	}
 
+	// Store type parameters of generic types in metadata
+	if typeParams := e.extractTypeParameters(typeSpecNode); typeParams != "" {
+		chunk.Metadata["type_params"] = typeParams
+	}
+
 	return chunk
 }
 
@@ -191,6 +191,34 @@ func sum(numbers ...int) int {
 				},
 			},
 		},
+		{
+			name: "generic function and type",
+			sourceCode: `package main
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	return nil
+}
+
+type Set[K comparable] struct {
+	items map[K]struct{}
+}`,
+			expectedCount: 2,
+			checks: []func(*testing.T, *Chunk){
+				func(t *testing.T, c *Chunk) {
+					if c.Signature != "[T, U any](xs []T, f func(T) U) []U" {
+						t.Errorf("Expected signature with type parameters, got '%s'", c.Signature)
+					}
+					if c.Metadata["type_params"] != "T, U any" {
+						t.Errorf("Expected type_params 'T, U any', got '%s'", c.Metadata["type_params"])
+					}
+				},
+				func(t *testing.T, c *Chunk) {
+					if c.Name != "Set" || c.Metadata["type_params"] != "K comparable" {
+						t.Errorf("Expected Set with type_params 'K comparable', got %s '%s'", c.Name, c.Metadata["type_params"])
+					}
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -50,6 +50,9 @@ func (l Language) String() string {
 	case LanguageScala:
 		return "scala"
 	default:
+		if r, ok := registeredInfo(l); ok {
+			return r.name
+		}
 		return "unknown"
 	}
 }
@@ -58,6 +61,11 @@ func (l Language) String() string {
 func DetectLanguage(filePath string, content []byte) Language {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
+	// Registered languages take precedence over built-in ones
+	if lang := registeredLanguageForExtension(ext); lang != LanguageUnknown {
+		return lang
+	}
+
 	// Unambiguous extensions
 	switch ext {
 	case ".go":
@@ -106,9 +114,70 @@ func DetectLanguage(filePath string, content []byte) Language {
 		return LanguageCPP
 	}
 
+	// Scripts without an extension are detected from their shebang line
+	if ext == "" {
+		firstLine, _, _ := bytes.Cut(content, []byte("\n"))
+		return interpreterLanguages[ShebangInterpreter(string(firstLine))]
+	}
+
 	return LanguageUnknown
 }
 
+// Languages returns every language the parser supports, built-in ones first
+// and then registered ones in the order they were registered
+func Languages() []Language {
+	var languages []Language
+	for l := LanguageGo; l <= LanguageScala; l++ {
+		if l.IsSupported() {
+			languages = append(languages, l)
+		}
+	}
+	for l := LanguageScala + 1; l.IsSupported(); l++ {
+		languages = append(languages, l)
+	}
+	return languages
+}
+
+// interpreterLanguages maps script interpreters to the languages they run
+var interpreterLanguages = map[string]Language{
+	"python": LanguagePython,
+	"node":   LanguageJavaScript,
+	"nodejs": LanguageJavaScript,
+	"ruby":   LanguageRuby,
+	"php":    LanguagePHP,
+}
+
+// ShebangInterpreter returns the interpreter named by a "#!" line, without
+// its path or version ("#!/usr/bin/env python3" gives "python"), or "" if
+// line isn't a shebang. Options to /usr/bin/env are skipped.
+func ShebangInterpreter(line string) string {
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	return strings.TrimRight(interpreter, "0123456789.")
+}
+
+// InterpreterLanguage returns the language run by a script interpreter, as
+// returned by ShebangInterpreter, or LanguageUnknown
+func InterpreterLanguage(interpreter string) Language {
+	return interpreterLanguages[interpreter]
+}
+
 // containsCPlusPlusMarkers checks if content contains C++-specific constructs
 func containsCPlusPlusMarkers(content []byte) bool {
 	cppMarkers := [][]byte{
@@ -189,7 +258,8 @@ func (l Language) IsSupported() bool {
 		LanguageScala:
 		return true // Will be implemented
 	default:
-		return false
+		_, ok := registeredInfo(l)
+		return ok
 	}
 }
 
@@ -219,6 +289,9 @@ func (l Language) FileExtensions() []string {
 	case LanguageScala:
 		return []string{".scala"}
 	default:
+		if r, ok := registeredInfo(l); ok {
+			return r.extensions
+		}
 		return []string{}
 	}
 }
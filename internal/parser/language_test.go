@@ -156,6 +156,24 @@ func TestDetectLanguage(t *testing.T) {
 			content:  "all: build",
 			want:     LanguageUnknown,
 		},
+		{
+			name:     "python shebang",
+			filePath: "bin/deploy",
+			content:  "#!/usr/bin/env python3\nimport sys",
+			want:     LanguagePython,
+		},
+		{
+			name:     "node shebang",
+			filePath: "bin/serve",
+			content:  "#!/usr/bin/node\nconsole.log(1)",
+			want:     LanguageJavaScript,
+		},
+		{
+			name:     "shell shebang",
+			filePath: "bin/setup",
+			content:  "#!/bin/bash\necho hi",
+			want:     LanguageUnknown, // No shell parser
+		},
 	}
 
 	for _, tt := range tests {
@@ -258,3 +276,34 @@ func TestLanguageFileExtensions(t *testing.T) {
 		})
 	}
 }
+
+func TestLanguages(t *testing.T) {
+	languages := Languages()
+	if len(languages) != 11 {
+		t.Fatalf("Expected 11 languages, got %d: %v", len(languages), languages)
+	}
+	for _, l := range languages {
+		if !l.IsSupported() || len(l.FileExtensions()) == 0 {
+			t.Errorf("Language %s is unsupported or has no extensions", l)
+		}
+	}
+}
+
+func TestShebangInterpreter(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"#!/usr/bin/env python3", "python"},
+		{"#!/usr/bin/python3.12 -u", "python"},
+		{"#!/usr/bin/env -S FOO=1 node --flag", "node"},
+		{"#! /bin/bash", "bash"},
+		{"#!", ""},
+		{"// not a shebang", ""},
+	}
+	for _, tt := range tests {
+		if got := ShebangInterpreter(tt.line); got != tt.want {
+			t.Errorf("ShebangInterpreter(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
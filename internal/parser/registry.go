@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// ExtractionRules map Tree-sitter node kinds to the chunk type each becomes,
+// e.g. {"function_declaration": ChunkTypeFunction}. Nodes of other kinds
+// aren't chunked, though their children are still searched.
+type ExtractionRules map[string]ChunkType
+
+// registeredLanguage is a language added with RegisterLanguage
+type registeredLanguage struct {
+	name       string
+	extensions []string
+	grammar    unsafe.Pointer
+}
+
+var (
+	registryMu sync.RWMutex
+	// registered holds the languages added with RegisterLanguage, numbered after the built-in ones
+	registered = make(map[Language]*registeredLanguage)
+	// ruleOverrides replaces the built-in extraction of a language with rules
+	ruleOverrides = make(map[Language]ExtractionRules)
+)
+
+// RegisterLanguage adds a language to the parser, or overrides how a
+// built-in one is chunked, without changing the extractor.
+//
+// For a new language, name is its String() and the language name files are
+// indexed under, extensions are its file extensions (with the dot; they take
+// precedence over built-in ones), grammar is the Language() pointer from its
+// Tree-sitter Go bindings, and rules pick the nodes that become chunks.
+//
+// For a built-in name such as "python", only rules are used: they replace
+// the built-in extraction, and extensions and grammar must be empty.
+func RegisterLanguage(name string, extensions []string, grammar unsafe.Pointer, rules ExtractionRules) (Language, error) {
+	if name == "" {
+		return LanguageUnknown, fmt.Errorf("language name cannot be empty")
+	}
+	if len(rules) == 0 {
+		return LanguageUnknown, fmt.Errorf("language %s has no extraction rules", name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	// Registering a name again replaces its rules, and a registered
+	// language's extensions and grammar if given
+	if lang := languageByNameLocked(name); lang != LanguageUnknown {
+		r, isRegistered := registered[lang]
+		switch {
+		case !isRegistered && (len(extensions) > 0 || grammar != nil):
+			return LanguageUnknown, fmt.Errorf("built-in language %s only accepts extraction rules", name)
+		case isRegistered && len(extensions) > 0:
+			r.extensions = normalizeExtensions(extensions)
+		}
+		if isRegistered && grammar != nil {
+			r.grammar = grammar
+		}
+		ruleOverrides[lang] = rules
+		return lang, nil
+	}
+
+	if len(extensions) == 0 {
+		return LanguageUnknown, fmt.Errorf("language %s has no file extensions", name)
+	}
+	if grammar == nil {
+		return LanguageUnknown, fmt.Errorf("language %s has no grammar", name)
+	}
+	lang := LanguageScala + 1 + Language(len(registered))
+	registered[lang] = &registeredLanguage{
+		name:       name,
+		extensions: normalizeExtensions(extensions),
+		grammar:    grammar,
+	}
+	ruleOverrides[lang] = rules
+	return lang, nil
+}
+
+// LanguageByName returns the built-in or registered language with the given
+// name (see String), or LanguageUnknown
+func LanguageByName(name string) Language {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return languageByNameLocked(name)
+}
+
+func languageByNameLocked(name string) Language {
+	for l := LanguageGo; l <= LanguageScala; l++ {
+		if l.String() == name {
+			return l
+		}
+	}
+	for l, r := range registered {
+		if r.name == name {
+			return l
+		}
+	}
+	return LanguageUnknown
+}
+
+// registeredInfo returns a registered language's details, if l is one
+func registeredInfo(l Language) (*registeredLanguage, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registered[l]
+	return r, ok
+}
+
+// registeredLanguageForExtension returns the registered language claiming ext
+func registeredLanguageForExtension(ext string) Language {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for l, r := range registered {
+		for _, e := range r.extensions {
+			if e == ext {
+				return l
+			}
+		}
+	}
+	return LanguageUnknown
+}
+
+// extractionRules returns the registered rules for l, if any
+func extractionRules(l Language) (ExtractionRules, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rules, ok := ruleOverrides[l]
+	return rules, ok
+}
+
+// normalizeExtensions lowercases extensions and adds a missing leading dot
+func normalizeExtensions(extensions []string) []string {
+	normalized := make([]string, len(extensions))
+	for i, ext := range extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[i] = ext
+	}
+	return normalized
+}
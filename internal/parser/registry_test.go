@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	tree_sitter_python "github.com/tree-sitter/tree-sitter-python/bindings/go"
+)
+
+// resetRegistry removes every registered language and rule override
+func resetRegistry() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registered = make(map[Language]*registeredLanguage)
+	ruleOverrides = make(map[Language]ExtractionRules)
+}
+
+// extractWith parses source as lang and returns its chunks
+func extractWith(t *testing.T, lang Language, source string) []*Chunk {
+	t.Helper()
+	p, err := NewParser(lang)
+	if err != nil {
+		t.Fatalf("NewParser(%s) failed: %v", lang, err)
+	}
+	chunks, err := NewExtractor(p, []byte(source)).ExtractFunctions(context.Background())
+	if err != nil {
+		t.Fatalf("ExtractFunctions failed: %v", err)
+	}
+	return chunks
+}
+
+func TestRegisterLanguage(t *testing.T) {
+	t.Cleanup(resetRegistry)
+
+	// Starlark is close enough to Python to parse with its grammar
+	lang, err := RegisterLanguage("starlark", []string{"star", ".BZL"}, tree_sitter_python.Language(),
+		ExtractionRules{"function_definition": ChunkTypeFunction})
+	if err != nil {
+		t.Fatalf("RegisterLanguage failed: %v", err)
+	}
+
+	if lang.String() != "starlark" || !lang.IsSupported() {
+		t.Errorf("Registered language = %q, supported %v", lang.String(), lang.IsSupported())
+	}
+	if got := LanguageByName("starlark"); got != lang {
+		t.Errorf("LanguageByName(starlark) = %v, want %v", got, lang)
+	}
+	if got := DetectLanguage("defs.bzl", nil); got != lang {
+		t.Errorf("DetectLanguage(defs.bzl) = %v, want %v", got, lang)
+	}
+	if exts := lang.FileExtensions(); len(exts) != 2 || exts[0] != ".star" || exts[1] != ".bzl" {
+		t.Errorf("FileExtensions() = %v, want [.star .bzl]", exts)
+	}
+	if languages := Languages(); languages[len(languages)-1] != lang {
+		t.Errorf("Languages() = %v, want %v last", languages, lang)
+	}
+
+	chunks := extractWith(t, lang, "def build(ctx):\n    pass\n\nclass Rule:\n    pass\n")
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+	if chunks[0].Name != "build" || chunks[0].Type != ChunkTypeFunction {
+		t.Errorf("Chunk = %s %q, want function \"build\"", chunks[0].Type, chunks[0].Name)
+	}
+}
+
+func TestRegisterLanguage_OverridesBuiltin(t *testing.T) {
+	t.Cleanup(resetRegistry)
+
+	lang, err := RegisterLanguage("python", nil, nil, ExtractionRules{"class_definition": ChunkTypeStruct})
+	if err != nil {
+		t.Fatalf("RegisterLanguage failed: %v", err)
+	}
+	if lang != LanguagePython {
+		t.Fatalf("RegisterLanguage(python) = %v, want %v", lang, LanguagePython)
+	}
+
+	chunks := extractWith(t, LanguagePython, "class Point:\n    def norm(self):\n        pass\n")
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+	if chunks[0].Name != "Point" || chunks[0].Type != ChunkTypeStruct {
+		t.Errorf("Chunk = %s %q, want struct \"Point\"", chunks[0].Type, chunks[0].Name)
+	}
+}
+
+func TestRegisterLanguage_Errors(t *testing.T) {
+	t.Cleanup(resetRegistry)
+
+	grammar := tree_sitter_python.Language()
+	rules := ExtractionRules{"function_definition": ChunkTypeFunction}
+	tests := []struct {
+		name       string
+		langName   string
+		extensions []string
+		rules      ExtractionRules
+		grammar    bool
+	}{
+		{"empty name", "", []string{".star"}, rules, true},
+		{"no rules", "starlark", []string{".star"}, nil, true},
+		{"no extensions", "starlark", nil, rules, true},
+		{"no grammar", "starlark", []string{".star"}, rules, false},
+		{"built-in with extensions", "python", []string{".pyw"}, rules, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := grammar
+			if !tt.grammar {
+				g = nil
+			}
+			if _, err := RegisterLanguage(tt.langName, tt.extensions, g, tt.rules); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+	if len(Languages()) != 11 {
+		t.Errorf("Failed registrations added languages: %v", Languages())
+	}
+}
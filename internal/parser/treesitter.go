@@ -53,7 +53,11 @@ func NewParser(lang Language) (*Parser, error) {
 	case LanguageScala:
 		tsLang = sitter.NewLanguage(tree_sitter_scala.Language())
 	default:
-		return nil, fmt.Errorf("unsupported language: %s", lang.String())
+		r, ok := registeredInfo(lang)
+		if !ok {
+			return nil, fmt.Errorf("unsupported language: %s", lang.String())
+		}
+		tsLang = sitter.NewLanguage(r.grammar)
 	}
 
 	if err := parser.SetLanguage(tsLang); err != nil {
@@ -0,0 +1,47 @@
+// Package pathglob matches slash-separated paths against glob patterns that
+// support "**" for any number of directories, as in .gitignore-style configs.
+package pathglob
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether name matches pattern. Patterns use path.Match syntax
+// within each segment, plus "**" as a whole segment to match zero or more
+// directories. Both are slash-separated and relative to the same root.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// Validate returns an error if pattern is malformed
+func Validate(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try consuming zero or more name segments
+			for skip := 0; skip <= len(name); skip++ {
+				if matchSegments(pattern[1:], name[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
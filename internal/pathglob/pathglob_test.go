@@ -0,0 +1,35 @@
+package pathglob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"internal/core/**", "internal/core/engine.go", true},
+		{"internal/core/**", "internal/core/sub/deep.go", true},
+		{"internal/core/**", "internal/corex/engine.go", false},
+		{"**/testdata/**", "testdata/fixture.json", true},
+		{"**/testdata/**", "pkg/parser/testdata/a/b.go", true},
+		{"**/testdata/**", "pkg/testdatax/b.go", false},
+		{"**/*.pb.go", "pkg/api/service.pb.go", true},
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/*/main.go", "cmd/tool/main.go", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.expected {
+			t.Errorf("Match(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("internal/**/*.go"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := Validate("internal/[a-"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
@@ -0,0 +1,138 @@
+// Package queryexpand rewrites search queries into paraphrases, so a search
+// can embed several phrasings and fuse their results to improve recall.
+package queryexpand
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/llm"
+)
+
+// Expander generates alternative phrasings of a query
+type Expander interface {
+	// Expand returns up to n paraphrases of query, excluding query itself
+	Expand(query string, n int) ([]string, error)
+}
+
+// synonymGroups lists interchangeable terms common in code and its documentation
+var synonymGroups = [][]string{
+	{"function", "func", "method"},
+	{"error", "err", "exception"},
+	{"delete", "remove"},
+	{"create", "new", "make"},
+	{"get", "fetch", "retrieve", "load"},
+	{"config", "configuration", "settings"},
+	{"auth", "authentication", "login"},
+	{"db", "database"},
+	{"init", "initialize", "setup"},
+	{"parse", "decode", "unmarshal"},
+	{"serialize", "encode", "marshal"},
+	{"start", "run", "launch"},
+	{"stop", "shutdown", "close"},
+	{"test", "spec"},
+	{"directory", "dir", "folder"},
+	{"args", "arguments", "params", "parameters"},
+	{"check", "validate", "verify"},
+	{"send", "write", "emit"},
+}
+
+// synonyms maps each term to the other members of its group
+var synonyms = buildSynonyms()
+
+func buildSynonyms() map[string][]string {
+	index := make(map[string][]string)
+	for _, group := range synonymGroups {
+		for _, term := range group {
+			for _, other := range group {
+				if other != term {
+					index[term] = append(index[term], other)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// RuleExpander paraphrases queries by swapping code terms for their synonyms
+type RuleExpander struct{}
+
+// Expand replaces one known term at a time with each of its synonyms, cycling
+// through the query's terms so the paraphrases cover as many of them as possible
+func (RuleExpander) Expand(query string, n int) ([]string, error) {
+	words := strings.Fields(query)
+
+	// Candidate substitutions for each replaceable word, in query order
+	type slot struct {
+		index        int
+		alternatives []string
+	}
+	var slots []slot
+	for i, word := range words {
+		if alts, ok := synonyms[strings.ToLower(word)]; ok {
+			slots = append(slots, slot{index: i, alternatives: alts})
+		}
+	}
+
+	seen := map[string]bool{strings.ToLower(query): true}
+	var variants []string
+	for round := 0; len(variants) < n; round++ {
+		progressed := false
+		for _, s := range slots {
+			if round >= len(s.alternatives) {
+				continue
+			}
+			progressed = true
+			rewritten := append([]string(nil), words...)
+			rewritten[s.index] = s.alternatives[round]
+			variant := strings.Join(rewritten, " ")
+			if !seen[strings.ToLower(variant)] {
+				seen[strings.ToLower(variant)] = true
+				variants = append(variants, variant)
+				if len(variants) == n {
+					break
+				}
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return variants, nil
+}
+
+// listMarker matches bullets and numbering that models put in front of list items
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// LLMExpander asks a chat model for paraphrases
+type LLMExpander struct {
+	Client llm.Client
+}
+
+// Expand prompts the model for n rewrites of query, one per line
+func (e LLMExpander) Expand(query string, n int) ([]string, error) {
+	reply, err := e.Client.CompleteContext(context.Background(), []llm.Message{
+		{Role: "system", Content: "You rewrite code search queries. Reply with only the rewritten queries, one per line, with no numbering or commentary."},
+		{Role: "user", Content: fmt.Sprintf("Write %d alternative phrasings of this code search query, using different terms a programmer might use for the same thing:\n\n%s", n, query)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand query: %w", err)
+	}
+
+	seen := map[string]bool{strings.ToLower(query): true}
+	var variants []string
+	for _, line := range strings.Split(reply, "\n") {
+		variant := strings.Trim(strings.TrimSpace(listMarker.ReplaceAllString(line, "")), `"`)
+		if variant == "" || seen[strings.ToLower(variant)] {
+			continue
+		}
+		seen[strings.ToLower(variant)] = true
+		variants = append(variants, variant)
+		if len(variants) == n {
+			break
+		}
+	}
+	return variants, nil
+}
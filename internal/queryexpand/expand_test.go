@@ -0,0 +1,48 @@
+package queryexpand
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/llm"
+)
+
+func TestRuleExpander(t *testing.T) {
+	variants, err := RuleExpander{}.Expand("delete user config", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"remove user config", "delete user configuration", "delete user settings"}
+	if !reflect.DeepEqual(variants, expected) {
+		t.Errorf("expected %v, got %v", expected, variants)
+	}
+}
+
+func TestRuleExpander_NoKnownTerms(t *testing.T) {
+	variants, err := RuleExpander{}.Expand("quaternion slerp", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 0 {
+		t.Errorf("expected no variants, got %v", variants)
+	}
+}
+
+type fakeClient struct{ reply string }
+
+func (f fakeClient) CompleteContext(context.Context, []llm.Message) (string, error) {
+	return f.reply, nil
+}
+
+func TestLLMExpander(t *testing.T) {
+	expander := LLMExpander{Client: fakeClient{reply: "1. remove a user\n- Delete User\n\n* \"drop user account\"\n2) purge user records"}}
+	variants, err := expander.Expand("delete user", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"remove a user", "drop user account"}
+	if !reflect.DeepEqual(variants, expected) {
+		t.Errorf("expected %v, got %v", expected, variants)
+	}
+}
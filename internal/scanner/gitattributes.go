@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/pathglob"
+)
+
+// linguistAttributes are the .gitattributes attributes GitHub's linguist uses
+// to leave files out of language statistics and diffs; the scanner skips them
+var linguistAttributes = []string{"linguist-generated", "linguist-vendored"}
+
+// attributeRule is one .gitattributes line setting or unsetting linguist attributes
+type attributeRule struct {
+	base    string          // Directory of the .gitattributes file, relative to the root ("" for the root)
+	pattern string          // Pattern relative to base, as a pathglob glob
+	attrs   map[string]bool // Attribute to whether it is set (true) or unset (false)
+}
+
+// loadGitattributes adds the linguist rules of the .gitattributes file in
+// dir, at rel relative to the root, if there is one
+func (s *Scanner) loadGitattributes(dir, rel string) {
+	file, err := os.Open(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	base := rel
+	if base == "." {
+		base = ""
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseAttributeLine(scanner.Text()); ok {
+			rule.base = base
+			s.attributeRules = append(s.attributeRules, rule)
+		}
+	}
+}
+
+// parseAttributeLine parses a .gitattributes line, returning false if it is
+// blank, a comment, a macro definition, or doesn't mention a linguist attribute
+func parseAttributeLine(line string) (attributeRule, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+		return attributeRule{}, false
+	}
+
+	rule := attributeRule{attrs: make(map[string]bool)}
+	for _, field := range fields[1:] {
+		name, value, hasValue := strings.Cut(field, "=")
+		set := true
+		switch {
+		case strings.HasPrefix(name, "-"), strings.HasPrefix(name, "!"):
+			name, set = name[1:], false
+		case hasValue:
+			set = value != "false"
+		}
+		for _, attr := range linguistAttributes {
+			if name == attr {
+				rule.attrs[attr] = set
+			}
+		}
+	}
+	if len(rule.attrs) == 0 {
+		return attributeRule{}, false
+	}
+
+	// A pattern without a slash matches a file name at any depth, as in .gitignore
+	rule.pattern = strings.TrimPrefix(fields[0], "/")
+	if !strings.Contains(fields[0], "/") {
+		rule.pattern = "**/" + rule.pattern
+	}
+	return rule, true
+}
+
+// linguistAttribute returns the linguist attribute set on a file, at rel
+// relative to the root, or "" if none is. Later rules, and rules from deeper
+// .gitattributes files, override earlier ones.
+func (s *Scanner) linguistAttribute(rel string) string {
+	attrs := make(map[string]bool)
+	for _, rule := range s.attributeRules {
+		name := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			name = rel[len(rule.base)+1:]
+		}
+		if pathglob.Match(rule.pattern, name) {
+			for attr, set := range rule.attrs {
+				attrs[attr] = set
+			}
+		}
+	}
+	for _, attr := range linguistAttributes {
+		if attrs[attr] {
+			return attr
+		}
+	}
+	return ""
+}
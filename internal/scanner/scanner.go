@@ -1,10 +1,15 @@
 package scanner
 
 import (
+	"bufio"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/jlanders/code-scout/internal/parser"
 )
 
 // FileInfo represents a discovered file
@@ -17,6 +22,13 @@ type FileInfo struct {
 // Scanner scans directories for code files
 type Scanner struct {
 	rootDir string
+	rules   SkipRules
+	skipped map[string]int // Skipped directories and files by rule, from the last scan
+
+	attributeRules []attributeRule // linguist rules from the .gitattributes files scanned so far
+
+	extensions map[string]string // Language by file extension, from languageExtensions
+	ambiguous  map[string]bool   // Extensions claimed by more than one language
 }
 
 // New creates a new Scanner
@@ -24,20 +36,54 @@ func New(rootDir string) *Scanner {
 	return &Scanner{rootDir: rootDir}
 }
 
-// languageExtensions maps file extensions to language names
-var languageExtensions = map[string]string{
-	// Code files
-	".py": "python",
-	".go": "go",
-	// Documentation files
+// docExtensions maps documentation file extensions to language names
+var docExtensions = map[string]string{
 	".md":  "markdown",
 	".txt": "text",
 	".rst": "rst",
 }
 
-// ScanCodeFiles recursively scans for code and documentation files
+// languageExtensions maps file extensions to language names: documentation,
+// plus every language the parser supports, including registered ones. The
+// ambiguous extensions are claimed by more than one language (".h"); their
+// files are told apart by content.
+func languageExtensions() (map[string]string, map[string]bool) {
+	extensions := maps.Clone(docExtensions)
+	ambiguous := make(map[string]bool)
+	for _, lang := range parser.Languages() {
+		for _, ext := range lang.FileExtensions() {
+			if other, ok := extensions[ext]; ok && other != lang.String() {
+				ambiguous[ext] = true
+			}
+			extensions[ext] = lang.String()
+		}
+	}
+	return extensions, ambiguous
+}
+
+// fileLanguage returns the language of a file with extension ext, or "" if
+// it isn't supported
+func (s *Scanner) fileLanguage(path, ext string) string {
+	if !s.ambiguous[ext] {
+		return s.extensions[ext]
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if lang := parser.DetectLanguage(path, content); lang != parser.LanguageUnknown {
+		return lang.String()
+	}
+	return ""
+}
+
+// ScanCodeFiles recursively scans for code and documentation files, leaving
+// out vendored, generated and minified code (see SkipRules)
 func (s *Scanner) ScanCodeFiles() ([]FileInfo, error) {
 	var files []FileInfo
+	s.skipped = make(map[string]int)
+	s.attributeRules = nil
+	s.extensions, s.ambiguous = languageExtensions()
 
 	err := filepath.Walk(s.rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -54,21 +100,48 @@ func (s *Scanner) ScanCodeFiles() ([]FileInfo, error) {
 			return filepath.SkipDir
 		}
 
+		rel, err := filepath.Rel(s.rootDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		// Skip vendored and build output directories
+		if info.IsDir() && rel != "." {
+			if rule := s.skipDir(rel, info.Name()); rule != "" {
+				s.skipped[rule]++
+				return filepath.SkipDir
+			}
+		}
+		if info.IsDir() {
+			s.loadGitattributes(path, rel)
+		}
+
 		// Skip hidden files
 		if !info.IsDir() && strings.HasPrefix(info.Name(), ".") {
 			return nil
 		}
 
-		// Check for supported code and documentation files
+		// Check for supported code and documentation files, and scripts
+		// without an extension whose shebang names a supported interpreter
 		if !info.IsDir() {
 			ext := filepath.Ext(info.Name())
-			if lang, ok := languageExtensions[ext]; ok {
-				files = append(files, FileInfo{
-					Path:     path,
-					Language: lang,
-					ModTime:  info.ModTime(),
-				})
+			lang := s.fileLanguage(path, ext)
+			if ext == "" && info.Mode().IsRegular() {
+				lang = shebangLanguage(path)
+			}
+			if lang == "" {
+				return nil
 			}
+			if rule := s.skipFile(path, rel, lang); rule != "" {
+				s.skipped[rule]++
+				return nil
+			}
+			files = append(files, FileInfo{
+				Path:     path,
+				Language: lang,
+				ModTime:  info.ModTime(),
+			})
 		}
 
 		return nil
@@ -81,6 +154,40 @@ func (s *Scanner) ScanCodeFiles() ([]FileInfo, error) {
 	return files, nil
 }
 
+// shellInterpreters are the shells whose scripts are indexed as "shell"
+var shellInterpreters = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// shebangLanguage returns the language of a script from its "#!" line, e.g.
+// "#!/usr/bin/env python3" or "#!/bin/bash -e", or "" if the file has no
+// shebang or its interpreter isn't supported
+func shebangLanguage(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(io.LimitReader(file, 256)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	return ShebangLanguage(line)
+}
+
+// ShebangLanguage returns the language named by a "#!" interpreter line:
+// a parser language, or "shell" for shell scripts. It returns "" if line
+// isn't a shebang or its interpreter isn't supported.
+func ShebangLanguage(line string) string {
+	interpreter := parser.ShebangInterpreter(line)
+	if lang := parser.InterpreterLanguage(interpreter); lang != parser.LanguageUnknown {
+		return lang.String()
+	}
+	if shellInterpreters[interpreter] {
+		return "shell"
+	}
+	return ""
+}
+
 // ScanPythonFiles recursively scans for Python files (deprecated: use ScanCodeFiles)
 func (s *Scanner) ScanPythonFiles() ([]FileInfo, error) {
 	return s.ScanCodeFiles()
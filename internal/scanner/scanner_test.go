@@ -18,7 +18,8 @@ func TestScanCodeFiles_DetectsCodeFiles(t *testing.T) {
 		"docs.txt":      "Documentation",
 		"guide.rst":     "Guide",
 		".hidden.go":    "should be skipped",
-		"ignored.java":  "should be ignored (not supported)",
+		"Main.java":     "class Main {}",
+		"ignored.xyz":   "should be ignored (not supported)",
 	}
 
 	for name, content := range files {
@@ -51,6 +52,7 @@ func TestScanCodeFiles_DetectsCodeFiles(t *testing.T) {
 		"README.md": "markdown",
 		"docs.txt":  "text",
 		"guide.rst": "rst",
+		"Main.java": "java",
 	}
 
 	if len(results) != len(expected) {
@@ -128,13 +130,16 @@ func TestLanguageExtensions(t *testing.T) {
 		{".md", "markdown", true},
 		{".txt", "text", true},
 		{".rst", "rst", true},
-		{".java", "", false},
-		{".rs", "", false},
-		{".js", "", false},
+		{".java", "java", true},
+		{".rs", "rust", true},
+		{".js", "javascript", true},
+		{".tsx", "typescript", true},
+		{".xyz", "", false},
 	}
 
+	extensions, _ := languageExtensions()
 	for _, tt := range tests {
-		lang, ok := languageExtensions[tt.ext]
+		lang, ok := extensions[tt.ext]
 		if ok != tt.exists {
 			t.Errorf("Extension %s: expected exists=%v, got %v", tt.ext, tt.exists, ok)
 		}
@@ -143,3 +148,85 @@ func TestLanguageExtensions(t *testing.T) {
 		}
 	}
 }
+
+func TestScanCodeFiles_DetectsShebangScripts(t *testing.T) {
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	if err := os.Mkdir(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"deploy":   "#!/usr/bin/env python3\nimport sys\n",
+		"serve":    "#!/usr/bin/env -S node --no-warnings\nconsole.log(1)\n",
+		"setup":    "#!/bin/bash -e\necho setup\n",
+		"Makefile": "all:\n\tgo build\n",
+		"perlish":  "#!/usr/bin/perl\nprint 1;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := New(tmpDir).ScanCodeFiles()
+	if err != nil {
+		t.Fatalf("ScanCodeFiles failed: %v", err)
+	}
+
+	found := make(map[string]string)
+	for _, result := range results {
+		found[filepath.Base(result.Path)] = result.Language
+	}
+	expected := map[string]string{"deploy": "python", "serve": "javascript", "setup": "shell"}
+	if len(found) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+	for name, lang := range expected {
+		if found[name] != lang {
+			t.Errorf("File %s: expected language %s, got %q", name, lang, found[name])
+		}
+	}
+}
+
+func TestShebangLanguage(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"#!/usr/bin/env python3\n", "python"},
+		{"#!/usr/bin/python3.12", "python"},
+		{"#! /bin/sh", "shell"},
+		{"#!/usr/bin/env bash", "shell"},
+		{"#!/usr/bin/env -S FOO=1 node --flag", "javascript"},
+		{"#!/usr/bin/env ruby", "ruby"},
+		{"#!/usr/bin/perl", ""},
+		{"# not a shebang", ""},
+		{"#!", ""},
+	}
+	for _, tt := range tests {
+		if got := ShebangLanguage(tt.line); got != tt.want {
+			t.Errorf("ShebangLanguage(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestScanCodeFiles_AmbiguousHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"c.h":   "typedef struct point { int x; } point;",
+		"cpp.h": "namespace geo { class Point {}; }",
+	})
+
+	files, err := New(tmpDir).ScanCodeFiles()
+	if err != nil {
+		t.Fatalf("ScanCodeFiles failed: %v", err)
+	}
+	found := make(map[string]string)
+	for _, f := range files {
+		found[filepath.Base(f.Path)] = f.Language
+	}
+	if found["c.h"] != "c" || found["cpp.h"] != "cpp" {
+		t.Errorf("Expected c.h as c and cpp.h as cpp, got %v", found)
+	}
+}
@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/pathglob"
+)
+
+// SkipRules adds to and overrides the built-in rules that leave vendored,
+// generated and minified code out of a scan. Patterns are pathglob globs
+// relative to the scan root, e.g. "third_party/**".
+type SkipRules struct {
+	Skip    []string // Files to skip in addition to the built-in rules
+	Include []string // Files to scan even if a skip rule matches them
+	// Languages enables (true) or disables (false) languages by name, e.g.
+	// {"php": false}. Unlisted languages are scanned. Include doesn't
+	// override a disabled language.
+	Languages map[string]bool
+}
+
+// skippedDirs are dependency and build output directories, skipped at any depth
+var skippedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"dist":         true,
+	"target":       true,
+}
+
+// skippedSuffixes are minified and generated file names
+var skippedSuffixes = []string{".min.js", ".pb.go"}
+
+// generatedHeader matches the conventional marker of generated files: Go's
+// "// Code generated ... DO NOT EDIT." and the "@generated" tag
+var generatedHeader = regexp.MustCompile(`(?m)^(//|#) Code generated .* DO NOT EDIT\.?\s*$|@generated\b`)
+
+// docLanguages are documentation languages, which are never generated code
+var docLanguages = map[string]bool{"markdown": true, "text": true, "rst": true}
+
+// generatedHeaderBytes is how much of a file is checked for a generated header
+const generatedHeaderBytes = 1024
+
+// SetSkipRules sets the configured skip rules
+func (s *Scanner) SetSkipRules(rules SkipRules) {
+	s.rules = rules
+}
+
+// Skipped returns how many directories and files the last scan skipped, by
+// the rule that skipped them: a directory ("vendor/"), a file name pattern
+// ("*.pb.go"), "generated" for a generated code header, a .gitattributes
+// linguist attribute ("linguist-generated"), a skip pattern, or a disabled
+// language ("php (disabled)")
+func (s *Scanner) Skipped() map[string]int {
+	return s.skipped
+}
+
+// skipDir returns the rule that skips a directory, at rel relative to the
+// root, or "" if it isn't skipped. With include patterns, no directory is
+// skipped outright, since one may match a file inside it; its files are
+// checked by skipFile.
+func (s *Scanner) skipDir(rel, name string) string {
+	if len(s.rules.Include) > 0 {
+		return ""
+	}
+	if skippedDirs[name] {
+		return name + "/"
+	}
+	for _, pattern := range s.rules.Skip {
+		if pathglob.Match(pattern, rel) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// skipFile returns the rule that skips a file of language lang, at rel
+// relative to the root, or "" if it isn't skipped: its language is disabled,
+// or it is vendored, generated, minified, marked linguist-generated or
+// linguist-vendored in .gitattributes, or matches a skip pattern, and isn't
+// included. Only code files are checked for a generated header.
+func (s *Scanner) skipFile(path, rel, lang string) string {
+	if enabled, ok := s.rules.Languages[lang]; ok && !enabled {
+		return lang + " (disabled)"
+	}
+
+	for _, pattern := range s.rules.Include {
+		if pathglob.Match(pattern, rel) {
+			return ""
+		}
+	}
+
+	if attr := s.linguistAttribute(rel); attr != "" {
+		return attr
+	}
+
+	dirs := strings.Split(rel, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if skippedDirs[dir] {
+			return dir + "/"
+		}
+	}
+	for _, suffix := range skippedSuffixes {
+		if strings.HasSuffix(rel, suffix) {
+			return "*" + suffix
+		}
+	}
+	for _, pattern := range s.rules.Skip {
+		if pathglob.Match(pattern, rel) || pathglob.Match(pattern+"/**", rel) {
+			return pattern
+		}
+	}
+	if !docLanguages[lang] && isGenerated(path) {
+		return "generated"
+	}
+	return ""
+}
+
+// isGenerated reports whether a file starts with a generated code header
+func isGenerated(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, generatedHeaderBytes)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	head = head[:n]
+	if i := bytes.LastIndexByte(head, '\n'); i >= 0 && n == generatedHeaderBytes {
+		head = head[:i] // Drop a partial last line
+	}
+	return generatedHeader.Match(head)
+}
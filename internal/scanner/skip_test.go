@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files (slash-separated paths relative to root) with the given contents
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// scanRel scans root and returns the sorted slash-separated relative paths found
+func scanRel(t *testing.T, s *Scanner, root string) []string {
+	t.Helper()
+	files, err := s.ScanCodeFiles()
+	if err != nil {
+		t.Fatalf("ScanCodeFiles failed: %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestScanCodeFiles_SkipsVendoredAndGenerated(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"main.go":                       "package main",
+		"api/api.pb.go":                 "package api",
+		"api/types_gen.go":              "// Code generated by stringer; DO NOT EDIT.\n\npackage api",
+		"api/hand.go":                   "// Code generated is mentioned here, but by hand.\npackage api",
+		"vendor/github.com/x/y/y.go":    "package y",
+		"web/node_modules/lib/index.py": "x = 1",
+		"web/dist/app.min.js":           "x",
+		"rust/target/debug/build.py":    "x = 1",
+		"tools/schema_pb2.py":           "# @generated by protoc\nx = 1",
+		"docs/generated.md":             "# Docs\n@generated is just a word here",
+		"nested/dist-tools/ok.py":       "x = 1",
+	})
+
+	s := New(tmpDir)
+	got := scanRel(t, s, tmpDir)
+	want := []string{"api/hand.go", "docs/generated.md", "main.go", "nested/dist-tools/ok.py"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	wantSkipped := map[string]int{
+		"vendor/": 1, "node_modules/": 1, "dist/": 1, "target/": 1,
+		"*.pb.go": 1, "generated": 2,
+	}
+	if !maps.Equal(s.Skipped(), wantSkipped) {
+		t.Errorf("Expected skipped %v, got %v", wantSkipped, s.Skipped())
+	}
+}
+
+func TestScanCodeFiles_SkipRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"main.go":                        "package main",
+		"third_party/lib.go":             "package lib",
+		"scripts/gen.py":                 "x = 1",
+		"vendor/github.com/acme/a/a.go":  "package a",
+		"vendor/github.com/other/b/b.go": "package b",
+	})
+
+	s := New(tmpDir)
+	s.SetSkipRules(SkipRules{
+		Skip:    []string{"third_party", "**/gen.py"},
+		Include: []string{"vendor/github.com/acme/**"},
+	})
+	got := scanRel(t, s, tmpDir)
+	want := []string{"main.go", "vendor/github.com/acme/a/a.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestScanCodeFiles_DisabledLanguages(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"main.go":       "package main",
+		"web/index.php": "<?php echo 1;",
+		"web/admin.php": "<?php echo 2;",
+		"README.md":     "# Readme",
+	})
+
+	s := New(tmpDir)
+	s.SetSkipRules(SkipRules{
+		Include:   []string{"web/**"},
+		Languages: map[string]bool{"php": false, "go": true},
+	})
+	got := scanRel(t, s, tmpDir)
+	want := []string{"README.md", "main.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if n := s.Skipped()["php (disabled)"]; n != 2 {
+		t.Errorf("Expected 2 disabled php files, got %d (%v)", n, s.Skipped())
+	}
+}
+
+func TestScanCodeFiles_Gitattributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		".gitattributes": "# Generated and vendored code\n" +
+			"*.gen.go linguist-generated=true\n" +
+			"/assets/** linguist-vendored\n" +
+			"assets/keep.py -linguist-vendored\n" +
+			"*.md text eol=lf\n",
+		"main.go":              "package main",
+		"api/types.gen.go":     "package api",
+		"assets/lib/jquery.py": "x = 1",
+		"assets/keep.py":       "x = 1",
+		"docs/README.md":       "# Docs",
+		"sub/.gitattributes":   "local.py linguist-generated\n",
+		"sub/local.py":         "x = 1",
+		"other/local.py":       "x = 1",
+	})
+
+	s := New(tmpDir)
+	got := scanRel(t, s, tmpDir)
+	want := []string{"assets/keep.py", "docs/README.md", "main.go", "other/local.py"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	wantSkipped := map[string]int{"linguist-generated": 2, "linguist-vendored": 1}
+	if !maps.Equal(s.Skipped(), wantSkipped) {
+		t.Errorf("Expected skipped %v, got %v", wantSkipped, s.Skipped())
+	}
+}
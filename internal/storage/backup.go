@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// backupManifestName is the archive entry listing every file and its checksum
+	backupManifestName = "backup-manifest.json"
+	// BackupFormatVersion is the archive layout version written by this build
+	BackupFormatVersion = 1
+	// restoreStagingSuffix names the directory a backup is extracted into before it replaces the index
+	restoreStagingSuffix = ".restore"
+	// restoreOldSuffix names the directory holding the previous index while a restore swaps it out
+	restoreOldSuffix = ".old"
+	// IndexLockFile is the lock file in the index directory held by commands
+	// that write the index; it belongs to the running process, so backups skip it
+	IndexLockFile = "lock"
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	FormatVersion int               `json:"format_version"`
+	CreatedAt     time.Time         `json:"created_at"`
+	SchemaVersion int               `json:"schema_version"`
+	Files         map[string]string `json:"files"` // Slash-separated path within the index -> SHA-256
+	TotalBytes    int64             `json:"total_bytes"`
+}
+
+// CreateBackup writes the index in dbDir (LanceDB tables and metadata.json) to a
+// gzipped tar archive at archivePath, with a manifest of SHA-256 checksums. The
+// archive is written to a temporary file and renamed into place, so a failed
+// backup never leaves a truncated archive behind.
+func CreateBackup(dbDir, archivePath string) (*BackupManifest, error) {
+	metadata, err := loadMetadata(dbDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = filepath.WalkDir(dbDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !isIndexLockFile(dbDir, path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index files: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no index found in %s", dbDir)
+	}
+	sort.Strings(paths)
+
+	tmpPath := archivePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmpPath)
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	manifest := &BackupManifest{
+		FormatVersion: BackupFormatVersion,
+		CreatedAt:     time.Now(),
+		SchemaVersion: metadata.SchemaVersion,
+		Files:         make(map[string]string),
+	}
+	for _, path := range paths {
+		rel, err := filepath.Rel(dbDir, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		name := filepath.ToSlash(rel)
+
+		checksum, size, err := addBackupFile(tw, path, name)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files[name] = checksum
+		manifest.TotalBytes += size
+	}
+
+	// The manifest goes last so checksums can be computed while streaming files
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	header := &tar.Header{Name: backupManifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+	if err := tw.WriteHeader(header); err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish backup archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish backup archive: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to flush backup file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close backup file: %w", err)
+	}
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		return nil, fmt.Errorf("failed to move backup into place: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// isIndexLockFile reports whether path is the lock file, or one of its
+// temporary files, at the top of dbDir
+func isIndexLockFile(dbDir, path string) bool {
+	if filepath.Dir(path) != filepath.Clean(dbDir) {
+		return false
+	}
+	name := filepath.Base(path)
+	return name == IndexLockFile || strings.HasPrefix(name, IndexLockFile+".")
+}
+
+// addBackupFile writes one index file to the archive and returns its checksum and size
+func addBackupFile(tw *tar.Writer, path, name string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to build archive header for %s: %w", path, err)
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return "", 0, fmt.Errorf("failed to write %s to backup: %w", name, err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tw, hash), file)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write %s to backup: %w", name, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// RestoreBackup replaces the index in dbDir with the contents of a backup
+// archive. The archive is extracted into a staging directory and every file is
+// checked against the manifest before the existing index is swapped out, so a
+// corrupt or truncated archive leaves the current index untouched.
+func RestoreBackup(archivePath, dbDir string) (*BackupManifest, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	staging := dbDir + restoreStagingSuffix
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, fmt.Errorf("failed to clear staging directory: %w", err)
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	restored := false
+	defer func() {
+		if !restored {
+			os.RemoveAll(staging)
+		}
+	}()
+
+	var manifest *BackupManifest
+	checksums := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if header.Name == backupManifestName {
+			manifest = &BackupManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+			}
+			continue
+		}
+
+		checksum, err := extractBackupFile(tr, staging, header.Name)
+		if err != nil {
+			return nil, err
+		}
+		checksums[header.Name] = checksum
+	}
+
+	if err := verifyBackup(manifest, checksums); err != nil {
+		return nil, err
+	}
+
+	// The restoring process's lock moves with the index, so it stays held
+	// until released; a lock file from an older backup is dropped
+	stagedLock := filepath.Join(staging, IndexLockFile)
+	if err := os.Remove(stagedLock); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove backed-up lock file: %w", err)
+	}
+	os.Link(filepath.Join(dbDir, IndexLockFile), stagedLock)
+
+	// Swap the verified copy into place, keeping the old index until the swap succeeds
+	old := dbDir + restoreOldSuffix
+	if err := os.RemoveAll(old); err != nil {
+		return nil, fmt.Errorf("failed to clear previous index backup: %w", err)
+	}
+	hadIndex := false
+	if _, err := os.Stat(dbDir); err == nil {
+		if err := os.Rename(dbDir, old); err != nil {
+			return nil, fmt.Errorf("failed to move existing index aside: %w", err)
+		}
+		hadIndex = true
+	}
+	if err := os.Rename(staging, dbDir); err != nil {
+		if hadIndex {
+			os.Rename(old, dbDir)
+		}
+		return nil, fmt.Errorf("failed to move restored index into place: %w", err)
+	}
+	restored = true
+	if hadIndex {
+		if err := os.RemoveAll(old); err != nil {
+			return nil, fmt.Errorf("failed to remove previous index (left in %s): %w", old, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+// extractBackupFile writes one archive entry under dir and returns its checksum
+func extractBackupFile(r io.Reader, dir, name string) (string, error) {
+	// Reject entries that would escape the staging directory
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("backup contains invalid path %q", name)
+	}
+	path := filepath.Join(dir, clean)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), r); err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyBackup checks the extracted files against the manifest
+func verifyBackup(manifest *BackupManifest, checksums map[string]string) error {
+	if manifest == nil {
+		return fmt.Errorf("backup has no manifest (truncated or not a code-scout backup)")
+	}
+	if manifest.FormatVersion > BackupFormatVersion {
+		return fmt.Errorf("backup format version %d is newer than supported version %d; upgrade code-scout", manifest.FormatVersion, BackupFormatVersion)
+	}
+
+	for name, expected := range manifest.Files {
+		actual, ok := checksums[name]
+		if !ok {
+			return fmt.Errorf("backup is missing %s", name)
+		}
+		if actual != expected {
+			return fmt.Errorf("checksum mismatch for %s: backup is corrupt", name)
+		}
+	}
+	for name := range checksums {
+		if _, ok := manifest.Files[name]; !ok {
+			return fmt.Errorf("backup contains %s, which is not in its manifest", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestIndex creates a fake index directory with a table file and metadata
+func writeTestIndex(t *testing.T, dbDir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dbDir, "code_chunks.lance", "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dbDir, "code_chunks.lance", "data", "0.lance"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveMetadata(dbDir, &IndexMetadata{SchemaVersion: CurrentSchemaVersion}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source", DefaultDBDir)
+	writeTestIndex(t, source, "original vectors")
+
+	archive := filepath.Join(root, "index.tar.gz")
+	manifest, err := CreateBackup(source, archive)
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	if len(manifest.Files) != 2 || manifest.SchemaVersion != CurrentSchemaVersion {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if _, err := os.Stat(archive + ".tmp"); !os.IsNotExist(err) {
+		t.Error("expected temporary archive to be removed")
+	}
+
+	target := filepath.Join(root, "target", DefaultDBDir)
+	writeTestIndex(t, target, "stale vectors")
+	if err := os.WriteFile(filepath.Join(target, "extra"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestoreBackup(archive, target); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(target, "code_chunks.lance", "data", "0.lance"))
+	if err != nil || string(data) != "original vectors" {
+		t.Errorf("expected restored table data, got %q (err %v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "extra")); !os.IsNotExist(err) {
+		t.Error("expected files from the previous index to be gone")
+	}
+	for _, suffix := range []string{restoreStagingSuffix, restoreOldSuffix} {
+		if _, err := os.Stat(target + suffix); !os.IsNotExist(err) {
+			t.Errorf("expected %s directory to be cleaned up", suffix)
+		}
+	}
+}
+
+func TestRestoreBackup_RejectsCorruptArchive(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source", DefaultDBDir)
+	writeTestIndex(t, source, "original vectors")
+
+	archive := filepath.Join(root, "index.tar.gz")
+	if _, err := CreateBackup(source, archive); err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+
+	// Rewrite the archive with one file's contents altered but the manifest unchanged
+	corrupt := filepath.Join(root, "corrupt.tar.gz")
+	rewriteArchive(t, archive, corrupt, func(name string, data []byte) []byte {
+		if strings.HasSuffix(name, "0.lance") {
+			return []byte("tampered vectors")
+		}
+		return data
+	})
+
+	target := filepath.Join(root, "target", DefaultDBDir)
+	writeTestIndex(t, target, "current vectors")
+
+	_, err := RestoreBackup(corrupt, target)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(target, "code_chunks.lance", "data", "0.lance"))
+	if string(data) != "current vectors" {
+		t.Errorf("expected existing index to be untouched, got %q", data)
+	}
+	if _, err := os.Stat(target + restoreStagingSuffix); !os.IsNotExist(err) {
+		t.Error("expected staging directory to be removed after a failed restore")
+	}
+}
+
+// rewriteArchive copies a backup archive, passing each entry's contents through edit
+func rewriteArchive(t *testing.T, src, dst string, edit func(name string, data []byte) []byte) {
+	t.Helper()
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	gzIn, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gzIn)
+
+	out, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	gzOut := gzip.NewWriter(out)
+	tw := tar.NewWriter(gzOut)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = edit(header.Name, data)
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzOut.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupRestore_LockFileStaysWithRunningProcess(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source", DefaultDBDir)
+	writeTestIndex(t, source, "original vectors")
+	if err := os.WriteFile(filepath.Join(source, IndexLockFile), []byte(`{"pid":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(root, "index.tar.gz")
+	manifest, err := CreateBackup(source, archive)
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	if _, ok := manifest.Files[IndexLockFile]; ok {
+		t.Error("expected the lock file to be left out of the backup")
+	}
+
+	target := filepath.Join(root, "target", DefaultDBDir)
+	writeTestIndex(t, target, "stale vectors")
+	if err := os.WriteFile(filepath.Join(target, IndexLockFile), []byte(`{"pid":2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreBackup(archive, target); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(target, IndexLockFile))
+	if err != nil || string(data) != `{"pid":2}` {
+		t.Errorf("expected the restoring process's lock to stay held, got %q (err %v)", data, err)
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/lancedb/lancedb-go/pkg/contracts"
+)
+
+// textIndexColumns are the columns covered by the full-text index
+var textIndexColumns = []string{"code", "name"}
+
+// CreateTextIndex (re)builds the full-text indexes over chunk code and symbol names.
+// LanceDB only searches rows covered by the index, so call this after adding rows.
+func (s *LanceDBStore) CreateTextIndex() error {
+	if len(s.tables) == 0 {
+		return fmt.Errorf("table not initialized; call StoreChunks or OpenTable first")
+	}
+
+	ctx := context.Background()
+	for embeddingType, table := range s.tables {
+		count, err := table.Count(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to count %s rows: %w", embeddingType, err)
+		}
+		if count == 0 {
+			// Nothing to index yet
+			continue
+		}
+
+		for _, column := range textIndexColumns {
+			if err := table.CreateIndexWithName(ctx, []string{column}, contracts.IndexTypeFts, column+"_fts"); err != nil {
+				return fmt.Errorf("failed to build full-text index on %s %s: %w", embeddingType, column, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// FullTextSearch performs a BM25 keyword search over chunk code and symbol names in
+// the table for embeddingType, or every table if it is empty. Results carry a
+// "_score" field (higher is better) and are ordered by it.
+func (s *LanceDBStore) FullTextSearch(query string, limit int, embeddingType string, filter SearchFilter) ([]map[string]interface{}, error) {
+	if len(s.tables) == 0 {
+		return nil, fmt.Errorf("table not initialized; call OpenTable first")
+	}
+
+	ctx := context.Background()
+	where := filter.sqlWhere()
+	best := make(map[string]map[string]interface{})
+	for tableType, table := range s.tables {
+		if embeddingType != "" && tableType != embeddingType {
+			continue
+		}
+		for _, column := range textIndexColumns {
+			rows, err := table.Select(ctx, contracts.QueryConfig{
+				FTSSearch: &contracts.FTSSearch{Column: column, Query: query},
+				Where:     where,
+				Limit:     &limit,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to search %s (is the full-text index built?): %w", column, err)
+			}
+
+			// A chunk can match on both its name and its code; keep the stronger match
+			for _, row := range rows {
+				id := rowString(row, "chunk_id")
+				if existing, ok := best[id]; ok && rowFloat(existing, "_score") >= rowFloat(row, "_score") {
+					continue
+				}
+				best[id] = row
+			}
+		}
+	}
+
+	results := make([]map[string]interface{}, 0, len(best))
+	for _, row := range best {
+		results = append(results, row)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return rowFloat(results[i], "_score") > rowFloat(results[j], "_score")
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// rowFloat returns a float column value, or 0 if missing
+func rowFloat(row map[string]interface{}, key string) float64 {
+	switch value := row[key].(type) {
+	case float64:
+		return value
+	case float32:
+		return float64(value)
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+const (
+	// GlobalDBDir is the directory under ~/.code-scout/ holding the shared multi-project index
+	GlobalDBDir = "global"
+	// projectsDir holds each project's metadata inside the global index
+	projectsDir = "projects"
+)
+
+// invalidProjectChars matches characters not allowed in project names, which
+// are used as directory names inside the global index
+var invalidProjectChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// GlobalIndexDir returns the location of the shared multi-project index
+func GlobalIndexDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, DefaultDBDir, GlobalDBDir), nil
+}
+
+// ProjectName returns the name a project is stored under in the global index:
+// the configured name if set, otherwise the project directory's base name
+func ProjectName(rootDir, configured string) string {
+	name := configured
+	if name == "" {
+		name = filepath.Base(rootDir)
+	}
+	name = strings.Trim(invalidProjectChars.ReplaceAllString(name, "-"), "-")
+	if name == "" || name == "." || name == ".." {
+		return "default"
+	}
+	return name
+}
+
+// NewGlobalLanceDBStore opens the shared multi-project index. Rows written by
+// the store are tagged with project, and file listings are scoped to it. An
+// empty project opens the index read-only across all projects, for searches
+// with --all-projects or --project.
+//
+// Table-level state (schema version and embedding models) is shared by every
+// project, while file-level state (hashes, mod times, git commit) lives in a
+// per-project metadata file so projects index independently.
+func NewGlobalLanceDBStore(project string) (*LanceDBStore, error) {
+	dbDir, err := GlobalIndexDir()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := newLocalLanceDBStore(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	store.project = project
+	return store, nil
+}
+
+// tagProject records the project on each chunk, which buildRecord writes to the project column
+func tagProject(chunks []chunker.Chunk, project string) {
+	for i := range chunks {
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = make(map[string]string)
+		}
+		chunks[i].Metadata["project"] = project
+	}
+}
+
+// loadProjectMetadata loads a project's metadata from the global index, with
+// the shared table-level fields taken from the index-wide metadata
+func (s *LanceDBStore) loadProjectMetadata() (*IndexMetadata, error) {
+	shared, err := loadMetadata(s.dbDir)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := loadMetadata(filepath.Join(s.dbDir, projectsDir, s.project))
+	if err != nil {
+		return nil, err
+	}
+	metadata.SchemaVersion = shared.SchemaVersion
+	metadata.EmbeddingModels = shared.EmbeddingModels
+	return metadata, nil
+}
+
+// saveProjectMetadata saves a project's metadata to the global index, writing
+// the table-level fields to the index-wide metadata
+func (s *LanceDBStore) saveProjectMetadata(metadata *IndexMetadata) error {
+	shared, err := loadMetadata(s.dbDir)
+	if err != nil {
+		return err
+	}
+	shared.SchemaVersion = metadata.SchemaVersion
+	shared.EmbeddingModels = metadata.EmbeddingModels
+	if err := saveMetadata(s.dbDir, shared); err != nil {
+		return err
+	}
+
+	projectDir := filepath.Join(s.dbDir, projectsDir, s.project)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return fmt.Errorf("failed to create project metadata directory: %w", err)
+	}
+	return saveMetadata(projectDir, metadata)
+}
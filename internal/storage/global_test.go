@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		rootDir    string
+		configured string
+		expected   string
+	}{
+		{"/home/dev/code-scout", "", "code-scout"},
+		{"/home/dev/code-scout", "scout", "scout"},
+		{"/home/dev/my repo", "", "my-repo"},
+		{"/home/dev/x", "../escape", "..-escape"},
+		{"/", "", "default"},
+	}
+
+	for _, tt := range tests {
+		if got := ProjectName(tt.rootDir, tt.configured); got != tt.expected {
+			t.Errorf("ProjectName(%q, %q) = %q, expected %q", tt.rootDir, tt.configured, got, tt.expected)
+		}
+	}
+}
+
+func TestProjectMetadata_SharesTableState(t *testing.T) {
+	dbDir := t.TempDir()
+	api := &LanceDBStore{dbDir: dbDir, project: "api"}
+	web := &LanceDBStore{dbDir: dbDir, project: "web"}
+
+	metadata, err := api.LoadMetadata()
+	if err != nil {
+		t.Fatalf("LoadMetadata failed: %v", err)
+	}
+	metadata.SchemaVersion = CurrentSchemaVersion
+	metadata.RecordEmbeddingModel("code", "code-model", 768)
+	metadata.FileModTimes["/src/api/main.go"] = time.Now()
+	if err := api.SaveMetadata(metadata); err != nil {
+		t.Fatalf("SaveMetadata failed: %v", err)
+	}
+
+	other, err := web.LoadMetadata()
+	if err != nil {
+		t.Fatalf("LoadMetadata failed: %v", err)
+	}
+	if other.SchemaVersion != CurrentSchemaVersion || other.EmbeddingModels["code"].Model != "code-model" {
+		t.Errorf("expected table-level state to be shared, got %+v", other)
+	}
+	if len(other.FileModTimes) != 0 {
+		t.Errorf("expected file state to be per project, got %v", other.FileModTimes)
+	}
+
+	if _, err := loadMetadata(filepath.Join(dbDir, projectsDir, "api")); err != nil {
+		t.Errorf("expected project metadata file: %v", err)
+	}
+}
+
+func TestSearchFilter_Project(t *testing.T) {
+	filter := SearchFilter{Language: "go", Project: "o'brien"}
+	if filter.IsEmpty() {
+		t.Error("expected project filter to be non-empty")
+	}
+	expected := "language = 'go' AND project = 'o''brien'"
+	if got := filter.sqlWhere(); got != expected {
+		t.Errorf("sqlWhere() = %q, expected %q", got, expected)
+	}
+}
+
+func TestSearchFilter_Tests(t *testing.T) {
+	if got := (SearchFilter{ExcludeTests: true}).sqlWhere(); got != "is_test = false" {
+		t.Errorf("unexpected predicate: %q", got)
+	}
+	if got := (SearchFilter{Language: "go", OnlyTests: true}).sqlWhere(); got != "language = 'go' AND is_test = true" {
+		t.Errorf("unexpected predicate: %q", got)
+	}
+}
+
+func TestSearchFilter_ExcludeLanguages(t *testing.T) {
+	filter := SearchFilter{ExcludeLanguages: []string{"php", "o'caml"}}
+	if filter.IsEmpty() {
+		t.Error("expected language exclusion to be non-empty")
+	}
+	expected := "language NOT IN ('php', 'o''caml')"
+	if got := filter.sqlWhere(); got != expected {
+		t.Errorf("sqlWhere() = %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+const (
+	identifiersFileName = "identifiers.json"
+	// identifiersTableName holds the identifier index for remote stores
+	identifiersTableName = "identifier_index"
+	// minTokenLength is the shortest identifier or identifier part indexed
+	minTokenLength = 3
+)
+
+// IdentifierIndex is an inverted index from identifier tokens to the code chunks
+// containing them. Each identifier is indexed whole and split into its
+// camelCase and snake_case parts, all lowercased, so "parseHTTPHeader" is found
+// by "parsehttpheader", "parse", "http" and "header". It is kept beside the
+// vectors because embeddings often miss rare symbol names.
+type IdentifierIndex struct {
+	Tokens map[string][]string `json:"tokens"` // Token -> chunk IDs
+	Files  map[string][]string `json:"files"`  // File path -> chunk IDs
+}
+
+// IdentifierHit is a chunk matched by identifiers in a query
+type IdentifierHit struct {
+	ChunkID string
+	Score   float64  // Sum of the matched tokens' inverse document frequencies
+	Matched []string // Query tokens found in the chunk
+}
+
+// NewIdentifierIndex returns an empty identifier index
+func NewIdentifierIndex() *IdentifierIndex {
+	return &IdentifierIndex{Tokens: make(map[string][]string), Files: make(map[string][]string)}
+}
+
+// Add indexes the identifiers of code chunks. Docs chunks are skipped.
+func (idx *IdentifierIndex) Add(chunks []chunker.Chunk) {
+	for _, chunk := range chunks {
+		if chunk.EmbeddingType != "code" {
+			continue
+		}
+		idx.add(chunk.ID, chunk.FilePath, chunk.Name+"\n"+chunk.Code)
+	}
+}
+
+// add indexes the identifiers in text under a chunk
+func (idx *IdentifierIndex) add(chunkID, filePath, text string) {
+	idx.Files[filePath] = append(idx.Files[filePath], chunkID)
+	for _, token := range IdentifierTokens(text) {
+		idx.Tokens[token] = append(idx.Tokens[token], chunkID)
+	}
+}
+
+// RemoveFiles drops every chunk of the given files from the index
+func (idx *IdentifierIndex) RemoveFiles(filePaths []string) {
+	removed := make(map[string]bool)
+	for _, path := range filePaths {
+		for _, id := range idx.Files[path] {
+			removed[id] = true
+		}
+		delete(idx.Files, path)
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	for token, ids := range idx.Tokens {
+		kept := ids[:0]
+		for _, id := range ids {
+			if !removed[id] {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			delete(idx.Tokens, token)
+		} else {
+			idx.Tokens[token] = kept
+		}
+	}
+}
+
+// RenameFile moves a file's chunks to a new path; chunk IDs are unchanged
+func (idx *IdentifierIndex) RenameFile(oldPath, newPath string) {
+	if ids, ok := idx.Files[oldPath]; ok {
+		delete(idx.Files, oldPath)
+		idx.Files[newPath] = ids
+	}
+}
+
+// Lookup returns up to limit chunks containing the identifiers in query,
+// ordered by score. Only symbol-like words of the query (see QueryIdentifiers)
+// are looked up; a whole identifier counts fully, and each of its parts counts
+// for a share, weighted by how rare the token is across chunks.
+func (idx *IdentifierIndex) Lookup(query string, limit int) []IdentifierHit {
+	chunkCount := 0
+	for _, ids := range idx.Files {
+		chunkCount += len(ids)
+	}
+	if chunkCount == 0 {
+		return nil
+	}
+
+	hits := make(map[string]*IdentifierHit)
+	match := func(token string, weight float64) {
+		ids := idx.Tokens[token]
+		if len(ids) == 0 {
+			return
+		}
+		idf := math.Log(1 + float64(chunkCount)/float64(len(ids)))
+		for _, id := range ids {
+			hit, ok := hits[id]
+			if !ok {
+				hit = &IdentifierHit{ChunkID: id}
+				hits[id] = hit
+			}
+			hit.Score += weight * idf
+			hit.Matched = append(hit.Matched, token)
+		}
+	}
+	for _, identifier := range QueryIdentifiers(query) {
+		match(strings.ToLower(identifier), 1)
+		parts := splitIdentifier(identifier)
+		if len(parts) > 1 {
+			for _, part := range parts {
+				match(part, 1/float64(len(parts)))
+			}
+		}
+	}
+
+	results := make([]IdentifierHit, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, *hit)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ChunkID < results[j].ChunkID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// IdentifierTokens returns the distinct lowercased tokens indexed for text:
+// each identifier, plus its parts if it has several
+func IdentifierTokens(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	addToken := func(token string) {
+		if len(token) >= minTokenLength && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	for _, identifier := range identifiers(text) {
+		addToken(strings.ToLower(identifier))
+		if parts := splitIdentifier(identifier); len(parts) > 1 {
+			for _, part := range parts {
+				addToken(part)
+			}
+		}
+	}
+	return tokens
+}
+
+// QueryIdentifiers returns the words of a query that look like symbol names:
+// ones with an underscore or an inner capital ("parse_header", "parseHeader",
+// "HTTPServer"), or the whole query when it is a single identifier
+func QueryIdentifiers(query string) []string {
+	words := identifiers(query)
+	if len(words) == 1 && strings.TrimSpace(query) == words[0] {
+		return words
+	}
+
+	var symbols []string
+	for _, word := range words {
+		if strings.Contains(strings.Trim(word, "_"), "_") || hasInnerUpper(word) {
+			symbols = append(symbols, word)
+		}
+	}
+	return symbols
+}
+
+// hasInnerUpper reports whether a word has an uppercase letter after its first character
+func hasInnerUpper(word string) bool {
+	for i, r := range word {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// identifiers returns the identifier-like words in text
+func identifiers(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	})
+}
+
+// splitIdentifier splits an identifier at underscores and camelCase
+// boundaries, lowercasing the parts: "parseHTTPHeader" gives parse, http,
+// header; "MAX_RETRY_COUNT" gives max, retry, count
+func splitIdentifier(identifier string) []string {
+	var parts []string
+	for _, word := range strings.FieldsFunc(identifier, func(r rune) bool { return r == '_' }) {
+		runes := []rune(word)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			lowerToUpper := unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+			// The last capital of an acronym starts the next word: HTTP|Header
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		parts = append(parts, strings.ToLower(string(runes[start:])))
+	}
+	return parts
+}
+
+// BuildIdentifierIndex indexes every code chunk already in the store, for
+// indexes created before the identifier index existed
+func BuildIdentifierIndex(store Store) (*IdentifierIndex, error) {
+	paths, err := store.ListFilePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := NewIdentifierIndex()
+	for _, path := range paths {
+		rows, err := store.FileChunks(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if rowString(row, "embedding_type") != "code" {
+				continue
+			}
+			idx.add(rowString(row, "chunk_id"), path, rowString(row, "name")+"\n"+rowString(row, "code"))
+		}
+	}
+	return idx, nil
+}
+
+// LoadIdentifiers loads the identifier index, or nil if none has been saved
+func (s *LanceDBStore) LoadIdentifiers() (*IdentifierIndex, error) {
+	if s.remote {
+		data, err := s.loadRemoteDocument(identifiersTableName)
+		if err != nil || data == nil {
+			return nil, err
+		}
+		return decodeIdentifierIndex(data)
+	}
+	return loadIdentifierIndex(s.identifiersDir())
+}
+
+// SaveIdentifiers saves the identifier index beside the index metadata
+func (s *LanceDBStore) SaveIdentifiers(idx *IdentifierIndex) error {
+	if s.remote {
+		data, err := json.Marshal(idx)
+		if err != nil {
+			return fmt.Errorf("failed to marshal identifier index: %w", err)
+		}
+		if err := s.saveRemoteDocument(identifiersTableName, data); err != nil {
+			return fmt.Errorf("failed to write identifier index: %w", err)
+		}
+		return nil
+	}
+	dir := s.identifiersDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	return saveIdentifierIndex(dir, idx)
+}
+
+// identifiersDir is the directory holding a local store's identifier index:
+// the project's own directory within the global index
+func (s *LanceDBStore) identifiersDir() string {
+	if s.project != "" {
+		return filepath.Join(s.dbDir, projectsDir, s.project)
+	}
+	return s.dbDir
+}
+
+// LoadIdentifiers loads the identifier index from the local index directory
+func (s *QdrantStore) LoadIdentifiers() (*IdentifierIndex, error) {
+	return loadIdentifierIndex(s.dbDir)
+}
+
+// SaveIdentifiers saves the identifier index to the local index directory
+func (s *QdrantStore) SaveIdentifiers(idx *IdentifierIndex) error {
+	return saveIdentifierIndex(s.dbDir, idx)
+}
+
+// loadIdentifierIndex loads the identifier index from dir, or nil if it doesn't exist
+func loadIdentifierIndex(dir string) (*IdentifierIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, identifiersFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read identifier index: %w", err)
+	}
+	return decodeIdentifierIndex(data)
+}
+
+// saveIdentifierIndex writes the identifier index to dir
+func saveIdentifierIndex(dir string, idx *IdentifierIndex) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal identifier index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, identifiersFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write identifier index: %w", err)
+	}
+	return nil
+}
+
+// decodeIdentifierIndex parses a serialized identifier index
+func decodeIdentifierIndex(data []byte) (*IdentifierIndex, error) {
+	idx := NewIdentifierIndex()
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse identifier index: %w", err)
+	}
+	if idx.Tokens == nil {
+		idx.Tokens = make(map[string][]string)
+	}
+	if idx.Files == nil {
+		idx.Files = make(map[string][]string)
+	}
+	return idx, nil
+}
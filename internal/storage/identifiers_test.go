@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	for identifier, want := range map[string]string{
+		"parseHTTPHeader": "[parse http header]",
+		"MAX_RETRY_COUNT": "[max retry count]",
+		"utf8Decode":      "[utf8 decode]",
+		"Scanner":         "[scanner]",
+		"_private_name":   "[private name]",
+	} {
+		if got := fmt.Sprint(splitIdentifier(identifier)); got != want {
+			t.Errorf("splitIdentifier(%q) = %s, want %s", identifier, got, want)
+		}
+	}
+}
+
+func TestQueryIdentifiers(t *testing.T) {
+	for query, want := range map[string]string{
+		"where is parseHTTPHeader called": "[parseHTTPHeader]",
+		"max_retry_count default":         "[max_retry_count]",
+		"Scanner":                         "[Scanner]",
+		"How is the config loaded":        "[]",
+	} {
+		if got := fmt.Sprint(QueryIdentifiers(query)); got != want {
+			t.Errorf("QueryIdentifiers(%q) = %s, want %s", query, got, want)
+		}
+	}
+}
+
+func TestIdentifierIndexLookup(t *testing.T) {
+	idx := NewIdentifierIndex()
+	idx.Add([]chunker.Chunk{
+		{ID: "a", FilePath: "a.go", Name: "parseHTTPHeader", Code: "func parseHTTPHeader(h string) {}", EmbeddingType: "code"},
+		{ID: "b", FilePath: "b.go", Name: "parseBody", Code: "func parseBody(b string) {}", EmbeddingType: "code"},
+		{ID: "c", FilePath: "c.go", Name: "writeHeader", Code: "func writeHeader() {}", EmbeddingType: "code"},
+		{ID: "d", FilePath: "README.md", Code: "parseHTTPHeader parses headers", EmbeddingType: "docs"},
+	})
+
+	hits := idx.Lookup("parseHTTPHeader", 10)
+	if len(hits) != 3 || hits[0].ChunkID != "a" {
+		t.Fatalf("expected the exact match first among 3 hits, got %+v", hits)
+	}
+	if hits[0].Score <= hits[1].Score {
+		t.Errorf("expected the exact match to outscore partial matches, got %+v", hits)
+	}
+
+	idx.RenameFile("a.go", "renamed.go")
+	idx.RemoveFiles([]string{"renamed.go"})
+	if hits := idx.Lookup("parseHTTPHeader", 10); len(hits) != 2 {
+		t.Errorf("expected removed chunks to stop matching, got %+v", hits)
+	}
+	if _, ok := idx.Tokens["parsehttpheader"]; ok {
+		t.Error("expected tokens with no chunks left to be dropped")
+	}
+}
+
+func TestIdentifierIndexSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	if idx, err := loadIdentifierIndex(dir); err != nil || idx != nil {
+		t.Fatalf("expected no index before saving, got %v (%v)", idx, err)
+	}
+
+	idx := NewIdentifierIndex()
+	idx.Add([]chunker.Chunk{{ID: "a", FilePath: "a.go", Code: "func scanFiles() {}", EmbeddingType: "code"}})
+	if err := saveIdentifierIndex(dir, idx); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded, err := loadIdentifierIndex(dir)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if hits := loaded.Lookup("scanFiles", 10); len(hits) != 1 || hits[0].ChunkID != "a" {
+		t.Errorf("expected the loaded index to match, got %+v", hits)
+	}
+}
@@ -2,13 +2,16 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/apache/arrow/go/v17/arrow"
 	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/float16"
 	"github.com/apache/arrow/go/v17/arrow/memory"
 	"github.com/jlanders/code-scout/internal/chunker"
 	"github.com/lancedb/lancedb-go/pkg/contracts"
@@ -18,24 +21,46 @@ import (
 const (
 	// DefaultDBDir is the default directory for LanceDB storage
 	DefaultDBDir = ".code-scout"
-	// DefaultTableName is the default table name for code chunks
+	// DefaultTableName is the table for code chunks (before schema v3 it also held docs chunks)
 	DefaultTableName = "code_chunks"
-	// VectorDimension is the embedding dimension (nomic-embed-code uses 3584)
-	VectorDimension = 3584
+	// DocsTableName is the table for documentation chunks
+	DocsTableName = "docs_chunks"
+	// VectorPrecisionFloat32 stores vectors at full precision; new tables always use it
+	VectorPrecisionFloat32 = "float32"
+	// VectorPrecisionFloat16 marks half-precision tables written by earlier builds. Rows
+	// are still searched and added, but can't be read back to rewrite the table.
+	VectorPrecisionFloat16 = "float16"
 )
 
+// EmbeddingTypes lists the embedding spaces. Each is stored in its own table
+// with its own vector dimension, so vectors are never padded to fit.
+var EmbeddingTypes = []string{"code", "docs"}
+
 // LanceDBStore handles storage and retrieval from LanceDB
 type LanceDBStore struct {
-	conn   contracts.IConnection
-	table  contracts.ITable
-	schema *arrow.Schema
-	dbDir  string
+	conn    contracts.IConnection
+	tables  map[string]contracts.ITable // embedding type -> open table
+	dbDir   string                      // local index directory, or the object store URI for remote stores
+	remote  bool                        // true if dbDir is an object store URI
+	project string                      // project whose rows this store reads and writes (global index only)
 }
 
 // NewLanceDBStore creates a new LanceDB store
 func NewLanceDBStore(rootDir string) (*LanceDBStore, error) {
-	dbDir := filepath.Join(rootDir, DefaultDBDir)
+	return newLocalLanceDBStore(filepath.Join(rootDir, DefaultDBDir))
+}
+
+// ShadowDBDir is the directory inside the project's index directory that holds
+// the shadow index, built with the shadow models
+const ShadowDBDir = "shadow"
 
+// NewShadowLanceDBStore creates the store for the project's shadow index
+func NewShadowLanceDBStore(rootDir string) (*LanceDBStore, error) {
+	return newLocalLanceDBStore(filepath.Join(rootDir, DefaultDBDir, ShadowDBDir))
+}
+
+// newLocalLanceDBStore creates a LanceDB store in the given local directory
+func newLocalLanceDBStore(dbDir string) (*LanceDBStore, error) {
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
@@ -49,17 +74,48 @@ func NewLanceDBStore(rootDir string) (*LanceDBStore, error) {
 	}
 
 	return &LanceDBStore{
-		conn:  conn,
-		dbDir: dbDir,
+		conn:   conn,
+		tables: make(map[string]contracts.ITable),
+		dbDir:  dbDir,
 	}, nil
 }
 
-// getOrCreateSchema returns the schema, creating it if needed
-func (s *LanceDBStore) getOrCreateSchema() (*arrow.Schema, error) {
-	if s.schema != nil {
-		return s.schema, nil
+// NewRemoteLanceDBStore creates a LanceDB store backed by an object store URI
+// (s3://, gs://, az://). Index metadata is kept in the object store alongside
+// the tables so every machine using the URI sees the same index state.
+func NewRemoteLanceDBStore(uri string, storageOptions *contracts.StorageOptions) (*LanceDBStore, error) {
+	ctx := context.Background()
+	conn, err := lancedb.Connect(ctx, uri, &contracts.ConnectionOptions{StorageOptions: storageOptions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LanceDB at %s: %w", uri, err)
 	}
 
+	return &LanceDBStore{
+		conn:   conn,
+		tables: make(map[string]contracts.ITable),
+		dbDir:  uri,
+		remote: true,
+	}, nil
+}
+
+// tableName returns the table that stores chunks of the given embedding type
+func tableName(embeddingType string) string {
+	if embeddingType == "docs" {
+		return DocsTableName
+	}
+	return DefaultTableName
+}
+
+// vectorElementType returns the Arrow element type for a vector precision
+func vectorElementType(precision string) arrow.DataType {
+	if precision == VectorPrecisionFloat16 {
+		return arrow.FixedWidthTypes.Float16
+	}
+	return arrow.PrimitiveTypes.Float32
+}
+
+// newSchema returns the table schema for vectors of the given dimension and precision
+func newSchema(dimension int, precision string) *arrow.Schema {
 	fields := []arrow.Field{
 		{Name: "chunk_id", Type: arrow.BinaryTypes.String, Nullable: false},
 		{Name: "file_path", Type: arrow.BinaryTypes.String, Nullable: false},
@@ -68,48 +124,63 @@ func (s *LanceDBStore) getOrCreateSchema() (*arrow.Schema, error) {
 		{Name: "language", Type: arrow.BinaryTypes.String, Nullable: false},
 		{Name: "code", Type: arrow.BinaryTypes.String, Nullable: false},
 		{Name: "chunk_type", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
 		{Name: "heading", Type: arrow.BinaryTypes.String, Nullable: true},
 		{Name: "heading_level", Type: arrow.BinaryTypes.String, Nullable: true},
 		{Name: "parent_heading", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "metadata", Type: arrow.BinaryTypes.String, Nullable: true},        // JSON-encoded chunk metadata map
 		{Name: "embedding_type", Type: arrow.BinaryTypes.String, Nullable: false}, // "code" or "docs"
-		{Name: "vector", Type: arrow.FixedSizeListOf(VectorDimension, arrow.PrimitiveTypes.Float32), Nullable: false},
+		{Name: "project", Type: arrow.BinaryTypes.String, Nullable: true},         // Project name in the global index
+		{Name: "is_test", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},    // Chunk is from a test file
+		{Name: "vector", Type: arrow.FixedSizeListOf(int32(dimension), vectorElementType(precision)), Nullable: false},
 	}
-	s.schema = arrow.NewSchema(fields, nil)
-	return s.schema, nil
+	return arrow.NewSchema(fields, nil)
 }
 
-// ensureTable ensures the table exists, creating it if needed
-func (s *LanceDBStore) ensureTable() error {
-	if s.table != nil {
-		return nil
+// ensureTable ensures the table for an embedding type exists, creating it
+// with the given vector dimension if needed
+func (s *LanceDBStore) ensureTable(embeddingType string, dimension int) (contracts.ITable, error) {
+	if table, ok := s.tables[embeddingType]; ok {
+		return table, nil
 	}
 
 	ctx := context.Background()
 
 	// Try to open existing table first
-	var err error
-	s.table, err = s.conn.OpenTable(ctx, DefaultTableName)
+	table, err := s.conn.OpenTable(ctx, tableName(embeddingType))
 	if err == nil {
-		return nil
+		s.tables[embeddingType] = table
+		return table, nil
 	}
 
 	// Table doesn't exist, create it
-	schema, err := s.getOrCreateSchema()
+	lanceSchema, err := lancedb.NewSchema(newSchema(dimension, VectorPrecisionFloat32))
 	if err != nil {
-		return fmt.Errorf("failed to get schema: %w", err)
+		return nil, fmt.Errorf("failed to create Lance schema: %w", err)
 	}
 
-	lanceSchema, err := lancedb.NewSchema(schema)
+	table, err = s.conn.CreateTable(ctx, tableName(embeddingType), lanceSchema)
 	if err != nil {
-		return fmt.Errorf("failed to create Lance schema: %w", err)
+		return nil, fmt.Errorf("failed to create table: %w", err)
 	}
+	s.tables[embeddingType] = table
 
-	s.table, err = s.conn.CreateTable(ctx, DefaultTableName, lanceSchema)
-	if err != nil {
-		return fmt.Errorf("failed to create table: %w", err)
-	}
+	return table, nil
+}
 
-	return nil
+// openExistingTables opens the table of every embedding space that has one.
+// The caller must close the returned tables.
+func (s *LanceDBStore) openExistingTables(ctx context.Context) map[string]contracts.ITable {
+	tables := make(map[string]contracts.ITable)
+	for _, embeddingType := range EmbeddingTypes {
+		table, err := s.conn.OpenTable(ctx, tableName(embeddingType))
+		if err != nil {
+			// Table doesn't exist yet
+			continue
+		}
+		tables[embeddingType] = table
+	}
+	return tables
 }
 
 // DeleteChunksByFilePath deletes all chunks for the given file paths
@@ -118,61 +189,267 @@ func (s *LanceDBStore) DeleteChunksByFilePath(filePaths []string) error {
 		return nil
 	}
 
-	// Try to open table - if it doesn't exist, nothing to delete
+	filter := filePathWhere(filePaths)
+
+	// Tables that don't exist yet have nothing to delete
 	ctx := context.Background()
-	table, err := s.conn.OpenTable(ctx, DefaultTableName)
-	if err != nil {
-		// Table doesn't exist yet, nothing to delete
-		return nil
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	for _, table := range tables {
+		if err := table.Delete(ctx, filter); err != nil {
+			return fmt.Errorf("failed to delete chunks: %w", err)
+		}
 	}
-	defer table.Close()
 
-	// Build filter expression: file_path = 'path1' OR file_path = 'path2' OR ...
-	// Escape single quotes in file paths
+	return nil
+}
+
+// filePathWhere returns a filter matching the rows of any of filePaths:
+// (file_path = 'path1' OR file_path = 'path2' OR ...)
+func filePathWhere(filePaths []string) string {
 	filterParts := make([]string, 0, len(filePaths))
 	for _, path := range filePaths {
-		// Escape single quotes by doubling them
-		escaped := ""
-		for _, r := range path {
-			if r == '\'' {
-				escaped += "''"
-			} else {
-				escaped += string(r)
+		filterParts = append(filterParts, fmt.Sprintf("file_path = '%s'", escapeSQLString(path)))
+	}
+	return "(" + strings.Join(filterParts, " OR ") + ")"
+}
+
+// chunkIDList returns the chunk IDs as a quoted SQL list: 'id1', 'id2', ...
+func chunkIDList(chunkIDs []string) string {
+	quoted := make([]string, len(chunkIDs))
+	for i, id := range chunkIDs {
+		quoted[i] = "'" + escapeSQLString(id) + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// ListFilePaths returns the distinct file paths that have chunks stored in any table.
+// A global index store only lists its own project's files.
+func (s *LanceDBStore) ListFilePaths() ([]string, error) {
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, table := range tables {
+		rows, err := s.selectFilePaths(ctx, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file paths: %w", err)
+		}
+
+		for _, row := range rows {
+			path, ok := row["file_path"].(string)
+			if !ok || seen[path] {
+				continue
 			}
+			seen[path] = true
+			paths = append(paths, path)
 		}
-		filterParts = append(filterParts, fmt.Sprintf("file_path = '%s'", escaped))
 	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// selectFilePaths reads the file_path column of every row, scoped to the
+// store's project if it has one. LanceDB caps a query without a limit, so
+// the limit is the table's row count.
+func (s *LanceDBStore) selectFilePaths(ctx context.Context, table contracts.ITable) ([]map[string]interface{}, error) {
+	count, err := table.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	limit := int(count)
+	return table.Select(ctx, contracts.QueryConfig{
+		Columns: []string{"file_path"},
+		Where:   SearchFilter{Project: s.project}.sqlWhere(),
+		Limit:   &limit,
+	})
+}
+
+// UpdateFilePath rewrites the file path of all chunks stored for oldPath, so a
+// renamed file keeps its embeddings without being re-embedded
+func (s *LanceDBStore) UpdateFilePath(oldPath, newPath string) error {
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
 
-	filter := "(" + strings.Join(filterParts, " OR ") + ")"
+	filter := fmt.Sprintf("file_path = '%s'", escapeSQLString(oldPath))
+	// Update values are interpolated as SQL string literals, so they need escaping too
+	updates := map[string]interface{}{
+		"file_path": escapeSQLString(newPath),
+	}
 
-	if err := table.Delete(ctx, filter); err != nil {
-		return fmt.Errorf("failed to delete chunks: %w", err)
+	for _, table := range tables {
+		if err := table.Update(ctx, filter, updates); err != nil {
+			return fmt.Errorf("failed to update file path: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// StoreChunks stores chunks with their embeddings (incremental - adds to existing table)
+// closeTables closes every table in the map
+func closeTables(tables map[string]contracts.ITable) {
+	for _, table := range tables {
+		table.Close()
+	}
+}
+
+// escapeSQLString escapes single quotes by doubling them for use in SQL string literals
+func escapeSQLString(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// StoreChunks stores chunks with their embeddings (incremental - adds to existing tables).
+// Chunks are routed to the table for their embedding type.
 func (s *LanceDBStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float64) error {
+	records, err := s.prepareRecords(chunks, embeddings)
+	if err != nil {
+		return err
+	}
+	return s.addRecords(records)
+}
+
+// ReplaceChunks replaces all chunks stored for filePaths with the given chunks.
+// LanceDB has no transactions spanning an add and a delete, so the new rows are
+// added first and the files' other rows deleted after: if the add fails, the
+// old rows are untouched, and if the delete fails, the next replace of the
+// files removes the rows it left.
+func (s *LanceDBStore) ReplaceChunks(filePaths []string, chunks []chunker.Chunk, embeddings [][]float64) error {
+	records, err := s.prepareRecords(chunks, embeddings)
+	if err != nil {
+		return err
+	}
+	if err := s.addRecords(records); err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return nil
+	}
+
+	filter := filePathWhere(filePaths)
+	if len(chunks) > 0 {
+		ids := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			ids[i] = chunk.ID
+		}
+		filter += " AND chunk_id NOT IN (" + chunkIDList(ids) + ")"
+	}
+
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	for _, table := range tables {
+		if err := table.Delete(ctx, filter); err != nil {
+			return fmt.Errorf("failed to delete replaced chunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// prepareRecords builds the Arrow record for each embedding space's chunks,
+// creating missing tables and matching each table's vector precision.
+// The caller must release the returned records, normally via addRecords.
+func (s *LanceDBStore) prepareRecords(chunks []chunker.Chunk, embeddings [][]float64) (map[string]arrow.Record, error) {
 	if len(chunks) != len(embeddings) {
-		return fmt.Errorf("chunks and embeddings length mismatch: %d vs %d", len(chunks), len(embeddings))
+		return nil, fmt.Errorf("chunks and embeddings length mismatch: %d vs %d", len(chunks), len(embeddings))
 	}
 
+	records := make(map[string]arrow.Record)
 	if len(chunks) == 0 {
-		return nil // Nothing to store
+		return records, nil // Nothing to store
 	}
 
-	if err := s.ensureTable(); err != nil {
-		return err
+	if s.project != "" {
+		tagProject(chunks, s.project)
 	}
 
-	schema, err := s.getOrCreateSchema()
-	if err != nil {
-		return fmt.Errorf("failed to get schema: %w", err)
+	chunksByType := make(map[string][]chunker.Chunk)
+	embeddingsByType := make(map[string][][]float64)
+	for i, chunk := range chunks {
+		embeddingType := chunk.EmbeddingType
+		if embeddingType != "docs" {
+			embeddingType = "code"
+		}
+		chunksByType[embeddingType] = append(chunksByType[embeddingType], chunk)
+		embeddingsByType[embeddingType] = append(embeddingsByType[embeddingType], embeddings[i])
 	}
-	_ = schema // Schema is used implicitly via s.schema
 
 	ctx := context.Background()
+	for _, embeddingType := range EmbeddingTypes {
+		typeChunks := chunksByType[embeddingType]
+		if len(typeChunks) == 0 {
+			continue
+		}
+		typeEmbeddings := embeddingsByType[embeddingType]
+
+		table, err := s.ensureTable(embeddingType, len(typeEmbeddings[0]))
+		if err != nil {
+			releaseRecords(records)
+			return nil, err
+		}
+
+		_, precision, err := tableVectorType(ctx, table)
+		if err != nil {
+			releaseRecords(records)
+			return nil, err
+		}
+
+		record, err := buildRecord(typeChunks, typeEmbeddings, precision)
+		if err != nil {
+			releaseRecords(records)
+			return nil, err
+		}
+		records[embeddingType] = record
+	}
+
+	return records, nil
+}
+
+// addRecords adds each embedding space's record to its table and releases the records
+func (s *LanceDBStore) addRecords(records map[string]arrow.Record) error {
+	defer releaseRecords(records)
+
+	ctx := context.Background()
+	for _, embeddingType := range EmbeddingTypes {
+		record, ok := records[embeddingType]
+		if !ok {
+			continue
+		}
+		if err := s.tables[embeddingType].Add(ctx, record, nil); err != nil {
+			return fmt.Errorf("failed to add %s records: %w", embeddingType, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseRecords releases every record in the map
+func releaseRecords(records map[string]arrow.Record) {
+	for _, record := range records {
+		record.Release()
+	}
+}
+
+// buildRecord converts chunks and their embeddings into an Arrow record with vectors
+// stored at the given precision. The vector dimension is taken from the embeddings,
+// which must all be the same length.
+func buildRecord(chunks []chunker.Chunk, embeddings [][]float64, precision string) (arrow.Record, error) {
+	dimension := len(embeddings[0])
+	for i, embedding := range embeddings {
+		if len(embedding) != dimension {
+			return nil, fmt.Errorf("embedding dimension mismatch for chunk %s: got %d, expected %d", chunks[i].ID, len(embedding), dimension)
+		}
+	}
+	schema := newSchema(dimension, precision)
 
 	// Build Arrow arrays
 	pool := memory.NewGoAllocator()
@@ -185,11 +462,15 @@ func (s *LanceDBStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float6
 	languages := make([]string, len(chunks))
 	codes := make([]string, len(chunks))
 	chunkTypes := make([]string, len(chunks))
+	names := make([]string, len(chunks))
 	headings := make([]string, len(chunks))
 	headingLevels := make([]string, len(chunks))
 	parentHeadings := make([]string, len(chunks))
+	metadataJSON := make([]string, len(chunks))
 	embeddingTypes := make([]string, len(chunks))
-	allVectors := make([]float32, len(chunks)*VectorDimension)
+	projects := make([]string, len(chunks))
+	isTests := make([]bool, len(chunks))
+	allVectors := make([]float32, len(chunks)*dimension)
 
 	for i, chunk := range chunks {
 		chunkIDs[i] = chunk.ID
@@ -199,16 +480,24 @@ func (s *LanceDBStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float6
 		languages[i] = chunk.Language
 		codes[i] = chunk.Code
 		chunkTypes[i] = chunk.ChunkType
+		names[i] = chunk.Name
 		if chunk.Metadata != nil {
 			headings[i] = chunk.Metadata["heading"]
 			headingLevels[i] = chunk.Metadata["heading_level"]
 			parentHeadings[i] = chunk.Metadata["parent_heading"]
+			projects[i] = chunk.Metadata["project"]
+		}
+		encoded, err := encodeChunkMetadata(chunk.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata for chunk %s: %w", chunk.ID, err)
 		}
+		metadataJSON[i] = encoded
 		embeddingTypes[i] = chunk.EmbeddingType
+		isTests[i] = IsTestFile(chunk.FilePath)
 
 		// Convert float64 embeddings to float32 and flatten
 		for j, val := range embeddings[i] {
-			allVectors[i*VectorDimension+j] = float32(val)
+			allVectors[i*dimension+j] = float32(val)
 		}
 	}
 
@@ -248,6 +537,11 @@ func (s *LanceDBStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float6
 	chunkTypeArray := chunkTypeBuilder.NewArray()
 	defer chunkTypeArray.Release()
 
+	nameBuilder := array.NewStringBuilder(pool)
+	nameBuilder.AppendValues(names, nil)
+	nameArray := nameBuilder.NewArray()
+	defer nameArray.Release()
+
 	headingBuilder := array.NewStringBuilder(pool)
 	headingBuilder.AppendValues(headings, nil)
 	headingArray := headingBuilder.NewArray()
@@ -263,25 +557,51 @@ func (s *LanceDBStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float6
 	parentHeadingArray := parentHeadingBuilder.NewArray()
 	defer parentHeadingArray.Release()
 
+	metadataBuilder := array.NewStringBuilder(pool)
+	metadataBuilder.AppendValues(metadataJSON, nil)
+	metadataArray := metadataBuilder.NewArray()
+	defer metadataArray.Release()
+
 	embeddingTypeBuilder := array.NewStringBuilder(pool)
 	embeddingTypeBuilder.AppendValues(embeddingTypes, nil)
 	embeddingTypeArray := embeddingTypeBuilder.NewArray()
 	defer embeddingTypeArray.Release()
 
+	projectBuilder := array.NewStringBuilder(pool)
+	projectBuilder.AppendValues(projects, nil)
+	projectArray := projectBuilder.NewArray()
+	defer projectArray.Release()
+
+	isTestBuilder := array.NewBooleanBuilder(pool)
+	isTestBuilder.AppendValues(isTests, nil)
+	isTestArray := isTestBuilder.NewArray()
+	defer isTestArray.Release()
+
 	// Build vector array
-	vectorFloat32Builder := array.NewFloat32Builder(pool)
-	vectorFloat32Builder.AppendValues(allVectors, nil)
-	vectorFloat32Array := vectorFloat32Builder.NewArray()
-	defer vectorFloat32Array.Release()
+	var vectorValues arrow.Array
+	if precision == VectorPrecisionFloat16 {
+		halfVectors := make([]float16.Num, len(allVectors))
+		for i, val := range allVectors {
+			halfVectors[i] = float16.New(val)
+		}
+		vectorFloat16Builder := array.NewFloat16Builder(pool)
+		vectorFloat16Builder.AppendValues(halfVectors, nil)
+		vectorValues = vectorFloat16Builder.NewArray()
+	} else {
+		vectorFloat32Builder := array.NewFloat32Builder(pool)
+		vectorFloat32Builder.AppendValues(allVectors, nil)
+		vectorValues = vectorFloat32Builder.NewArray()
+	}
+	defer vectorValues.Release()
 
-	vectorListType := arrow.FixedSizeListOf(VectorDimension, arrow.PrimitiveTypes.Float32)
+	vectorListType := arrow.FixedSizeListOf(int32(dimension), vectorElementType(precision))
 	vectorArray := array.NewFixedSizeListData(
 		array.NewData(vectorListType, len(chunks), []*memory.Buffer{nil},
-			[]arrow.ArrayData{vectorFloat32Array.Data()}, 0, 0),
+			[]arrow.ArrayData{vectorValues.Data()}, 0, 0),
 	)
 	defer vectorArray.Release()
 
-	// Create record and insert
+	// Create record (the record retains its columns, so releasing the arrays above is safe)
 	columns := []arrow.Array{
 		chunkIDArray,
 		filePathArray,
@@ -290,50 +610,76 @@ func (s *LanceDBStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float6
 		languageArray,
 		codeArray,
 		chunkTypeArray,
+		nameArray,
 		headingArray,
 		headingLevelArray,
 		parentHeadingArray,
+		metadataArray,
 		embeddingTypeArray,
+		projectArray,
+		isTestArray,
 		vectorArray,
 	}
-	record := array.NewRecord(s.schema, columns, int64(len(chunks)))
-	defer record.Release()
+	return array.NewRecord(schema, columns, int64(len(chunks))), nil
+}
 
-	if err := s.table.Add(ctx, record, nil); err != nil {
-		return fmt.Errorf("failed to add records: %w", err)
+// encodeChunkMetadata serializes a chunk metadata map for the metadata column
+func encodeChunkMetadata(metadata map[string]string) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
 	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
 
-	return nil
+// DecodeChunkMetadata parses the metadata column of a stored chunk.
+// Empty or malformed values decode to an empty map.
+func DecodeChunkMetadata(value string) map[string]string {
+	metadata := make(map[string]string)
+	if value == "" {
+		return metadata
+	}
+	if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+		return make(map[string]string)
+	}
+	return metadata
 }
 
-// OpenTable opens an existing table for searching
+// OpenTable opens the existing tables for searching
 func (s *LanceDBStore) OpenTable() error {
 	ctx := context.Background()
 
-	// Open existing table
-	var err error
-	s.table, err = s.conn.OpenTable(ctx, DefaultTableName)
-	if err != nil {
-		return fmt.Errorf("failed to open table: %w", err)
+	for embeddingType, table := range s.openExistingTables(ctx) {
+		if existing, ok := s.tables[embeddingType]; ok {
+			existing.Close()
+		}
+		s.tables[embeddingType] = table
+	}
+	if len(s.tables) == 0 {
+		return &IndexMissingError{Location: s.dbDir}
 	}
 
 	return nil
 }
 
-// Search performs vector similarity search
-func (s *LanceDBStore) Search(queryVector []float64, limit int, filter string) ([]map[string]interface{}, error) {
-	if s.table == nil {
-		return nil, fmt.Errorf("table not initialized; call StoreChunks first")
+// Search performs vector similarity search in the table for the given embedding type.
+// An embedding space with no table (e.g. a repo without docs) returns no results.
+func (s *LanceDBStore) Search(embeddingType string, queryVector []float64, limit int, filter SearchFilter) ([]map[string]interface{}, error) {
+	if len(s.tables) == 0 {
+		return nil, fmt.Errorf("table not initialized; call OpenTable first")
+	}
+	table, ok := s.tables[embeddingType]
+	if !ok {
+		return nil, nil
 	}
 
-	// Convert float64 query vector to fixed-size float32 slice with padding
-	queryVectorFloat32 := make([]float32, VectorDimension)
-	for i := 0; i < VectorDimension; i++ {
-		if i < len(queryVector) {
-			queryVectorFloat32[i] = float32(queryVector[i])
-		} else {
-			queryVectorFloat32[i] = 0
-		}
+	// Convert float64 query vector to float32
+	queryVectorFloat32 := make([]float32, len(queryVector))
+	for i, v := range queryVector {
+		queryVectorFloat32[i] = float32(v)
 	}
 
 	ctx := context.Background()
@@ -342,10 +688,10 @@ func (s *LanceDBStore) Search(queryVector []float64, limit int, filter string) (
 		err     error
 	)
 
-	if filter != "" {
-		results, err = s.table.VectorSearchWithFilter(ctx, "vector", queryVectorFloat32, limit, filter)
+	if where := filter.sqlWhere(); where != "" {
+		results, err = table.VectorSearchWithFilter(ctx, "vector", queryVectorFloat32, limit, where)
 	} else {
-		results, err = s.table.VectorSearch(ctx, "vector", queryVectorFloat32, limit)
+		results, err = table.VectorSearch(ctx, "vector", queryVectorFloat32, limit)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to search: %w", err)
@@ -354,12 +700,143 @@ func (s *LanceDBStore) Search(queryVector []float64, limit int, filter string) (
 	return results, nil
 }
 
+// GetChunk returns the stored row for a chunk ID (without its vector), or nil
+// if no table has a chunk with that ID
+func (s *LanceDBStore) GetChunk(chunkID string) (map[string]interface{}, error) {
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	columns := rowColumns()
+	limit := 1
+	for _, embeddingType := range EmbeddingTypes {
+		table, ok := tables[embeddingType]
+		if !ok {
+			continue
+		}
+		rows, err := table.Select(ctx, contracts.QueryConfig{
+			Columns: columns,
+			Where:   fmt.Sprintf("chunk_id = '%s'", escapeSQLString(chunkID)),
+			Limit:   &limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		if len(rows) > 0 {
+			return rows[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetChunks returns the stored rows (without vectors) for several chunk IDs,
+// read with one query per table
+func (s *LanceDBStore) GetChunks(chunkIDs []string) (map[string]map[string]interface{}, error) {
+	found := make(map[string]map[string]interface{}, len(chunkIDs))
+	if len(chunkIDs) == 0 {
+		return found, nil
+	}
+
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	where := "chunk_id IN (" + chunkIDList(chunkIDs) + ")"
+	limit := len(chunkIDs)
+	for _, embeddingType := range EmbeddingTypes {
+		table, ok := tables[embeddingType]
+		if !ok {
+			continue
+		}
+		rows, err := table.Select(ctx, contracts.QueryConfig{Columns: rowColumns(), Where: where, Limit: &limit})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunks: %w", err)
+		}
+		for _, row := range rows {
+			if id, ok := row["chunk_id"].(string); ok {
+				found[id] = row
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// FileChunks returns the stored rows (without vectors) for every chunk of a file
+func (s *LanceDBStore) FileChunks(filePath string) ([]map[string]interface{}, error) {
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	where := fmt.Sprintf("file_path = '%s'", escapeSQLString(filePath))
+	if s.project != "" {
+		where += " AND " + SearchFilter{Project: s.project}.sqlWhere()
+	}
+
+	var rows []map[string]interface{}
+	for _, embeddingType := range EmbeddingTypes {
+		table, ok := tables[embeddingType]
+		if !ok {
+			continue
+		}
+		count, err := table.Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count rows: %w", err)
+		}
+		if count == 0 {
+			continue
+		}
+		limit := int(count)
+		tableRows, err := table.Select(ctx, contracts.QueryConfig{Columns: rowColumns(), Where: where, Limit: &limit})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file chunks: %w", err)
+		}
+		rows = append(rows, tableRows...)
+	}
+
+	return rows, nil
+}
+
+// DeleteChunks deletes chunks by ID from every table
+func (s *LanceDBStore) DeleteChunks(chunkIDs []string) error {
+	if len(chunkIDs) == 0 {
+		return nil
+	}
+
+	filter := "chunk_id IN (" + chunkIDList(chunkIDs) + ")"
+
+	ctx := context.Background()
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+
+	for _, table := range tables {
+		if err := table.Delete(ctx, filter); err != nil {
+			return fmt.Errorf("failed to delete chunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rowColumns returns every column except the vector, for reading chunk rows
+func rowColumns() []string {
+	var columns []string
+	for _, field := range newSchema(1, VectorPrecisionFloat32).Fields() {
+		if field.Name != "vector" {
+			columns = append(columns, field.Name)
+		}
+	}
+	return columns
+}
+
 // Close closes the database connection
 func (s *LanceDBStore) Close() error {
-	if s.table != nil {
-		if err := s.table.Close(); err != nil {
+	for embeddingType, table := range s.tables {
+		if err := table.Close(); err != nil {
 			return fmt.Errorf("failed to close table: %w", err)
 		}
+		delete(s.tables, embeddingType)
 	}
 	if s.conn != nil {
 		if err := s.conn.Close(); err != nil {
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestListFilePaths_ReadsPastDefaultLimit(t *testing.T) {
+	store := newMemStore(newMemConn())
+	var paths []string
+	for i := 0; i < memDefaultLimit*3; i++ {
+		paths = append(paths, fmt.Sprintf("file%02d.go", i))
+	}
+	chunks, vectors := memChunks("code", paths...)
+	if err := store.StoreChunks(chunks, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := store.ListFilePaths()
+	if err != nil {
+		t.Fatalf("ListFilePaths failed: %v", err)
+	}
+	if len(listed) != len(paths) {
+		t.Errorf("expected all %d files listed, got %d", len(paths), len(listed))
+	}
+}
+
+func TestReplaceChunks_FailedAddKeepsOldRows(t *testing.T) {
+	conn := newMemConn()
+	store := newMemStore(conn)
+	old, oldVectors := memChunks("code", "a.go", "b.go")
+	if err := store.StoreChunks(old, oldVectors); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.tables[DefaultTableName].addErr = errors.New("disk full")
+	updated, updatedVectors := memChunks("code", "a.go")
+	updated[0].ID = "new"
+	if err := store.ReplaceChunks([]string{"a.go"}, updated, updatedVectors); err == nil {
+		t.Fatal("expected ReplaceChunks to fail")
+	}
+	if rows := conn.tables[DefaultTableName].rows; len(rows) != 2 {
+		t.Errorf("expected the 2 old rows kept, got %d", len(rows))
+	}
+
+	conn.tables[DefaultTableName].addErr = nil
+	if err := store.ReplaceChunks([]string{"a.go"}, updated, updatedVectors); err != nil {
+		t.Fatalf("ReplaceChunks failed: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, row := range conn.tables[DefaultTableName].rows {
+		ids[row["chunk_id"].(string)] = true
+	}
+	if len(ids) != 2 || !ids["new"] || !ids["code:b.go"] {
+		t.Errorf("expected a.go's old row replaced and b.go's kept, got %v", ids)
+	}
+}
+
+func TestGetChunks(t *testing.T) {
+	store := newMemStore(newMemConn())
+	chunks, vectors := memChunks("code", "a.go", "b.go", "c.go")
+	if err := store.StoreChunks(chunks, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.GetChunks([]string{"code:a.go", "code:c.go", "missing"})
+	if err != nil {
+		t.Fatalf("GetChunks failed: %v", err)
+	}
+	if len(found) != 2 || found["code:a.go"]["file_path"] != "a.go" || found["code:c.go"]["file_path"] != "c.go" {
+		t.Errorf("expected the rows of the two stored IDs, got %v", found)
+	}
+	if _, ok := found["code:a.go"]["vector"]; ok {
+		t.Error("expected rows without their vectors")
+	}
+}
@@ -0,0 +1,430 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/lancedb/lancedb-go/pkg/contracts"
+	"github.com/lancedb/lancedb-go/pkg/lancedb"
+)
+
+// memDefaultLimit is how many rows a memTable select returns without a
+// limit, like LanceDB, which caps plain queries at 10
+const memDefaultLimit = 10
+
+// memConn is an in-memory LanceDB connection for testing LanceDBStore
+type memConn struct {
+	tables map[string]*memTableData
+}
+
+func newMemConn() *memConn {
+	return &memConn{tables: make(map[string]*memTableData)}
+}
+
+// newMemStore returns a LanceDBStore backed by conn
+func newMemStore(conn *memConn) *LanceDBStore {
+	return &LanceDBStore{conn: conn, tables: make(map[string]contracts.ITable), dbDir: "mem://", remote: true}
+}
+
+// writeMemTable creates a table with vectors of dimension 4 at the given precision holding chunks
+func writeMemTable(conn *memConn, name, precision string, chunks []chunker.Chunk, vectors [][]float64) error {
+	lanceSchema, err := lancedb.NewSchema(newSchema(4, precision))
+	if err != nil {
+		return err
+	}
+	table, err := conn.CreateTable(context.Background(), name, lanceSchema)
+	if err != nil {
+		return err
+	}
+	record, err := buildRecord(chunks, vectors, precision)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+	return table.Add(context.Background(), record, nil)
+}
+
+func (c *memConn) Close() error   { return nil }
+func (c *memConn) IsClosed() bool { return false }
+
+func (c *memConn) TableNames(context.Context) ([]string, error) {
+	var names []string
+	for name := range c.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *memConn) OpenTable(_ context.Context, name string) (contracts.ITable, error) {
+	data, ok := c.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", name)
+	}
+	return &memTable{name: name, data: data}, nil
+}
+
+func (c *memConn) CreateTable(_ context.Context, name string, schema contracts.ISchema) (contracts.ITable, error) {
+	if _, ok := c.tables[name]; ok {
+		return nil, fmt.Errorf("table %s already exists", name)
+	}
+	data := &memTableData{schema: schema.ToArrowSchema(), version: 1}
+	c.tables[name] = data
+	return &memTable{name: name, data: data}, nil
+}
+
+func (c *memConn) DropTable(_ context.Context, name string) error {
+	if _, ok := c.tables[name]; !ok {
+		return fmt.Errorf("table %s not found", name)
+	}
+	delete(c.tables, name)
+	return nil
+}
+
+// memTableData is a table's rows, shared by every handle opened on it
+type memTableData struct {
+	schema  *arrow.Schema
+	rows    []map[string]interface{}
+	version int
+	addErr  error  // Returned by Add, when set
+	onRead  func() // Called by Select before reading, when set
+}
+
+// memTable is an open handle on an in-memory table
+type memTable struct {
+	name string
+	data *memTableData
+}
+
+func (t *memTable) Name() string { return t.name }
+func (t *memTable) IsOpen() bool { return true }
+func (t *memTable) Close() error { return nil }
+
+func (t *memTable) Schema(context.Context) (*arrow.Schema, error) { return t.data.schema, nil }
+
+func (t *memTable) Add(_ context.Context, record arrow.Record, _ *contracts.AddDataOptions) error {
+	if t.data.addErr != nil {
+		return t.data.addErr
+	}
+	for i := 0; i < int(record.NumRows()); i++ {
+		row := make(map[string]interface{})
+		for j, field := range record.Schema().Fields() {
+			row[field.Name] = memValue(record.Column(j), i)
+		}
+		t.data.rows = append(t.data.rows, row)
+	}
+	t.data.version++
+	return nil
+}
+
+// memValue returns a column value as LanceDB's JSON results decode it;
+// float16 vectors come back unreadable, as they do from lancedb-go
+func memValue(column arrow.Array, i int) interface{} {
+	switch c := column.(type) {
+	case *array.String:
+		return c.Value(i)
+	case *array.Int32:
+		return float64(c.Value(i))
+	case *array.Boolean:
+		return c.Value(i)
+	case *array.FixedSizeList:
+		size := int(c.DataType().(*arrow.FixedSizeListType).Len())
+		values := make([]interface{}, size)
+		for j := range values {
+			switch v := c.ListValues().(type) {
+			case *array.Float32:
+				values[j] = float64(v.Value(i*size + j))
+			default:
+				values[j] = "Unsupported type: Float16"
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func (t *memTable) AddRecords(ctx context.Context, records []arrow.Record, options *contracts.AddDataOptions) error {
+	for _, record := range records {
+		if err := t.Add(ctx, record, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *memTable) Query() contracts.IQueryBuilder { return nil }
+
+func (t *memTable) Count(context.Context) (int64, error) { return int64(len(t.data.rows)), nil }
+
+func (t *memTable) Version(context.Context) (int, error) { return t.data.version, nil }
+
+func (t *memTable) Update(_ context.Context, filter string, updates map[string]interface{}) error {
+	for _, row := range t.data.rows {
+		matched, err := memMatch(filter, row)
+		if err != nil {
+			return err
+		}
+		if matched {
+			for column, value := range updates {
+				if s, ok := value.(string); ok {
+					value = strings.ReplaceAll(s, "''", "'")
+				}
+				row[column] = value
+			}
+		}
+	}
+	t.data.version++
+	return nil
+}
+
+func (t *memTable) Delete(_ context.Context, filter string) error {
+	var kept []map[string]interface{}
+	for _, row := range t.data.rows {
+		matched, err := memMatch(filter, row)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			kept = append(kept, row)
+		}
+	}
+	t.data.rows = kept
+	t.data.version++
+	return nil
+}
+
+func (t *memTable) CreateIndex(context.Context, []string, contracts.IndexType) error { return nil }
+
+func (t *memTable) CreateIndexWithName(context.Context, []string, contracts.IndexType, string) error {
+	return nil
+}
+
+func (t *memTable) GetAllIndexes(context.Context) ([]contracts.IndexInfo, error) { return nil, nil }
+
+func (t *memTable) Select(_ context.Context, config contracts.QueryConfig) ([]map[string]interface{}, error) {
+	if config.VectorSearch != nil || config.FTSSearch != nil {
+		return nil, errors.New("memTable only supports plain selects")
+	}
+	if t.data.onRead != nil {
+		t.data.onRead()
+	}
+	limit := memDefaultLimit
+	if config.Limit != nil {
+		limit = *config.Limit
+	}
+	offset := 0
+	if config.Offset != nil {
+		offset = *config.Offset
+	}
+	var rows []map[string]interface{}
+	for _, row := range t.data.rows {
+		if len(rows) == limit {
+			break
+		}
+		if config.Where != "" {
+			matched, err := memMatch(config.Where, row)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(config.Columns) == 0 {
+			rows = append(rows, row)
+			continue
+		}
+		selected := make(map[string]interface{}, len(config.Columns))
+		for _, column := range config.Columns {
+			selected[column] = row[column]
+		}
+		rows = append(rows, selected)
+	}
+	return rows, nil
+}
+
+func (t *memTable) SelectWithColumns(ctx context.Context, columns []string) ([]map[string]interface{}, error) {
+	return t.Select(ctx, contracts.QueryConfig{Columns: columns})
+}
+
+func (t *memTable) SelectWithFilter(ctx context.Context, filter string) ([]map[string]interface{}, error) {
+	return t.Select(ctx, contracts.QueryConfig{Where: filter})
+}
+
+func (t *memTable) VectorSearch(context.Context, string, []float32, int) ([]map[string]interface{}, error) {
+	return nil, errors.New("memTable doesn't support vector search")
+}
+
+func (t *memTable) VectorSearchWithFilter(context.Context, string, []float32, int, string) ([]map[string]interface{}, error) {
+	return nil, errors.New("memTable doesn't support vector search")
+}
+
+func (t *memTable) FullTextSearch(context.Context, string, string) ([]map[string]interface{}, error) {
+	return nil, errors.New("memTable doesn't support full-text search")
+}
+
+func (t *memTable) FullTextSearchWithFilter(context.Context, string, string, string) ([]map[string]interface{}, error) {
+	return nil, errors.New("memTable doesn't support full-text search")
+}
+
+func (t *memTable) SelectWithLimit(ctx context.Context, limit int, offset int) ([]map[string]interface{}, error) {
+	return t.Select(ctx, contracts.QueryConfig{Limit: &limit, Offset: &offset})
+}
+
+// memMatch evaluates the SQL filters LanceDBStore writes against a row:
+// comparisons (=, IN, NOT IN, LIKE 'prefix%') joined by AND and OR, with parentheses
+func memMatch(filter string, row map[string]interface{}) (bool, error) {
+	p := &memFilterParser{tokens: memTokenize(filter), row: row}
+	matched, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q in filter %q", p.tokens[p.pos], filter)
+	}
+	return matched, err
+}
+
+// memTokenize splits a filter into words, punctuation and quoted strings
+// (kept with their quotes)
+func memTokenize(filter string) []string {
+	var tokens []string
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ':
+			i++
+		case c == '(' || c == ')' || c == ',' || c == '=':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'':
+			j := i + 1
+			for j < len(filter) {
+				if filter[j] == '\'' {
+					if j+1 < len(filter) && filter[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(filter) && !strings.ContainsRune(" (),='", rune(filter[j])) {
+				j++
+			}
+			tokens = append(tokens, filter[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+type memFilterParser struct {
+	tokens []string
+	pos    int
+	row    map[string]interface{}
+}
+
+func (p *memFilterParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+func (p *memFilterParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *memFilterParser) or() (bool, error) {
+	matched, err := p.and()
+	for err == nil && p.peek() == "OR" {
+		p.next()
+		var right bool
+		right, err = p.and()
+		matched = matched || right
+	}
+	return matched, err
+}
+
+func (p *memFilterParser) and() (bool, error) {
+	matched, err := p.comparison()
+	for err == nil && p.peek() == "AND" {
+		p.next()
+		var right bool
+		right, err = p.comparison()
+		matched = matched && right
+	}
+	return matched, err
+}
+
+func (p *memFilterParser) comparison() (bool, error) {
+	if p.peek() == "(" {
+		p.next()
+		matched, err := p.or()
+		if err == nil && p.next() != ")" {
+			err = errors.New("missing ) in filter")
+		}
+		return matched, err
+	}
+
+	value := fmt.Sprint(p.row[p.next()])
+	switch op := p.next(); op {
+	case "=":
+		return value == memLiteral(p.next()), nil
+	case "LIKE":
+		return strings.HasPrefix(value, strings.TrimSuffix(memLiteral(p.next()), "%")), nil
+	case "IN":
+		return p.in(value)
+	case "NOT":
+		if p.next() != "IN" {
+			return false, errors.New("expected IN after NOT")
+		}
+		matched, err := p.in(value)
+		return !matched, err
+	default:
+		return false, fmt.Errorf("unsupported operator %q", op)
+	}
+}
+
+func (p *memFilterParser) in(value string) (bool, error) {
+	if p.next() != "(" {
+		return false, errors.New("expected ( after IN")
+	}
+	matched := false
+	for {
+		if memLiteral(p.next()) == value {
+			matched = true
+		}
+		switch p.next() {
+		case ",":
+			continue
+		case ")":
+			return matched, nil
+		default:
+			return false, errors.New("unterminated IN list")
+		}
+	}
+}
+
+// memLiteral returns the value of a quoted string or bare literal token
+func memLiteral(token string) string {
+	if strings.HasPrefix(token, "'") {
+		return strings.ReplaceAll(token[1:len(token)-1], "''", "'")
+	}
+	return token
+}
@@ -12,26 +12,125 @@ const metadataFileName = "metadata.json"
 
 // IndexMetadata tracks indexing state
 type IndexMetadata struct {
-	LastIndexTime time.Time              `json:"last_index_time"`
-	FileModTimes  map[string]time.Time   `json:"file_mod_times"` // file path -> modification time
+	LastIndexTime time.Time            `json:"last_index_time"`
+	FileModTimes  map[string]time.Time `json:"file_mod_times"`           // file path -> modification time
+	FileHashes    map[string]string    `json:"file_hashes,omitempty"`    // file path -> SHA256 of file content
+	GitCommit     string               `json:"git_commit,omitempty"`     // HEAD commit at index time (empty outside git)
+	GitDirty      bool                 `json:"git_dirty,omitempty"`      // True if the working tree had uncommitted changes
+	SchemaVersion int                  `json:"schema_version,omitempty"` // Table schema version (see CurrentSchemaVersion)
+	// EmbeddingModels records the model that produced each embedding space ("code", "docs")
+	EmbeddingModels map[string]EmbeddingModel `json:"embedding_models,omitempty"`
+	// RelativePaths is set once the stored file paths are relative to the
+	// project root (see RootedStore)
+	RelativePaths bool `json:"relative_paths,omitempty"`
 }
 
-// LoadMetadata loads metadata from disk
+// EmbeddingModel identifies the model and vector dimension used for an embedding space
+type EmbeddingModel struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+}
+
+// ValidateEmbeddingModel returns an error if the embedding space was built with a
+// different model or dimension. A zero dimension skips the dimension check, and
+// spaces with no recorded model (older indexes) always pass.
+func (m *IndexMetadata) ValidateEmbeddingModel(embeddingType, model string, dimension int) error {
+	recorded, ok := m.EmbeddingModels[embeddingType]
+	if !ok {
+		return nil
+	}
+	mismatch := &ModelMismatchError{
+		EmbeddingType:    embeddingType,
+		IndexedModel:     recorded.Model,
+		IndexedDimension: recorded.Dimension,
+		Model:            model,
+		Dimension:        dimension,
+	}
+	if recorded.Model != model {
+		return mismatch
+	}
+	if dimension != 0 && recorded.Dimension != 0 && recorded.Dimension != dimension {
+		return mismatch
+	}
+	return nil
+}
+
+// ModelMismatchError is an embedding model or dimension that differs from the
+// one an embedding space was built with
+type ModelMismatchError struct {
+	EmbeddingType    string // "code" or "docs"
+	IndexedModel     string
+	IndexedDimension int
+	Model            string
+	Dimension        int // 0 if only the model was checked
+}
+
+func (e *ModelMismatchError) Error() string {
+	if e.IndexedModel != e.Model {
+		return fmt.Sprintf("index built with %s model %q but %q is configured; reindex required (delete %s/ and run 'code-scout index')",
+			e.EmbeddingType, e.IndexedModel, e.Model, DefaultDBDir)
+	}
+	return fmt.Sprintf("index built with %d-dimensional %s embeddings but model %q returned %d dimensions; reindex required (delete %s/ and run 'code-scout index')",
+		e.IndexedDimension, e.EmbeddingType, e.Model, e.Dimension, DefaultDBDir)
+}
+
+// RecordEmbeddingModel records the model and dimension used for an embedding space
+func (m *IndexMetadata) RecordEmbeddingModel(embeddingType, model string, dimension int) {
+	if m.EmbeddingModels == nil {
+		m.EmbeddingModels = make(map[string]EmbeddingModel)
+	}
+	m.EmbeddingModels[embeddingType] = EmbeddingModel{Model: model, Dimension: dimension}
+}
+
+// LoadMetadata loads metadata from disk, or from the object store for remote stores
 func (s *LanceDBStore) LoadMetadata() (*IndexMetadata, error) {
-	metadataPath := filepath.Join(s.dbDir, metadataFileName)
-	
+	if s.remote {
+		return s.loadRemoteMetadata()
+	}
+	if s.project != "" {
+		return s.loadProjectMetadata()
+	}
+	return loadMetadata(s.dbDir)
+}
+
+// SaveMetadata saves metadata to disk, or to the object store for remote stores
+func (s *LanceDBStore) SaveMetadata(metadata *IndexMetadata) error {
+	if s.remote {
+		return s.saveRemoteMetadata(metadata)
+	}
+	if s.project != "" {
+		return s.saveProjectMetadata(metadata)
+	}
+	return saveMetadata(s.dbDir, metadata)
+}
+
+// loadMetadata loads metadata from the given index directory
+func loadMetadata(dbDir string) (*IndexMetadata, error) {
+	metadataPath := filepath.Join(dbDir, metadataFileName)
+
 	data, err := os.ReadFile(metadataPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Return empty metadata if file doesn't exist
-			return &IndexMetadata{
-				LastIndexTime: time.Time{},
-				FileModTimes:  make(map[string]time.Time),
-			}, nil
+			return emptyMetadata(), nil
 		}
 		return nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
 
+	return decodeMetadata(data)
+}
+
+// emptyMetadata returns metadata for an index that has never been written
+func emptyMetadata() *IndexMetadata {
+	return &IndexMetadata{
+		LastIndexTime: time.Time{},
+		FileModTimes:  make(map[string]time.Time),
+		FileHashes:    make(map[string]string),
+	}
+}
+
+// decodeMetadata parses serialized metadata
+func decodeMetadata(data []byte) (*IndexMetadata, error) {
 	var metadata IndexMetadata
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("failed to parse metadata: %w", err)
@@ -40,14 +139,17 @@ func (s *LanceDBStore) LoadMetadata() (*IndexMetadata, error) {
 	if metadata.FileModTimes == nil {
 		metadata.FileModTimes = make(map[string]time.Time)
 	}
+	if metadata.FileHashes == nil {
+		metadata.FileHashes = make(map[string]string)
+	}
 
 	return &metadata, nil
 }
 
-// SaveMetadata saves metadata to disk
-func (s *LanceDBStore) SaveMetadata(metadata *IndexMetadata) error {
-	metadataPath := filepath.Join(s.dbDir, metadataFileName)
-	
+// saveMetadata saves metadata to the given index directory
+func saveMetadata(dbDir string, metadata *IndexMetadata) error {
+	metadataPath := filepath.Join(dbDir, metadataFileName)
+
 	data, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// CurrentSchemaVersion is the table schema version written by this build.
+// Bump it and append to migrations whenever the Arrow schema changes.
+const CurrentSchemaVersion = 5
+
+// migration upgrades table rows to a new schema version
+type migration struct {
+	version     int    // Schema version produced by this migration
+	description string // Human-readable summary shown when the migration runs
+	// apply rewrites a page of the rows read from one embedding type's table
+	// under the previous schema. It returns the rows to keep, plus the paths of
+	// files whose chunks were dropped and must be re-embedded. Columns missing
+	// from the old table are read as zero values, so migrations that only add
+	// columns can leave apply nil.
+	apply func(embeddingType string, chunks []chunker.Chunk, embeddings [][]float64) ([]chunker.Chunk, [][]float64, []string)
+}
+
+// migrations lists every schema change in order, starting after version 1
+// (the original layout, used by indexes created before versioning existed)
+var migrations = []migration{
+	{
+		// Existing rows keep empty values until their files are re-indexed
+		version:     2,
+		description: "add name and metadata columns",
+	},
+	{
+		version:     3,
+		description: "move docs chunks to their own table",
+		apply:       dropPaddedDocsChunks,
+	},
+	{
+		// Only the global index sets a project; per-project indexes leave it empty
+		version:     4,
+		description: "add project column",
+	},
+	{
+		// buildRecord derives is_test from the file path, so rewriting the rows fills it in
+		version:     5,
+		description: "add is_test column",
+	},
+}
+
+// dropPaddedDocsChunks removes docs chunks from the shared table. Their vectors
+// were zero-padded to the code dimension and the original length is unknown, so
+// the files are re-embedded into the docs table instead.
+func dropPaddedDocsChunks(embeddingType string, chunks []chunker.Chunk, embeddings [][]float64) ([]chunker.Chunk, [][]float64, []string) {
+	if embeddingType != "code" {
+		return chunks, embeddings, nil
+	}
+
+	var (
+		keptChunks     []chunker.Chunk
+		keptEmbeddings [][]float64
+		dropped        []string
+	)
+	seen := make(map[string]bool)
+	for i, chunk := range chunks {
+		if chunk.EmbeddingType != "docs" {
+			keptChunks = append(keptChunks, chunk)
+			keptEmbeddings = append(keptEmbeddings, embeddings[i])
+			continue
+		}
+		if !seen[chunk.FilePath] {
+			seen[chunk.FilePath] = true
+			dropped = append(dropped, chunk.FilePath)
+		}
+	}
+
+	return keptChunks, keptEmbeddings, dropped
+}
+
+// EffectiveSchemaVersion returns the effective schema version recorded in metadata.
+// Indexes created before versioning have no recorded version and use schema 1.
+func (m *IndexMetadata) EffectiveSchemaVersion() int {
+	if m.SchemaVersion == 0 {
+		return 1
+	}
+	return m.SchemaVersion
+}
+
+// CheckSchemaVersion returns an error if the index needs a migration or was
+// written by a newer build of code-scout
+func CheckSchemaVersion(metadata *IndexMetadata) error {
+	version := metadata.EffectiveSchemaVersion()
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("index schema version %d is newer than supported version %d; upgrade code-scout", version, CurrentSchemaVersion)
+	}
+	if version < CurrentSchemaVersion {
+		return fmt.Errorf("index schema version %d is older than current version %d; run 'code-scout index' to migrate", version, CurrentSchemaVersion)
+	}
+	return nil
+}
+
+// Migrate upgrades every table to CurrentSchemaVersion, rewriting existing rows
+// with any new columns, and records the new version in metadata. Files whose
+// chunks a migration dropped have their remaining rows deleted from every
+// table and are removed from metadata, so the next index run re-embeds them
+// from scratch. Returns the descriptions of the migrations that were applied.
+// The caller is responsible for saving metadata afterwards.
+func (s *LanceDBStore) Migrate(metadata *IndexMetadata) ([]string, error) {
+	ctx := context.Background()
+
+	// A rewrite that stopped part way may have left only a staging table
+	if err := s.recoverRewrites(ctx); err != nil {
+		return nil, err
+	}
+
+	tables := s.openExistingTables(ctx)
+	defer closeTables(tables)
+	if len(tables) == 0 {
+		// No tables yet: they will be created with the current schema
+		metadata.SchemaVersion = CurrentSchemaVersion
+		return nil, nil
+	}
+
+	version := metadata.EffectiveSchemaVersion()
+	if version > CurrentSchemaVersion {
+		return nil, CheckSchemaVersion(metadata)
+	}
+
+	var pending []migration
+	var applied []string
+	for _, m := range migrations {
+		if m.version > version {
+			pending = append(pending, m)
+			applied = append(applied, fmt.Sprintf("v%d: %s", m.version, m.description))
+		}
+	}
+	if len(pending) == 0 {
+		metadata.SchemaVersion = CurrentSchemaVersion
+		return nil, nil
+	}
+
+	var dropped []string
+	for _, embeddingType := range EmbeddingTypes {
+		table, ok := tables[embeddingType]
+		if !ok {
+			continue
+		}
+
+		dimension, precision, err := tableVectorType(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		// float16 vectors can't be read back, so drop the table and re-embed its files
+		if precision == VectorPrecisionFloat16 {
+			_, err := forEachPage(ctx, table, []string{"file_path"}, func(rows []map[string]interface{}) error {
+				for _, row := range rows {
+					dropped = append(dropped, rowString(row, "file_path"))
+				}
+				return nil
+			})
+			table.Close()
+			delete(tables, embeddingType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rows for migration: %w", err)
+			}
+			if err := s.conn.DropTable(ctx, tableName(embeddingType)); err != nil {
+				return nil, fmt.Errorf("failed to drop %s table: %w", embeddingType, err)
+			}
+			continue
+		}
+		table.Close()
+		delete(tables, embeddingType)
+
+		transform := func(chunks []chunker.Chunk, embeddings [][]float64) ([]chunker.Chunk, [][]float64, []string) {
+			var dropped []string
+			for _, m := range pending {
+				if m.apply == nil {
+					continue
+				}
+				var files []string
+				chunks, embeddings, files = m.apply(embeddingType, chunks, embeddings)
+				dropped = append(dropped, files...)
+			}
+			return chunks, embeddings, dropped
+		}
+
+		// Rewriting with the current schema adds any new columns
+		_, files, err := s.rewriteTable(ctx, embeddingType, dimension, transform)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate %s table: %w", embeddingType, err)
+		}
+		dropped = append(dropped, files...)
+	}
+
+	// A dropped file's rows in the other table (its docs, TODO and fenced code
+	// rows, say) go too, or re-indexing it as a new file would duplicate them
+	slices.Sort(dropped)
+	dropped = slices.Compact(dropped)
+	if err := s.DeleteChunksByFilePath(dropped); err != nil {
+		return nil, fmt.Errorf("failed to delete chunks of dropped files: %w", err)
+	}
+	for _, path := range dropped {
+		delete(metadata.FileModTimes, path)
+		delete(metadata.FileHashes, path)
+	}
+
+	metadata.SchemaVersion = CurrentSchemaVersion
+	return applied, nil
+}
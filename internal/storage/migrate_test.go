@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// memChunks returns a chunk of embeddingType for each path, with unit vectors of dimension 4
+func memChunks(embeddingType string, paths ...string) ([]chunker.Chunk, [][]float64) {
+	chunks := make([]chunker.Chunk, len(paths))
+	vectors := make([][]float64, len(paths))
+	for i, path := range paths {
+		chunks[i] = chunker.Chunk{
+			ID:            embeddingType + ":" + path,
+			FilePath:      path,
+			LineStart:     1,
+			LineEnd:       2,
+			Language:      "go",
+			Code:          "func F() {}",
+			EmbeddingType: embeddingType,
+		}
+		vectors[i] = []float64{1, 0, 0, 0}
+	}
+	return chunks, vectors
+}
+
+func TestMigrate_Float16TableDeletesDroppedFilesEverywhere(t *testing.T) {
+	conn := newMemConn()
+	store := newMemStore(conn)
+	// A float16 docs table, as earlier builds could write
+	docs, docsVectors := memChunks("docs", "a.go")
+	if err := writeMemTable(conn, DocsTableName, VectorPrecisionFloat16, docs, docsVectors); err != nil {
+		t.Fatal(err)
+	}
+	code, codeVectors := memChunks("code", "a.go", "b.go")
+	if err := store.StoreChunks(code, codeVectors); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	metadata := &IndexMetadata{
+		SchemaVersion: CurrentSchemaVersion - 1,
+		FileModTimes:  map[string]time.Time{"a.go": now, "b.go": now},
+		FileHashes:    map[string]string{"a.go": "a", "b.go": "b"},
+	}
+	if _, err := store.Migrate(metadata); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	if _, ok := conn.tables[DocsTableName]; ok {
+		t.Error("expected the float16 docs table to be dropped")
+	}
+	paths, err := store.ListFilePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "b.go" {
+		t.Errorf("expected only b.go left in the code table, got %v", paths)
+	}
+	if _, ok := metadata.FileModTimes["a.go"]; ok {
+		t.Error("expected a.go cleared from metadata so it's re-embedded")
+	}
+	if _, ok := metadata.FileModTimes["b.go"]; !ok {
+		t.Error("expected b.go kept in metadata")
+	}
+	if metadata.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion, metadata.SchemaVersion)
+	}
+}
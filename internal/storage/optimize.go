@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/jlanders/code-scout/internal/chunker"
+	"github.com/lancedb/lancedb-go/pkg/contracts"
+	"github.com/lancedb/lancedb-go/pkg/lancedb"
+)
+
+// rewriteStagingSuffix names the table holding a copy of the data while a table is rewritten
+const rewriteStagingSuffix = "_rewrite"
+
+// OptimizeResult reports what an Optimize run did
+type OptimizeResult struct {
+	Rows           int64 // Number of rows in the tables compacted
+	VersionsBefore int   // Sum of the compacted tables' versions before optimizing
+	SizeBefore     int64 // Size of the database directory in bytes before optimizing (0 for remote stores)
+	SizeAfter      int64 // Size of the database directory in bytes after optimizing (0 for remote stores)
+	IndexBuilt     bool  // True if a vector index was (re)built
+	// VectorIndexes lists the ANN index tuned for each table, by embedding type
+	VectorIndexes []VectorIndex
+	// Uncompacted lists the tables left as they were; their vector indexes
+	// are still rebuilt
+	Uncompacted []UncompactedTable
+}
+
+// UncompactedTable is a table Optimize couldn't compact, and why
+type UncompactedTable struct {
+	EmbeddingType string
+	Rows          int64
+	Reason        string
+}
+
+// Reclaimed returns the number of bytes freed by optimizing
+func (r *OptimizeResult) Reclaimed() int64 {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// Optimize compacts each table by rewriting all rows into a fresh dataset, which
+// merges fragments left behind by delete+add cycles and discards old versions,
+// then rebuilds the vector and full-text indexes. The vector index is tuned to
+// each table's row count and dimension (see tuneVectorIndex).
+func (s *LanceDBStore) Optimize() (*OptimizeResult, error) {
+	ctx := context.Background()
+
+	sizeBefore, err := s.size()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recoverRewrites(ctx); err != nil {
+		return nil, err
+	}
+
+	tables := s.openExistingTables(ctx)
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("failed to open table: no tables found in %s", s.dbDir)
+	}
+
+	result := &OptimizeResult{SizeBefore: sizeBefore}
+	for _, embeddingType := range EmbeddingTypes {
+		table, ok := tables[embeddingType]
+		if !ok {
+			continue
+		}
+
+		version, err := table.Version(ctx)
+		if err != nil {
+			closeTables(tables)
+			return nil, fmt.Errorf("failed to read table version: %w", err)
+		}
+		dimension, precision, err := tableVectorType(ctx, table)
+		if err != nil {
+			closeTables(tables)
+			return nil, err
+		}
+		// lancedb-go can't read float16 vectors back, so the rows can't be rewritten
+		if precision == VectorPrecisionFloat16 {
+			count, err := table.Count(ctx)
+			if err != nil {
+				closeTables(tables)
+				return nil, fmt.Errorf("failed to count %s rows: %w", embeddingType, err)
+			}
+			result.Uncompacted = append(result.Uncompacted, UncompactedTable{
+				EmbeddingType: embeddingType,
+				Rows:          count,
+				Reason:        "float16 vectors can't be read back to rewrite",
+			})
+			continue
+		}
+		table.Close()
+		delete(tables, embeddingType)
+
+		rows, _, err := s.rewriteTable(ctx, embeddingType, dimension, nil)
+		if err != nil {
+			closeTables(tables)
+			return nil, err
+		}
+		result.Rows += rows
+		result.VersionsBefore += version
+	}
+
+	if err := s.OpenTable(); err != nil {
+		return nil, err
+	}
+
+	// The vector index is re-tuned to each table's current size and dimension
+	for _, embeddingType := range EmbeddingTypes {
+		table, ok := s.tables[embeddingType]
+		if !ok {
+			continue
+		}
+		count, err := table.Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s rows: %w", embeddingType, err)
+		}
+		dimension, _, err := tableVectorType(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		index := tuneVectorIndex(embeddingType, count, dimension)
+		result.VectorIndexes = append(result.VectorIndexes, index)
+		// Small tables are fast enough without an index
+		if index.Type == VectorIndexNone {
+			continue
+		}
+		if err := table.CreateIndex(ctx, []string{"vector"}, index.indexType()); err != nil {
+			return nil, fmt.Errorf("failed to build %s vector index: %w", embeddingType, err)
+		}
+		result.IndexBuilt = true
+	}
+
+	// Rewriting the tables drops the full-text index as well
+	if err := s.CreateTextIndex(); err != nil {
+		return nil, err
+	}
+
+	result.SizeAfter, err = s.size()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// rewritePageRows is how many rows a rewrite reads and writes at a time, so
+// rewriting a table never holds more than one page of it in memory
+var rewritePageRows = 1000
+
+// rowTransform rewrites one page of a table's rows. It returns the rows to
+// keep, plus the paths of files whose rows it dropped.
+type rowTransform func(chunks []chunker.Chunk, embeddings [][]float64) ([]chunker.Chunk, [][]float64, []string)
+
+// rewriteTable rewrites an embedding type's table into a fresh dataset, passing
+// its rows through transform if set, and returns the number of rows read and
+// the files transform dropped. The rows are first copied into a staging table,
+// a page at a time. The main table is only replaced if its row count and
+// version are unchanged since the copy started, so rows a concurrent writer
+// added aren't lost, and the staging table is kept until the main table is
+// rewritten in full; recoverRewrites restores it on the next Optimize or
+// Migrate if the rewrite stops part way.
+func (s *LanceDBStore) rewriteTable(ctx context.Context, embeddingType string, dimension int, transform rowTransform) (int64, []string, error) {
+	if table, ok := s.tables[embeddingType]; ok {
+		table.Close()
+		delete(s.tables, embeddingType)
+	}
+
+	name := tableName(embeddingType)
+	staging := name + rewriteStagingSuffix
+
+	count, version, err := s.tableState(ctx, name)
+	if err != nil {
+		return 0, nil, err
+	}
+	rows, dropped, err := s.copyTable(ctx, name, staging, dimension, transform)
+	if err != nil {
+		s.conn.DropTable(ctx, staging)
+		return 0, nil, fmt.Errorf("failed to write staging table: %w", err)
+	}
+	countAfter, versionAfter, err := s.tableState(ctx, name)
+	if err != nil {
+		return 0, nil, err
+	}
+	if countAfter != count || versionAfter != version {
+		if err := s.conn.DropTable(ctx, staging); err != nil {
+			return 0, nil, fmt.Errorf("failed to drop staging table: %w", err)
+		}
+		return 0, nil, fmt.Errorf("%s table changed while it was being rewritten; run again when nothing else is writing the index", embeddingType)
+	}
+
+	if err := s.conn.DropTable(ctx, name); err != nil {
+		return 0, nil, fmt.Errorf("failed to drop table: %w", err)
+	}
+	if _, _, err := s.copyTable(ctx, staging, name, dimension, nil); err != nil {
+		return 0, nil, fmt.Errorf("failed to rewrite table (data preserved in %q): %w", staging, err)
+	}
+	if err := s.conn.DropTable(ctx, staging); err != nil {
+		return 0, nil, fmt.Errorf("failed to drop staging table: %w", err)
+	}
+
+	return rows, dropped, nil
+}
+
+// tableState returns a table's row count and version, read through a fresh
+// handle so writes by other processes are seen
+func (s *LanceDBStore) tableState(ctx context.Context, name string) (int64, int, error) {
+	table, err := s.conn.OpenTable(ctx, name)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open table %q: %w", name, err)
+	}
+	defer table.Close()
+	count, err := table.Count(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count rows of %q: %w", name, err)
+	}
+	version, err := table.Version(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read version of %q: %w", name, err)
+	}
+	return count, version, nil
+}
+
+// recoverRewrites finishes or cleans up after a rewriteTable that stopped part
+// way through, which leaves its staging table behind. A staging table is only
+// dropped once the main table was rewritten in full, so if the main table is
+// missing or holds fewer rows, it is restored from the staging table first.
+func (s *LanceDBStore) recoverRewrites(ctx context.Context) error {
+	names, err := s.conn.TableNames(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[name] = true
+	}
+
+	for _, embeddingType := range EmbeddingTypes {
+		name := tableName(embeddingType)
+		staging := name + rewriteStagingSuffix
+		if !exists[staging] {
+			continue
+		}
+
+		stagingTable, err := s.conn.OpenTable(ctx, staging)
+		if err != nil {
+			return fmt.Errorf("failed to open staging table %q: %w", staging, err)
+		}
+		dimension, _, err := tableVectorType(ctx, stagingTable)
+		if err != nil {
+			stagingTable.Close()
+			return err
+		}
+		staged, err := stagingTable.Count(ctx)
+		stagingTable.Close()
+		if err != nil {
+			return fmt.Errorf("failed to count rows of staging table %q: %w", staging, err)
+		}
+
+		restore := !exists[name]
+		if !restore {
+			count, _, err := s.tableState(ctx, name)
+			if err != nil {
+				return err
+			}
+			restore = count < staged
+		}
+		if restore {
+			if table, ok := s.tables[embeddingType]; ok {
+				table.Close()
+				delete(s.tables, embeddingType)
+			}
+			if exists[name] {
+				if err := s.conn.DropTable(ctx, name); err != nil {
+					return fmt.Errorf("failed to drop incomplete %s table: %w", embeddingType, err)
+				}
+			}
+			if _, _, err := s.copyTable(ctx, staging, name, dimension, nil); err != nil {
+				return fmt.Errorf("failed to restore %s table from %q: %w", embeddingType, staging, err)
+			}
+		}
+		if err := s.conn.DropTable(ctx, staging); err != nil {
+			return fmt.Errorf("failed to drop staging table %q: %w", staging, err)
+		}
+	}
+
+	return nil
+}
+
+// copyTable creates the full-precision table dst with the given vector
+// dimension and copies every row of src into it, a page at a time, passing each
+// page through transform if set. Returns the number of rows read from src and
+// the files transform dropped.
+func (s *LanceDBStore) copyTable(ctx context.Context, src, dst string, dimension int, transform rowTransform) (int64, []string, error) {
+	from, err := s.conn.OpenTable(ctx, src)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open table %q: %w", src, err)
+	}
+	defer from.Close()
+
+	lanceSchema, err := lancedb.NewSchema(newSchema(dimension, VectorPrecisionFloat32))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create Lance schema: %w", err)
+	}
+	to, err := s.conn.CreateTable(ctx, dst, lanceSchema)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create table: %w", err)
+	}
+	defer to.Close()
+
+	var dropped []string
+	read, err := forEachPage(ctx, from, nil, func(rows []map[string]interface{}) error {
+		chunks := make([]chunker.Chunk, len(rows))
+		embeddings := make([][]float64, len(rows))
+		for i, row := range rows {
+			chunks[i] = rowToChunk(row)
+			embeddings[i] = rowVector(row, "vector")
+		}
+		if transform != nil {
+			var files []string
+			chunks, embeddings, files = transform(chunks, embeddings)
+			dropped = append(dropped, files...)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		record, err := buildRecord(chunks, embeddings, VectorPrecisionFloat32)
+		if err != nil {
+			return err
+		}
+		defer record.Release()
+		if err := to.Add(ctx, record, nil); err != nil {
+			return fmt.Errorf("failed to add records: %w", err)
+		}
+		return nil
+	})
+	return read, dropped, err
+}
+
+// forEachPage reads every row of the table, rewritePageRows at a time, and
+// passes each page to fn. Columns limits the columns read; nil reads them all.
+// Returns the number of rows read.
+func forEachPage(ctx context.Context, table contracts.ITable, columns []string, fn func(rows []map[string]interface{}) error) (int64, error) {
+	count, err := table.Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	var read int64
+	for read < count {
+		limit, offset := rewritePageRows, int(read)
+		rows, err := table.Select(ctx, contracts.QueryConfig{Columns: columns, Limit: &limit, Offset: &offset})
+		if err != nil {
+			return read, fmt.Errorf("failed to read rows: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		read += int64(len(rows))
+		if err := fn(rows); err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
+
+// tableVectorType returns the vector dimension and precision of a table from its schema
+func tableVectorType(ctx context.Context, table contracts.ITable) (int, string, error) {
+	schema, err := table.Schema(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read table schema: %w", err)
+	}
+	fields, ok := schema.FieldsByName("vector")
+	if !ok || len(fields) == 0 {
+		return 0, "", fmt.Errorf("table has no vector column")
+	}
+	listType, ok := fields[0].Type.(*arrow.FixedSizeListType)
+	if !ok {
+		return 0, "", fmt.Errorf("unexpected vector column type %s", fields[0].Type)
+	}
+
+	precision := VectorPrecisionFloat32
+	if listType.Elem().ID() == arrow.FLOAT16 {
+		precision = VectorPrecisionFloat16
+	}
+	return int(listType.Len()), precision, nil
+}
+
+// rowToChunk converts a table row back into a chunk
+func rowToChunk(row map[string]interface{}) chunker.Chunk {
+	chunk := chunker.Chunk{
+		ID:            rowString(row, "chunk_id"),
+		FilePath:      rowString(row, "file_path"),
+		LineStart:     rowInt(row, "line_start"),
+		LineEnd:       rowInt(row, "line_end"),
+		Language:      rowString(row, "language"),
+		Code:          rowString(row, "code"),
+		ChunkType:     rowString(row, "chunk_type"),
+		Name:          rowString(row, "name"),
+		EmbeddingType: rowString(row, "embedding_type"),
+		Metadata:      DecodeChunkMetadata(rowString(row, "metadata")),
+	}
+
+	for _, key := range []string{"heading", "heading_level", "parent_heading", "project"} {
+		if value := rowString(row, key); value != "" {
+			chunk.Metadata[key] = value
+		}
+	}
+
+	return chunk
+}
+
+// rowString returns a string column value, or "" if missing or null
+func rowString(row map[string]interface{}, key string) string {
+	if value, ok := row[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// rowInt returns an integer column value (JSON numbers decode as float64)
+func rowInt(row map[string]interface{}, key string) int {
+	switch value := row[key].(type) {
+	case float64:
+		return int(value)
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
+
+// rowVector returns a vector column value as float64s
+func rowVector(row map[string]interface{}, key string) []float64 {
+	switch values := row[key].(type) {
+	case []interface{}:
+		vector := make([]float64, len(values))
+		for i, v := range values {
+			if f, ok := v.(float64); ok {
+				vector[i] = f
+			}
+		}
+		return vector
+	case []float32:
+		vector := make([]float64, len(values))
+		for i, v := range values {
+			vector[i] = float64(v)
+		}
+		return vector
+	}
+	return nil
+}
+
+// size returns the size of the local database directory. Remote stores
+// report 0 since object stores have no cheap way to measure a prefix.
+func (s *LanceDBStore) size() (int64, error) {
+	if s.remote {
+		return 0, nil
+	}
+	size, err := dirSize(s.dbDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure database size: %w", err)
+	}
+	return size, nil
+}
+
+// dirSize returns the total size in bytes of all files under dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
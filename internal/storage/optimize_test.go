@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptimize_ReportsFloat16TablesUncompacted(t *testing.T) {
+	conn := newMemConn()
+	store := newMemStore(conn)
+	// A float16 docs table, as earlier builds could write
+	docs, docsVectors := memChunks("docs", "README.md")
+	if err := writeMemTable(conn, DocsTableName, VectorPrecisionFloat16, docs, docsVectors); err != nil {
+		t.Fatal(err)
+	}
+	code, codeVectors := memChunks("code", "a.go", "b.go")
+	if err := store.StoreChunks(code, codeVectors); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := store.Optimize()
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if len(result.Uncompacted) != 1 || result.Uncompacted[0].EmbeddingType != "docs" || result.Uncompacted[0].Rows != 1 {
+		t.Errorf("expected the docs table reported uncompacted, got %+v", result.Uncompacted)
+	}
+	if result.Rows != 2 {
+		t.Errorf("expected the 2 code rows compacted, got %d", result.Rows)
+	}
+}
+
+func TestOptimize_RestoresMissingTableFromStaging(t *testing.T) {
+	conn := newMemConn()
+	store := newMemStore(conn)
+	chunks, vectors := memChunks("code", "a.go", "b.go")
+	if err := writeMemTable(conn, DefaultTableName+rewriteStagingSuffix, VectorPrecisionFloat32, chunks, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := store.Optimize()
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if result.Rows != 2 {
+		t.Errorf("expected the 2 staged rows restored, got %d", result.Rows)
+	}
+	if _, ok := conn.tables[DefaultTableName+rewriteStagingSuffix]; ok {
+		t.Error("expected the staging table dropped")
+	}
+}
+
+func TestOptimize_DropsLeftoverStagingTable(t *testing.T) {
+	conn := newMemConn()
+	store := newMemStore(conn)
+	chunks, vectors := memChunks("code", "a.go", "b.go")
+	if err := store.StoreChunks(chunks, vectors); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeMemTable(conn, DefaultTableName+rewriteStagingSuffix, VectorPrecisionFloat32, chunks[:1], vectors[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := store.Optimize()
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if result.Rows != 2 {
+		t.Errorf("expected the main table's 2 rows kept, got %d", result.Rows)
+	}
+	if _, ok := conn.tables[DefaultTableName+rewriteStagingSuffix]; ok {
+		t.Error("expected the staging table dropped")
+	}
+}
+
+func TestOptimize_RewritesTablesInPages(t *testing.T) {
+	prevPageRows := rewritePageRows
+	rewritePageRows = 2
+	t.Cleanup(func() { rewritePageRows = prevPageRows })
+
+	conn := newMemConn()
+	store := newMemStore(conn)
+	chunks, vectors := memChunks("code", "a.go", "b.go", "c.go", "d.go", "e.go")
+	if err := store.StoreChunks(chunks, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := store.Optimize()
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if result.Rows != 5 {
+		t.Errorf("expected 5 rows compacted, got %d", result.Rows)
+	}
+	paths, err := store.ListFilePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 5 {
+		t.Errorf("expected every row kept across pages, got %v", paths)
+	}
+}
+
+func TestOptimize_KeepsRowsWrittenDuringRewrite(t *testing.T) {
+	conn := newMemConn()
+	store := newMemStore(conn)
+	chunks, vectors := memChunks("code", "a.go", "b.go")
+	if err := store.StoreChunks(chunks, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another writer adds a row while the table is being copied
+	data := conn.tables[DefaultTableName]
+	late, lateVectors := memChunks("code", "late.go")
+	data.onRead = func() {
+		data.onRead = nil
+		if err := store.StoreChunks(late, lateVectors); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := store.Optimize(); err == nil || !strings.Contains(err.Error(), "changed while") {
+		t.Fatalf("expected Optimize to stop when the table changed, got %v", err)
+	}
+	if len(conn.tables[DefaultTableName].rows) != 3 {
+		t.Errorf("expected the concurrently written row kept, got %d rows", len(conn.tables[DefaultTableName].rows))
+	}
+	if _, ok := conn.tables[DefaultTableName+rewriteStagingSuffix]; ok {
+		t.Error("expected the staging table dropped")
+	}
+}
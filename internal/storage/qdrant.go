@@ -0,0 +1,831 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+const (
+	// qdrantUpsertBatchSize is the number of points sent per upsert request
+	qdrantUpsertBatchSize = 256
+	// qdrantScrollPageSize is the number of points fetched per scroll request
+	qdrantScrollPageSize = 1000
+	// qdrantTextScanPages caps how many pages of text matches FullTextSearch ranks per collection
+	qdrantTextScanPages = 10
+)
+
+// qdrantIndexedFields are the payload fields indexed for filtering
+var qdrantIndexedFields = []string{"file_path", "dirs", "language", "chunk_type", "embedding_type", "is_test"}
+
+// QdrantStore stores chunks in a remote Qdrant instance over its HTTP API, with
+// one collection per embedding space. Index metadata stays in the local
+// .code-scout/ directory.
+type QdrantStore struct {
+	baseURL     string
+	apiKey      string
+	collection  string          // collection name prefix
+	dbDir       string          // local directory for index metadata
+	collections map[string]bool // embedding types whose collection is known to exist
+	httpClient  *http.Client
+}
+
+// NewQdrantStore creates a store backed by the Qdrant instance at baseURL. Collections
+// are named "<collection>_code" and "<collection>_docs"; an empty collection name is
+// derived from the project directory name.
+func NewQdrantStore(rootDir, baseURL, apiKey, collection string) (*QdrantStore, error) {
+	dbDir := filepath.Join(rootDir, DefaultDBDir)
+	if err := os.MkdirAll(dbDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	if collection == "" {
+		collection = defaultQdrantCollection(rootDir)
+	}
+
+	return &QdrantStore{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		apiKey:      apiKey,
+		collection:  collection,
+		dbDir:       dbDir,
+		collections: make(map[string]bool),
+		httpClient:  &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+var invalidCollectionChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// defaultQdrantCollection derives a collection name prefix from the project directory
+func defaultQdrantCollection(rootDir string) string {
+	name := invalidCollectionChars.ReplaceAllString(filepath.Base(filepath.Clean(rootDir)), "_")
+	return "code_scout_" + strings.ToLower(name)
+}
+
+// collectionName returns the collection that stores chunks of the given embedding type
+func (s *QdrantStore) collectionName(embeddingType string) string {
+	return s.collection + "_" + embeddingType
+}
+
+// qdrantError is a non-2xx response from the Qdrant API
+type qdrantError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// call sends a request to the Qdrant API and decodes the "result" field of the response into out
+func (s *QdrantStore) call(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to Qdrant: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		message := strings.TrimSpace(string(respBody))
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Status.Error != "" {
+			message = errResp.Status.Error
+		}
+		return &qdrantError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	if out == nil {
+		return nil
+	}
+	envelope := struct {
+		Result json.RawMessage `json:"result"`
+	}{}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// collectionExists reports whether the collection for an embedding type exists
+func (s *QdrantStore) collectionExists(embeddingType string) (bool, error) {
+	if s.collections[embeddingType] {
+		return true, nil
+	}
+	err := s.call(http.MethodGet, "/collections/"+s.collectionName(embeddingType), nil, nil)
+	if qerr, ok := err.(*qdrantError); ok && qerr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.collections[embeddingType] = true
+	return true, nil
+}
+
+// existingTypes returns the embedding types that have a collection
+func (s *QdrantStore) existingTypes() ([]string, error) {
+	var types []string
+	for _, embeddingType := range EmbeddingTypes {
+		exists, err := s.collectionExists(embeddingType)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			types = append(types, embeddingType)
+		}
+	}
+	return types, nil
+}
+
+// ensureCollection creates the collection for an embedding type, with payload
+// indexes for filtering, if it doesn't exist yet
+func (s *QdrantStore) ensureCollection(embeddingType string, dimension int) error {
+	exists, err := s.collectionExists(embeddingType)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	name := s.collectionName(embeddingType)
+	create := map[string]interface{}{
+		"vectors": map[string]interface{}{"size": dimension, "distance": "Cosine"},
+	}
+	if err := s.call(http.MethodPut, "/collections/"+name, create, nil); err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", name, err)
+	}
+
+	for _, field := range qdrantIndexedFields {
+		schema := "keyword"
+		if field == "is_test" {
+			schema = "bool"
+		}
+		index := map[string]interface{}{"field_name": field, "field_schema": schema}
+		if err := s.call(http.MethodPut, "/collections/"+name+"/index?wait=true", index, nil); err != nil {
+			return fmt.Errorf("failed to index payload field %s: %w", field, err)
+		}
+	}
+
+	s.collections[embeddingType] = true
+	return nil
+}
+
+// LoadMetadata loads metadata from the local index directory
+func (s *QdrantStore) LoadMetadata() (*IndexMetadata, error) {
+	return loadMetadata(s.dbDir)
+}
+
+// SaveMetadata saves metadata to the local index directory
+func (s *QdrantStore) SaveMetadata(metadata *IndexMetadata) error {
+	return saveMetadata(s.dbDir, metadata)
+}
+
+// Migrate records the current schema version. Qdrant payloads are schemaless,
+// so there is nothing to rewrite.
+func (s *QdrantStore) Migrate(metadata *IndexMetadata) ([]string, error) {
+	if metadata.EffectiveSchemaVersion() > CurrentSchemaVersion {
+		return nil, CheckSchemaVersion(metadata)
+	}
+	metadata.SchemaVersion = CurrentSchemaVersion
+	return nil, nil
+}
+
+// OpenTable checks that at least one collection exists
+func (s *QdrantStore) OpenTable() error {
+	types, err := s.existingTypes()
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return &IndexMissingError{Location: "Qdrant collections with prefix " + s.collection}
+	}
+	return nil
+}
+
+// StoreChunks upserts chunks into the collection for their embedding type in batches
+func (s *QdrantStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float64) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("chunks and embeddings length mismatch: %d vs %d", len(chunks), len(embeddings))
+	}
+
+	pointsByType := make(map[string][]map[string]interface{})
+	dimensions := make(map[string]int)
+	for i, chunk := range chunks {
+		embeddingType := chunk.EmbeddingType
+		if embeddingType != "docs" {
+			embeddingType = "code"
+		}
+		if dimension, ok := dimensions[embeddingType]; ok && dimension != len(embeddings[i]) {
+			return fmt.Errorf("embedding dimension mismatch for chunk %s: got %d, expected %d", chunk.ID, len(embeddings[i]), dimension)
+		}
+		dimensions[embeddingType] = len(embeddings[i])
+
+		pointsByType[embeddingType] = append(pointsByType[embeddingType], map[string]interface{}{
+			"id":      chunk.ID,
+			"vector":  embeddings[i],
+			"payload": chunkPayload(chunk),
+		})
+	}
+
+	for _, embeddingType := range EmbeddingTypes {
+		points := pointsByType[embeddingType]
+		if len(points) == 0 {
+			continue
+		}
+		if err := s.ensureCollection(embeddingType, dimensions[embeddingType]); err != nil {
+			return err
+		}
+
+		path := "/collections/" + s.collectionName(embeddingType) + "/points?wait=true"
+		for start := 0; start < len(points); start += qdrantUpsertBatchSize {
+			end := start + qdrantUpsertBatchSize
+			if end > len(points) {
+				end = len(points)
+			}
+			if err := s.call(http.MethodPut, path, map[string]interface{}{"points": points[start:end]}, nil); err != nil {
+				return fmt.Errorf("failed to upsert %s points: %w", embeddingType, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// chunkPayload converts a chunk into a Qdrant point payload with the same fields as the LanceDB columns
+func chunkPayload(chunk chunker.Chunk) map[string]interface{} {
+	metadata, _ := encodeChunkMetadata(chunk.Metadata)
+	payload := map[string]interface{}{
+		"chunk_id":       chunk.ID,
+		"file_path":      chunk.FilePath,
+		"dirs":           ancestorDirs(chunk.FilePath),
+		"line_start":     chunk.LineStart,
+		"line_end":       chunk.LineEnd,
+		"language":       chunk.Language,
+		"code":           chunk.Code,
+		"chunk_type":     chunk.ChunkType,
+		"name":           chunk.Name,
+		"metadata":       metadata,
+		"embedding_type": chunk.EmbeddingType,
+		"is_test":        IsTestFile(chunk.FilePath),
+	}
+	for _, key := range []string{"heading", "heading_level", "parent_heading"} {
+		payload[key] = chunk.Metadata[key]
+	}
+	return payload
+}
+
+// ancestorDirs returns every directory containing path, nearest first. Stored in the
+// payload so path-prefix filters become exact keyword matches.
+func ancestorDirs(path string) []string {
+	var dirs []string
+	dir := filepath.Dir(path)
+	for {
+		dirs = append(dirs, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return dirs
+}
+
+// qdrantFilter converts a search filter into a Qdrant payload filter (nil if empty)
+func qdrantFilter(filter SearchFilter) map[string]interface{} {
+	var must []map[string]interface{}
+	if filter.Language != "" {
+		must = append(must, matchValue("language", filter.Language))
+	}
+	if filter.ChunkType != "" {
+		must = append(must, matchValue("chunk_type", filter.ChunkType))
+	}
+	if filter.PathPrefix != "" {
+		must = append(must, matchValue("dirs", filepath.Clean(filter.PathPrefix)))
+	}
+	if filter.OnlyTests {
+		must = append(must, matchValue("is_test", true))
+	}
+	// must_not keeps points indexed before is_test existed
+	var mustNot []map[string]interface{}
+	if filter.ExcludeTests {
+		mustNot = append(mustNot, matchValue("is_test", true))
+	}
+	if len(filter.ExcludeLanguages) > 0 {
+		mustNot = append(mustNot, map[string]interface{}{
+			"key": "language", "match": map[string]interface{}{"any": filter.ExcludeLanguages},
+		})
+	}
+	if len(must) == 0 && len(mustNot) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{})
+	if len(must) > 0 {
+		result["must"] = must
+	}
+	if len(mustNot) > 0 {
+		result["must_not"] = mustNot
+	}
+	return result
+}
+
+// matchValue builds a Qdrant condition matching a keyword field exactly
+func matchValue(key string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{"key": key, "match": map[string]interface{}{"value": value}}
+}
+
+// filePathFilter builds a Qdrant filter matching any of the given file paths
+func filePathFilter(filePaths []string) map[string]interface{} {
+	return map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": "file_path", "match": map[string]interface{}{"any": filePaths}},
+		},
+	}
+}
+
+// DeleteChunksByFilePath deletes all chunks for the given file paths
+func (s *QdrantStore) DeleteChunksByFilePath(filePaths []string) error {
+	if len(filePaths) == 0 {
+		return nil
+	}
+
+	types, err := s.existingTypes()
+	if err != nil {
+		return err
+	}
+	for _, embeddingType := range types {
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/delete?wait=true"
+		if err := s.call(http.MethodPost, path, map[string]interface{}{"filter": filePathFilter(filePaths)}, nil); err != nil {
+			return fmt.Errorf("failed to delete chunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ReplaceChunks replaces all chunks stored for filePaths with the given chunks.
+// The new points are upserted first and only then are the files' other points
+// deleted, so searches never see a file with no chunks.
+func (s *QdrantStore) ReplaceChunks(filePaths []string, chunks []chunker.Chunk, embeddings [][]float64) error {
+	if err := s.StoreChunks(chunks, embeddings); err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return nil
+	}
+
+	filter := filePathFilter(filePaths)
+	if len(chunks) > 0 {
+		ids := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			ids[i] = chunk.ID
+		}
+		filter["must_not"] = []map[string]interface{}{{"has_id": ids}}
+	}
+
+	types, err := s.existingTypes()
+	if err != nil {
+		return err
+	}
+	for _, embeddingType := range types {
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/delete?wait=true"
+		if err := s.call(http.MethodPost, path, map[string]interface{}{"filter": filter}, nil); err != nil {
+			return fmt.Errorf("failed to delete replaced chunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateFilePath rewrites the file path of all chunks stored for oldPath
+func (s *QdrantStore) UpdateFilePath(oldPath, newPath string) error {
+	types, err := s.existingTypes()
+	if err != nil {
+		return err
+	}
+
+	update := map[string]interface{}{
+		"payload": map[string]interface{}{
+			"file_path": newPath,
+			"dirs":      ancestorDirs(newPath),
+		},
+		"filter": filePathFilter([]string{oldPath}),
+	}
+	for _, embeddingType := range types {
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/payload?wait=true"
+		if err := s.call(http.MethodPost, path, update, nil); err != nil {
+			return fmt.Errorf("failed to update file path: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListFilePaths returns the distinct file paths that have chunks stored in any collection
+func (s *QdrantStore) ListFilePaths() ([]string, error) {
+	types, err := s.existingTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, embeddingType := range types {
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/scroll"
+		var offset interface{}
+		for {
+			request := map[string]interface{}{
+				"limit":        qdrantScrollPageSize,
+				"with_payload": []string{"file_path"},
+				"with_vector":  false,
+			}
+			if offset != nil {
+				request["offset"] = offset
+			}
+
+			var page struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			}
+			if err := s.call(http.MethodPost, path, request, &page); err != nil {
+				return nil, fmt.Errorf("failed to read file paths: %w", err)
+			}
+
+			for _, point := range page.Points {
+				filePath, ok := point.Payload["file_path"].(string)
+				if !ok || seen[filePath] {
+					continue
+				}
+				seen[filePath] = true
+				paths = append(paths, filePath)
+			}
+
+			if page.NextPageOffset == nil {
+				break
+			}
+			offset = page.NextPageOffset
+		}
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// Search performs vector similarity search in the collection for the given embedding type.
+// Qdrant returns cosine similarity, which is converted to a "_distance" (1 - similarity)
+// so results rank the same way as LanceDB's.
+func (s *QdrantStore) Search(embeddingType string, queryVector []float64, limit int, filter SearchFilter) ([]map[string]interface{}, error) {
+	exists, err := s.collectionExists(embeddingType)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	request := map[string]interface{}{
+		"vector":       queryVector,
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if f := qdrantFilter(filter); f != nil {
+		request["filter"] = f
+	}
+
+	var hits []struct {
+		Score   float64                `json:"score"`
+		Payload map[string]interface{} `json:"payload"`
+	}
+	path := "/collections/" + s.collectionName(embeddingType) + "/points/search"
+	if err := s.call(http.MethodPost, path, request, &hits); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]map[string]interface{}, len(hits))
+	for i, hit := range hits {
+		row := hit.Payload
+		if row == nil {
+			row = make(map[string]interface{})
+		}
+		delete(row, "dirs")
+		row["_distance"] = 1 - hit.Score
+		results[i] = row
+	}
+
+	return results, nil
+}
+
+// GetChunk returns the payload of the point with the given chunk ID, or nil if
+// no collection has it
+func (s *QdrantStore) GetChunk(chunkID string) (map[string]interface{}, error) {
+	types, err := s.existingTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, embeddingType := range types {
+		var point struct {
+			Payload map[string]interface{} `json:"payload"`
+		}
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/" + url.PathEscape(chunkID)
+		err := s.call(http.MethodGet, path, nil, &point)
+		if qerr, ok := err.(*qdrantError); ok && qerr.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		delete(point.Payload, "dirs")
+		return point.Payload, nil
+	}
+
+	return nil, nil
+}
+
+// GetChunks returns the payloads of the points with the given chunk IDs,
+// retrieved with one request per collection
+func (s *QdrantStore) GetChunks(chunkIDs []string) (map[string]map[string]interface{}, error) {
+	found := make(map[string]map[string]interface{}, len(chunkIDs))
+	if len(chunkIDs) == 0 {
+		return found, nil
+	}
+
+	types, err := s.existingTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, embeddingType := range types {
+		var points []struct {
+			Payload map[string]interface{} `json:"payload"`
+		}
+		request := map[string]interface{}{
+			"ids":          chunkIDs,
+			"with_payload": true,
+			"with_vector":  false,
+		}
+		path := "/collections/" + s.collectionName(embeddingType) + "/points"
+		if err := s.call(http.MethodPost, path, request, &points); err != nil {
+			return nil, fmt.Errorf("failed to read chunks: %w", err)
+		}
+		for _, point := range points {
+			if id, ok := point.Payload["chunk_id"].(string); ok {
+				delete(point.Payload, "dirs")
+				found[id] = point.Payload
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// FileChunks returns the stored payloads for every chunk of a file
+func (s *QdrantStore) FileChunks(filePath string) ([]map[string]interface{}, error) {
+	types, err := s.existingTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	for _, embeddingType := range types {
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/scroll"
+		var offset interface{}
+		for {
+			request := map[string]interface{}{
+				"limit":        qdrantScrollPageSize,
+				"filter":       filePathFilter([]string{filePath}),
+				"with_payload": true,
+				"with_vector":  false,
+			}
+			if offset != nil {
+				request["offset"] = offset
+			}
+
+			var page struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			}
+			if err := s.call(http.MethodPost, path, request, &page); err != nil {
+				return nil, fmt.Errorf("failed to read file chunks: %w", err)
+			}
+
+			for _, point := range page.Points {
+				delete(point.Payload, "dirs")
+				rows = append(rows, point.Payload)
+			}
+
+			if page.NextPageOffset == nil {
+				break
+			}
+			offset = page.NextPageOffset
+		}
+	}
+
+	return rows, nil
+}
+
+// DeleteChunks deletes chunks by ID from every collection
+func (s *QdrantStore) DeleteChunks(chunkIDs []string) error {
+	if len(chunkIDs) == 0 {
+		return nil
+	}
+
+	types, err := s.existingTypes()
+	if err != nil {
+		return err
+	}
+	for _, embeddingType := range types {
+		path := "/collections/" + s.collectionName(embeddingType) + "/points/delete?wait=true"
+		if err := s.call(http.MethodPost, path, map[string]interface{}{"points": chunkIDs}, nil); err != nil {
+			return fmt.Errorf("failed to delete chunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FullTextSearch finds chunks whose code or name contains any of the query's
+// words. Qdrant has no BM25 ranking, so this is a degraded stand-in for
+// LanceDB's: matching points are fetched with a text match filter (served by
+// the text payload indexes CreateTextIndex builds, or a substring scan without
+// them) and ranked here by how many query words they contain and how often.
+// At most qdrantTextScanPages pages of matches are ranked per collection.
+func (s *QdrantStore) FullTextSearch(query string, limit int, embeddingType string, filter SearchFilter) ([]map[string]interface{}, error) {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	types, err := s.existingTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var should []map[string]interface{}
+	for _, term := range terms {
+		for _, field := range textIndexColumns {
+			should = append(should, map[string]interface{}{"key": field, "match": map[string]interface{}{"text": term}})
+		}
+	}
+	request := qdrantFilter(filter)
+	if request == nil {
+		request = make(map[string]interface{})
+	}
+	request["should"] = should
+
+	var results []map[string]interface{}
+	for _, collectionType := range types {
+		if embeddingType != "" && collectionType != embeddingType {
+			continue
+		}
+		path := "/collections/" + s.collectionName(collectionType) + "/points/scroll"
+		var offset interface{}
+		for pages := 0; pages < qdrantTextScanPages; pages++ {
+			body := map[string]interface{}{
+				"limit":        qdrantScrollPageSize,
+				"filter":       request,
+				"with_payload": true,
+				"with_vector":  false,
+			}
+			if offset != nil {
+				body["offset"] = offset
+			}
+
+			var page struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			}
+			if err := s.call(http.MethodPost, path, body, &page); err != nil {
+				return nil, fmt.Errorf("failed to search text: %w", err)
+			}
+
+			for _, point := range page.Points {
+				row := point.Payload
+				score := max(termScore(rowString(row, "name"), terms), termScore(rowString(row, "code"), terms))
+				if score == 0 {
+					continue
+				}
+				delete(row, "dirs")
+				row["_score"] = score
+				results = append(results, row)
+			}
+
+			if page.NextPageOffset == nil {
+				break
+			}
+			offset = page.NextPageOffset
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return rowFloat(results[i], "_score") > rowFloat(results[j], "_score")
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// queryTerms splits a full-text query into its distinct lowercase words
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// termScore ranks text against query terms: each term it contains adds one,
+// plus a little for repeats, so matching more terms always ranks higher
+func termScore(text string, terms []string) float64 {
+	text = strings.ToLower(text)
+	var score float64
+	for _, term := range terms {
+		if count := strings.Count(text, term); count > 0 {
+			score += 1 + math.Log(float64(count))/10
+		}
+	}
+	return score
+}
+
+// CreateTextIndex builds text payload indexes on the columns FullTextSearch
+// matches, in every collection
+func (s *QdrantStore) CreateTextIndex() error {
+	types, err := s.existingTypes()
+	if err != nil {
+		return err
+	}
+	for _, embeddingType := range types {
+		name := s.collectionName(embeddingType)
+		for _, field := range textIndexColumns {
+			index := map[string]interface{}{
+				"field_name":   field,
+				"field_schema": map[string]interface{}{"type": "text", "tokenizer": "word", "lowercase": true},
+			}
+			if err := s.call(http.MethodPut, "/collections/"+name+"/index?wait=true", index, nil); err != nil {
+				return fmt.Errorf("failed to build text index on %s: %w", field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close releases idle HTTP connections
+func (s *QdrantStore) Close() error {
+	s.httpClient.CloseIdleConnections()
+	return nil
+}
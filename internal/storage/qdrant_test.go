@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// fakeQdrant is a minimal in-memory Qdrant HTTP API
+type fakeQdrant struct {
+	mu          sync.Mutex
+	collections map[string][]map[string]interface{} // collection -> points
+	apiKeys     []string
+}
+
+func newFakeQdrant(t *testing.T) (*fakeQdrant, *httptest.Server) {
+	fake := &fakeQdrant{collections: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeQdrant) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.apiKeys = append(f.apiKeys, r.Header.Get("api-key"))
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "collections" {
+		http.NotFound(w, r)
+		return
+	}
+	name := parts[1]
+	action := strings.Join(parts[2:], "/")
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	reply := func(result interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "status": "ok"})
+	}
+
+	points, exists := f.collections[name]
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": map[string]string{"error": "Not found"}})
+			return
+		}
+		reply(map[string]interface{}{})
+	case action == "" && r.Method == http.MethodPut:
+		f.collections[name] = nil
+		reply(true)
+	case action == "index":
+		reply(map[string]interface{}{})
+	case action == "points" && r.Method == http.MethodPut:
+		for _, p := range body["points"].([]interface{}) {
+			f.collections[name] = append(f.collections[name], p.(map[string]interface{}))
+		}
+		reply(map[string]interface{}{})
+	case action == "points" && r.Method == http.MethodPost:
+		ids := make(map[interface{}]bool)
+		for _, id := range body["ids"].([]interface{}) {
+			ids[id] = true
+		}
+		var found []map[string]interface{}
+		for _, p := range points {
+			if ids[p["id"]] {
+				found = append(found, map[string]interface{}{"id": p["id"], "payload": p["payload"]})
+			}
+		}
+		reply(found)
+	case action == "points/delete":
+		f.collections[name] = deletePoints(points, body["filter"].(map[string]interface{}))
+		reply(map[string]interface{}{})
+	case action == "points/search":
+		var hits []map[string]interface{}
+		for i, p := range points {
+			hits = append(hits, map[string]interface{}{"score": 0.9 - float64(i)*0.1, "payload": p["payload"]})
+		}
+		reply(hits)
+	case action == "points/scroll":
+		var page []map[string]interface{}
+		for _, p := range points {
+			page = append(page, map[string]interface{}{"payload": p["payload"]})
+		}
+		reply(map[string]interface{}{"points": page, "next_page_offset": nil})
+	default:
+		reply(map[string]interface{}{})
+	}
+}
+
+func TestQdrantStore_StoreAndSearch(t *testing.T) {
+	fake, server := newFakeQdrant(t)
+	store, err := NewQdrantStore(t.TempDir(), server.URL, "secret", "test")
+	if err != nil {
+		t.Fatalf("NewQdrantStore failed: %v", err)
+	}
+	defer store.Close()
+
+	var missing *IndexMissingError
+	if err := store.OpenTable(); !errors.As(err, &missing) {
+		t.Fatalf("expected OpenTable to fail with IndexMissingError before any collection exists, got %v", err)
+	}
+
+	chunks := []chunker.Chunk{
+		{ID: "11111111-1111-1111-1111-111111111111", FilePath: "/repo/a.go", LineStart: 1, LineEnd: 3, Language: "go", Code: "func A() {}", Name: "A", EmbeddingType: "code"},
+		{ID: "22222222-2222-2222-2222-222222222222", FilePath: "/repo/README.md", LineStart: 1, LineEnd: 2, Language: "markdown", Code: "# Readme", EmbeddingType: "docs"},
+	}
+	embeddings := [][]float64{{0.1, 0.2, 0.3}, {0.4, 0.5}}
+	if err := store.StoreChunks(chunks, embeddings); err != nil {
+		t.Fatalf("StoreChunks failed: %v", err)
+	}
+
+	if len(fake.collections["test_code"]) != 1 || len(fake.collections["test_docs"]) != 1 {
+		t.Fatalf("expected one point per collection, got %v", fake.collections)
+	}
+	if fake.apiKeys[0] != "secret" {
+		t.Errorf("expected api-key header to be sent, got %q", fake.apiKeys[0])
+	}
+
+	if err := store.OpenTable(); err != nil {
+		t.Fatalf("OpenTable failed: %v", err)
+	}
+
+	results, err := store.Search("code", []float64{0.1, 0.2, 0.3}, 5, SearchFilter{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0]["file_path"] != "/repo/a.go" || results[0]["name"] != "A" {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	if distance := results[0]["_distance"].(float64); distance < 0.099 || distance > 0.101 {
+		t.Errorf("expected distance 1-score (0.1), got %v", distance)
+	}
+
+	paths, err := store.ListFilePaths()
+	if err != nil {
+		t.Fatalf("ListFilePaths failed: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"/repo/README.md", "/repo/a.go"}) {
+		t.Errorf("unexpected file paths: %v", paths)
+	}
+}
+
+// deletePoints drops the points matching a delete filter of the shape
+// DeleteChunksByFilePath and ReplaceChunks send
+func deletePoints(points []map[string]interface{}, filter map[string]interface{}) []map[string]interface{} {
+	paths := make(map[string]bool)
+	for _, cond := range filter["must"].([]interface{}) {
+		for _, path := range cond.(map[string]interface{})["match"].(map[string]interface{})["any"].([]interface{}) {
+			paths[path.(string)] = true
+		}
+	}
+	kept := make(map[string]bool)
+	if mustNot, ok := filter["must_not"].([]interface{}); ok {
+		for _, id := range mustNot[0].(map[string]interface{})["has_id"].([]interface{}) {
+			kept[id.(string)] = true
+		}
+	}
+
+	var remaining []map[string]interface{}
+	for _, p := range points {
+		path := p["payload"].(map[string]interface{})["file_path"].(string)
+		if !paths[path] || kept[p["id"].(string)] {
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
+
+func TestQdrantStore_ReplaceChunks(t *testing.T) {
+	fake, server := newFakeQdrant(t)
+	store, err := NewQdrantStore(t.TempDir(), server.URL, "", "test")
+	if err != nil {
+		t.Fatalf("NewQdrantStore failed: %v", err)
+	}
+	defer store.Close()
+
+	old := []chunker.Chunk{
+		{ID: "11111111-1111-1111-1111-111111111111", FilePath: "/repo/a.go", Code: "func A() {}", EmbeddingType: "code"},
+		{ID: "22222222-2222-2222-2222-222222222222", FilePath: "/repo/b.go", Code: "func B() {}", EmbeddingType: "code"},
+	}
+	if err := store.StoreChunks(old, [][]float64{{0.1, 0.2}, {0.3, 0.4}}); err != nil {
+		t.Fatalf("StoreChunks failed: %v", err)
+	}
+
+	updated := []chunker.Chunk{
+		{ID: "33333333-3333-3333-3333-333333333333", FilePath: "/repo/a.go", Code: "func A2() {}", EmbeddingType: "code"},
+	}
+	if err := store.ReplaceChunks([]string{"/repo/a.go", "/repo/b.go"}, updated, [][]float64{{0.5, 0.6}}); err != nil {
+		t.Fatalf("ReplaceChunks failed: %v", err)
+	}
+
+	points := fake.collections["test_code"]
+	if len(points) != 1 || points[0]["id"] != updated[0].ID {
+		t.Errorf("expected only the replacement point to remain, got %v", points)
+	}
+}
+
+func TestQdrantStore_GetChunks(t *testing.T) {
+	_, server := newFakeQdrant(t)
+	store, err := NewQdrantStore(t.TempDir(), server.URL, "", "test")
+	if err != nil {
+		t.Fatalf("NewQdrantStore failed: %v", err)
+	}
+	defer store.Close()
+
+	chunks := []chunker.Chunk{
+		{ID: "11111111-1111-1111-1111-111111111111", FilePath: "/repo/a.go", Code: "func A() {}", EmbeddingType: "code"},
+		{ID: "22222222-2222-2222-2222-222222222222", FilePath: "/repo/README.md", Code: "# Readme", EmbeddingType: "docs"},
+	}
+	if err := store.StoreChunks(chunks, [][]float64{{0.1, 0.2}, {0.3, 0.4}}); err != nil {
+		t.Fatalf("StoreChunks failed: %v", err)
+	}
+
+	found, err := store.GetChunks([]string{chunks[0].ID, chunks[1].ID, "33333333-3333-3333-3333-333333333333"})
+	if err != nil {
+		t.Fatalf("GetChunks failed: %v", err)
+	}
+	if len(found) != 2 || found[chunks[0].ID]["code"] != "func A() {}" || found[chunks[1].ID]["code"] != "# Readme" {
+		t.Errorf("expected the payloads of both stored chunks, got %v", found)
+	}
+	if _, ok := found[chunks[0].ID]["dirs"]; ok {
+		t.Error("expected the dirs payload field dropped")
+	}
+}
+
+func TestQdrantFilter(t *testing.T) {
+	if qdrantFilter(SearchFilter{}) != nil {
+		t.Error("expected nil filter for empty search filter")
+	}
+
+	filter := qdrantFilter(SearchFilter{Language: "go", PathPrefix: "/repo/internal/"})
+	must := filter["must"].([]map[string]interface{})
+	if len(must) != 2 {
+		t.Fatalf("expected 2 conditions, got %d", len(must))
+	}
+	if must[1]["key"] != "dirs" || must[1]["match"].(map[string]interface{})["value"] != "/repo/internal" {
+		t.Errorf("unexpected path condition: %v", must[1])
+	}
+
+	filter = qdrantFilter(SearchFilter{ExcludeTests: true})
+	if _, ok := filter["must"]; ok {
+		t.Errorf("expected only a must_not condition, got %v", filter)
+	}
+	if mustNot := filter["must_not"].([]map[string]interface{}); len(mustNot) != 1 || mustNot[0]["key"] != "is_test" {
+		t.Errorf("unexpected must_not conditions: %v", filter["must_not"])
+	}
+
+	filter = qdrantFilter(SearchFilter{ExcludeLanguages: []string{"php"}})
+	if mustNot := filter["must_not"].([]map[string]interface{}); len(mustNot) != 1 || mustNot[0]["key"] != "language" {
+		t.Errorf("unexpected must_not conditions: %v", filter["must_not"])
+	}
+
+	if got := ancestorDirs("/repo/internal/a.go"); !reflect.DeepEqual(got, []string{"/repo/internal", "/repo", "/"}) {
+		t.Errorf("unexpected ancestor dirs: %v", got)
+	}
+}
+
+func TestQdrantStore_FullTextSearch(t *testing.T) {
+	_, server := newFakeQdrant(t)
+	store, err := NewQdrantStore(t.TempDir(), server.URL, "", "test")
+	if err != nil {
+		t.Fatalf("NewQdrantStore failed: %v", err)
+	}
+	defer store.Close()
+
+	chunks := []chunker.Chunk{
+		{ID: "11111111-1111-1111-1111-111111111111", FilePath: "/repo/a.go", Code: "func load() { parse(cfg) }", Name: "load", EmbeddingType: "code"},
+		{ID: "22222222-2222-2222-2222-222222222222", FilePath: "/repo/b.go", Code: "func parseConfig() { parse(cfg); load() }", Name: "parseConfig", EmbeddingType: "code"},
+		{ID: "33333333-3333-3333-3333-333333333333", FilePath: "/repo/c.go", Code: "func unrelated() {}", Name: "unrelated", EmbeddingType: "code"},
+	}
+	if err := store.StoreChunks(chunks, [][]float64{{1, 0}, {0, 1}, {1, 1}}); err != nil {
+		t.Fatalf("StoreChunks failed: %v", err)
+	}
+	if err := store.CreateTextIndex(); err != nil {
+		t.Fatalf("CreateTextIndex failed: %v", err)
+	}
+
+	results, err := store.FullTextSearch("Parse load", 10, "", SearchFilter{})
+	if err != nil {
+		t.Fatalf("FullTextSearch failed: %v", err)
+	}
+	if len(results) != 2 || results[0]["file_path"] != "/repo/b.go" || results[1]["file_path"] != "/repo/a.go" {
+		t.Fatalf("expected b.go then a.go, got %v", results)
+	}
+	if _, ok := results[0]["_score"].(float64); !ok {
+		t.Errorf("expected a _score, got %v", results[0])
+	}
+	if _, ok := results[0]["dirs"]; ok {
+		t.Error("expected the dirs payload field removed")
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/memory"
+	"github.com/lancedb/lancedb-go/pkg/contracts"
+	"github.com/lancedb/lancedb-go/pkg/lancedb"
+)
+
+// metadataTableName is the table holding index metadata for remote stores,
+// which have no local directory to write metadata.json into
+const metadataTableName = "index_metadata"
+
+// storageSetting is an object store setting read from config, falling back to
+// an environment variable
+type storageSetting struct {
+	key string // Key in the config's storage_options map
+	env string // Environment variable used when the key is unset
+}
+
+// ObjectStoreOptions builds LanceDB storage options for an object store URI.
+// Each setting comes from the settings map (the config's storage_options) or,
+// if unset there, from the provider's standard environment variable. Settings
+// left empty are omitted so the provider's default credential chain applies.
+func ObjectStoreOptions(uri string, settings map[string]string) (*contracts.StorageOptions, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store URI: %w", err)
+	}
+
+	lookup := func(setting storageSetting) *string {
+		value := settings[setting.key]
+		if value == "" && setting.env != "" {
+			value = os.Getenv(setting.env)
+		}
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	switch parsed.Scheme {
+	case "s3":
+		s3 := &contracts.S3Config{
+			AccessKeyID:     lookup(storageSetting{"access_key_id", "AWS_ACCESS_KEY_ID"}),
+			SecretAccessKey: lookup(storageSetting{"secret_access_key", "AWS_SECRET_ACCESS_KEY"}),
+			SessionToken:    lookup(storageSetting{"session_token", "AWS_SESSION_TOKEN"}),
+			Region:          lookup(storageSetting{"region", "AWS_REGION"}),
+			Endpoint:        lookup(storageSetting{"endpoint", "AWS_ENDPOINT_URL"}),
+			Profile:         lookup(storageSetting{"profile", "AWS_PROFILE"}),
+		}
+		// Self-hosted S3-compatible stores such as MinIO usually need path-style addressing
+		if s3.Endpoint != nil {
+			forcePathStyle := true
+			s3.ForcePathStyle = &forcePathStyle
+		}
+		return &contracts.StorageOptions{S3Config: s3}, nil
+	case "gs":
+		return &contracts.StorageOptions{GCSConfig: &contracts.GCSConfig{
+			ServiceAccountPath: lookup(storageSetting{"service_account_path", "GOOGLE_APPLICATION_CREDENTIALS"}),
+			ServiceAccountKey:  lookup(storageSetting{"service_account_key", ""}),
+			ProjectID:          lookup(storageSetting{"project_id", "GOOGLE_CLOUD_PROJECT"}),
+		}}, nil
+	case "az":
+		return &contracts.StorageOptions{AzureConfig: &contracts.AzureConfig{
+			AccountName: lookup(storageSetting{"account_name", "AZURE_STORAGE_ACCOUNT_NAME"}),
+			AccessKey:   lookup(storageSetting{"access_key", "AZURE_STORAGE_ACCOUNT_KEY"}),
+			SasToken:    lookup(storageSetting{"sas_token", "AZURE_STORAGE_SAS_TOKEN"}),
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported object store scheme %q (expected s3, gs, or az)", parsed.Scheme)
+	}
+}
+
+// metadataSchema returns the schema of the remote metadata table, and other
+// document tables: a single row holding the document as JSON
+func metadataSchema() *arrow.Schema {
+	return arrow.NewSchema([]arrow.Field{
+		{Name: "data", Type: arrow.BinaryTypes.String},
+	}, nil)
+}
+
+// loadRemoteMetadata loads metadata from the metadata table
+func (s *LanceDBStore) loadRemoteMetadata() (*IndexMetadata, error) {
+	data, err := s.loadRemoteDocument(metadataTableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if data == nil {
+		return emptyMetadata(), nil
+	}
+	return decodeMetadata(data)
+}
+
+// saveRemoteMetadata replaces the contents of the metadata table
+func (s *LanceDBStore) saveRemoteMetadata(metadata *IndexMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := s.saveRemoteDocument(metadataTableName, data); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return nil
+}
+
+// loadRemoteDocument reads the JSON document stored in a single-row table, or
+// nil if the table doesn't exist
+func (s *LanceDBStore) loadRemoteDocument(tableName string) ([]byte, error) {
+	ctx := context.Background()
+
+	names, err := s.conn.TableNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	found := false
+	for _, name := range names {
+		if name == tableName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	table, err := s.conn.OpenTable(ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s table: %w", tableName, err)
+	}
+	defer table.Close()
+
+	rows, err := table.SelectWithColumns(ctx, []string{"data"})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return []byte(rowString(rows[0], "data")), nil
+}
+
+// saveRemoteDocument replaces the contents of a single-row document table
+func (s *LanceDBStore) saveRemoteDocument(tableName string, data []byte) error {
+	ctx := context.Background()
+
+	schema := metadataSchema()
+	lanceSchema, err := lancedb.NewSchema(schema)
+	if err != nil {
+		return fmt.Errorf("failed to create Lance schema: %w", err)
+	}
+
+	// Add can't overwrite, so recreate the table. A missing table is not an error.
+	_ = s.conn.DropTable(ctx, tableName)
+	table, err := s.conn.CreateTable(ctx, tableName, lanceSchema)
+	if err != nil {
+		return fmt.Errorf("failed to create %s table: %w", tableName, err)
+	}
+	defer table.Close()
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.StringBuilder).Append(string(data))
+	record := builder.NewRecord()
+	defer record.Release()
+
+	return table.Add(ctx, record, nil)
+}
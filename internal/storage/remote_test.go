@@ -0,0 +1,46 @@
+package storage
+
+import "testing"
+
+func TestObjectStoreOptions(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_REGION", "")
+
+	options, err := ObjectStoreOptions("s3://bucket/index", map[string]string{
+		"access_key_id": "config-key",
+		"endpoint":      "http://localhost:9000",
+	})
+	if err != nil {
+		t.Fatalf("ObjectStoreOptions failed: %v", err)
+	}
+	s3 := options.S3Config
+	if s3 == nil {
+		t.Fatal("expected S3 config")
+	}
+	if *s3.AccessKeyID != "config-key" {
+		t.Errorf("expected config value to take precedence, got %q", *s3.AccessKeyID)
+	}
+	if *s3.SecretAccessKey != "env-secret" {
+		t.Errorf("expected environment fallback, got %q", *s3.SecretAccessKey)
+	}
+	if s3.Region != nil {
+		t.Errorf("expected unset region to be omitted, got %q", *s3.Region)
+	}
+	if s3.ForcePathStyle == nil || !*s3.ForcePathStyle {
+		t.Error("expected path-style addressing with a custom endpoint")
+	}
+
+	t.Setenv("AZURE_STORAGE_ACCOUNT_NAME", "account")
+	options, err = ObjectStoreOptions("az://container/index", nil)
+	if err != nil {
+		t.Fatalf("ObjectStoreOptions failed: %v", err)
+	}
+	if options.AzureConfig == nil || *options.AzureConfig.AccountName != "account" {
+		t.Errorf("unexpected Azure config: %+v", options.AzureConfig)
+	}
+
+	if _, err := ObjectStoreOptions("file:///tmp/index", nil); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+}
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// RootedStore wraps a per-project store so file paths are stored relative to
+// the project root, and handed back absolute. The index then keeps working
+// when the repository is moved, or restored from a backup on another machine.
+// Paths outside the root, and absolute paths stored before paths were made
+// relative, are passed through unchanged.
+type RootedStore struct {
+	Store
+	root string
+}
+
+var _ Store = (*RootedStore)(nil)
+
+// NewRootedStore wraps store, storing paths relative to root
+func NewRootedStore(store Store, root string) *RootedStore {
+	return &RootedStore{Store: store, root: filepath.Clean(root)}
+}
+
+// stored returns the form of path kept in the store
+func (s *RootedStore) stored(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return path
+	}
+	return rel
+}
+
+// resolve returns the absolute form of a stored path
+func (s *RootedStore) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.root, path)
+}
+
+// storedPaths converts paths to their stored form. Absolute forms are kept
+// too, so files stored before the index was migrated still match.
+func (s *RootedStore) storedPaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, s.stored(path))
+		if s.stored(path) != path {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// storedChunks returns copies of chunks with stored paths; the caller's chunks keep theirs
+func (s *RootedStore) storedChunks(chunks []chunker.Chunk) []chunker.Chunk {
+	result := make([]chunker.Chunk, len(chunks))
+	for i, chunk := range chunks {
+		chunk.FilePath = s.stored(chunk.FilePath)
+		result[i] = chunk
+	}
+	return result
+}
+
+// storedFilter converts the filter's path prefix; the root itself matches every file
+func (s *RootedStore) storedFilter(filter SearchFilter) SearchFilter {
+	if filter.PathPrefix == "" {
+		return filter
+	}
+	if filepath.Clean(filter.PathPrefix) == s.root {
+		filter.PathPrefix = ""
+	} else {
+		filter.PathPrefix = s.stored(filter.PathPrefix)
+	}
+	return filter
+}
+
+// resolveRows makes the rows' file paths absolute
+func (s *RootedStore) resolveRows(rows []map[string]interface{}) []map[string]interface{} {
+	for _, row := range rows {
+		if path, ok := row["file_path"].(string); ok {
+			row["file_path"] = s.resolve(path)
+		}
+	}
+	return rows
+}
+
+// LoadMetadata loads the index metadata with absolute file paths
+func (s *RootedStore) LoadMetadata() (*IndexMetadata, error) {
+	metadata, err := s.Store.LoadMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return s.convertMetadata(metadata, s.resolve), nil
+}
+
+// SaveMetadata saves the index metadata with stored file paths
+func (s *RootedStore) SaveMetadata(metadata *IndexMetadata) error {
+	return s.Store.SaveMetadata(s.convertMetadata(metadata, s.stored))
+}
+
+// convertMetadata returns a copy of metadata with its file paths passed through convert
+func (s *RootedStore) convertMetadata(metadata *IndexMetadata, convert func(string) string) *IndexMetadata {
+	converted := *metadata
+	converted.FileModTimes = make(map[string]time.Time, len(metadata.FileModTimes))
+	for path, modTime := range metadata.FileModTimes {
+		converted.FileModTimes[convert(path)] = modTime
+	}
+	converted.FileHashes = make(map[string]string, len(metadata.FileHashes))
+	for path, hash := range metadata.FileHashes {
+		converted.FileHashes[convert(path)] = hash
+	}
+	return &converted
+}
+
+// Migrate upgrades the wrapped store, then rewrites the absolute paths of
+// files under the root that were stored before paths were made relative
+func (s *RootedStore) Migrate(metadata *IndexMetadata) ([]string, error) {
+	// Migrations remove dropped files from metadata by their stored paths
+	stored := s.convertMetadata(metadata, s.stored)
+	applied, err := s.Store.Migrate(stored)
+	*metadata = *s.convertMetadata(stored, s.resolve)
+	if err != nil || metadata.RelativePaths {
+		return applied, err
+	}
+
+	paths, err := s.Store.ListFilePaths()
+	if err != nil {
+		return applied, err
+	}
+	converted := 0
+	for _, path := range paths {
+		if rel := s.stored(path); rel != path {
+			if err := s.Store.UpdateFilePath(path, rel); err != nil {
+				return applied, err
+			}
+			converted++
+		}
+	}
+	if converted > 0 {
+		applied = append(applied, fmt.Sprintf("store %d file path(s) relative to the project root", converted))
+	}
+	metadata.RelativePaths = true
+	return applied, nil
+}
+
+// StoreChunks adds chunks with their paths made relative to the root
+func (s *RootedStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float64) error {
+	return s.Store.StoreChunks(s.storedChunks(chunks), embeddings)
+}
+
+// DeleteChunksByFilePath deletes all chunks for the given file paths
+func (s *RootedStore) DeleteChunksByFilePath(filePaths []string) error {
+	return s.Store.DeleteChunksByFilePath(s.storedPaths(filePaths))
+}
+
+// ReplaceChunks replaces all chunks stored for filePaths with the given chunks
+func (s *RootedStore) ReplaceChunks(filePaths []string, chunks []chunker.Chunk, embeddings [][]float64) error {
+	return s.Store.ReplaceChunks(s.storedPaths(filePaths), s.storedChunks(chunks), embeddings)
+}
+
+// UpdateFilePath moves all chunks stored for oldPath to newPath
+func (s *RootedStore) UpdateFilePath(oldPath, newPath string) error {
+	return s.Store.UpdateFilePath(s.stored(oldPath), s.stored(newPath))
+}
+
+// ListFilePaths returns the absolute paths of the files that have chunks stored
+func (s *RootedStore) ListFilePaths() ([]string, error) {
+	paths, err := s.Store.ListFilePaths()
+	if err != nil {
+		return nil, err
+	}
+	for i, path := range paths {
+		paths[i] = s.resolve(path)
+	}
+	return paths, nil
+}
+
+// Search performs vector similarity search, returning absolute paths
+func (s *RootedStore) Search(embeddingType string, queryVector []float64, limit int, filter SearchFilter) ([]map[string]interface{}, error) {
+	rows, err := s.Store.Search(embeddingType, queryVector, limit, s.storedFilter(filter))
+	return s.resolveRows(rows), err
+}
+
+// FullTextSearch performs keyword search, returning absolute paths
+func (s *RootedStore) FullTextSearch(query string, limit int, embeddingType string, filter SearchFilter) ([]map[string]interface{}, error) {
+	rows, err := s.Store.FullTextSearch(query, limit, embeddingType, s.storedFilter(filter))
+	return s.resolveRows(rows), err
+}
+
+// GetChunk returns the stored row for a chunk ID with an absolute path
+func (s *RootedStore) GetChunk(chunkID string) (map[string]interface{}, error) {
+	row, err := s.Store.GetChunk(chunkID)
+	if err != nil || row == nil {
+		return row, err
+	}
+	return s.resolveRows([]map[string]interface{}{row})[0], nil
+}
+
+// GetChunks returns the stored rows for several chunk IDs with absolute paths
+func (s *RootedStore) GetChunks(chunkIDs []string) (map[string]map[string]interface{}, error) {
+	rows, err := s.Store.GetChunks(chunkIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id, row := range rows {
+		rows[id] = s.resolveRows([]map[string]interface{}{row})[0]
+	}
+	return rows, nil
+}
+
+// FileChunks returns the stored rows for every chunk of a file, with absolute
+// paths. A file stored before the index was migrated is found by its absolute path.
+func (s *RootedStore) FileChunks(filePath string) ([]map[string]interface{}, error) {
+	rows, err := s.Store.FileChunks(s.stored(filePath))
+	if err == nil && len(rows) == 0 && s.stored(filePath) != filePath {
+		rows, err = s.Store.FileChunks(filePath)
+	}
+	return s.resolveRows(rows), err
+}
+
+// LoadIdentifiers loads the identifier index with absolute file paths
+func (s *RootedStore) LoadIdentifiers() (*IdentifierIndex, error) {
+	idx, err := s.Store.LoadIdentifiers()
+	if err != nil || idx == nil {
+		return idx, err
+	}
+	return s.convertIdentifiers(idx, s.resolve), nil
+}
+
+// SaveIdentifiers saves the identifier index with stored file paths
+func (s *RootedStore) SaveIdentifiers(idx *IdentifierIndex) error {
+	return s.Store.SaveIdentifiers(s.convertIdentifiers(idx, s.stored))
+}
+
+// convertIdentifiers returns a copy of idx with its file paths passed through convert
+func (s *RootedStore) convertIdentifiers(idx *IdentifierIndex, convert func(string) string) *IdentifierIndex {
+	converted := &IdentifierIndex{Tokens: idx.Tokens, Files: make(map[string][]string, len(idx.Files))}
+	for path, ids := range idx.Files {
+		converted.Files[convert(path)] = ids
+	}
+	return converted
+}
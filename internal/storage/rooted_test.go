@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// pathStore is an in-memory Store keeping just chunk IDs, file paths and metadata
+type pathStore struct {
+	Store
+	paths       map[string]string // Chunk ID -> stored file path
+	metadata    *IndexMetadata
+	identifiers *IdentifierIndex
+	lastFilter  SearchFilter
+}
+
+func newPathStore() *pathStore {
+	return &pathStore{paths: make(map[string]string), metadata: emptyMetadata()}
+}
+
+func (s *pathStore) LoadMetadata() (*IndexMetadata, error)      { return s.metadata, nil }
+func (s *pathStore) SaveMetadata(m *IndexMetadata) error        { s.metadata = m; return nil }
+func (s *pathStore) Migrate(m *IndexMetadata) ([]string, error) { return nil, nil }
+func (s *pathStore) LoadIdentifiers() (*IdentifierIndex, error) { return s.identifiers, nil }
+func (s *pathStore) SaveIdentifiers(idx *IdentifierIndex) error { s.identifiers = idx; return nil }
+
+func (s *pathStore) StoreChunks(chunks []chunker.Chunk, embeddings [][]float64) error {
+	for _, chunk := range chunks {
+		s.paths[chunk.ID] = chunk.FilePath
+	}
+	return nil
+}
+
+func (s *pathStore) DeleteChunksByFilePath(filePaths []string) error {
+	for id, path := range s.paths {
+		for _, deleted := range filePaths {
+			if path == deleted {
+				delete(s.paths, id)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *pathStore) UpdateFilePath(oldPath, newPath string) error {
+	for id, path := range s.paths {
+		if path == oldPath {
+			s.paths[id] = newPath
+		}
+	}
+	return nil
+}
+
+func (s *pathStore) ListFilePaths() ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, path := range s.paths {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (s *pathStore) Search(embeddingType string, queryVector []float64, limit int, filter SearchFilter) ([]map[string]interface{}, error) {
+	s.lastFilter = filter
+	var rows []map[string]interface{}
+	for id, path := range s.paths {
+		rows = append(rows, map[string]interface{}{"chunk_id": id, "file_path": path})
+	}
+	return rows, nil
+}
+
+func TestRootedStore_StoresRelativePaths(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "home", "dev", "repo")
+	inner := newPathStore()
+	store := NewRootedStore(inner, root)
+
+	chunks := []chunker.Chunk{{ID: "c1", FilePath: filepath.Join(root, "internal", "a.go")}}
+	if err := store.StoreChunks(chunks, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := inner.paths["c1"]; got != filepath.Join("internal", "a.go") {
+		t.Errorf("expected a root-relative stored path, got %q", got)
+	}
+	if chunks[0].FilePath != filepath.Join(root, "internal", "a.go") {
+		t.Error("expected the caller's chunks to keep their absolute paths")
+	}
+
+	rows, err := store.Search("code", nil, 10, SearchFilter{PathPrefix: filepath.Join(root, "internal")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0]["file_path"] != filepath.Join(root, "internal", "a.go") {
+		t.Errorf("expected search results with absolute paths, got %v", rows[0]["file_path"])
+	}
+	if inner.lastFilter.PathPrefix != "internal" {
+		t.Errorf("expected a root-relative path prefix, got %q", inner.lastFilter.PathPrefix)
+	}
+	if _, err := store.Search("code", nil, 10, SearchFilter{PathPrefix: root}); err != nil || inner.lastFilter.PathPrefix != "" {
+		t.Errorf("expected the root as prefix to match everything, got %q", inner.lastFilter.PathPrefix)
+	}
+
+	// The same stored index resolves against wherever the repository now lives
+	moved := filepath.Join(string(filepath.Separator), "srv", "repo")
+	paths, err := NewRootedStore(inner, moved).ListFilePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, []string{filepath.Join(moved, "internal", "a.go")}) {
+		t.Errorf("expected paths under the new root, got %v", paths)
+	}
+
+	if err := store.DeleteChunksByFilePath([]string{filepath.Join(root, "internal", "a.go")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.paths) != 0 {
+		t.Errorf("expected the chunk to be deleted, got %v", inner.paths)
+	}
+}
+
+func TestRootedStore_Metadata(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "home", "dev", "repo")
+	inner := newPathStore()
+	store := NewRootedStore(inner, root)
+	now := time.Now()
+
+	metadata := emptyMetadata()
+	metadata.FileModTimes[filepath.Join(root, "a.go")] = now
+	metadata.FileHashes[filepath.Join(root, "a.go")] = "hash"
+	if err := store.SaveMetadata(metadata); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := inner.metadata.FileModTimes["a.go"]; !ok || inner.metadata.FileHashes["a.go"] != "hash" {
+		t.Errorf("expected relative paths in saved metadata, got %+v", inner.metadata)
+	}
+	if _, ok := metadata.FileModTimes[filepath.Join(root, "a.go")]; !ok {
+		t.Error("expected the caller's metadata to keep absolute paths")
+	}
+
+	loaded, err := store.LoadMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.FileModTimes[filepath.Join(root, "a.go")].Equal(now) {
+		t.Errorf("expected absolute paths in loaded metadata, got %+v", loaded.FileModTimes)
+	}
+}
+
+func TestRootedStore_MigratesAbsolutePaths(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "home", "dev", "repo")
+	outside := filepath.Join(string(filepath.Separator), "elsewhere", "b.go")
+	inner := newPathStore()
+	inner.paths["c1"] = filepath.Join(root, "a.go")
+	inner.paths["c2"] = outside
+	store := NewRootedStore(inner, root)
+
+	metadata := emptyMetadata()
+	applied, err := store.Migrate(metadata)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) != 1 || !metadata.RelativePaths {
+		t.Errorf("expected one path migration and RelativePaths set, got %v, %v", applied, metadata.RelativePaths)
+	}
+	if inner.paths["c1"] != "a.go" || inner.paths["c2"] != outside {
+		t.Errorf("expected only paths under the root rewritten, got %v", inner.paths)
+	}
+
+	if applied, err := store.Migrate(metadata); err != nil || len(applied) != 0 {
+		t.Errorf("expected a migrated index to be left alone, got %v, %v", applied, err)
+	}
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jlanders/code-scout/internal/chunker"
+)
+
+// Store is a vector store backend for indexed chunks. Each embedding space
+// ("code", "docs") is stored separately. Search results are returned as rows
+// keyed by column name, with "_distance" (lower is better) for vector search
+// and "_score" (higher is better) for full-text search.
+type Store interface {
+	// LoadMetadata loads the index metadata for the project
+	LoadMetadata() (*IndexMetadata, error)
+	// SaveMetadata saves the index metadata for the project
+	SaveMetadata(metadata *IndexMetadata) error
+	// Migrate upgrades stored data to CurrentSchemaVersion
+	Migrate(metadata *IndexMetadata) ([]string, error)
+	// OpenTable opens the existing stored data for searching
+	OpenTable() error
+	// StoreChunks adds chunks with their embeddings
+	StoreChunks(chunks []chunker.Chunk, embeddings [][]float64) error
+	// DeleteChunksByFilePath deletes all chunks for the given file paths
+	DeleteChunksByFilePath(filePaths []string) error
+	// ReplaceChunks replaces all chunks stored for filePaths with the given chunks,
+	// storing the new chunks before any old chunk is removed
+	ReplaceChunks(filePaths []string, chunks []chunker.Chunk, embeddings [][]float64) error
+	// UpdateFilePath moves all chunks stored for oldPath to newPath
+	UpdateFilePath(oldPath, newPath string) error
+	// ListFilePaths returns the distinct file paths that have chunks stored
+	ListFilePaths() ([]string, error)
+	// Search performs vector similarity search in one embedding space
+	Search(embeddingType string, queryVector []float64, limit int, filter SearchFilter) ([]map[string]interface{}, error)
+	// FullTextSearch performs keyword search; an empty embeddingType searches every space
+	FullTextSearch(query string, limit int, embeddingType string, filter SearchFilter) ([]map[string]interface{}, error)
+	// GetChunk returns the stored row for a chunk ID, or nil if no chunk has that ID
+	GetChunk(chunkID string) (map[string]interface{}, error)
+	// GetChunks returns the stored rows for several chunk IDs, keyed by ID;
+	// IDs no chunk has are left out
+	GetChunks(chunkIDs []string) (map[string]map[string]interface{}, error)
+	// FileChunks returns the stored rows for every chunk of a file, in no particular order
+	FileChunks(filePath string) ([]map[string]interface{}, error)
+	// DeleteChunks deletes chunks by ID
+	DeleteChunks(chunkIDs []string) error
+	// CreateTextIndex (re)builds the full-text index after rows change
+	CreateTextIndex() error
+	// LoadIdentifiers loads the identifier index, or nil if none has been saved
+	LoadIdentifiers() (*IdentifierIndex, error)
+	// SaveIdentifiers saves the identifier index kept beside the vectors
+	SaveIdentifiers(idx *IdentifierIndex) error
+	// Close releases the store's resources
+	Close() error
+}
+
+var (
+	_ Store = (*LanceDBStore)(nil)
+	_ Store = (*QdrantStore)(nil)
+)
+
+// IndexMissingError is returned by OpenTable when nothing has been indexed yet
+type IndexMissingError struct {
+	Location string // Where the index was looked for
+}
+
+func (e *IndexMissingError) Error() string {
+	return "failed to open table: no index found in " + e.Location
+}
+
+// SearchFilter restricts search results. Empty fields match everything.
+type SearchFilter struct {
+	Language   string // Exact language, e.g. "go"
+	ChunkType  string // Exact chunk type, e.g. "function"
+	PathPrefix string // Only chunks from files under this directory
+	Project    string // Only chunks from this project (global index only)
+	// ExcludeTests drops chunks from test files; OnlyTests keeps only them (see IsTestFile)
+	ExcludeTests bool
+	OnlyTests    bool
+	// ExcludeLanguages drops chunks in these languages, e.g. ones disabled in the config
+	ExcludeLanguages []string
+}
+
+// IsEmpty reports whether the filter matches everything
+func (f SearchFilter) IsEmpty() bool {
+	return f.Language == "" && f.ChunkType == "" && f.PathPrefix == "" && f.Project == "" &&
+		!f.ExcludeTests && !f.OnlyTests && len(f.ExcludeLanguages) == 0
+}
+
+// Matches reports whether a stored row passes the filter, for rows fetched
+// without it (e.g. by GetChunk)
+func (f SearchFilter) Matches(row map[string]interface{}) bool {
+	if f.Language != "" && rowString(row, "language") != f.Language {
+		return false
+	}
+	if f.ChunkType != "" && rowString(row, "chunk_type") != f.ChunkType {
+		return false
+	}
+	if f.PathPrefix != "" && !strings.HasPrefix(rowString(row, "file_path"), dirPrefix(f.PathPrefix)) {
+		return false
+	}
+	if f.Project != "" && rowString(row, "project") != f.Project {
+		return false
+	}
+	isTest, _ := row["is_test"].(bool)
+	if (f.ExcludeTests && isTest) || (f.OnlyTests && !isTest) {
+		return false
+	}
+	for _, lang := range f.ExcludeLanguages {
+		if rowString(row, "language") == lang {
+			return false
+		}
+	}
+	return true
+}
+
+// sqlWhere renders the filter as a LanceDB SQL predicate ("" if empty)
+func (f SearchFilter) sqlWhere() string {
+	var clauses []string
+	if f.Language != "" {
+		clauses = append(clauses, fmt.Sprintf("language = '%s'", escapeSQLString(f.Language)))
+	}
+	if f.ChunkType != "" {
+		clauses = append(clauses, fmt.Sprintf("chunk_type = '%s'", escapeSQLString(f.ChunkType)))
+	}
+	if f.PathPrefix != "" {
+		clauses = append(clauses, fmt.Sprintf("file_path LIKE '%s%%'", escapeSQLString(dirPrefix(f.PathPrefix))))
+	}
+	if f.Project != "" {
+		clauses = append(clauses, fmt.Sprintf("project = '%s'", escapeSQLString(f.Project)))
+	}
+	if f.ExcludeTests {
+		clauses = append(clauses, "is_test = false")
+	}
+	if f.OnlyTests {
+		clauses = append(clauses, "is_test = true")
+	}
+	if len(f.ExcludeLanguages) > 0 {
+		quoted := make([]string, len(f.ExcludeLanguages))
+		for i, lang := range f.ExcludeLanguages {
+			quoted[i] = fmt.Sprintf("'%s'", escapeSQLString(lang))
+		}
+		clauses = append(clauses, fmt.Sprintf("language NOT IN (%s)", strings.Join(quoted, ", ")))
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// dirPrefix normalizes a directory path to end with a separator, so "/a/b"
+// doesn't match files in "/a/bc"
+func dirPrefix(dir string) string {
+	dir = filepath.Clean(dir)
+	if strings.HasSuffix(dir, string(filepath.Separator)) {
+		return dir
+	}
+	return dir + string(filepath.Separator)
+}
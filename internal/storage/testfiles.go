@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// testDirs are directory names whose files are tests
+var testDirs = map[string]bool{"__tests__": true, "test": true, "tests": true, "spec": true}
+
+// testSuffixes are file name endings used by test files across languages
+var testSuffixes = []string{
+	"_test.go", "_test.py", "_test.rb", "_spec.rb", "_test.rs", "_test.cpp", "_test.cc", "_test.c",
+	"Test.java", "Tests.java", "Test.scala", "Spec.scala", "Test.php",
+	".test.js", ".test.ts", ".test.jsx", ".test.tsx", ".spec.js", ".spec.ts", ".spec.jsx", ".spec.tsx",
+}
+
+// IsTestFile reports whether path is a test file, going by common naming
+// conventions (foo_test.go, test_foo.py, foo.spec.ts, ...) and test directories
+// (__tests__/, tests/, ...). Chunks from test files are stored with is_test set.
+func IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py") {
+		return true
+	}
+	for _, suffix := range testSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if testDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
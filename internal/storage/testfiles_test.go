@@ -0,0 +1,24 @@
+package storage
+
+import "testing"
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/repo/internal/storage/store_test.go", true},
+		{"/repo/pkg/test_parser.py", true},
+		{"/repo/web/src/__tests__/app.js", true},
+		{"/repo/web/src/app.spec.ts", true},
+		{"/repo/src/test/java/com/acme/FooTest.java", true},
+		{"/repo/internal/storage/store.go", false},
+		{"/repo/pkg/testing_utils.py", false},
+		{"/repo/cmd/attest/main.go", false},
+	}
+	for _, tt := range tests {
+		if got := IsTestFile(tt.path); got != tt.expected {
+			t.Errorf("IsTestFile(%s) = %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/lancedb/lancedb-go/pkg/contracts"
+)
+
+const (
+	// minRowsForVectorIndex is the minimum row count needed to train an IVF-PQ index
+	minRowsForVectorIndex = 256
+	// minRowsForPQ is the row count below which IVF-Flat is used instead of
+	// IVF-PQ: small tables fit in memory uncompressed, and PQ costs recall
+	minRowsForPQ = 10000
+)
+
+// Vector index types chosen by tuneVectorIndex
+const (
+	VectorIndexNone    = ""
+	VectorIndexIvfFlat = "IVF_FLAT"
+	VectorIndexIvfPq   = "IVF_PQ"
+)
+
+// VectorIndex describes the ANN index tuned for one table
+type VectorIndex struct {
+	EmbeddingType string
+	Rows          int64
+	Dimension     int
+	Type          string // VectorIndexNone if the table is too small to need one
+}
+
+// String describes the index for progress output. Only the type is chosen
+// here; LanceDB picks the partition and sub-vector counts itself.
+func (v VectorIndex) String() string {
+	switch v.Type {
+	case VectorIndexNone:
+		return fmt.Sprintf("no index (%d rows)", v.Rows)
+	case VectorIndexIvfFlat:
+		return fmt.Sprintf("%s (%d rows; LanceDB picks the partition count)", v.Type, v.Rows)
+	default:
+		return fmt.Sprintf("%s (%d rows; LanceDB picks the partition and sub-vector counts)", v.Type, v.Rows)
+	}
+}
+
+// indexType returns the LanceDB index type to build
+func (v VectorIndex) indexType() contracts.IndexType {
+	if v.Type == VectorIndexIvfFlat {
+		return contracts.IndexTypeIvfFlat
+	}
+	return contracts.IndexTypeIvfPq
+}
+
+// tuneVectorIndex picks the ANN index type for a table from its row count and
+// vector dimension: none for tables too small to train one, IVF-Flat for small
+// tables, and IVF-PQ for large ones. PQ splits each vector into sub-vectors, so
+// IVF-Flat is also used when neither 16 nor 8 divides the dimension.
+//
+// lancedb-go can't pass IVF parameters, so only the type is applied; LanceDB
+// picks its own partition and sub-vector counts.
+func tuneVectorIndex(embeddingType string, rows int64, dimension int) VectorIndex {
+	index := VectorIndex{EmbeddingType: embeddingType, Rows: rows, Dimension: dimension}
+	switch {
+	case rows < minRowsForVectorIndex:
+		index.Type = VectorIndexNone
+	case rows < minRowsForPQ || (dimension%16 != 0 && dimension%8 != 0):
+		index.Type = VectorIndexIvfFlat
+	default:
+		index.Type = VectorIndexIvfPq
+	}
+	return index
+}
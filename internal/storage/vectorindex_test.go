@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTuneVectorIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int64
+		dimension int
+		want      string
+	}{
+		{"too few rows", 100, 768, VectorIndexNone},
+		{"small table", 4096, 768, VectorIndexIvfFlat},
+		{"large table", 1000000, 768, VectorIndexIvfPq},
+		{"dimension divisible by 8 only", 40000, 3592, VectorIndexIvfPq},
+		{"dimension PQ can't split", 40000, 1001, VectorIndexIvfFlat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if index := tuneVectorIndex("code", tt.rows, tt.dimension); index.Type != tt.want {
+				t.Errorf("got %q, want %q", index.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorIndexString_ReportsOnlyAppliedSettings(t *testing.T) {
+	got := tuneVectorIndex("code", 1000000, 768).String()
+	if !strings.HasPrefix(got, "IVF_PQ (1000000 rows") || !strings.Contains(got, "LanceDB picks") {
+		t.Errorf("unexpected description %q", got)
+	}
+}
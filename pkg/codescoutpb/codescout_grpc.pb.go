@@ -0,0 +1,218 @@
+// gRPC interface to a code-scout index, served by `code-scout serve --grpc-port`.
+//
+// Messages mirror the JSON documents in pkg/searchapi; field meanings are
+// documented there. Regenerate the Go bindings in pkg/codescoutpb after
+// editing this file (see docs/design/cli-interface.md).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.2
+// source: codescout/v1/codescout.proto
+
+package codescoutpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CodeScout_Search_FullMethodName = "/codescout.v1.CodeScout/Search"
+	CodeScout_Index_FullMethodName  = "/codescout.v1.CodeScout/Index"
+	CodeScout_Watch_FullMethodName  = "/codescout.v1.CodeScout/Watch"
+)
+
+// CodeScoutClient is the client API for CodeScout service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CodeScoutClient interface {
+	// Search runs a semantic search over the index
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// Index runs an incremental index and streams its progress
+	Index(ctx context.Context, in *IndexRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexEvent], error)
+	// Watch polls the project for file changes and streams an event when the
+	// index falls behind, optionally re-indexing automatically
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+}
+
+type codeScoutClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCodeScoutClient(cc grpc.ClientConnInterface) CodeScoutClient {
+	return &codeScoutClient{cc}
+}
+
+func (c *codeScoutClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, CodeScout_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *codeScoutClient) Index(ctx context.Context, in *IndexRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CodeScout_ServiceDesc.Streams[0], CodeScout_Index_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IndexRequest, IndexEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CodeScout_IndexClient = grpc.ServerStreamingClient[IndexEvent]
+
+func (c *codeScoutClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CodeScout_ServiceDesc.Streams[1], CodeScout_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CodeScout_WatchClient = grpc.ServerStreamingClient[WatchEvent]
+
+// CodeScoutServer is the server API for CodeScout service.
+// All implementations must embed UnimplementedCodeScoutServer
+// for forward compatibility.
+type CodeScoutServer interface {
+	// Search runs a semantic search over the index
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// Index runs an incremental index and streams its progress
+	Index(*IndexRequest, grpc.ServerStreamingServer[IndexEvent]) error
+	// Watch polls the project for file changes and streams an event when the
+	// index falls behind, optionally re-indexing automatically
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	mustEmbedUnimplementedCodeScoutServer()
+}
+
+// UnimplementedCodeScoutServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCodeScoutServer struct{}
+
+func (UnimplementedCodeScoutServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedCodeScoutServer) Index(*IndexRequest, grpc.ServerStreamingServer[IndexEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Index not implemented")
+}
+func (UnimplementedCodeScoutServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedCodeScoutServer) mustEmbedUnimplementedCodeScoutServer() {}
+func (UnimplementedCodeScoutServer) testEmbeddedByValue()                   {}
+
+// UnsafeCodeScoutServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CodeScoutServer will
+// result in compilation errors.
+type UnsafeCodeScoutServer interface {
+	mustEmbedUnimplementedCodeScoutServer()
+}
+
+func RegisterCodeScoutServer(s grpc.ServiceRegistrar, srv CodeScoutServer) {
+	// If the following call pancis, it indicates UnimplementedCodeScoutServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CodeScout_ServiceDesc, srv)
+}
+
+func _CodeScout_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CodeScoutServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CodeScout_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CodeScoutServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CodeScout_Index_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IndexRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CodeScoutServer).Index(m, &grpc.GenericServerStream[IndexRequest, IndexEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CodeScout_IndexServer = grpc.ServerStreamingServer[IndexEvent]
+
+func _CodeScout_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CodeScoutServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CodeScout_WatchServer = grpc.ServerStreamingServer[WatchEvent]
+
+// CodeScout_ServiceDesc is the grpc.ServiceDesc for CodeScout service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CodeScout_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "codescout.v1.CodeScout",
+	HandlerType: (*CodeScoutServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _CodeScout_Search_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Index",
+			Handler:       _CodeScout_Index_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _CodeScout_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "codescout/v1/codescout.proto",
+}
@@ -0,0 +1,5 @@
+// Package codescoutpb contains the Go bindings for the code-scout gRPC service
+// defined in proto/codescout/v1/codescout.proto.
+package codescoutpb
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/jlanders/code-scout --go-grpc_out=../.. --go-grpc_opt=module=github.com/jlanders/code-scout codescout/v1/codescout.proto
@@ -0,0 +1,144 @@
+// Package searchapi defines the JSON documents printed by `code-scout search --json`
+// and `code-scout grep --json`.
+//
+// The schema is versioned: SchemaVersion is bumped whenever a field is renamed,
+// removed, or changes meaning. Adding a new optional field does not bump the
+// version, so consumers should ignore fields they don't recognize.
+package searchapi
+
+import "time"
+
+// SchemaVersion is the version of the JSON output schema written by this build
+const SchemaVersion = 1
+
+// Response is the top-level document printed by `search --json`
+type Response struct {
+	SchemaVersion int         `json:"schema_version"`
+	Query         string      `json:"query"`
+	Mode          string      `json:"mode"`                       // "code", "docs", or "hybrid"
+	TotalResults  int         `json:"total_results"`              // Raw matches before deduplication
+	Returned      int         `json:"returned"`                   // Length of Results
+	Offset        int         `json:"offset"`                     // Ranked results skipped before this page
+	NextCursor    string      `json:"next_cursor,omitempty"`      // Resumes at the next page (search --cursor); empty on the last page
+	Expansions    []string    `json:"expanded_queries,omitempty"` // Paraphrases searched alongside Query (search --expand)
+	Results       []Result    `json:"results"`
+	Files         []FileGroup `json:"files,omitempty"` // Results nested by file (search --group-by file); Results is then empty
+	Index         *IndexState `json:"index,omitempty"`
+	Timing        *Timing     `json:"timing,omitempty"` // Where the search spent its time (search --timing)
+}
+
+// Timing breaks down a search's latency, in milliseconds
+type Timing struct {
+	EmbeddingMS     float64 `json:"embedding_ms"`      // Embedding the query and any expansions
+	VectorSearchMS  float64 `json:"vector_search_ms"`  // Nearest neighbor lookups
+	KeywordSearchMS float64 `json:"keyword_search_ms"` // Full-text (--lexical) and identifier lookups
+	RerankMS        float64 `json:"rerank_ms"`         // Fusing, filtering, boosting and diversifying
+	TotalMS         float64 `json:"total_ms"`
+}
+
+// FileGroup is the results from one file, in rank order
+type FileGroup struct {
+	FilePath string   `json:"file_path"`
+	Language string   `json:"language"`
+	Results  []Result `json:"results"`
+}
+
+// GrepResponse is the top-level document printed by `grep --json`
+type GrepResponse struct {
+	SchemaVersion int      `json:"schema_version"`
+	Query         string   `json:"query"`
+	Returned      int      `json:"returned"`
+	Results       []Result `json:"results"`
+}
+
+// Result is a single matching chunk
+type Result struct {
+	ChunkID   string `json:"chunk_id"`
+	FilePath  string `json:"file_path"`  // Absolute path to the source file
+	LineStart int    `json:"line_start"` // 1-indexed, inclusive
+	LineEnd   int    `json:"line_end"`   // 1-indexed, inclusive
+	Language  string `json:"language"`
+	Code      string `json:"code"`
+	// RelativePath is FilePath relative to the current directory; set by search --in
+	RelativePath string `json:"relative_path,omitempty"`
+
+	// Score is the raw vector distance (lower is better); 0 for keyword-only matches
+	Score float64 `json:"score"`
+	// NormalizedScore maps the match to a relevance in (0, 1] where higher is
+	// better, so results are comparable across modes and backends. Vector
+	// matches use 1/(1+distance); keyword-only matches use bm25/(1+bm25).
+	NormalizedScore float64 `json:"normalized_score"`
+	LexicalScore    float64 `json:"lexical_score,omitempty"` // BM25 score from the full-text index
+	FusedScore      float64 `json:"fused_score,omitempty"`   // Reciprocal rank fusion score (hybrid mode, --lexical)
+	// IdentifierScore is the identifier index score (summed inverse document
+	// frequency of the matched tokens); IdentifierMatches are those tokens
+	IdentifierScore   float64  `json:"identifier_score,omitempty"`
+	IdentifierMatches []string `json:"identifier_matches,omitempty"`
+	// Boost is the ranking multiplier applied by recency and path boosts; omitted when none apply
+	Boost float64 `json:"boost,omitempty"`
+
+	EmbeddingType string `json:"embedding_type"`       // "code" or "docs"
+	Project       string `json:"project,omitempty"`    // Project name (global index only)
+	ChunkType     string `json:"chunk_type,omitempty"` // function, method, struct, section, ...
+	Name          string `json:"name,omitempty"`       // Symbol or heading name
+	IsTest        bool   `json:"is_test,omitempty"`    // From a test file (e.g. *_test.go, __tests__/)
+	Signature     string `json:"signature,omitempty"`
+	DocComment    string `json:"doc_comment,omitempty"`
+	Heading       string `json:"heading,omitempty"`
+	HeadingLevel  string `json:"heading_level,omitempty"`
+	ParentHeading string `json:"parent_heading,omitempty"`
+	// Metadata holds all chunk metadata (package, receiver, doc_comment, ...)
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Explanation breaks down the result's ranking (search --explain)
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// Explanation shows how each signal contributed to a result's rank
+type Explanation struct {
+	// VectorSimilarity is 1/(1+distance) for vector matches; omitted for
+	// results found only by keyword or identifier
+	VectorSimilarity  float64            `json:"vector_similarity,omitempty"`
+	LexicalScore      float64            `json:"lexical_score,omitempty"`
+	MatchedTerms      []string           `json:"matched_terms,omitempty"` // Query words found in the chunk
+	IdentifierScore   float64            `json:"identifier_score,omitempty"`
+	IdentifierMatches []string           `json:"identifier_matches,omitempty"`
+	Boosts            map[string]float64 `json:"boosts,omitempty"` // Multiplier from each boost ("recency", "path") that applied
+	FusedScore        float64            `json:"fused_score,omitempty"`
+	// FinalScore is the score results are ranked by: the fused score (or the
+	// normalized score, without fusion) times every boost
+	FinalScore float64 `json:"final_score"`
+}
+
+// IndexState describes how fresh the index is relative to the working tree
+type IndexState struct {
+	LastIndexTime time.Time `json:"last_index_time"`
+	FilesIndexed  int       `json:"files_indexed"`
+	GitCommit     string    `json:"git_commit,omitempty"`
+	GitDirty      bool      `json:"git_dirty"`
+	CurrentCommit string    `json:"current_commit,omitempty"`
+	CurrentDirty  bool      `json:"current_dirty"`
+	Stale         bool      `json:"stale"` // True if HEAD has moved since the index was built
+	// EmbeddingModels lists the model and dimension used for each embedding space
+	EmbeddingModels map[string]EmbeddingModel `json:"embedding_models,omitempty"`
+	// Project is the project's name in the global index (empty for per-project indexes)
+	Project string `json:"project,omitempty"`
+}
+
+// EmbeddingModel identifies the model and vector dimension used for an embedding space
+type EmbeddingModel struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+}
+
+// NormalizeScore returns the NormalizedScore for a result with the given
+// vector distance and BM25 score. Results with a vector distance use it;
+// keyword-only matches (no distance) fall back to the BM25 score.
+func NormalizeScore(distance, lexicalScore float64) float64 {
+	if distance == 0 && lexicalScore > 0 {
+		return lexicalScore / (1 + lexicalScore)
+	}
+	if distance < 0 {
+		distance = 0
+	}
+	return 1 / (1 + distance)
+}
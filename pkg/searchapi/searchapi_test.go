@@ -0,0 +1,61 @@
+package searchapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestResultFieldNames guards the JSON field names of schema version 1.
+// Renaming or removing one of these requires bumping SchemaVersion.
+func TestResultFieldNames(t *testing.T) {
+	result := Result{
+		ChunkID: "id", FilePath: "/a.go", LineStart: 1, LineEnd: 2, Language: "go", Code: "x",
+		Score: 0.5, NormalizedScore: 0.6, LexicalScore: 1, FusedScore: 0.1, Boost: 1.2,
+		EmbeddingType: "code", Project: "p", ChunkType: "function", Name: "A", IsTest: true,
+		Signature: "func A()", DocComment: "A does", Heading: "h", HeadingLevel: "1",
+		ParentHeading: "p", Metadata: map[string]string{"k": "v"},
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	expected := []string{
+		"boost", "chunk_id", "chunk_type", "code", "doc_comment", "embedding_type", "file_path",
+		"fused_score", "heading", "heading_level", "is_test", "language", "lexical_score", "line_end",
+		"line_start", "metadata", "name", "normalized_score", "parent_heading", "project",
+		"score", "signature",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("result fields changed:\n got %v\nwant %v", names, expected)
+	}
+}
+
+func TestNormalizeScore(t *testing.T) {
+	tests := []struct {
+		distance, lexical, expected float64
+	}{
+		{0, 0, 1},
+		{1, 0, 0.5},
+		{3, 0, 0.25},
+		{1, 9, 0.5}, // vector distance wins when present
+		{0, 3, 0.75},
+	}
+	for _, tt := range tests {
+		if got := NormalizeScore(tt.distance, tt.lexical); got != tt.expected {
+			t.Errorf("NormalizeScore(%v, %v) = %v, expected %v", tt.distance, tt.lexical, got, tt.expected)
+		}
+	}
+}